@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gumgum/pkg/api"
+)
+
+func cmdAttachments(args []string) {
+	path := args[0]
+	outDir := "."
+
+	for i := 1; i < len(args); i++ {
+		if args[i] == "-o" && i+1 < len(args) {
+			outDir = args[i+1]
+			i++
+		}
+	}
+
+	doc, err := api.Open(path)
+	if err != nil {
+		fatalOpenError(err)
+	}
+	defer doc.Close()
+
+	attachments, err := doc.Attachments()
+	if err != nil {
+		fmt.Printf("Error reading attachments: %v\n", err)
+		os.Exit(1)
+	}
+	if len(attachments) == 0 {
+		fmt.Println("No attachments")
+		return
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		fmt.Printf("Error creating output directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	used := make(map[string]bool)
+	for i, att := range attachments {
+		output := filepath.Join(outDir, attachmentFileName(att.Name, i, used))
+		if err := os.WriteFile(output, att.Data, 0644); err != nil {
+			fmt.Printf("Error writing %s: %v\n", output, err)
+			os.Exit(1)
+		}
+
+		mimeType := att.MimeType
+		if mimeType == "" {
+			mimeType = "unknown type"
+		}
+		fmt.Printf("Saved %s (%d bytes, %s)\n", output, att.Size, mimeType)
+	}
+}
+
+// attachmentFileName returns a file name for an attachment that stays inside
+// the output directory and differs from the names already used.
+func attachmentFileName(name string, index int, used map[string]bool) string {
+	// Names come from the PDF, so drop any directory parts
+	base := filepath.Base(filepath.Clean("/" + filepath.ToSlash(name)))
+	if base == "/" || base == "." {
+		base = fmt.Sprintf("attachment-%03d", index+1)
+	}
+
+	result := base
+	ext := filepath.Ext(base)
+	for n := 2; used[result]; n++ {
+		result = fmt.Sprintf("%s-%d%s", base[:len(base)-len(ext)], n, ext)
+	}
+	used[result] = true
+	return result
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// progressBar draws an in-place terminal progress bar on stderr.
+// It is safe to call Increment from multiple goroutines.
+type progressBar struct {
+	mu      sync.Mutex
+	total   int
+	done    int
+	width   int
+	enabled bool
+}
+
+// newProgressBar creates a progress bar for the given number of items.
+// The bar is disabled when stderr is not a terminal (e.g. when piped).
+func newProgressBar(total int) *progressBar {
+	return &progressBar{
+		total:   total,
+		width:   30,
+		enabled: isTerminal(os.Stderr),
+	}
+}
+
+// isTerminal reports whether f is attached to a character device.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Increment marks one more item as complete and redraws the bar.
+func (p *progressBar) Increment() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done++
+	p.draw()
+}
+
+// Finish ends the progress line so following output starts on a new line.
+func (p *progressBar) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.enabled {
+		fmt.Fprintln(os.Stderr)
+	}
+}
+
+// draw renders the bar, e.g. "[=====>    ] 42/100 pages".
+func (p *progressBar) draw() {
+	if !p.enabled || p.total <= 0 {
+		return
+	}
+
+	filled := p.done * p.width / p.total
+	bar := strings.Repeat("=", filled)
+	if filled < p.width {
+		bar += ">" + strings.Repeat(" ", p.width-filled-1)
+	}
+
+	fmt.Fprintf(os.Stderr, "\r[%s] %d/%d pages", bar, p.done, p.total)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"gumgum/pkg/api"
+)
+
+// statsReport is the JSON output of the stats command.
+type statsReport struct {
+	Page int `json:"page"`
+	api.PageObjectStats
+}
+
+func cmdStats(args []string) {
+	path := args[0]
+	pageNum := 0
+
+	for i := 1; i < len(args); i++ {
+		switch args[i] {
+		case "-p":
+			if i+1 < len(args) {
+				pageNum, _ = strconv.Atoi(args[i+1])
+				i++
+			}
+		}
+	}
+
+	doc, err := api.Open(path)
+	if err != nil {
+		fatalOpenError(err)
+	}
+	defer doc.Close()
+
+	if pageNum < 0 || pageNum >= doc.PageCount() {
+		fmt.Printf("Page %d out of range (0-%d)\n", pageNum, doc.PageCount()-1)
+		os.Exit(1)
+	}
+
+	page, err := doc.Page(pageNum)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting page: %v\n", err)
+		os.Exit(1)
+	}
+	stats, err := page.ObjectStats()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error counting objects: %v\n", err)
+		os.Exit(1)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(statsReport{Page: pageNum, PageObjectStats: stats})
+}
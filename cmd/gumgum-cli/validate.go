@@ -0,0 +1,469 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gumgum/pkg/cos"
+	"gumgum/pkg/graphics"
+)
+
+// checkStatus is the outcome of a single validation check.
+type checkStatus int
+
+const (
+	checkPass checkStatus = iota
+	checkWarn
+	checkFail
+)
+
+func (s checkStatus) String() string {
+	switch s {
+	case checkPass:
+		return "PASS"
+	case checkWarn:
+		return "WARN"
+	default:
+		return "FAIL"
+	}
+}
+
+// checkResult records the outcome of a validation check.
+type checkResult struct {
+	Name   string
+	Status checkStatus
+	Detail string
+}
+
+// maxCycleCheckObjects limits how many objects are checked for circular
+// indirect references.
+const maxCycleCheckObjects = 1000
+
+func cmdValidate(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Printf("Error reading file: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("File: %s\n", path)
+	fmt.Println("────────────────────────────────────────")
+
+	results := validatePDF(data)
+
+	failed := false
+	for _, res := range results {
+		if res.Detail != "" {
+			fmt.Printf("[%s] %s: %s\n", res.Status, res.Name, res.Detail)
+		} else {
+			fmt.Printf("[%s] %s\n", res.Status, res.Name)
+		}
+		if res.Status == checkFail {
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// validatePDF runs all structure checks on the given PDF data.
+func validatePDF(data []byte) []checkResult {
+	var results []checkResult
+
+	results = append(results, checkHeader(data))
+
+	reader, err := cos.NewReader(data)
+	if errors.Is(err, cos.ErrEncrypted) {
+		results = append(results, checkResult{"Encryption", checkFail, err.Error()})
+		return results
+	}
+	if err != nil {
+		results = append(results, checkResult{"Cross-reference table", checkFail, err.Error()})
+		return results
+	}
+	results = append(results, checkResult{
+		Name:   "Cross-reference table",
+		Status: checkPass,
+		Detail: fmt.Sprintf("%d objects", len(reader.ObjectNumbers())),
+	})
+
+	results = append(results, checkPageTree(reader))
+	results = append(results, checkCircularReferences(reader))
+	results = append(results, checkMediaBoxes(reader))
+	results = append(results, checkPageResources(reader))
+	results = append(results, checkPageContent(reader))
+
+	return results
+}
+
+// checkHeader verifies the %PDF- header is present near the start of the file.
+func checkHeader(data []byte) checkResult {
+	name := "Header"
+
+	// Some producers emit junk before the header; readers accept it within 1KB
+	head := data
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+
+	idx := bytes.Index(head, []byte("%PDF-"))
+	if idx < 0 {
+		return checkResult{name, checkFail, "%PDF- not found"}
+	}
+
+	version := string(head[idx+5:])
+	if end := bytes.IndexAny([]byte(version), "\r\n "); end >= 0 {
+		version = version[:end]
+	}
+
+	if idx > 0 {
+		return checkResult{name, checkWarn, fmt.Sprintf("version %s, header at offset %d", version, idx)}
+	}
+	return checkResult{name, checkPass, "version " + version}
+}
+
+// checkPageTree verifies the catalog, pages root and page count agree.
+func checkPageTree(reader *cos.Reader) checkResult {
+	name := "Page tree"
+
+	if _, err := reader.Catalog(); err != nil {
+		return checkResult{name, checkFail, err.Error()}
+	}
+
+	pages, err := reader.Pages()
+	if err != nil {
+		return checkResult{name, checkFail, err.Error()}
+	}
+	count, ok := pages.GetInt("Count")
+	if !ok {
+		return checkResult{name, checkFail, "no Count in Pages"}
+	}
+
+	refs, err := reader.PageRefs()
+	if err != nil {
+		return checkResult{name, checkFail, err.Error()}
+	}
+
+	if len(refs) != int(count) {
+		return checkResult{name, checkFail, fmt.Sprintf("Count is %d but found %d pages", count, len(refs))}
+	}
+	return checkResult{name, checkPass, fmt.Sprintf("%d pages", count)}
+}
+
+// checkCircularReferences looks for cycles of indirect references among
+// the objects reachable from the first objects in the file, following
+// references inside dictionaries, arrays and stream dictionaries.
+func checkCircularReferences(reader *cos.Reader) checkResult {
+	name := "Circular references"
+
+	nums := reader.ObjectNumbers()
+	if len(nums) > maxCycleCheckObjects {
+		nums = nums[:maxCycleCheckObjects]
+	}
+
+	f := &cycleFinder{
+		reader: reader,
+		done:   make(map[int]bool),
+		onPath: make(map[int]bool),
+	}
+	for _, objNum := range nums {
+		if cycle := f.visit(objNum); cycle != nil {
+			steps := make([]string, len(cycle))
+			for i, num := range cycle {
+				steps[i] = strconv.Itoa(num)
+			}
+			return checkResult{name, checkFail, "objects " + strings.Join(steps, " -> ")}
+		}
+	}
+
+	return checkResult{name, checkPass, fmt.Sprintf("%d objects checked", len(f.done))}
+}
+
+// backLinkKeys are dictionary entries that point back up or across the
+// object graph by design, such as a page's Parent, an annotation's page P,
+// a link's destination page, the links between outline items and the
+// circular list of beads in an article thread. They are not followed, or
+// every page tree would be reported as circular.
+var backLinkKeys = map[cos.Name]bool{
+	"Parent": true,
+	"P":      true,
+	"Prev":   true,
+	"Pg":     true,
+	"Dest":   true,
+	"D":      true,
+
+	// Outline items
+	"First": true,
+	"Last":  true,
+	"Next":  true,
+
+	// Thread beads and the thread they belong to
+	"N": true,
+	"V": true,
+	"T": true,
+}
+
+// cycleFinder walks the object graph depth first, remembering the objects
+// on the current path to find references back into it.
+type cycleFinder struct {
+	reader *cos.Reader
+	done   map[int]bool // Objects whose descendants were all walked
+	path   []int        // Objects being walked, outermost first
+	onPath map[int]bool
+}
+
+// visit walks the descendants of an object. It returns the first cycle
+// found as the object numbers along it, ending with the one repeated, or
+// nil if there is none.
+func (f *cycleFinder) visit(objNum int) []int {
+	if f.onPath[objNum] {
+		for i, num := range f.path {
+			if num == objNum {
+				return append(append([]int(nil), f.path[i:]...), objNum)
+			}
+		}
+	}
+	if f.done[objNum] {
+		return nil
+	}
+
+	obj, err := f.reader.GetObject(objNum)
+	if err != nil {
+		f.done[objNum] = true
+		// The object cannot be read without itself, e.g. through its
+		// stream Length; other failures are left to the resources check
+		if errors.Is(err, cos.ErrCircularReference) {
+			return []int{objNum, objNum}
+		}
+		return nil
+	}
+
+	f.path = append(f.path, objNum)
+	f.onPath[objNum] = true
+	cycle := f.walk(obj)
+	f.path = f.path[:len(f.path)-1]
+	delete(f.onPath, objNum)
+	f.done[objNum] = true
+	return cycle
+}
+
+// walk visits the references inside a direct object.
+func (f *cycleFinder) walk(obj cos.Object) []int {
+	switch o := obj.(type) {
+	case *cos.Reference:
+		return f.visit(o.ObjectNumber)
+	case cos.Dict:
+		keys := make([]string, 0, len(o))
+		for key := range o {
+			if !backLinkKeys[key] {
+				keys = append(keys, string(key))
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if cycle := f.walk(o[cos.Name(key)]); cycle != nil {
+				return cycle
+			}
+		}
+	case cos.Array:
+		for _, item := range o {
+			if cycle := f.walk(item); cycle != nil {
+				return cycle
+			}
+		}
+	case *cos.Stream:
+		return f.walk(o.Dict)
+	}
+	return nil
+}
+
+// checkMediaBoxes verifies every page has a MediaBox, directly or inherited.
+func checkMediaBoxes(reader *cos.Reader) checkResult {
+	name := "Page MediaBox"
+
+	refs, err := reader.PageRefs()
+	if err != nil {
+		return checkResult{name, checkFail, err.Error()}
+	}
+
+	// GetPage also finds pages stored as direct objects
+	var missing, inherited, unresolved []int
+	var resolveErr error
+	for i := range refs {
+		page, err := reader.GetPage(i)
+		if err != nil {
+			unresolved = append(unresolved, i)
+			if resolveErr == nil {
+				resolveErr = err
+			}
+			continue
+		}
+
+		if page.Get("MediaBox") != nil {
+			continue
+		}
+		if hasInheritedKey(reader, page, "MediaBox") {
+			inherited = append(inherited, i)
+			continue
+		}
+		missing = append(missing, i)
+	}
+
+	var problems []string
+	if len(unresolved) > 0 {
+		problems = append(problems, fmt.Sprintf("cannot resolve pages %v (%v)", unresolved, resolveErr))
+	}
+	if len(missing) > 0 {
+		problems = append(problems, fmt.Sprintf("missing on pages %v", missing))
+	}
+	if len(problems) > 0 {
+		return checkResult{name, checkFail, strings.Join(problems, "; ")}
+	}
+	if len(inherited) > 0 {
+		return checkResult{name, checkWarn, fmt.Sprintf("inherited from parent on pages %v", inherited)}
+	}
+	return checkResult{name, checkPass, ""}
+}
+
+// hasInheritedKey reports whether any ancestor of the page defines the key.
+func hasInheritedKey(reader *cos.Reader, page cos.Dict, key string) bool {
+	node := page
+	for depth := 0; depth < 64; depth++ {
+		parent, err := reader.ResolveDict(node.Get("Parent"))
+		if err != nil {
+			return false
+		}
+		if parent.Get(key) != nil {
+			return true
+		}
+		node = parent
+	}
+	return false
+}
+
+// checkPageResources verifies all references reachable from page resources
+// resolve to objects present in the file.
+func checkPageResources(reader *cos.Reader) checkResult {
+	name := "Page resources"
+
+	refs, err := reader.PageRefs()
+	if err != nil {
+		return checkResult{name, checkFail, err.Error()}
+	}
+
+	visited := make(map[int]bool)
+	var broken []string
+
+	for i := range refs {
+		page, err := reader.GetPage(i)
+		if err != nil {
+			continue
+		}
+
+		resources := page.Get("Resources")
+		if resources == nil {
+			continue
+		}
+
+		findBrokenRefs(reader, resources, visited, func(ref *cos.Reference, err error) {
+			broken = append(broken, fmt.Sprintf("page %d: %s (%v)", i, ref, err))
+		})
+	}
+
+	if len(broken) > 0 {
+		detail := broken[0]
+		if len(broken) > 1 {
+			detail = fmt.Sprintf("%s and %d more", detail, len(broken)-1)
+		}
+		return checkResult{name, checkFail, detail}
+	}
+	return checkResult{name, checkPass, fmt.Sprintf("%d objects reachable", len(visited))}
+}
+
+// findBrokenRefs walks an object graph and reports references that cannot be
+// resolved. Stream data is not inspected, only the stream dictionary.
+func findBrokenRefs(reader *cos.Reader, obj cos.Object, visited map[int]bool, report func(*cos.Reference, error)) {
+	switch o := obj.(type) {
+	case *cos.Reference:
+		if visited[o.ObjectNumber] {
+			return
+		}
+		visited[o.ObjectNumber] = true
+
+		resolved, err := reader.GetObject(o.ObjectNumber)
+		if err != nil {
+			report(o, err)
+			return
+		}
+		findBrokenRefs(reader, resolved, visited, report)
+	case cos.Dict:
+		for key, value := range o {
+			// Parent links point back up the page tree
+			if key == "Parent" {
+				continue
+			}
+			findBrokenRefs(reader, value, visited, report)
+		}
+	case cos.Array:
+		for _, item := range o {
+			findBrokenRefs(reader, item, visited, report)
+		}
+	case *cos.Stream:
+		findBrokenRefs(reader, o.Dict, visited, report)
+	}
+}
+
+// checkPageContent parses each page's content stream and looks for
+// unbalanced or misplaced operators.
+func checkPageContent(reader *cos.Reader) checkResult {
+	name := "Page content"
+
+	count, err := reader.PageCount()
+	if err != nil {
+		return checkResult{name, checkFail, err.Error()}
+	}
+
+	var problems []string
+	for i := 0; i < count; i++ {
+		page, err := reader.GetPage(i)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("page %d: %v", i, err))
+			continue
+		}
+
+		contents, err := reader.GetPageContents(page)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("page %d: %v", i, err))
+			continue
+		}
+
+		ops, err := graphics.ParseContentStream(contents)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("page %d: %v", i, err))
+			continue
+		}
+
+		errs := graphics.ValidateContentStream(ops)
+		for _, verr := range errs {
+			problems = append(problems, fmt.Sprintf("page %d: %v", i, verr))
+		}
+	}
+
+	if len(problems) == 0 {
+		return checkResult{name, checkPass, fmt.Sprintf("%d pages checked", count)}
+	}
+
+	// Viewers tolerate these errors, so report them as warnings
+	detail := problems[0]
+	if len(problems) > 1 {
+		detail = fmt.Sprintf("%s and %d more", detail, len(problems)-1)
+	}
+	return checkResult{name, checkWarn, detail}
+}
@@ -0,0 +1,66 @@
+//go:build js && wasm
+
+// Command gumgum-wasm exposes the renderer to JavaScript. Once the module
+// has been started it defines a global function
+//
+//	renderPage(bytes, pageNum, dpi) Uint8Array
+//
+// that renders a 0-indexed page of the PDF in bytes (a Uint8Array) and
+// returns it as PNG. On failure it returns an Error instead.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"syscall/js"
+
+	"gumgum/pkg/api"
+)
+
+func main() {
+	js.Global().Set("renderPage", js.FuncOf(renderPage))
+
+	// Keep the module alive so renderPage stays callable
+	select {}
+}
+
+func renderPage(this js.Value, args []js.Value) (result interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = jsError(fmt.Errorf("render failed: %v", r))
+		}
+	}()
+
+	if len(args) != 3 {
+		return jsError(fmt.Errorf("renderPage expects (bytes, pageNum, dpi), got %d arguments", len(args)))
+	}
+	data := make([]byte, args[0].Get("length").Int())
+	js.CopyBytesToGo(data, args[0])
+	pageNum := args[1].Int()
+	dpi := args[2].Float()
+
+	doc, err := api.OpenBytes(data)
+	if err != nil {
+		return jsError(err)
+	}
+	defer doc.Close()
+
+	img, err := doc.RenderWithOptions(pageNum, api.WithDPI(dpi))
+	if err != nil {
+		return jsError(err)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return jsError(fmt.Errorf("failed to encode PNG: %w", err))
+	}
+
+	out := js.Global().Get("Uint8Array").New(buf.Len())
+	js.CopyBytesToJS(out, buf.Bytes())
+	return out
+}
+
+// jsError converts err to a JavaScript Error.
+func jsError(err error) interface{} {
+	return js.Global().Get("Error").New(err.Error())
+}
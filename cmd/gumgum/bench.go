@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"gumgum/pkg/api"
+)
+
+// benchStats summarises a set of render timings.
+type benchStats struct {
+	Mean   time.Duration
+	Median time.Duration
+	P95    time.Duration
+	P99    time.Duration
+	Total  time.Duration
+	Count  int
+}
+
+// allocStats holds the average heap allocations of one render.
+type allocStats struct {
+	Bytes  uint64
+	Allocs uint64
+}
+
+// Throughput returns the number of pages rendered per second.
+func (s benchStats) Throughput() float64 {
+	if s.Total <= 0 {
+		return 0
+	}
+	return float64(s.Count) / s.Total.Seconds()
+}
+
+func cmdBench(args []string) {
+	path := args[0]
+	pageNum := 0
+	dpi := 150.0
+	iterations := 10
+	allPages := false
+	showMem := false
+
+	for i := 1; i < len(args); i++ {
+		switch args[i] {
+		case "-p":
+			if i+1 < len(args) {
+				pageNum, _ = strconv.Atoi(args[i+1])
+				i++
+			}
+		case "-dpi":
+			if i+1 < len(args) {
+				dpi, _ = strconv.ParseFloat(args[i+1], 64)
+				i++
+			}
+		case "-n":
+			if i+1 < len(args) {
+				iterations, _ = strconv.Atoi(args[i+1])
+				i++
+			}
+		case "-all":
+			allPages = true
+		case "-mem":
+			showMem = true
+		}
+	}
+
+	if iterations < 1 {
+		iterations = 1
+	}
+
+	doc, err := api.Open(path)
+	if err != nil {
+		fatalOpenError(err)
+	}
+	defer doc.Close()
+
+	pages := []int{pageNum}
+	if allPages {
+		pages = make([]int, doc.PageCount())
+		for i := range pages {
+			pages[i] = i
+		}
+	} else if pageNum < 0 || pageNum >= doc.PageCount() {
+		fmt.Printf("Page %d out of range (0-%d)\n", pageNum, doc.PageCount()-1)
+		os.Exit(1)
+	}
+
+	fmt.Printf("File: %s\n", path)
+	fmt.Printf("DPI: %.0f, iterations: %d\n\n", dpi, iterations)
+
+	opts := api.WithDPI(dpi)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	header := "Page\tMean\tMedian\tP95\tP99\tPages/s\t"
+	if showMem {
+		header += "B/op\tallocs/op\t"
+	}
+	fmt.Fprintln(w, header)
+
+	var all []time.Duration
+	for _, p := range pages {
+		timings, allocs, err := benchPage(doc, p, opts, iterations)
+		if err != nil {
+			w.Flush()
+			fmt.Printf("Error rendering page %d: %v\n", p, err)
+			os.Exit(1)
+		}
+		all = append(all, timings...)
+
+		stats := computeBenchStats(timings)
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%.2f\t",
+			p, formatDuration(stats.Mean), formatDuration(stats.Median),
+			formatDuration(stats.P95), formatDuration(stats.P99), stats.Throughput())
+		if showMem {
+			fmt.Fprintf(w, "%d\t%d\t", allocs.Bytes, allocs.Allocs)
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(pages) > 1 {
+		stats := computeBenchStats(all)
+		fmt.Fprintf(w, "all\t%s\t%s\t%s\t%s\t%.2f\t\n",
+			formatDuration(stats.Mean), formatDuration(stats.Median),
+			formatDuration(stats.P95), formatDuration(stats.P99), stats.Throughput())
+	}
+	w.Flush()
+
+	cache := doc.Reader().CacheStats()
+	fmt.Printf("\nObject cache: %d hits, %d misses, %d entries (%.1f%% hit rate)\n",
+		cache.Hits, cache.Misses, cache.Entries, cache.HitRate()*100)
+}
+
+// benchPage renders a page the given number of times and returns each
+// timing and the average allocations per render.
+func benchPage(doc *api.Document, pageNum int, opts api.RenderOptions, iterations int) ([]time.Duration, allocStats, error) {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	timings := make([]time.Duration, 0, iterations)
+	for i := 0; i < iterations; i++ {
+		start := time.Now()
+		if _, err := doc.RenderWithOptions(pageNum, opts); err != nil {
+			return nil, allocStats{}, err
+		}
+		timings = append(timings, time.Since(start))
+	}
+
+	runtime.ReadMemStats(&after)
+	allocs := allocStats{
+		Bytes:  (after.TotalAlloc - before.TotalAlloc) / uint64(iterations),
+		Allocs: (after.Mallocs - before.Mallocs) / uint64(iterations),
+	}
+	return timings, allocs, nil
+}
+
+// computeBenchStats calculates summary statistics for the timings.
+func computeBenchStats(timings []time.Duration) benchStats {
+	stats := benchStats{Count: len(timings)}
+	if len(timings) == 0 {
+		return stats
+	}
+
+	sorted := make([]time.Duration, len(timings))
+	copy(sorted, timings)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	for _, t := range sorted {
+		stats.Total += t
+	}
+	stats.Mean = stats.Total / time.Duration(len(sorted))
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		stats.Median = (sorted[mid-1] + sorted[mid]) / 2
+	} else {
+		stats.Median = sorted[mid]
+	}
+
+	stats.P95 = percentile(sorted, 0.95)
+	stats.P99 = percentile(sorted, 0.99)
+
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of sorted timings.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// formatDuration formats a duration in milliseconds.
+func formatDuration(d time.Duration) string {
+	return fmt.Sprintf("%.2fms", float64(d.Microseconds())/1000)
+}
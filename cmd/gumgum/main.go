@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"image/png"
 	"os"
@@ -10,6 +11,7 @@ import (
 
 	"gumgum/internal/gui"
 	"gumgum/pkg/api"
+	"gumgum/pkg/cos"
 	"gumgum/pkg/graphics"
 )
 
@@ -37,21 +39,65 @@ func main() {
 		page, _ := strconv.Atoi(os.Args[3])
 		cmdStream(os.Args[2], page)
 
+	case "dict":
+		if len(os.Args) < 4 {
+			fmt.Println("Usage: gumgum dict <file.pdf> <objnum>")
+			os.Exit(1)
+		}
+		objNum, _ := strconv.Atoi(os.Args[3])
+		cmdDict(os.Args[2], objNum)
+
 	case "ops":
 		if len(os.Args) < 4 {
-			fmt.Println("Usage: gumgum ops <file.pdf> <page>")
+			fmt.Println("Usage: gumgum ops <file.pdf> <page> [-tree]")
 			os.Exit(1)
 		}
 		page, _ := strconv.Atoi(os.Args[3])
-		cmdOps(os.Args[2], page)
+		tree := len(os.Args) > 4 && os.Args[4] == "-tree"
+		cmdOps(os.Args[2], page, tree)
 
 	case "render":
 		if len(os.Args) < 3 {
-			fmt.Println("Usage: gumgum render <file.pdf> [-o output.png] [-p page] [-dpi value]")
+			fmt.Println("Usage: gumgum render <file.pdf> [-o output.png] [-p page] [-dpi value] [-invert]")
 			os.Exit(1)
 		}
 		cmdRender(os.Args[2:])
 
+	case "render-all":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: gumgum render-all <file.pdf> [-o dir] [-dpi value]")
+			os.Exit(1)
+		}
+		cmdRenderAll(os.Args[2:])
+
+	case "validate":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: gumgum validate <file.pdf>")
+			os.Exit(1)
+		}
+		cmdValidate(os.Args[2])
+
+	case "attachments":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: gumgum attachments <file.pdf> [-o dir]")
+			os.Exit(1)
+		}
+		cmdAttachments(os.Args[2:])
+
+	case "split":
+		if len(os.Args) < 4 {
+			fmt.Println("Usage: gumgum split <file.pdf> <range>... [-o dir] [-compress level]")
+			os.Exit(1)
+		}
+		cmdSplit(os.Args[2:])
+
+	case "bench":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: gumgum bench <file.pdf> [-p page] [-dpi value] [-n iterations] [-all] [-mem]")
+			os.Exit(1)
+		}
+		cmdBench(os.Args[2:])
+
 	case "gui":
 		if len(os.Args) < 3 {
 			cmdGUI(nil)
@@ -59,6 +105,20 @@ func main() {
 			cmdGUI(os.Args[2:])
 		}
 
+	case "profile":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: gumgum profile <file.pdf> [-p page] [-dpi value]")
+			os.Exit(1)
+		}
+		cmdProfile(os.Args[2:])
+
+	case "stats":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: gumgum stats <file.pdf> [-p page]")
+			os.Exit(1)
+		}
+		cmdStats(os.Args[2:])
+
 	case "help", "-h", "--help":
 		printUsage()
 
@@ -91,18 +151,50 @@ Usage:
 Commands:
   info <file.pdf>              Show PDF metadata and page count
   stream <file.pdf> <page>     Dump raw content stream for a page
+  dict <file.pdf> <objnum>     Pretty-print an indirect object
   ops <file.pdf> <page>        List drawing operations for a page
-  render <file.pdf> [options]  Render a page to PNG
-    -o <output.png>            Output file (default: output.png)
+    -tree                      Nest operators by q/Q and BT/ET and show
+                               the transform after each cm
+  render <file.pdf> [options]  Render a page to an image
+    -o <output.png>            Output file, or - for stdout
+                               (default: output.<format>)
     -p <page>                  Page number, 0-indexed (default: 0)
     -dpi <value>               Resolution (default: 150)
+    -fmt <format>              png, jpeg or webp (default: png)
+    -invert                    Invert colors, for reading on dark screens
+  render-all <file.pdf> [options]
+                               Render every page to PNG files
+    -o <dir>                   Output directory (default: .)
+    -dpi <value>               Resolution (default: 150)
+  validate <file.pdf>          Check PDF structure and report problems
+  attachments <file.pdf> [-o <dir>]
+                               Extract embedded files (default dir: .)
+  split <file.pdf> <range>... [options]
+                               Write each page range, such as 1-10, to a
+                               separate PDF
+    -o <dir>                   Output directory (default: .)
+    -compress <level>          Compress streams that have no filter, from
+                               0 (store) to 9 (default: leave as they are)
+  bench <file.pdf> [options]   Measure render time for a page
+    -p <page>                  Page number, 0-indexed (default: 0)
+    -dpi <value>               Resolution (default: 150)
+    -n <iterations>            Renders per page (default: 10)
+    -all                       Benchmark every page in order
+    -mem                       Report bytes and allocations per render
+  profile <file.pdf> [options] Print render statistics for a page as JSON
+    -p <page>                  Page number, 0-indexed (default: 0)
+    -dpi <value>               Resolution (default: 150)
+  stats <file.pdf> [-p <page>] Count paths, text, images, graphics states
+                               and fonts on a page as JSON
   gui [file.pdf]               Open GUI viewer
   <file.pdf>                   Open PDF in GUI viewer (shortcut)
 
 Examples:
   gumgum info document.pdf
   gumgum stream document.pdf 0
+  gumgum dict document.pdf 12
   gumgum render document.pdf -o page1.png -p 0 -dpi 300
+  gumgum render document.pdf -o - | convert - -resize 50% thumb.png
   gumgum document.pdf
 
 Built with:
@@ -112,17 +204,22 @@ Built with:
   - fyne.io for native GUI`)
 }
 
+// infoDateLayout formats document dates for the info command.
+const infoDateLayout = "2 Jan 2006 15:04:05 -07:00"
+
 func cmdInfo(path string) {
 	doc, err := api.Open(path)
 	if err != nil {
-		fmt.Printf("Error opening PDF: %v\n", err)
-		os.Exit(1)
+		fatalOpenError(err)
 	}
 	defer doc.Close()
 
 	fmt.Printf("File: %s\n", path)
 	fmt.Println("────────────────────────────────────────")
 	fmt.Printf("Pages: %d\n", doc.PageCount())
+	if doc.Reader().IsLinearized() {
+		fmt.Println("Linearized: yes")
+	}
 
 	// Document info
 	info := doc.Info()
@@ -141,11 +238,11 @@ func cmdInfo(path string) {
 	if info.Producer != "" {
 		fmt.Printf("Producer: %s\n", info.Producer)
 	}
-	if info.CreationDate != "" {
-		fmt.Printf("Created: %s\n", info.CreationDate)
+	if !info.CreationDate.IsZero() {
+		fmt.Printf("Created: %s\n", info.CreationDate.Format(infoDateLayout))
 	}
-	if info.ModDate != "" {
-		fmt.Printf("Modified: %s\n", info.ModDate)
+	if !info.ModDate.IsZero() {
+		fmt.Printf("Modified: %s\n", info.ModDate.Format(infoDateLayout))
 	}
 
 	// First page info
@@ -167,8 +264,7 @@ func cmdInfo(path string) {
 func cmdStream(path string, pageNum int) {
 	doc, err := api.Open(path)
 	if err != nil {
-		fmt.Printf("Error opening PDF: %v\n", err)
-		os.Exit(1)
+		fatalOpenError(err)
 	}
 	defer doc.Close()
 
@@ -193,12 +289,28 @@ func cmdStream(path string, pageNum int) {
 	fmt.Println(string(contents))
 }
 
-func cmdOps(path string, pageNum int) {
+func cmdDict(path string, objNum int) {
 	doc, err := api.Open(path)
 	if err != nil {
-		fmt.Printf("Error opening PDF: %v\n", err)
+		fatalOpenError(err)
+	}
+	defer doc.Close()
+
+	obj, err := doc.Reader().GetObject(objNum)
+	if err != nil {
+		fmt.Printf("Error getting object %d: %v\n", objNum, err)
 		os.Exit(1)
 	}
+
+	fmt.Printf("=== Object %d ===\n\n", objNum)
+	fmt.Println(cos.PrettyPrint(obj, 0))
+}
+
+func cmdOps(path string, pageNum int, tree bool) {
+	doc, err := api.Open(path)
+	if err != nil {
+		fatalOpenError(err)
+	}
 	defer doc.Close()
 
 	if pageNum < 0 || pageNum >= doc.PageCount() {
@@ -226,6 +338,11 @@ func cmdOps(path string, pageNum int) {
 
 	fmt.Printf("=== Page %d Operations (%d total) ===\n\n", pageNum, len(ops))
 
+	if tree {
+		printOperatorTree(graphics.GroupOperators(ops), "")
+		return
+	}
+
 	for i, op := range ops {
 		if len(op.Operands) > 0 {
 			fmt.Printf("%4d: %v %s\n", i+1, op.Operands, op.Name)
@@ -235,16 +352,50 @@ func cmdOps(path string, pageNum int) {
 	}
 }
 
+// printOperatorTree prints grouped operators with box-drawing indentation.
+func printOperatorTree(nodes []*graphics.OperatorNode, indent string) {
+	for i, node := range nodes {
+		branch, childIndent := "├── ", indent+"│   "
+		if i == len(nodes)-1 {
+			branch, childIndent = "└── ", indent+"    "
+		}
+
+		end := "unclosed"
+		if node.End >= 0 {
+			end = fmt.Sprintf("%d", node.End+1)
+		}
+		switch node.Group {
+		case graphics.GroupState:
+			fmt.Printf("%s%sq … Q (%d-%s)\n", indent, branch, node.Index+1, end)
+		case graphics.GroupText:
+			fmt.Printf("%s%stext block (%d-%s)\n", indent, branch, node.Index+1, end)
+		default:
+			line := fmt.Sprintf("%d: %s", node.Index+1, node.Op.Name)
+			if len(node.Op.Operands) > 0 {
+				line = fmt.Sprintf("%d: %v %s", node.Index+1, node.Op.Operands, node.Op.Name)
+			}
+			if node.Op.Name == "cm" {
+				m := node.CTM
+				line += fmt.Sprintf("  → CTM [%.4g %.4g %.4g %.4g %.4g %.4g]", m[0], m[1], m[2], m[3], m[4], m[5])
+			}
+			fmt.Printf("%s%s%s\n", indent, branch, line)
+		}
+		printOperatorTree(node.Children, childIndent)
+	}
+}
+
 func cmdRender(args []string) {
 	if len(args) < 1 {
-		fmt.Println("Usage: gumgum render <file.pdf> [-o output.png] [-p page] [-dpi value]")
+		fmt.Println("Usage: gumgum render <file.pdf> [-o output.png|-] [-p page] [-dpi value] [-fmt format] [-invert]")
 		os.Exit(1)
 	}
 
 	path := args[0]
-	output := "output.png"
+	output := ""
+	format := "png"
 	pageNum := 0
 	dpi := 150.0
+	invert := false
 
 	// Parse arguments
 	for i := 1; i < len(args); i++ {
@@ -264,52 +415,76 @@ func cmdRender(args []string) {
 				dpi, _ = strconv.ParseFloat(args[i+1], 64)
 				i++
 			}
+		case "-fmt":
+			if i+1 < len(args) {
+				format = strings.ToLower(args[i+1])
+				i++
+			}
+		case "-invert":
+			invert = true
 		}
 	}
+	if output == "" {
+		output = "output." + format
+	}
 
-	fmt.Printf("Opening %s...\n", path)
+	// With -o - the image goes to stdout, so messages go to stderr
+	status := os.Stdout
+	if output == "-" {
+		status = os.Stderr
+	}
+
+	fmt.Fprintf(status, "Opening %s...\n", path)
 
 	doc, err := api.Open(path)
 	if err != nil {
-		fmt.Printf("Error opening PDF: %v\n", err)
-		os.Exit(1)
+		fatalOpenError(err)
 	}
 	defer doc.Close()
 
 	if pageNum < 0 || pageNum >= doc.PageCount() {
-		fmt.Printf("Page %d out of range (0-%d)\n", pageNum, doc.PageCount()-1)
+		fmt.Fprintf(status, "Page %d out of range (0-%d)\n", pageNum, doc.PageCount()-1)
 		os.Exit(1)
 	}
 
-	fmt.Printf("Rendering page %d at %.0f DPI...\n", pageNum, dpi)
+	fmt.Fprintf(status, "Rendering page %d at %.0f DPI...\n", pageNum, dpi)
 
 	opts := api.WithDPI(dpi)
+	opts.Invert = invert
 	img, err := doc.RenderWithOptions(pageNum, opts)
 	if err != nil {
-		fmt.Printf("Error rendering page: %v\n", err)
+		fmt.Fprintf(status, "Error rendering page: %v\n", err)
 		os.Exit(1)
 	}
 
+	if output == "-" {
+		if err := api.EncodeImage(os.Stdout, img, format); err != nil {
+			fmt.Fprintf(status, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Ensure output directory exists
 	dir := filepath.Dir(output)
 	if dir != "" && dir != "." {
 		os.MkdirAll(dir, 0755)
 	}
 
-	// Save PNG
+	// Save image
 	f, err := os.Create(output)
 	if err != nil {
-		fmt.Printf("Error creating output file: %v\n", err)
+		fmt.Fprintf(status, "Error creating output file: %v\n", err)
 		os.Exit(1)
 	}
 	defer f.Close()
 
-	if err := png.Encode(f, img); err != nil {
-		fmt.Printf("Error encoding PNG: %v\n", err)
+	if err := api.EncodeImage(f, img, format); err != nil {
+		fmt.Fprintf(status, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Printf("Saved %s (%dx%d pixels)\n", output, img.Bounds().Dx(), img.Bounds().Dy())
+	fmt.Fprintf(status, "Saved %s (%dx%d pixels)\n", output, img.Bounds().Dx(), img.Bounds().Dy())
 }
 
 func cmdGUI(args []string) {
@@ -321,3 +496,88 @@ func cmdGUI(args []string) {
 		app.Run()
 	}
 }
+
+func cmdRenderAll(args []string) {
+	path := args[0]
+	outDir := "."
+	dpi := 150.0
+
+	for i := 1; i < len(args); i++ {
+		switch args[i] {
+		case "-o":
+			if i+1 < len(args) {
+				outDir = args[i+1]
+				i++
+			}
+		case "-dpi":
+			if i+1 < len(args) {
+				dpi, _ = strconv.ParseFloat(args[i+1], 64)
+				i++
+			}
+		}
+	}
+
+	doc, err := api.Open(path)
+	if err != nil {
+		fatalOpenError(err)
+	}
+	defer doc.Close()
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		fmt.Printf("Error creating output directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	pageCount := doc.PageCount()
+	fmt.Fprintf(os.Stderr, "Rendering %d pages at %.0f DPI...\n", pageCount, dpi)
+
+	progress := newProgressBar(pageCount)
+	opts := api.WithDPI(dpi)
+
+	// Errors are reported once the bar is finished, so they do not break
+	// up the progress line
+	var errs []string
+	for i := 0; i < pageCount; i++ {
+		output := filepath.Join(outDir, fmt.Sprintf("page-%03d.png", i+1))
+		if err := renderPageToFile(doc, i, opts, output); err != nil {
+			errs = append(errs, fmt.Sprintf("Error rendering page %d: %v", i, err))
+		}
+		progress.Increment()
+	}
+	progress.Finish()
+
+	for _, msg := range errs {
+		fmt.Fprintln(os.Stderr, msg)
+	}
+	failed := len(errs)
+	fmt.Fprintf(os.Stderr, "Saved %d pages to %s\n", pageCount-failed, outDir)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// renderPageToFile renders a single page and writes it as a PNG file.
+func renderPageToFile(doc *api.Document, pageNum int, opts api.RenderOptions, output string) error {
+	img, err := doc.RenderWithOptions(pageNum, opts)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return png.Encode(f, img)
+}
+
+// fatalOpenError reports a failure to open a PDF and exits.
+func fatalOpenError(err error) {
+	if errors.Is(err, api.ErrEncrypted) {
+		fmt.Println("This PDF is encrypted; password entry is not yet supported")
+	} else {
+		fmt.Printf("Error opening PDF: %v\n", err)
+	}
+	os.Exit(1)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"gumgum/pkg/api"
+)
+
+// profileReport is the JSON output of the profile command. Durations are
+// in milliseconds.
+type profileReport struct {
+	Page             int                `json:"page"`
+	DPI              float64            `json:"dpi"`
+	OperatorCount    int                `json:"operatorCount"`
+	PathSegmentCount int                `json:"pathSegmentCount"`
+	GlyphCount       int                `json:"glyphCount"`
+	PeakAllocBytes   int64              `json:"peakAllocBytes"`
+	DurationMs       float64            `json:"durationMs"`
+	StagesMs         map[string]float64 `json:"stagesMs"`
+}
+
+func cmdProfile(args []string) {
+	path := args[0]
+	pageNum := 0
+	dpi := 150.0
+
+	for i := 1; i < len(args); i++ {
+		switch args[i] {
+		case "-p":
+			if i+1 < len(args) {
+				pageNum, _ = strconv.Atoi(args[i+1])
+				i++
+			}
+		case "-dpi":
+			if i+1 < len(args) {
+				dpi, _ = strconv.ParseFloat(args[i+1], 64)
+				i++
+			}
+		}
+	}
+
+	doc, err := api.Open(path)
+	if err != nil {
+		fatalOpenError(err)
+	}
+	defer doc.Close()
+
+	if pageNum < 0 || pageNum >= doc.PageCount() {
+		fmt.Printf("Page %d out of range (0-%d)\n", pageNum, doc.PageCount()-1)
+		os.Exit(1)
+	}
+
+	report := profileReport{Page: pageNum, DPI: dpi, StagesMs: make(map[string]float64)}
+	opts := api.WithDPI(dpi)
+	opts.Profiler = func(stage string, d time.Duration) {
+		report.StagesMs[stage] = milliseconds(d)
+	}
+
+	_, profile, err := doc.RenderWithProfile(pageNum, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error rendering page %d: %v\n", pageNum, err)
+		os.Exit(1)
+	}
+	report.OperatorCount = profile.OperatorCount
+	report.PathSegmentCount = profile.PathSegmentCount
+	report.GlyphCount = profile.GlyphCount
+	report.PeakAllocBytes = profile.PeakAllocBytes
+	report.DurationMs = milliseconds(profile.Duration)
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(report)
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gumgum/pkg/api"
+	"gumgum/pkg/cos"
+)
+
+func cmdSplit(args []string) {
+	path := args[0]
+	outDir := "."
+	level := -1 // Unset: streams are copied as they are
+	var ranges []api.PageRange
+	var names []string
+
+	for i := 1; i < len(args); i++ {
+		if args[i] == "-o" {
+			if i+1 < len(args) {
+				outDir = args[i+1]
+				i++
+			}
+			continue
+		}
+		if args[i] == "-compress" {
+			if i+1 < len(args) {
+				n, err := strconv.Atoi(args[i+1])
+				if err != nil || n < 0 || n > 9 {
+					fmt.Printf("Error: invalid compression level %q (0-9)\n", args[i+1])
+					os.Exit(1)
+				}
+				level = n
+				i++
+			}
+			continue
+		}
+		r, err := parsePageRange(args[i])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		ranges = append(ranges, r)
+		names = append(names, args[i])
+	}
+	if len(ranges) == 0 {
+		fmt.Println("Usage: gumgum split <file.pdf> <range>... [-o dir] [-compress level]")
+		os.Exit(1)
+	}
+
+	doc, err := api.Open(path)
+	if err != nil {
+		fatalOpenError(err)
+	}
+	defer doc.Close()
+
+	// Level 0 stores streams uncompressed, which is not the same as
+	// leaving them alone
+	var streams cos.StreamOptions
+	if level >= 0 {
+		streams = cos.StreamOptions{Compress: true, Level: level}
+	}
+
+	parts, err := api.SplitByRange(doc, ranges, streams)
+	if err != nil {
+		fmt.Printf("Error splitting PDF: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		fmt.Printf("Error creating output directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	for i, part := range parts {
+		output := filepath.Join(outDir, fmt.Sprintf("%s-%s.pdf", base, names[i]))
+		if err := writePDF(part, output); err != nil {
+			fmt.Printf("Error writing %s: %v\n", output, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Saved %s (%d pages)\n", output, part.PageCount())
+	}
+}
+
+// parsePageRange parses a 1-indexed page or page range such as 3 or 1-10.
+func parsePageRange(s string) (api.PageRange, error) {
+	first, last, isRange := strings.Cut(s, "-")
+	if !isRange {
+		last = first
+	}
+	a, err1 := strconv.Atoi(first)
+	b, err2 := strconv.Atoi(last)
+	if err1 != nil || err2 != nil || a < 1 || b < a {
+		return api.PageRange{}, fmt.Errorf("invalid page range %q", s)
+	}
+	return api.PageRange{Start: a - 1, End: b}, nil
+}
+
+// writePDF writes a document to a file.
+func writePDF(doc *api.Document, output string) error {
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if _, err := doc.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -2,14 +2,18 @@
 package gui
 
 import (
+	"errors"
 	"fmt"
 	"image"
+	"net/url"
+	"sync"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
-	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
@@ -20,29 +24,61 @@ import (
 type App struct {
 	fyneApp    fyne.App
 	mainWindow fyne.Window
+	
+	// navMu guards the document and how it is shown: the fields from
+	// document to darkMode. Pages are turned by the presentation ticker as
+	// well as from the UI, so everything reading or changing them takes
+	// it. It is taken before presentMu.
+	navMu      sync.Mutex
 	document   *api.Document
 	currentPage int
 	dpi        float64
+	tileDPI    float64 // Resolution of the tile shown of a page too large to render whole
+	
+	// Rotation added to each page for this session, in degrees clockwise
+	pageRotations map[int]int
+	
+	// Where each page visited was panned to when the user left it
+	pageScrollPositions map[int]fyne.Position
+	
+	// Dark mode inverts rendered pages and uses the dark theme
+	darkMode     bool
+	darkModeItem *fyne.MenuItem
 
 	// UI components
-	pageImage   *canvas.Image
+	viewer      *PageViewer
 	pageLabel   *widget.Label
 	prevButton  *widget.Button
 	nextButton  *widget.Button
 	zoomInBtn   *widget.Button
 	zoomOutBtn  *widget.Button
-	scrollContainer *container.Scroll
+	
+	// Presentation mode
+	presentProgress *widget.ProgressBar
+	presentInterval time.Duration
+	presentMu       sync.Mutex    // Guards presentation
+	presentation    *presentation // Running auto-advance, nil if none
+
+	// Keyboard shortcuts, loaded from the preferences
+	KeyMap       map[fyne.KeyName]func()
+	keyBindings  map[fyne.KeyName]string // Action ID of each key in KeyMap
+	onKeyCapture func(*fyne.KeyEvent)    // Takes the next key while rebinding
 }
 
 // NewApp creates a new PDF viewer application.
 func NewApp() *App {
 	a := &App{
-		fyneApp: app.New(),
+		fyneApp: app.NewWithID("io.gumgum.viewer"),
 		currentPage: 0,
 		dpi: 150,
+		presentInterval: 5 * time.Second,
+		pageRotations: make(map[int]int),
+		pageScrollPositions: make(map[int]fyne.Position),
 	}
 	
-	a.fyneApp.Settings().SetTheme(theme.DarkTheme())
+	a.darkMode = a.fyneApp.Preferences().Bool(darkModePreference)
+	a.applyTheme()
+	a.loadKeyMap()
 	a.mainWindow = a.fyneApp.NewWindow("GumGum PDF Viewer")
 	a.mainWindow.Resize(fyne.NewSize(900, 700))
 	
@@ -62,7 +98,7 @@ func (a *App) RunWithFile(path string) {
 	// Load file after window is ready
 	go func() {
 		if err := a.loadFile(path); err != nil {
-			dialog.ShowError(err, a.mainWindow)
+			a.showLoadError(err)
 		}
 	}()
 	
@@ -71,10 +107,16 @@ func (a *App) RunWithFile(path string) {
 
 // buildUI constructs the user interface.
 func (a *App) buildUI() {
-	// Create placeholder image
-	a.pageImage = canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 1, 1)))
-	a.pageImage.FillMode = canvas.ImageFillContain
-	a.pageImage.ScaleMode = canvas.ImageScaleSmooth
+	// Page viewer
+	a.viewer = NewPageViewer()
+	a.viewer.OnGoToPage = a.goToPage
+	a.viewer.OnTapped = a.stopPresentation
+	a.viewer.OnViewChanged = a.updateTile
+	a.viewer.OnOpenURI = func(u *url.URL) {
+		if err := fyne.CurrentApp().OpenURL(u); err != nil {
+			dialog.ShowError(err, a.mainWindow)
+		}
+	}
 	
 	// Page label
 	a.pageLabel = widget.NewLabel("No document loaded")
@@ -94,9 +136,29 @@ func (a *App) buildUI() {
 	// Open button
 	openBtn := widget.NewButtonWithIcon("Open", theme.FolderOpenIcon(), a.openFile)
 	
+	// Print button
+	printBtn := widget.NewButtonWithIcon("Print", theme.DocumentPrintIcon(), a.printPage)
+	
+	// Rotation buttons
+	rotateCCWBtn := widget.NewButtonWithIcon("", theme.ContentUndoIcon(), a.rotateCounterClockwise)
+	rotateCWBtn := widget.NewButtonWithIcon("", theme.ContentRedoIcon(), a.rotateClockwise)
+	
+	// Presentation controls
+	presentBtn := widget.NewButtonWithIcon("Presentation", theme.MediaPlayIcon(), a.togglePresentation)
+	var intervalBtn *widget.Button
+	intervalBtn = widget.NewButtonWithIcon("", theme.SettingsIcon(), func() {
+		a.showIntervalMenu(intervalBtn)
+	})
+	
+	// Countdown shown while presenting
+	a.presentProgress = widget.NewProgressBar()
+	a.presentProgress.TextFormatter = func() string { return "" }
+	a.presentProgress.Hide()
+	
 	// Toolbar
 	toolbar := container.NewHBox(
 		openBtn,
+		printBtn,
 		widget.NewSeparator(),
 		a.prevButton,
 		a.pageLabel,
@@ -105,43 +167,70 @@ func (a *App) buildUI() {
 		a.zoomOutBtn,
 		widget.NewLabel("Zoom"),
 		a.zoomInBtn,
+		widget.NewSeparator(),
+		rotateCCWBtn,
+		rotateCWBtn,
+		widget.NewSeparator(),
+		presentBtn,
+		intervalBtn,
 	)
 	
-	// Scroll container for the page
-	a.scrollContainer = container.NewScroll(a.pageImage)
-	
 	// Main layout
 	content := container.NewBorder(
 		container.NewPadded(toolbar), // Top
-		nil, // Bottom
+		a.presentProgress, // Bottom
 		nil, // Left
 		nil, // Right
-		a.scrollContainer, // Center
+		a.viewer, // Center
 	)
 	
 	a.mainWindow.SetContent(content)
+	a.darkModeItem = fyne.NewMenuItem("Dark Mode", a.toggleDarkMode)
+	a.darkModeItem.Checked = a.darkMode
+	a.mainWindow.SetMainMenu(fyne.NewMainMenu(
+		fyne.NewMenu("View",
+			fyne.NewMenuItem("Rotate Clockwise", a.rotateClockwise),
+			fyne.NewMenuItem("Rotate Counter-Clockwise", a.rotateCounterClockwise),
+			fyne.NewMenuItem("Reset Rotation", a.resetRotation),
+			fyne.NewMenuItemSeparator(),
+			a.darkModeItem,
+		),
+		fyne.NewMenu("Help",
+			fyne.NewMenuItem("Keyboard Shortcuts", a.showKeyboardShortcuts),
+		),
+	))
 	
 	// Set up keyboard shortcuts
 	a.mainWindow.Canvas().SetOnTypedKey(a.handleKey)
+	a.mainWindow.Canvas().AddShortcut(&desktop.CustomShortcut{
+		KeyName:  fyne.KeyP,
+		Modifier: fyne.KeyModifierShortcutDefault,
+	}, func(fyne.Shortcut) {
+		a.printPage()
+	})
+	a.mainWindow.Canvas().AddShortcut(&desktop.CustomShortcut{
+		KeyName:  fyne.KeyR,
+		Modifier: fyne.KeyModifierShift,
+	}, func(fyne.Shortcut) {
+		a.rotateCounterClockwise()
+	})
 }
 
-// handleKey handles keyboard navigation.
+// handleKey runs the action bound to a key in KeyMap.
 func (a *App) handleKey(key *fyne.KeyEvent) {
-	switch key.Name {
-	case fyne.KeyLeft, fyne.KeyUp, fyne.KeyPageUp:
-		a.prevPage()
-	case fyne.KeyRight, fyne.KeyDown, fyne.KeyPageDown, fyne.KeySpace:
-		a.nextPage()
-	case fyne.KeyHome:
-		a.goToPage(0)
-	case fyne.KeyEnd:
-		if a.document != nil {
-			a.goToPage(a.document.PageCount() - 1)
-		}
-	case fyne.KeyPlus, fyne.KeyEqual:
-		a.zoomIn()
-	case fyne.KeyMinus:
-		a.zoomOut()
+	if a.onKeyCapture != nil {
+		a.onKeyCapture(key)
+		return
+	}
+
+	// Any key ends the presentation
+	if a.presenting() {
+		a.stopPresentation()
+		return
+	}
+	
+	if action := a.KeyMap[key.Name]; action != nil {
+		action()
 	}
 }
 
@@ -159,11 +248,21 @@ func (a *App) openFile() {
 		
 		path := reader.URI().Path()
 		if err := a.loadFile(path); err != nil {
-			dialog.ShowError(err, a.mainWindow)
+			a.showLoadError(err)
 		}
 	}, a.mainWindow)
 }
 
+// showLoadError reports a failure to open a document.
+func (a *App) showLoadError(err error) {
+	if errors.Is(err, api.ErrEncrypted) {
+		dialog.ShowInformation("Encrypted PDF",
+			"This PDF is encrypted; password entry is not yet supported.", a.mainWindow)
+		return
+	}
+	dialog.ShowError(err, a.mainWindow)
+}
+
 // loadFile loads a PDF file.
 func (a *App) loadFile(path string) error {
 	doc, err := api.Open(path)
@@ -171,6 +270,9 @@ func (a *App) loadFile(path string) error {
 		return fmt.Errorf("failed to open PDF: %w", err)
 	}
 	
+	a.navMu.Lock()
+	defer a.navMu.Unlock()
+	
 	// Close previous document
 	if a.document != nil {
 		a.document.Close()
@@ -178,6 +280,8 @@ func (a *App) loadFile(path string) error {
 	
 	a.document = doc
 	a.currentPage = 0
+	a.pageRotations = make(map[int]int)
+	a.pageScrollPositions = make(map[int]fyne.Position)
 	
 	// Update window title
 	a.mainWindow.SetTitle(fmt.Sprintf("GumGum - %s", path))
@@ -189,30 +293,50 @@ func (a *App) loadFile(path string) error {
 	return a.renderCurrentPage()
 }
 
-// renderCurrentPage renders and displays the current page.
+// renderCurrentPage renders and displays the current page; the caller
+// holds navMu.
 func (a *App) renderCurrentPage() error {
 	if a.document == nil {
 		return nil
 	}
 	
-	opts := api.WithDPI(a.dpi)
-	img, err := a.document.RenderWithOptions(a.currentPage, opts)
+	page, err := a.document.Page(a.currentPage)
 	if err != nil {
 		return fmt.Errorf("failed to render page: %w", err)
 	}
 	
-	// Update image
-	a.pageImage.Image = img
-	a.pageImage.SetMinSize(fyne.NewSize(float32(img.Bounds().Dx()), float32(img.Bounds().Dy())))
-	a.pageImage.Refresh()
+	rotation := a.pageRotation(page)
+	
+	// Pages too large to render whole are rendered a tile at a time,
+	// covering the part in view
+	if w, h, tiled := pageTooLarge(page, a.dpi); tiled {
+		if rotation == 90 || rotation == 270 {
+			w, h = h, w
+		}
+		a.viewer.setTiled(image.Pt(w, h))
+		a.updateTileLocked()
+	} else {
+		opts := api.WithDPI(a.dpi)
+		opts.Invert = a.darkMode
+		opts.Prefetch = 2
+		img, err := a.document.RenderWithOptions(a.currentPage, opts)
+		if err != nil {
+			return fmt.Errorf("failed to render page: %w", err)
+		}
+		
+		// Update image (also resets the view position)
+		a.viewer.SetImage(rotateImage(img, rotation))
+	}
 	
-	// Reset scroll position
-	a.scrollContainer.ScrollToTop()
+	// Update clickable links
+	links, _ := page.Links()
+	a.viewer.SetLinks(links, page, a.dpi, rotation)
 	
 	return nil
 }
 
-// updateNavigation updates navigation buttons and label.
+// updateNavigation updates navigation buttons and label; the caller holds
+// navMu.
 func (a *App) updateNavigation() {
 	if a.document == nil {
 		a.pageLabel.SetText("No document loaded")
@@ -237,46 +361,102 @@ func (a *App) updateNavigation() {
 	}
 }
 
+// pageTurn is a request to change page, handled by turnPage.
+type pageTurn struct {
+	target       func(current int) int // Page to show, given the current one
+	presentation *presentation         // Auto-advance that asked, nil if the user did
+}
+
 // prevPage navigates to the previous page.
 func (a *App) prevPage() {
-	if a.document == nil || a.currentPage <= 0 {
-		return
-	}
-	a.currentPage--
-	a.updateNavigation()
-	a.renderCurrentPage()
+	a.navigate(func(current int) int { return current - 1 })
 }
 
 // nextPage navigates to the next page.
 func (a *App) nextPage() {
-	if a.document == nil || a.currentPage >= a.document.PageCount()-1 {
-		return
-	}
-	a.currentPage++
-	a.updateNavigation()
-	a.renderCurrentPage()
+	a.navigate(func(current int) int { return current + 1 })
 }
 
 // goToPage navigates to a specific page.
 func (a *App) goToPage(page int) {
+	a.navigate(func(int) int { return page })
+}
+
+// navigate changes page for the user. Like any user navigation it ends
+// the presentation.
+func (a *App) navigate(target func(current int) int) {
+	a.turnPage(pageTurn{target: target})
+}
+
+// turnPage makes one page change. A change by the user stops the
+// presentation; one by a presentation that has since been stopped is
+// dropped, and one made on the last page ends the presentation instead.
+// navMu serializes it with every other change of page, whichever
+// goroutine it comes from.
+func (a *App) turnPage(turn pageTurn) {
+	a.navMu.Lock()
+	defer a.navMu.Unlock()
+
+	a.presentMu.Lock()
+	if turn.presentation == nil {
+		a.stopPresentationLocked()
+	} else if a.presentation != turn.presentation {
+		a.presentMu.Unlock()
+		return
+	}
+	a.presentMu.Unlock()
+
 	if a.document == nil {
 		return
 	}
+	last := a.document.PageCount() - 1
+	if turn.presentation != nil && a.currentPage >= last {
+		// Reached the last page
+		a.stopPresentation()
+		return
+	}
+
+	page := turn.target(a.currentPage)
 	if page < 0 {
 		page = 0
 	}
-	if page >= a.document.PageCount() {
-		page = a.document.PageCount() - 1
+	if page > last {
+		page = last
 	}
 	if page != a.currentPage {
-		a.currentPage = page
-		a.updateNavigation()
-		a.renderCurrentPage()
+		a.showPage(page)
+	}
+}
+
+// showPage switches to another page, remembering where the current one was
+// panned to and returning to where the new one was left, if it was visited
+// before. The caller holds navMu.
+func (a *App) showPage(page int) {
+	a.pageScrollPositions[a.currentPage] = a.viewer.Offset()
+	a.currentPage = page
+	a.updateNavigation()
+	a.renderCurrentPage()
+	if pos, ok := a.pageScrollPositions[page]; ok {
+		a.viewer.SetOffset(pos)
+		a.updateTileLocked()
+	}
+}
+
+// printPage prints the current page.
+func (a *App) printPage() {
+	a.navMu.Lock()
+	doc, page := a.document, a.currentPage
+	a.navMu.Unlock()
+	if doc == nil {
+		return
 	}
+	PrintPage(doc, page, a.mainWindow)
 }
 
 // zoomIn increases the DPI.
 func (a *App) zoomIn() {
+	a.navMu.Lock()
+	defer a.navMu.Unlock()
 	if a.dpi < 400 {
 		a.dpi += 25
 		a.renderCurrentPage()
@@ -285,6 +465,8 @@ func (a *App) zoomIn() {
 
 // zoomOut decreases the DPI.
 func (a *App) zoomOut() {
+	a.navMu.Lock()
+	defer a.navMu.Unlock()
 	if a.dpi > 50 {
 		a.dpi -= 25
 		a.renderCurrentPage()
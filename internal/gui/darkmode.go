@@ -0,0 +1,35 @@
+package gui
+
+import "fyne.io/fyne/v2/theme"
+
+// darkModePreference is the preferences key dark mode is saved under.
+const darkModePreference = "darkMode"
+
+// toggleDarkMode switches dark mode, saves the choice and redraws the page.
+func (a *App) toggleDarkMode() {
+	// Only the UI goroutine changes darkMode, so it may read it unlocked
+	a.navMu.Lock()
+	a.darkMode = !a.darkMode
+	a.navMu.Unlock()
+	a.fyneApp.Preferences().SetBool(darkModePreference, a.darkMode)
+
+	// Changing the theme lays out the window again, which can render a
+	// tile, so navMu is not held meanwhile
+	a.applyTheme()
+	a.darkModeItem.Checked = a.darkMode
+	a.mainWindow.MainMenu().Refresh()
+
+	a.navMu.Lock()
+	defer a.navMu.Unlock()
+	a.renderCurrentPage()
+}
+
+// applyTheme sets the theme matching dark mode. Theme icons are recolored
+// by Fyne when the theme changes.
+func (a *App) applyTheme() {
+	if a.darkMode {
+		a.fyneApp.Settings().SetTheme(theme.DarkTheme())
+	} else {
+		a.fyneApp.Settings().SetTheme(theme.LightTheme())
+	}
+}
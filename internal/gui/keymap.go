@@ -0,0 +1,202 @@
+package gui
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// keyMapPreference is the preferences key the key bindings are saved
+// under, as a JSON object mapping key names to action IDs.
+const keyMapPreference = "keymap"
+
+// keyAction is a command that can be bound to a key.
+type keyAction struct {
+	id    string
+	label string
+	run   func(a *App)
+}
+
+// keyActions lists the bindable commands in the order they are shown.
+var keyActions = []keyAction{
+	{"prev-page", "Previous page", (*App).prevPage},
+	{"next-page", "Next page", (*App).nextPage},
+	{"first-page", "First page", func(a *App) { a.goToPage(0) }},
+	{"last-page", "Last page", func(a *App) {
+		// turnPage holds navMu and has checked there is a document
+		a.navigate(func(int) int { return a.document.PageCount() - 1 })
+	}},
+	{"zoom-in", "Zoom in", (*App).zoomIn},
+	{"zoom-out", "Zoom out", (*App).zoomOut},
+	{"rotate-cw", "Rotate clockwise", (*App).rotateClockwise},
+	{"rotate-ccw", "Rotate counter-clockwise", (*App).rotateCounterClockwise},
+	{"reset-rotation", "Reset rotation", (*App).resetRotation},
+}
+
+// defaultKeyBindings maps keys to the IDs of their actions.
+var defaultKeyBindings = map[fyne.KeyName]string{
+	fyne.KeyLeft:     "prev-page",
+	fyne.KeyUp:       "prev-page",
+	fyne.KeyPageUp:   "prev-page",
+	fyne.KeyRight:    "next-page",
+	fyne.KeyDown:     "next-page",
+	fyne.KeyPageDown: "next-page",
+	fyne.KeySpace:    "next-page",
+	fyne.KeyHome:     "first-page",
+	fyne.KeyEnd:      "last-page",
+	fyne.KeyPlus:     "zoom-in",
+	fyne.KeyEqual:    "zoom-in",
+	fyne.KeyMinus:    "zoom-out",
+	fyne.KeyR:        "rotate-cw",
+}
+
+// findKeyAction returns the position of an action in keyActions, or -1.
+func findKeyAction(id string) int {
+	for i, action := range keyActions {
+		if action.id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// loadKeyMap reads the key bindings from the preferences, falling back to
+// defaultKeyBindings if none are saved or they cannot be read.
+func (a *App) loadKeyMap() {
+	bindings := make(map[fyne.KeyName]string)
+	saved := a.fyneApp.Preferences().String(keyMapPreference)
+	if saved != "" {
+		if err := json.Unmarshal([]byte(saved), &bindings); err != nil {
+			fmt.Printf("Warning: ignoring saved key bindings: %v\n", err)
+			bindings = nil
+		}
+	}
+	if len(bindings) == 0 {
+		bindings = make(map[fyne.KeyName]string, len(defaultKeyBindings))
+		for key, id := range defaultKeyBindings {
+			bindings[key] = id
+		}
+	}
+	a.setKeyBindings(bindings)
+}
+
+// saveKeyMap writes the key bindings to the preferences.
+func (a *App) saveKeyMap() {
+	data, err := json.Marshal(a.keyBindings)
+	if err != nil {
+		fmt.Printf("Warning: failed to save key bindings: %v\n", err)
+		return
+	}
+	a.fyneApp.Preferences().SetString(keyMapPreference, string(data))
+}
+
+// setKeyBindings replaces the key bindings and rebuilds KeyMap from them.
+// Bindings to unknown actions are dropped.
+func (a *App) setKeyBindings(bindings map[fyne.KeyName]string) {
+	a.keyBindings = make(map[fyne.KeyName]string, len(bindings))
+	a.KeyMap = make(map[fyne.KeyName]func(), len(bindings))
+	for key, id := range bindings {
+		i := findKeyAction(id)
+		if i < 0 {
+			continue
+		}
+		run := keyActions[i].run
+		a.keyBindings[key] = id
+		a.KeyMap[key] = func() { run(a) }
+	}
+}
+
+// keyBinding is a row of the keyboard shortcuts dialog.
+type keyBinding struct {
+	key    fyne.KeyName
+	action int // Index into keyActions
+}
+
+// sortedKeyBindings returns the bindings ordered by action, then key.
+func (a *App) sortedKeyBindings() []keyBinding {
+	rows := make([]keyBinding, 0, len(a.keyBindings))
+	for key, id := range a.keyBindings {
+		rows = append(rows, keyBinding{key: key, action: findKeyAction(id)})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].action != rows[j].action {
+			return rows[i].action < rows[j].action
+		}
+		return rows[i].key < rows[j].key
+	})
+	return rows
+}
+
+// showKeyboardShortcuts shows the key bindings. Selecting one and pressing
+// a key moves the binding to that key; Escape cancels.
+func (a *App) showKeyboardShortcuts() {
+	rows := a.sortedKeyBindings()
+	status := widget.NewLabel("Select a shortcut and press a new key to rebind it.")
+
+	var list *widget.List
+	list = widget.NewList(
+		func() int { return len(rows) },
+		func() fyne.CanvasObject {
+			return container.NewGridWithColumns(2, widget.NewLabel(""), widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			labels := item.(*fyne.Container).Objects
+			labels[0].(*widget.Label).SetText(keyActions[rows[id].action].label)
+			labels[1].(*widget.Label).SetText(string(rows[id].key))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		row := rows[id]
+		status.SetText(fmt.Sprintf("Press a new key for %s (was %s)...",
+			keyActions[row.action].label, row.key))
+
+		// Keys go to the canvas only while nothing has focus
+		a.mainWindow.Canvas().Unfocus()
+		a.onKeyCapture = func(key *fyne.KeyEvent) {
+			a.onKeyCapture = nil
+			list.UnselectAll()
+			if key.Name == fyne.KeyEscape {
+				status.SetText("Select a shortcut and press a new key to rebind it.")
+				return
+			}
+
+			bindings := a.keyBindings
+			delete(bindings, row.key)
+			if old, ok := bindings[key.Name]; ok && old != keyActions[row.action].id {
+				status.SetText(fmt.Sprintf("%s now runs %s instead of %s.", key.Name,
+					keyActions[row.action].label, keyActions[findKeyAction(old)].label))
+			} else {
+				status.SetText(fmt.Sprintf("%s now runs %s.", key.Name, keyActions[row.action].label))
+			}
+			bindings[key.Name] = keyActions[row.action].id
+			a.setKeyBindings(bindings)
+			a.saveKeyMap()
+
+			rows = a.sortedKeyBindings()
+			list.Refresh()
+		}
+	}
+
+	reset := widget.NewButton("Reset to Defaults", func() {
+		a.onKeyCapture = nil
+		list.UnselectAll()
+		a.setKeyBindings(defaultKeyBindings)
+		a.saveKeyMap()
+		rows = a.sortedKeyBindings()
+		list.Refresh()
+		status.SetText("Default shortcuts restored.")
+	})
+
+	content := container.NewBorder(nil, container.NewVBox(status, reset), nil, nil, list)
+	d := dialog.NewCustom("Keyboard Shortcuts", "Close", content, a.mainWindow)
+	d.SetOnClosed(func() {
+		a.onKeyCapture = nil
+	})
+	d.Resize(fyne.NewSize(420, 480))
+	d.Show()
+}
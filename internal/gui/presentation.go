@@ -0,0 +1,152 @@
+package gui
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+)
+
+// presentationIntervals are the auto-advance intervals offered to the user.
+var presentationIntervals = []time.Duration{
+	1 * time.Second,
+	2 * time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	60 * time.Second,
+}
+
+// presentationSteps is the number of countdown updates per slide.
+const presentationSteps = 50
+
+// presentation is one run of the auto-advance mode.
+type presentation struct {
+	stop     chan struct{} // Closed to end the ticker goroutine
+	stopOnce sync.Once
+	step     int // Countdown updates since the last page change
+}
+
+// end stops the ticker goroutine. It may be called more than once.
+func (p *presentation) end() {
+	p.stopOnce.Do(func() { close(p.stop) })
+}
+
+// presenting reports whether the auto-advance mode is running.
+func (a *App) presenting() bool {
+	a.presentMu.Lock()
+	defer a.presentMu.Unlock()
+	return a.presentation != nil
+}
+
+// togglePresentation starts or stops the auto-advance mode.
+func (a *App) togglePresentation() {
+	if a.presenting() {
+		a.stopPresentation()
+		return
+	}
+	a.startPresentation()
+}
+
+// startPresentation begins auto-advancing pages at the configured interval.
+func (a *App) startPresentation() {
+	a.navMu.Lock()
+	defer a.navMu.Unlock()
+	a.presentMu.Lock()
+	defer a.presentMu.Unlock()
+	if a.document == nil || a.presentation != nil {
+		return
+	}
+
+	p := &presentation{stop: make(chan struct{})}
+	a.presentation = p
+	a.presentProgress.SetValue(0)
+	a.presentProgress.Show()
+
+	go a.runPresentationTicker(p, a.presentInterval/presentationSteps)
+}
+
+// runPresentationTicker signals a countdown step every period until p is
+// ended. It changes no state itself; presentationTick does.
+func (a *App) runPresentationTicker(p *presentation, period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			a.presentationTick(p)
+		}
+	}
+}
+
+// presentationTick advances the countdown of p and turns the page when it
+// runs out. Fyne 2.5 has no fyne.Do to move this onto the UI goroutine, so
+// the page is turned here through turnPage, which navMu serializes with
+// the UI; a tick for a presentation already stopped is dropped.
+func (a *App) presentationTick(p *presentation) {
+	a.presentMu.Lock()
+	if a.presentation != p {
+		a.presentMu.Unlock()
+		return
+	}
+	p.step++
+	if p.step < presentationSteps {
+		a.presentProgress.SetValue(float64(p.step) / presentationSteps)
+		a.presentMu.Unlock()
+		return
+	}
+	p.step = 0
+	a.presentProgress.SetValue(0)
+	a.presentMu.Unlock()
+
+	// navMu is taken before presentMu, so turn the page without it
+	a.turnPage(pageTurn{
+		target:       func(current int) int { return current + 1 },
+		presentation: p,
+	})
+}
+
+// stopPresentation stops the auto-advance mode if it is running.
+func (a *App) stopPresentation() {
+	a.presentMu.Lock()
+	defer a.presentMu.Unlock()
+	a.stopPresentationLocked()
+}
+
+// stopPresentationLocked stops the auto-advance mode; the caller holds
+// presentMu.
+func (a *App) stopPresentationLocked() {
+	if a.presentation == nil {
+		return
+	}
+	a.presentation.end()
+	a.presentation = nil
+	a.presentProgress.Hide()
+}
+
+// showIntervalMenu shows a pop-up for choosing the auto-advance interval.
+func (a *App) showIntervalMenu(anchor fyne.CanvasObject) {
+	var items []*fyne.MenuItem
+	for _, interval := range presentationIntervals {
+		interval := interval
+		item := fyne.NewMenuItem(fmt.Sprintf("%ds", int(interval.Seconds())), func() {
+			a.presentInterval = interval
+			// Restart so the new interval takes effect immediately
+			if a.presenting() {
+				a.stopPresentation()
+				a.startPresentation()
+			}
+		})
+		item.Checked = interval == a.presentInterval
+		items = append(items, item)
+	}
+
+	canvas := a.mainWindow.Canvas()
+	pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(anchor)
+	pos = pos.Add(fyne.NewPos(0, anchor.Size().Height))
+	widget.ShowPopUpMenuAtPosition(fyne.NewMenu("", items...), canvas, pos)
+}
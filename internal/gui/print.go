@@ -0,0 +1,91 @@
+package gui
+
+import (
+	"fmt"
+	"image/png"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"gumgum/pkg/api"
+)
+
+// printDPI is the resolution pages are rendered at for printing.
+const printDPI = 300
+
+// PrintPage renders a page at printDPI and hands it to the operating
+// system for printing, showing a progress dialog over mainWindow while the
+// page renders. On macOS the page opens in Preview, on Linux it is sent to
+// lp (or opened in evince if lp is missing) and on Windows the shell's
+// print verb is used. Errors are shown in a dialog.
+func PrintPage(doc *api.Document, pageNum int, mainWindow fyne.Window) {
+	if doc == nil {
+		return
+	}
+
+	progress := dialog.NewCustomWithoutButtons(
+		fmt.Sprintf("Printing page %d", pageNum+1),
+		widget.NewProgressBarInfinite(),
+		mainWindow,
+	)
+	progress.Show()
+
+	go func() {
+		path, err := renderForPrint(doc, pageNum)
+		if err == nil {
+			err = printFile(path)
+		}
+		progress.Hide()
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("failed to print page: %w", err), mainWindow)
+		}
+	}()
+}
+
+// renderForPrint renders a page to a temporary PNG file and returns its
+// path.
+func renderForPrint(doc *api.Document, pageNum int) (string, error) {
+	img, err := doc.RenderWithOptions(pageNum, api.WithDPI(printDPI))
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.CreateTemp("", "gumgum-print-*.png")
+	if err != nil {
+		return "", err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// printFile sends an image file to the system's printing. Viewers opened
+// for printing read the file after the command returns, so it is only
+// removed once lp has queued it.
+func printFile(path string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", "-a", "Preview", path).Run()
+	case "windows":
+		// Start-Process -Verb Print is ShellExecute with the print verb
+		return exec.Command("powershell", "-NoProfile", "-Command",
+			"Start-Process -FilePath $args[0] -Verb Print", path).Run()
+	default:
+		if _, err := exec.LookPath("lp"); err == nil {
+			defer os.Remove(path)
+			return exec.Command("lp", "-o", "fit-to-page", path).Run()
+		}
+		return exec.Command("evince", path).Start()
+	}
+}
@@ -0,0 +1,88 @@
+package gui
+
+import (
+	"image"
+
+	"gumgum/pkg/api"
+)
+
+// rotateCurrentPage turns the current page by degrees, a multiple of 90,
+// clockwise if positive. The rotation is kept for the session only; the
+// file is not changed.
+func (a *App) rotateCurrentPage(degrees int) {
+	a.navMu.Lock()
+	defer a.navMu.Unlock()
+	if a.document == nil {
+		return
+	}
+	rotation := ((a.pageRotations[a.currentPage]+degrees)%360 + 360) % 360
+	if rotation == 0 {
+		delete(a.pageRotations, a.currentPage)
+	} else {
+		a.pageRotations[a.currentPage] = rotation
+	}
+	a.renderCurrentPage()
+}
+
+// rotateClockwise turns the current page a quarter turn clockwise.
+func (a *App) rotateClockwise() {
+	a.rotateCurrentPage(90)
+}
+
+// rotateCounterClockwise turns the current page a quarter turn
+// counter-clockwise.
+func (a *App) rotateCounterClockwise() {
+	a.rotateCurrentPage(-90)
+}
+
+// resetRotation shows the current page with its original rotation.
+func (a *App) resetRotation() {
+	a.navMu.Lock()
+	defer a.navMu.Unlock()
+	if a.document == nil {
+		return
+	}
+	if _, ok := a.pageRotations[a.currentPage]; ok {
+		delete(a.pageRotations, a.currentPage)
+		a.renderCurrentPage()
+	}
+}
+
+// pageRotation returns the rotation a page is shown with: its Rotate entry
+// plus the session override, in degrees clockwise. The caller holds navMu.
+func (a *App) pageRotation(page *api.Page) int {
+	return ((page.Rotation()+a.pageRotations[page.Number()])%360 + 360) % 360
+}
+
+// rotateImage returns img turned clockwise by degrees, a multiple of 90.
+func rotateImage(img *image.RGBA, degrees int) *image.RGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	var out *image.RGBA
+	switch degrees {
+	case 90, 270:
+		out = image.NewRGBA(image.Rect(0, 0, h, w))
+	case 180:
+		out = image.NewRGBA(image.Rect(0, 0, w, h))
+	default:
+		return img
+	}
+
+	for y := 0; y < h; y++ {
+		src := img.Pix[img.PixOffset(b.Min.X, b.Min.Y+y):]
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch degrees {
+			case 90:
+				dx, dy = h-1-y, x
+			case 180:
+				dx, dy = w-1-x, h-1-y
+			case 270:
+				dx, dy = y, w-1-x
+			}
+			copy(out.Pix[out.PixOffset(dx, dy):out.PixOffset(dx, dy)+4], src[x*4:x*4+4])
+		}
+	}
+	return out
+}
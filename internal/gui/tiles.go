@@ -0,0 +1,125 @@
+package gui
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"gumgum/pkg/api"
+)
+
+// maxFullPagePixels is the size of the largest page rendered whole, in
+// pixels; larger pages are rendered in tiles covering the part in view.
+const maxFullPagePixels = 25_000_000
+
+// tileMargin is how far a tile reaches beyond the view on each side, in
+// pixels, so that small pans are covered without rendering again.
+const tileMargin = 256
+
+// pixelRect is a rectangle in page pixels.
+type pixelRect struct {
+	x0, y0, x1, y1 float64
+}
+
+func (r pixelRect) empty() bool {
+	return r.x0 >= r.x1 || r.y0 >= r.y1
+}
+
+func (r pixelRect) contains(o pixelRect) bool {
+	return o.x0 >= r.x0 && o.y0 >= r.y0 && o.x1 <= r.x1 && o.y1 <= r.y1
+}
+
+func (r pixelRect) intersect(o pixelRect) pixelRect {
+	return pixelRect{
+		math.Max(r.x0, o.x0), math.Max(r.y0, o.y0),
+		math.Min(r.x1, o.x1), math.Min(r.y1, o.y1),
+	}
+}
+
+// rotateRect returns where r lies after an image of size w×h holding it is
+// turned clockwise by degrees, a multiple of 90, as rotateImage does.
+func rotateRect(r pixelRect, w, h float64, degrees int) pixelRect {
+	switch degrees {
+	case 90:
+		return pixelRect{h - r.y1, r.x0, h - r.y0, r.x1}
+	case 180:
+		return pixelRect{w - r.x1, h - r.y1, w - r.x0, h - r.y0}
+	case 270:
+		return pixelRect{r.y0, w - r.x1, r.y1, w - r.x0}
+	}
+	return r
+}
+
+// pageTooLarge reports whether a page shown at dpi should be rendered in
+// tiles, returning its size in pixels before rotation.
+func pageTooLarge(page *api.Page, dpi float64) (w, h int, tiled bool) {
+	w, h = page.SizeInPixels(dpi)
+	return w, h, w*h > maxFullPagePixels
+}
+
+// updateTile renders the part of a tiled page in view, unless the tile
+// shown covers it already. A view too large to render at full resolution,
+// when zoomed out, is rendered at a lower one and stretched.
+func (a *App) updateTile() {
+	a.navMu.Lock()
+	defer a.navMu.Unlock()
+	a.updateTileLocked()
+}
+
+// updateTileLocked is updateTile for a caller holding navMu.
+func (a *App) updateTileLocked() {
+	v := a.viewer
+	if a.document == nil {
+		return
+	}
+	tiled, size, view, covered := v.tileView()
+	if !tiled || view.empty() {
+		return
+	}
+
+	page, err := a.document.Page(a.currentPage)
+	if err != nil {
+		return
+	}
+	rotation := a.pageRotation(page)
+
+	// The view grown by the margin, on the page as rendered before rotation
+	shownW, shownH := float64(size.X), float64(size.Y)
+	grown := pixelRect{view.x0 - tileMargin, view.y0 - tileMargin, view.x1 + tileMargin, view.y1 + tileMargin}
+	grown = grown.intersect(pixelRect{0, 0, shownW, shownH})
+	w, h := shownW, shownH
+	if rotation == 90 || rotation == 270 {
+		w, h = h, w
+	}
+	region := rotateRect(grown, shownW, shownH, (360-rotation)%360)
+
+	// Tiles are whole points
+	scale := a.dpi / 72
+	tile := image.Rect(
+		int(math.Floor(region.x0/scale)), int(math.Floor(region.y0/scale)),
+		int(math.Ceil(region.x1/scale)), int(math.Ceil(region.y1/scale)),
+	)
+	dpi := a.dpi
+	if pixels := float64(tile.Dx()*tile.Dy()) * scale * scale; pixels > maxFullPagePixels {
+		dpi *= math.Sqrt(maxFullPagePixels / pixels)
+	}
+	if covered && a.tileDPI >= dpi {
+		return
+	}
+
+	img, err := a.document.RenderTile(a.currentPage, tile, dpi)
+	if err != nil {
+		fmt.Printf("Warning: failed to render tile: %v\n", err)
+		return
+	}
+	if a.darkMode {
+		api.InvertColors(img)
+	}
+	a.tileDPI = dpi
+
+	rendered := pixelRect{
+		float64(tile.Min.X) * scale, float64(tile.Min.Y) * scale,
+		float64(tile.Max.X) * scale, float64(tile.Max.Y) * scale,
+	}
+	v.setTile(rotateImage(img, rotation), rotateRect(rendered, w, h, rotation))
+}
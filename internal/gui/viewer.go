@@ -3,18 +3,34 @@ package gui
 import (
 	"image"
 	"math"
+	"net/url"
+	"sync"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/widget"
+
+	"gumgum/pkg/api"
 )
 
 // PageViewer is a custom widget for viewing PDF pages with pan/zoom.
 type PageViewer struct {
 	widget.BaseWidget
 	
+	// mu guards the state below: pages are set from whichever goroutine
+	// turns them, while input events arrive on the UI goroutine. It is
+	// never held while calling Refresh or a callback.
+	mu sync.Mutex
+	
 	image     *canvas.Image
 	pageImg   image.Image
+	pageSize  image.Point // Size of the whole page in pixels
+	
+	// Pages too large to render whole show a tile, pageImg, covering the
+	// part of the page at tileRect
+	tiled    bool
+	tileRect pixelRect
 	
 	// View state
 	zoom      float64
@@ -26,6 +42,22 @@ type PageViewer struct {
 	dragStart fyne.Position
 	startOffsetX float64
 	startOffsetY float64
+	
+	// Link state
+	links          []api.Link
+	mediaBox       [4]float64 // Page MediaBox, in page space units
+	pixelsPerUnit  float64    // Render scale (DPI / 72 * UserUnit)
+	rotation       int        // Clockwise rotation of the image, in degrees
+	hoverLink      bool
+	
+	// Callbacks
+	OnTapped   func()
+	OnOpenURI  func(u *url.URL)
+	OnGoToPage func(page int)
+	
+	// OnViewChanged is called when a tiled page is panned or zoomed, so a
+	// tile covering the new view can be rendered
+	OnViewChanged func()
 }
 
 // NewPageViewer creates a new page viewer widget.
@@ -44,13 +76,109 @@ func NewPageViewer() *PageViewer {
 
 // SetImage sets the page image to display.
 func (v *PageViewer) SetImage(img image.Image) {
+	v.mu.Lock()
 	v.pageImg = img
 	v.image.Image = img
+	v.pageSize = img.Bounds().Size()
+	v.tiled = false
+	v.resetView()
+	v.mu.Unlock()
+	v.Refresh()
+}
+
+// setTiled shows a page of the given size in pixels that is rendered in
+// tiles; it is blank until setTile is called.
+func (v *PageViewer) setTiled(size image.Point) {
+	v.mu.Lock()
+	v.pageImg = nil
+	v.image.Image = nil
+	v.pageSize = size
+	v.tiled = true
+	v.tileRect = pixelRect{}
 	v.resetView()
+	v.mu.Unlock()
 	v.Refresh()
 }
 
-// resetView resets zoom and offset.
+// setTile shows img over the part of a tiled page at rect, keeping the
+// view as it is.
+func (v *PageViewer) setTile(img image.Image, rect pixelRect) {
+	v.mu.Lock()
+	v.pageImg = img
+	v.image.Image = img
+	v.tileRect = rect
+	v.mu.Unlock()
+	v.Refresh()
+}
+
+// tileView returns, for a tiled page, its size in pixels, the part in
+// view and whether the tile shown covers that part.
+func (v *PageViewer) tileView() (tiled bool, size image.Point, view pixelRect, covered bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	view = v.visibleRect()
+	return v.tiled, v.pageSize, view, v.pageImg != nil && v.tileRect.contains(view)
+}
+
+// visibleRect returns the part of the page in view, in page pixels; the
+// caller holds mu.
+func (v *PageViewer) visibleRect() pixelRect {
+	size := v.Size()
+	originX := (float64(size.Width)-float64(v.pageSize.X)*v.zoom)/2 + v.offsetX
+	originY := (float64(size.Height)-float64(v.pageSize.Y)*v.zoom)/2 + v.offsetY
+	view := pixelRect{
+		x0: -originX / v.zoom,
+		y0: -originY / v.zoom,
+		x1: (float64(size.Width) - originX) / v.zoom,
+		y1: (float64(size.Height) - originY) / v.zoom,
+	}
+	return view.intersect(pixelRect{0, 0, float64(v.pageSize.X), float64(v.pageSize.Y)})
+}
+
+// viewChanged reports a pan or zoom of a tiled page.
+func (v *PageViewer) viewChanged() {
+	v.mu.Lock()
+	tiled := v.tiled
+	v.mu.Unlock()
+	if tiled && v.OnViewChanged != nil {
+		v.OnViewChanged()
+	}
+}
+
+// SetLinks sets the link annotations of the displayed page.
+// dpi is the resolution the image was rendered at and rotation is how far
+// the image was turned clockwise, in degrees.
+func (v *PageViewer) SetLinks(links []api.Link, page *api.Page, dpi float64, rotation int) {
+	x1, y1, x2, y2 := page.MediaBox()
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.links = links
+	v.mediaBox = [4]float64{x1, y1, x2, y2}
+	v.pixelsPerUnit = dpi / 72 * page.UserUnit()
+	v.rotation = rotation
+	v.hoverLink = false
+}
+
+// Offset returns how far the page has been panned from the center.
+func (v *PageViewer) Offset() fyne.Position {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return fyne.NewPos(float32(v.offsetX), float32(v.offsetY))
+}
+
+// SetOffset pans the page to an offset returned by Offset. Unlike a pan by
+// the user it does not call OnViewChanged.
+func (v *PageViewer) SetOffset(pos fyne.Position) {
+	v.mu.Lock()
+	v.offsetX = float64(pos.X)
+	v.offsetY = float64(pos.Y)
+	v.startOffsetX = v.offsetX
+	v.startOffsetY = v.offsetY
+	v.mu.Unlock()
+	v.Refresh()
+}
+
+// resetView resets zoom and offset; the caller holds mu.
 func (v *PageViewer) resetView() {
 	v.zoom = 1.0
 	v.offsetX = 0
@@ -66,19 +194,25 @@ func (v *PageViewer) CreateRenderer() fyne.WidgetRenderer {
 
 // Dragged handles drag events for panning.
 func (v *PageViewer) Dragged(event *fyne.DragEvent) {
+	v.mu.Lock()
 	v.offsetX = v.startOffsetX + float64(event.Dragged.DX)
 	v.offsetY = v.startOffsetY + float64(event.Dragged.DY)
+	v.mu.Unlock()
 	v.Refresh()
 }
 
 // DragEnd handles the end of a drag.
 func (v *PageViewer) DragEnd() {
+	v.mu.Lock()
 	v.startOffsetX = v.offsetX
 	v.startOffsetY = v.offsetY
+	v.mu.Unlock()
+	v.viewChanged()
 }
 
 // Scrolled handles scroll events for zooming.
 func (v *PageViewer) Scrolled(event *fyne.ScrollEvent) {
+	v.mu.Lock()
 	delta := float64(event.Scrolled.DY) / 100
 	newZoom := v.zoom * (1 + delta)
 	
@@ -86,11 +220,11 @@ func (v *PageViewer) Scrolled(event *fyne.ScrollEvent) {
 	newZoom = math.Max(0.1, math.Min(5.0, newZoom))
 	
 	// Zoom toward cursor position
-	if v.pageImg != nil {
+	if v.pageSize.X > 0 {
 		// Get cursor position relative to image center
 		size := v.Size()
-		imgW := float64(v.pageImg.Bounds().Dx()) * v.zoom
-		imgH := float64(v.pageImg.Bounds().Dy()) * v.zoom
+		imgW := float64(v.pageSize.X) * v.zoom
+		imgH := float64(v.pageSize.Y) * v.zoom
 		
 		centerX := float64(size.Width) / 2
 		centerY := float64(size.Height) / 2
@@ -107,45 +241,59 @@ func (v *PageViewer) Scrolled(event *fyne.ScrollEvent) {
 	}
 	
 	v.zoom = newZoom
+	v.mu.Unlock()
 	v.Refresh()
+	v.viewChanged()
 }
 
 // ZoomIn increases zoom level.
 func (v *PageViewer) ZoomIn() {
+	v.mu.Lock()
 	v.zoom = math.Min(5.0, v.zoom*1.2)
+	v.mu.Unlock()
 	v.Refresh()
+	v.viewChanged()
 }
 
 // ZoomOut decreases zoom level.
 func (v *PageViewer) ZoomOut() {
+	v.mu.Lock()
 	v.zoom = math.Max(0.1, v.zoom/1.2)
+	v.mu.Unlock()
 	v.Refresh()
+	v.viewChanged()
 }
 
 // FitWidth fits the image to the widget width.
 func (v *PageViewer) FitWidth() {
-	if v.pageImg == nil {
+	v.mu.Lock()
+	if v.pageSize.X == 0 {
+		v.mu.Unlock()
 		return
 	}
 	
 	size := v.Size()
-	imgW := float64(v.pageImg.Bounds().Dx())
+	imgW := float64(v.pageSize.X)
 	
 	v.zoom = float64(size.Width) / imgW
 	v.offsetX = 0
 	v.offsetY = 0
+	v.mu.Unlock()
 	v.Refresh()
+	v.viewChanged()
 }
 
 // FitPage fits the entire page in the widget.
 func (v *PageViewer) FitPage() {
-	if v.pageImg == nil {
+	v.mu.Lock()
+	if v.pageSize.X == 0 || v.pageSize.Y == 0 {
+		v.mu.Unlock()
 		return
 	}
 	
 	size := v.Size()
-	imgW := float64(v.pageImg.Bounds().Dx())
-	imgH := float64(v.pageImg.Bounds().Dy())
+	imgW := float64(v.pageSize.X)
+	imgH := float64(v.pageSize.Y)
 	
 	zoomW := float64(size.Width) / imgW
 	zoomH := float64(size.Height) / imgH
@@ -153,7 +301,119 @@ func (v *PageViewer) FitPage() {
 	v.zoom = math.Min(zoomW, zoomH)
 	v.offsetX = 0
 	v.offsetY = 0
+	v.mu.Unlock()
 	v.Refresh()
+	v.viewChanged()
+}
+
+// screenToPDF converts a widget position to PDF user space coordinates;
+// the caller holds mu.
+func (v *PageViewer) screenToPDF(pos fyne.Position) (x, y float64, ok bool) {
+	if v.pageSize.X == 0 || v.pixelsPerUnit == 0 || v.zoom == 0 {
+		return 0, 0, false
+	}
+	
+	size := v.Size()
+	imgW := float64(v.pageSize.X) * v.zoom
+	imgH := float64(v.pageSize.Y) * v.zoom
+	
+	// Image origin, matching the renderer layout
+	originX := (float64(size.Width)-imgW)/2 + v.offsetX
+	originY := (float64(size.Height)-imgH)/2 + v.offsetY
+	
+	// Widget position to image pixels
+	px := (float64(pos.X) - originX) / v.zoom
+	py := (float64(pos.Y) - originY) / v.zoom
+	
+	// Undo the rotation, giving pixels of the page as rendered
+	w := float64(v.pageSize.X)
+	h := float64(v.pageSize.Y)
+	switch v.rotation {
+	case 90:
+		px, py = py, w-px
+	case 180:
+		px, py = w-px, h-py
+	case 270:
+		px, py = h-py, px
+	}
+	
+	// Image pixels to PDF user space: the image's top-left corner is that
+	// of the MediaBox (flip Y)
+	x = v.mediaBox[0] + px/v.pixelsPerUnit
+	y = v.mediaBox[3] - py/v.pixelsPerUnit
+	return x, y, true
+}
+
+// linkAt returns the link under the given widget position, if any.
+func (v *PageViewer) linkAt(pos fyne.Position) *api.Link {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	x, y, ok := v.screenToPDF(pos)
+	if !ok {
+		return nil
+	}
+	
+	for i := range v.links {
+		if v.links[i].Contains(x, y) {
+			return &v.links[i]
+		}
+	}
+	return nil
+}
+
+// MouseIn handles the mouse entering the widget.
+func (v *PageViewer) MouseIn(event *desktop.MouseEvent) {
+	v.setHoverLink(v.linkAt(event.Position) != nil)
+}
+
+// MouseMoved tracks the mouse to show a pointer cursor over links.
+func (v *PageViewer) MouseMoved(event *desktop.MouseEvent) {
+	v.setHoverLink(v.linkAt(event.Position) != nil)
+}
+
+// MouseOut handles the mouse leaving the widget.
+func (v *PageViewer) MouseOut() {
+	v.setHoverLink(false)
+}
+
+// setHoverLink records whether the mouse is over a link.
+func (v *PageViewer) setHoverLink(hover bool) {
+	v.mu.Lock()
+	v.hoverLink = hover
+	v.mu.Unlock()
+}
+
+// Cursor returns the cursor to show, a pointer when hovering a link.
+func (v *PageViewer) Cursor() desktop.Cursor {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.hoverLink {
+		return desktop.PointerCursor
+	}
+	return desktop.DefaultCursor
+}
+
+// Tapped follows the link under the cursor, if any.
+func (v *PageViewer) Tapped(event *fyne.PointEvent) {
+	if v.OnTapped != nil {
+		v.OnTapped()
+	}
+	
+	link := v.linkAt(event.Position)
+	if link == nil {
+		return
+	}
+	
+	if link.IsInternal() {
+		if v.OnGoToPage != nil {
+			v.OnGoToPage(link.DestPage)
+		}
+		return
+	}
+	
+	if u, err := url.Parse(link.URI); err == nil && v.OnOpenURI != nil {
+		v.OnOpenURI(u)
+	}
 }
 
 // pageViewerRenderer renders the page viewer.
@@ -162,17 +422,31 @@ type pageViewerRenderer struct {
 }
 
 func (r *pageViewerRenderer) Layout(size fyne.Size) {
+	// Resizing may bring more of a tiled page into view
+	r.viewer.viewChanged()
+	r.viewer.mu.Lock()
+	defer r.viewer.mu.Unlock()
 	if r.viewer.pageImg == nil {
 		return
 	}
 	
-	imgW := float32(r.viewer.pageImg.Bounds().Dx()) * float32(r.viewer.zoom)
-	imgH := float32(r.viewer.pageImg.Bounds().Dy()) * float32(r.viewer.zoom)
+	zoom := float32(r.viewer.zoom)
+	imgW := float32(r.viewer.pageSize.X) * zoom
+	imgH := float32(r.viewer.pageSize.Y) * zoom
 	
 	// Center image with offset
 	x := (size.Width-imgW)/2 + float32(r.viewer.offsetX)
 	y := (size.Height-imgH)/2 + float32(r.viewer.offsetY)
 	
+	if r.viewer.tiled {
+		// Place the tile where it lies on the page
+		tile := r.viewer.tileRect
+		x += float32(tile.x0) * zoom
+		y += float32(tile.y0) * zoom
+		imgW = float32(tile.x1-tile.x0) * zoom
+		imgH = float32(tile.y1-tile.y0) * zoom
+	}
+	
 	r.viewer.image.Move(fyne.NewPos(x, y))
 	r.viewer.image.Resize(fyne.NewSize(imgW, imgH))
 }
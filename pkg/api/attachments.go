@@ -0,0 +1,162 @@
+package api
+
+import (
+	"fmt"
+	"unicode/utf16"
+
+	"gumgum/pkg/cos"
+)
+
+// Attachment is a file embedded in the document.
+type Attachment struct {
+	Name     string
+	MimeType string // Empty if the file stream has no Subtype
+	Data     []byte
+	Size     int // Uncompressed size in bytes
+}
+
+// maxNameTreeDepth limits how deeply name tree nodes may nest.
+const maxNameTreeDepth = 32
+
+// Attachments returns the files embedded in the document, from the
+// EmbeddedFiles name tree followed by file attachment annotations. A file
+// referenced from both is returned once.
+func (d *Document) Attachments() ([]Attachment, error) {
+	d.renderMu.Lock()
+	defer d.renderMu.Unlock()
+
+	catalog, err := d.reader.Catalog()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catalog: %w", err)
+	}
+
+	var specs []cos.Object
+	if names, err := d.reader.ResolveDict(catalog.Get("Names")); err == nil {
+		d.collectNameTree(names.Get("EmbeddedFiles"), 0, &specs)
+	}
+	for i := 0; i < d.pageCount; i++ {
+		page, err := d.reader.GetPage(i)
+		if err != nil {
+			continue
+		}
+		annots, err := d.reader.ResolveArray(page.Get("Annots"))
+		if err != nil {
+			continue
+		}
+		for _, item := range annots {
+			annot, err := d.reader.ResolveDict(item)
+			if err != nil {
+				continue
+			}
+			if subtype, _ := annot.GetName("Subtype"); subtype == "FileAttachment" {
+				specs = append(specs, annot.Get("FS"))
+			}
+		}
+	}
+
+	var attachments []Attachment
+	seen := make(map[int]bool)
+	for _, spec := range specs {
+		// Skip file specifications already read through another reference
+		if ref, ok := spec.(*cos.Reference); ok {
+			if seen[ref.ObjectNumber] {
+				continue
+			}
+			seen[ref.ObjectNumber] = true
+		}
+
+		attachment, err := d.readAttachment(spec)
+		if err != nil {
+			return nil, err
+		}
+		if attachment != nil {
+			attachments = append(attachments, *attachment)
+		}
+	}
+	return attachments, nil
+}
+
+// collectNameTree appends the values of a name tree in key order.
+func (d *Document) collectNameTree(obj cos.Object, depth int, values *[]cos.Object) {
+	if depth > maxNameTreeDepth {
+		return
+	}
+	node, err := d.reader.ResolveDict(obj)
+	if err != nil {
+		return
+	}
+
+	if names, err := d.reader.ResolveArray(node.Get("Names")); err == nil {
+		for i := 1; i < len(names); i += 2 {
+			*values = append(*values, names[i])
+		}
+	}
+	if kids, err := d.reader.ResolveArray(node.Get("Kids")); err == nil {
+		for _, kid := range kids {
+			d.collectNameTree(kid, depth+1, values)
+		}
+	}
+}
+
+// readAttachment reads the embedded file of a file specification. It
+// returns nil for specifications that only name an external file.
+func (d *Document) readAttachment(obj cos.Object) (*Attachment, error) {
+	spec, err := d.reader.ResolveDict(obj)
+	if err != nil {
+		return nil, nil
+	}
+	ef, err := d.reader.ResolveDict(spec.Get("EF"))
+	if err != nil {
+		return nil, nil
+	}
+
+	// The Unicode file name is preferred over the byte string one
+	var file *cos.Stream
+	for _, key := range []string{"UF", "F"} {
+		if resolved, err := d.reader.Resolve(ef.Get(key)); err == nil {
+			if s, ok := resolved.(*cos.Stream); ok {
+				file = s
+				break
+			}
+		}
+	}
+	if file == nil {
+		return nil, nil
+	}
+
+	attachment := &Attachment{}
+	for _, key := range []string{"UF", "F"} {
+		if name := decodeTextString(d.resolveString(spec.Get(key))); name != "" {
+			attachment.Name = name
+			break
+		}
+	}
+	if subtype, ok := file.Dict.GetName("Subtype"); ok {
+		attachment.MimeType = string(subtype)
+	}
+
+	attachment.Data, err = d.reader.DecodeStream(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode attachment %q: %w", attachment.Name, err)
+	}
+	attachment.Size = len(attachment.Data)
+	if params, err := d.reader.ResolveDict(file.Dict.Get("Params")); err == nil {
+		if size, ok := params.GetInt("Size"); ok && size >= 0 {
+			attachment.Size = int(size)
+		}
+	}
+	return attachment, nil
+}
+
+// decodeTextString decodes a PDF text string, which is UTF-16BE if it
+// starts with a byte order mark and otherwise taken as is.
+func decodeTextString(s string) string {
+	if len(s) < 2 || s[0] != 0xFE || s[1] != 0xFF {
+		return s
+	}
+	units := make([]uint16, 0, (len(s)-2)/2)
+	for i := 2; i+1 < len(s); i += 2 {
+		units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
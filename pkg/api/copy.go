@@ -0,0 +1,155 @@
+package api
+
+import (
+	"fmt"
+
+	"gumgum/pkg/cos"
+	"gumgum/pkg/stream"
+)
+
+// inheritedPageKeys are the page attributes that may be set on an ancestor
+// in the page tree instead of the page itself.
+var inheritedPageKeys = []string{"Resources", "MediaBox", "CropBox", "Rotate"}
+
+// objectCopier copies objects from a reader into a writer, renumbering
+// them. Objects are copied only when reached, so unused parts of the source
+// are left out. References to pages map to the copied pages, or to null for
+// pages that are not copied, so links do not pull in the whole document.
+// Streams without a filter are compressed if the stream options ask for it.
+type objectCopier struct {
+	reader  *cos.Reader
+	writer  *cos.Writer
+	streams cos.StreamOptions
+
+	refs   map[int]*cos.Reference // Source object number to copy
+	pages  map[int]*cos.Reference // Source page object number to copied page
+	isPage map[int]bool           // Every source page, copied or not
+	queue  []int                  // Source objects referenced but not yet written
+}
+
+func newObjectCopier(reader *cos.Reader, writer *cos.Writer, streams cos.StreamOptions) (*objectCopier, error) {
+	pageRefs, err := reader.PageRefs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pages: %w", err)
+	}
+	c := &objectCopier{
+		reader:  reader,
+		writer:  writer,
+		streams: streams,
+		refs:    make(map[int]*cos.Reference),
+		pages:   make(map[int]*cos.Reference),
+		isPage:  make(map[int]bool, len(pageRefs)),
+	}
+	for _, objNum := range pageRefs {
+		c.isPage[objNum] = true
+	}
+	return c, nil
+}
+
+// copy returns a deep copy of a direct object with its references mapped
+// to the output, queueing referenced objects to be written.
+func (c *objectCopier) copy(obj cos.Object) cos.Object {
+	switch o := obj.(type) {
+	case *cos.Reference:
+		if c.isPage[o.ObjectNumber] {
+			if ref, ok := c.pages[o.ObjectNumber]; ok {
+				return ref
+			}
+			return cos.Null{}
+		}
+		ref, ok := c.refs[o.ObjectNumber]
+		if !ok {
+			ref = c.writer.Reserve()
+			c.refs[o.ObjectNumber] = ref
+			c.queue = append(c.queue, o.ObjectNumber)
+		}
+		return ref
+	case cos.Dict:
+		dict := make(cos.Dict, len(o))
+		for key, value := range o {
+			dict[key] = c.copy(value)
+		}
+		return dict
+	case cos.Array:
+		arr := make(cos.Array, len(o))
+		for i, item := range o {
+			arr[i] = c.copy(item)
+		}
+		return arr
+	case *cos.Stream:
+		dict := c.copy(o.Dict).(cos.Dict)
+		data := o.Data
+		if c.streams.Compress && dict.Get("Filter") == nil {
+			// The level was checked by extractPages, so this cannot fail
+			if compressed, err := stream.EncodeFlateDecode(data, c.streams.Level); err == nil {
+				data = compressed
+				dict[cos.Name("Filter")] = cos.Name("FlateDecode")
+			}
+		}
+		// The data may have been decrypted or compressed, changing its
+		// length
+		dict[cos.Name("Length")] = cos.Integer(len(data))
+		return &cos.Stream{Dict: dict, Data: data}
+	}
+	return obj
+}
+
+// copyPage writes a copy of a source page under ref with the given parent.
+// Inherited attributes are set on the copy, since its ancestors are not
+// copied.
+func (c *objectCopier) copyPage(page cos.Dict, ref, parent *cos.Reference) error {
+	copied := make(cos.Dict, len(page)+len(inheritedPageKeys))
+	for key, value := range page {
+		if key != "Parent" {
+			copied[key] = c.copy(value)
+		}
+	}
+	for _, key := range inheritedPageKeys {
+		if copied.Get(key) != nil {
+			continue
+		}
+		if value := c.inherited(page, key); value != nil {
+			copied[cos.Name(key)] = c.copy(value)
+		}
+	}
+	copied[cos.Name("Parent")] = parent
+
+	if err := c.writer.WriteObject(ref.ObjectNumber, copied); err != nil {
+		return err
+	}
+	return c.flush()
+}
+
+// inherited returns the value of key on the nearest ancestor of a page.
+func (c *objectCopier) inherited(page cos.Dict, key string) cos.Object {
+	node := page
+	for depth := 0; depth < 64; depth++ {
+		parent, err := c.reader.ResolveDict(node.Get("Parent"))
+		if err != nil {
+			return nil
+		}
+		if value := parent.Get(key); value != nil {
+			return value
+		}
+		node = parent
+	}
+	return nil
+}
+
+// flush writes all queued objects and the objects they reference.
+func (c *objectCopier) flush() error {
+	for len(c.queue) > 0 {
+		objNum := c.queue[0]
+		c.queue = c.queue[1:]
+
+		obj, err := c.reader.GetObject(objNum)
+		if err != nil {
+			// Unresolvable references become null, as readers treat them
+			obj = cos.Null{}
+		}
+		if err := c.writer.WriteObject(c.refs[objNum].ObjectNumber, c.copy(obj)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// PageDiff compares one page rendered from two documents.
+type PageDiff struct {
+	PageNum int
+
+	// MaxDelta is the largest difference of any color channel.
+	MaxDelta uint8
+
+	// PSNR is the peak signal-to-noise ratio in decibels over the RGB
+	// channels. It is +Inf for identical pages.
+	PSNR float64
+
+	// DiffImage shows page a faded, with differing pixels in red. The
+	// stronger the red, the larger the difference.
+	DiffImage *image.NRGBA
+}
+
+// Identical returns true if the pages rendered exactly the same.
+func (p PageDiff) Identical() bool {
+	return p.MaxDelta == 0
+}
+
+// DiffPages renders the pages in opts.PageRange (all pages if nil) of both
+// documents and compares them pixel by pixel. The documents must have the
+// same number of pages. Pages of different sizes are compared over the
+// larger area, with missing pixels treated as white.
+func DiffPages(a, b *Document, opts RenderOptions) ([]PageDiff, error) {
+	if a.PageCount() != b.PageCount() {
+		return nil, fmt.Errorf("page counts differ: %d and %d", a.PageCount(), b.PageCount())
+	}
+
+	start, end := 0, a.PageCount()
+	if opts.PageRange != nil {
+		start, end = opts.PageRange.Start, opts.PageRange.End
+		if start < 0 {
+			start = 0
+		}
+		if end > a.PageCount() {
+			end = a.PageCount()
+		}
+	}
+
+	var diffs []PageDiff
+	for pageNum := start; pageNum < end; pageNum++ {
+		imgA, err := a.RenderWithOptions(pageNum, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render page %d of first document: %w", pageNum, err)
+		}
+		imgB, err := b.RenderWithOptions(pageNum, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render page %d of second document: %w", pageNum, err)
+		}
+
+		diff := diffImages(imgA, imgB)
+		diff.PageNum = pageNum
+		diffs = append(diffs, diff)
+	}
+
+	return diffs, nil
+}
+
+// diffImages compares two images, building the diff image and metrics.
+func diffImages(a, b *image.RGBA) PageDiff {
+	ba, bb := a.Bounds(), b.Bounds()
+	width := max(ba.Dx(), bb.Dx())
+	height := max(ba.Dy(), bb.Dy())
+
+	result := PageDiff{DiffImage: image.NewNRGBA(image.Rect(0, 0, width, height))}
+	var sumSquares float64
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			ca := pixelAt(a, x, y)
+			cb := pixelAt(b, x, y)
+
+			var delta uint8
+			for _, d := range []int{
+				int(ca.R) - int(cb.R),
+				int(ca.G) - int(cb.G),
+				int(ca.B) - int(cb.B),
+				int(ca.A) - int(cb.A),
+			} {
+				if d < 0 {
+					d = -d
+				}
+				if uint8(d) > delta {
+					delta = uint8(d)
+				}
+			}
+			for _, d := range []float64{
+				float64(ca.R) - float64(cb.R),
+				float64(ca.G) - float64(cb.G),
+				float64(ca.B) - float64(cb.B),
+			} {
+				sumSquares += d * d
+			}
+
+			if delta > result.MaxDelta {
+				result.MaxDelta = delta
+			}
+
+			if delta == 0 {
+				// Faded copy of the page for context
+				gray := uint8((int(ca.R)*30 + int(ca.G)*59 + int(ca.B)*11) / 100)
+				faded := 255 - (255-gray)/4
+				result.DiffImage.SetNRGBA(x, y, color.NRGBA{faded, faded, faded, 255})
+			} else {
+				result.DiffImage.SetNRGBA(x, y, color.NRGBA{255, 255 - delta, 255 - delta, 255})
+			}
+		}
+	}
+
+	mse := sumSquares / float64(width*height*3)
+	if mse == 0 {
+		result.PSNR = math.Inf(1)
+	} else {
+		result.PSNR = 10 * math.Log10(255*255/mse)
+	}
+	return result
+}
+
+// pixelAt returns the pixel at (x, y) relative to the image origin, or
+// white outside the image.
+func pixelAt(img *image.RGBA, x, y int) color.RGBA {
+	b := img.Bounds()
+	if x >= b.Dx() || y >= b.Dy() {
+		return color.RGBA{255, 255, 255, 255}
+	}
+	return img.RGBAAt(b.Min.X+x, b.Min.Y+y)
+}
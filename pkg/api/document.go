@@ -3,22 +3,43 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"image"
+	"io"
 	"os"
+	"sync"
+	"time"
 
 	"gumgum/pkg/cos"
 	"gumgum/pkg/raster"
 )
 
+// ErrEncrypted is returned by Open and OpenBytes for encrypted documents.
+var ErrEncrypted = cos.ErrEncrypted
+
 // Document represents a PDF document.
 type Document struct {
 	reader   *cos.Reader
 	renderer *raster.Renderer
+	path     string // Empty for documents opened from bytes
+
+	// renderMu serializes use of the renderer, which is not safe for
+	// concurrent use, and guards info; the reader is safe to share
+	renderMu sync.Mutex
 
 	// Cached info
-	pageCount int
-	info      *DocumentInfo
+	pageCount   int
+	info        *DocumentInfo // Replaced by SetInfo while holding renderMu
+	infoOnce    sync.Once     // Reads info on first use
+	infoChanged bool          // info was replaced by SetInfo
+
+	// Pages rendered ahead of time; see RenderOptions.Prefetch
+	prefetch prefetcher
+
+	// Done when the document is closed, stopping background work
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // DocumentInfo contains document metadata.
@@ -29,8 +50,8 @@ type DocumentInfo struct {
 	Keywords     string
 	Creator      string
 	Producer     string
-	CreationDate string
-	ModDate      string
+	CreationDate time.Time // Zero if missing or invalid
+	ModDate      time.Time
 }
 
 // Open opens a PDF file and returns a Document.
@@ -39,6 +60,21 @@ func Open(path string) (*Document, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
+	doc, err := OpenBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	doc.path = path
+	return doc, nil
+}
+
+// OpenReader reads a whole PDF from r and returns a Document. Unlike Open it
+// needs no file system, so it also works in WebAssembly.
+func OpenReader(r io.Reader) (*Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
 	return OpenBytes(data)
 }
 
@@ -48,7 +84,11 @@ func OpenBytes(data []byte) (*Document, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse PDF: %w", err)
 	}
+	return newDocument(reader)
+}
 
+// newDocument creates a Document for an opened reader.
+func newDocument(reader *cos.Reader) (*Document, error) {
 	pageCount, err := reader.PageCount()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get page count: %w", err)
@@ -59,9 +99,7 @@ func OpenBytes(data []byte) (*Document, error) {
 		renderer:  raster.NewRenderer(reader),
 		pageCount: pageCount,
 	}
-
-	// Parse document info
-	doc.parseInfo()
+	doc.ctx, doc.cancel = context.WithCancel(context.Background())
 
 	return doc, nil
 }
@@ -81,28 +119,44 @@ func (d *Document) parseInfo() {
 		Keywords:     getString(info, "Keywords"),
 		Creator:      getString(info, "Creator"),
 		Producer:     getString(info, "Producer"),
-		CreationDate: getString(info, "CreationDate"),
-		ModDate:      getString(info, "ModDate"),
+		CreationDate: getDate(info, "CreationDate"),
+		ModDate:      getDate(info, "ModDate"),
 	}
 }
 
 func getString(dict cos.Dict, key string) string {
 	if val := dict.Get(key); val != nil {
 		if s, ok := val.(cos.String); ok {
-			return string(s)
+			return decodeTextString(string(s))
 		}
 	}
 	return ""
 }
 
+func getDate(dict cos.Dict, key string) time.Time {
+	t, err := cos.ParsePDFDate(getString(dict, key))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
 // PageCount returns the number of pages in the document.
 func (d *Document) PageCount() int {
 	return d.pageCount
 }
 
-// Info returns document metadata.
+// Info returns a copy of the document metadata. It is read on first use,
+// as the Info dictionary of a linearized file may lie beyond the first
+// page's objects.
 func (d *Document) Info() *DocumentInfo {
-	return d.info
+	d.infoOnce.Do(d.parseInfo)
+
+	// SetInfo replaces info while holding renderMu
+	d.renderMu.Lock()
+	defer d.renderMu.Unlock()
+	info := *d.info
+	return &info
 }
 
 // Page returns a Page object for the given page number (0-indexed).
@@ -124,10 +178,78 @@ func (d *Document) Render(pageNum int) (*image.RGBA, error) {
 	return d.RenderWithOptions(pageNum, DefaultRenderOptions())
 }
 
-// RenderWithOptions renders a page with custom options.
+// RenderWithOptions renders a page with custom options. With
+// opts.Prefetch set, the following pages are then rendered in the
+// background, and a later call for one of them returns it at once.
 func (d *Document) RenderWithOptions(pageNum int, opts RenderOptions) (*image.RGBA, error) {
-	d.renderer.SetDPI(opts.DPI)
-	return d.renderer.RenderPage(pageNum)
+	if opts.Prefetch > 0 {
+		defer d.startPrefetch(pageNum, opts)
+	}
+	if opts.Profiler == nil {
+		if img := d.takePrefetched(pageNum, opts); img != nil {
+			return img, nil
+		}
+	}
+
+	// Prefetching gives way to callers waiting here
+	d.lockRender()
+	defer d.renderMu.Unlock()
+	return d.renderLocked(pageNum, opts)
+}
+
+// renderLocked renders a page; the caller holds renderMu.
+func (d *Document) renderLocked(pageNum int, opts RenderOptions) (*image.RGBA, error) {
+	return d.renderWith(d.renderer, pageNum, opts)
+}
+
+// renderWith renders a page with the given renderer, which the caller has
+// to itself.
+func (d *Document) renderWith(r *raster.Renderer, pageNum int, opts RenderOptions) (*image.RGBA, error) {
+	r.SetDPI(opts.DPI)
+	var img *image.RGBA
+	var err error
+	if opts.Profiler == nil {
+		img, err = r.RenderPage(pageNum)
+	} else {
+		r.SetProfiling(true)
+		img, err = r.RenderPage(pageNum)
+		d.reportStages(opts.Profiler, r.Stats())
+		r.SetProfiling(false)
+	}
+
+	if err == nil && opts.Invert {
+		InvertColors(img)
+	}
+	return img, err
+}
+
+// RenderTile renders part of a page at dpi. tile is in points from the
+// top-left corner of the page, like the rendered image, and is clipped to
+// the page; the result is the region tile.Min*dpi/72 to tile.Max*dpi/72 of
+// the full rendering. Drawing outside the tile is skipped, so pages too
+// large to render whole can be rendered in tiles.
+func (d *Document) RenderTile(pageNum int, tile image.Rectangle, dpi float64) (*image.RGBA, error) {
+	if pageNum < 0 || pageNum >= d.pageCount {
+		return nil, fmt.Errorf("page %d out of range (0-%d)", pageNum, d.pageCount-1)
+	}
+
+	d.lockRender()
+	defer d.renderMu.Unlock()
+
+	d.renderer.SetDPI(dpi)
+	return d.renderer.RenderTile(pageNum, tile)
+}
+
+// InvertColors replaces each pixel's color by its inverse, keeping alpha,
+// as the Invert option does. Pixels are premultiplied, so the inverse of a
+// component c is alpha - c.
+func InvertColors(img *image.RGBA) {
+	for i := 0; i+3 < len(img.Pix); i += 4 {
+		a := img.Pix[i+3]
+		img.Pix[i] = a - img.Pix[i]
+		img.Pix[i+1] = a - img.Pix[i+1]
+		img.Pix[i+2] = a - img.Pix[i+2]
+	}
 }
 
 // RenderAllPages renders all pages to images.
@@ -145,9 +267,11 @@ func (d *Document) RenderAllPages(opts RenderOptions) ([]*image.RGBA, error) {
 	return images, nil
 }
 
-// Close releases resources associated with the document.
+// Close releases resources associated with the document and stops
+// prefetching.
 func (d *Document) Close() error {
-	// Currently no cleanup needed, but this provides a consistent API
+	d.cancel()
+	d.prefetch.clear()
 	return nil
 }
 
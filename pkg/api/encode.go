@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// RenderPageTo renders a page and writes it to w in the given format:
+// "png", "jpeg" (or "jpg") or "webp". WebP output is lossless.
+// Cancelling ctx stops the call before rendering or before encoding.
+func (d *Document) RenderPageTo(ctx context.Context, pageNum int, w io.Writer, format string, opts RenderOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	format = strings.ToLower(format)
+	if _, ok := contentTypes[format]; !ok {
+		return fmt.Errorf("unsupported image format: %s", format)
+	}
+
+	img, err := d.RenderWithOptions(pageNum, opts)
+	if err != nil {
+		return fmt.Errorf("failed to render page %d: %w", pageNum, err)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return EncodeImage(w, img, format)
+}
+
+// contentTypes maps the supported output formats to their MIME types.
+var contentTypes = map[string]string{
+	"png":  "image/png",
+	"jpeg": "image/jpeg",
+	"jpg":  "image/jpeg",
+	"webp": "image/webp",
+}
+
+// EncodeImage writes img to w in one of the formats RenderPageTo supports.
+func EncodeImage(w io.Writer, img image.Image, format string) error {
+	var err error
+	switch format = strings.ToLower(format); format {
+	case "png":
+		err = png.Encode(w, img)
+	case "jpeg", "jpg":
+		err = jpeg.Encode(w, img, &jpeg.Options{Quality: DefaultExportOptions().Quality})
+	case "webp":
+		err = encodeWebP(w, img)
+	default:
+		return fmt.Errorf("unsupported image format: %s", format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", format, err)
+	}
+	return nil
+}
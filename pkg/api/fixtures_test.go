@@ -0,0 +1,81 @@
+package api_test
+
+import (
+	"strings"
+	"testing"
+
+	"gumgum/pkg/testutil"
+)
+
+func TestFixtureText(t *testing.T) {
+	tests := []struct {
+		name    string
+		pageNum int
+		want    string
+	}{
+		{testutil.SimpleText, 0, "Hello, world"},
+		{testutil.Rotated, 0, "Rotated page"},
+		{testutil.Encrypted, 0, "Encrypted"},
+		{testutil.Linearized, 0, "First page"},
+		{testutil.Linearized, 1, "Second page"},
+		{testutil.XrefStream, 0, "Cross-reference stream"},
+		{testutil.Images, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := testutil.OpenFixture(t, tt.name)
+			defer doc.Close()
+
+			page, err := doc.Page(tt.pageNum)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := page.ExtractStructuredText()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(result.Paragraphs, "\n"); got != tt.want {
+				t.Errorf("page %d text = %q, want %q", tt.pageNum, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFixtureSearch(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		pages []int
+	}{
+		{testutil.SimpleText, "WORLD", []int{0}},
+		{testutil.Linearized, "page", []int{0, 1}},
+		{testutil.Encrypted, "crypt", []int{0}},
+		{testutil.XrefStream, "missing", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := testutil.OpenFixture(t, tt.name)
+			defer doc.Close()
+
+			results, err := doc.SearchText(tt.query, 72)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var pages []int
+			for _, result := range results {
+				pages = append(pages, result.Page)
+				if result.DeviceRect.Empty() {
+					t.Errorf("hit on page %d has an empty rectangle", result.Page)
+				}
+			}
+			if len(pages) != len(tt.pages) {
+				t.Fatalf("hits on pages %v, want %v", pages, tt.pages)
+			}
+			for i := range pages {
+				if pages[i] != tt.pages[i] {
+					t.Errorf("hits on pages %v, want %v", pages, tt.pages)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"gumgum/pkg/cos"
+	"gumgum/pkg/font/cff"
+	"gumgum/pkg/font/ttf"
+)
+
+// FontInfo describes a font used by the document.
+type FontInfo struct {
+	Name       string // BaseFont, including any subset prefix
+	Type       string // Font Subtype: Type1, TrueType, Type0, Type3, ...
+	Embedded   bool   // The font program is in the file
+	Subset     bool   // The embedded program holds only the glyphs used
+	Encoding   string // Encoding name, "Custom" for a Differences dictionary, "" for the font's built-in encoding
+	GlyphCount int    // Glyphs in the embedded program, 0 if not known
+}
+
+// subsetPrefix matches the tag of a subset font name, such as ABCDEF+.
+var subsetPrefix = regexp.MustCompile(`^[A-Z]{6}\+`)
+
+// type1CharStrings matches the glyph count of a Type 1 font program.
+var type1CharStrings = regexp.MustCompile(`/CharStrings\s+(\d+)`)
+
+// FontReport lists every font referenced from the resources of the pages
+// and the form XObjects they use, in the order they are first found. Each
+// font object is listed once however many pages use it.
+func (d *Document) FontReport() ([]FontInfo, error) {
+	d.renderMu.Lock()
+	defer d.renderMu.Unlock()
+
+	c := &fontCollector{
+		reader:    d.reader,
+		seenFonts: make(map[int]bool),
+		seenForms: make(map[int]bool),
+	}
+	for i := 0; i < d.pageCount; i++ {
+		page, err := d.reader.GetPage(i)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get page %d: %w", i, err)
+		}
+		resources, err := d.reader.GetPageResources(page)
+		if err != nil {
+			continue
+		}
+		c.collect(resources)
+	}
+	return c.fonts, nil
+}
+
+// fontCollector walks resource dictionaries for fonts.
+type fontCollector struct {
+	reader    *cos.Reader
+	fonts     []FontInfo
+	seenFonts map[int]bool // Font objects already listed
+	seenForms map[int]bool // Form XObjects already walked
+}
+
+func (c *fontCollector) collect(resources cos.Dict) {
+	if fonts, err := c.reader.ResolveDict(resources.Get("Font")); err == nil {
+		for _, obj := range fonts {
+			if ref, ok := obj.(*cos.Reference); ok {
+				if c.seenFonts[ref.ObjectNumber] {
+					continue
+				}
+				c.seenFonts[ref.ObjectNumber] = true
+			}
+			font, err := c.reader.ResolveDict(obj)
+			if err != nil {
+				continue
+			}
+			c.fonts = append(c.fonts, c.describe(font))
+
+			// Type 3 glyphs may use fonts of their own
+			if res, err := c.reader.ResolveDict(font.Get("Resources")); err == nil {
+				c.collect(res)
+			}
+		}
+	}
+
+	xobjects, err := c.reader.ResolveDict(resources.Get("XObject"))
+	if err != nil {
+		return
+	}
+	for _, obj := range xobjects {
+		ref, ok := obj.(*cos.Reference)
+		if !ok || c.seenForms[ref.ObjectNumber] {
+			continue
+		}
+		c.seenForms[ref.ObjectNumber] = true
+		resolved, err := c.reader.Resolve(ref)
+		if err != nil {
+			continue
+		}
+		form, ok := resolved.(*cos.Stream)
+		if !ok {
+			continue
+		}
+		if subtype, _ := form.Dict.GetName("Subtype"); subtype != "Form" {
+			continue
+		}
+		if res, err := c.reader.ResolveDict(form.Dict.Get("Resources")); err == nil {
+			c.collect(res)
+		}
+	}
+}
+
+// describe builds the FontInfo of a font dictionary.
+func (c *fontCollector) describe(font cos.Dict) FontInfo {
+	subtype, _ := font.GetName("Subtype")
+	name, _ := font.GetName("BaseFont")
+	info := FontInfo{
+		Name:     string(name),
+		Type:     string(subtype),
+		Subset:   subsetPrefix.MatchString(string(name)),
+		Encoding: c.encodingName(font.Get("Encoding")),
+	}
+
+	if subtype == "Type3" {
+		// Glyphs are content streams in the font dictionary itself
+		info.Embedded = true
+		if procs, err := c.reader.ResolveDict(font.Get("CharProcs")); err == nil {
+			info.GlyphCount = len(procs)
+		}
+		return info
+	}
+
+	// The font program of a composite font belongs to its CIDFont
+	descriptorOwner := font
+	if subtype == "Type0" {
+		if descendants, err := c.reader.ResolveArray(font.Get("DescendantFonts")); err == nil && len(descendants) > 0 {
+			if cidFont, err := c.reader.ResolveDict(descendants[0]); err == nil {
+				descriptorOwner = cidFont
+			}
+		}
+	}
+	descriptor, err := c.reader.ResolveDict(descriptorOwner.Get("FontDescriptor"))
+	if err != nil {
+		return info
+	}
+
+	for _, key := range []string{"FontFile", "FontFile2", "FontFile3"} {
+		resolved, err := c.reader.Resolve(descriptor.Get(key))
+		if err != nil {
+			continue
+		}
+		stream, ok := resolved.(*cos.Stream)
+		if !ok {
+			continue
+		}
+		info.Embedded = true
+		if data, err := c.reader.DecodeStream(stream); err == nil {
+			info.GlyphCount = countGlyphs(key, stream.Dict, data)
+		}
+		break
+	}
+	return info
+}
+
+// encodingName describes a font's Encoding entry.
+func (c *fontCollector) encodingName(obj cos.Object) string {
+	resolved, err := c.reader.Resolve(obj)
+	if err != nil {
+		return ""
+	}
+	switch enc := resolved.(type) {
+	case cos.Name:
+		return string(enc)
+	case cos.Dict:
+		if base, ok := enc.GetName("BaseEncoding"); ok && enc.Get("Differences") == nil {
+			return string(base)
+		}
+		return "Custom"
+	case *cos.Stream:
+		// An embedded CMap
+		if name, ok := enc.Dict.GetName("CMapName"); ok {
+			return string(name)
+		}
+		return "Custom"
+	}
+	return ""
+}
+
+// countGlyphs returns the number of glyphs in an embedded font program, or
+// 0 if the program cannot be read.
+func countGlyphs(key string, dict cos.Dict, data []byte) int {
+	switch key {
+	case "FontFile":
+		// Type 1: the count precedes the CharStrings dictionary in the
+		// eexec-encrypted portion, which starts after Length1 bytes
+		if length1, ok := dict.GetInt("Length1"); ok && length1 > 0 && int(length1) < len(data) {
+			data = decryptEexec(data[length1:])
+		}
+		if m := type1CharStrings.FindSubmatch(data); m != nil {
+			n, _ := strconv.Atoi(string(m[1]))
+			return n
+		}
+	case "FontFile2":
+		if f, err := ttf.Parse(data); err == nil {
+			return int(f.NumGlyphs)
+		}
+	case "FontFile3":
+		subtype, _ := dict.GetName("Subtype")
+		if subtype == "OpenType" {
+			if f, err := ttf.Parse(data); err == nil && f.NumGlyphs > 0 {
+				return int(f.NumGlyphs)
+			}
+		}
+		if f, err := cff.Parse(data); err == nil {
+			return f.NumGlyphs
+		}
+	}
+	return 0
+}
+
+// decryptEexec decrypts the private portion of a Type 1 font program,
+// which is binary or hexadecimal, dropping the four random leading bytes.
+func decryptEexec(data []byte) []byte {
+	if len(data) >= 4 && isHexDigit(data[0]) && isHexDigit(data[1]) && isHexDigit(data[2]) && isHexDigit(data[3]) {
+		binary := make([]byte, 0, len(data)/2)
+		var hi byte
+		half := false
+		for _, ch := range data {
+			if !isHexDigit(ch) {
+				continue
+			}
+			v := hexValue(ch)
+			if half {
+				binary = append(binary, hi<<4|v)
+			} else {
+				hi = v
+			}
+			half = !half
+		}
+		data = binary
+	}
+
+	const c1, c2 = 52845, 22719
+	r := uint16(55665)
+	plain := make([]byte, len(data))
+	for i, cipher := range data {
+		plain[i] = cipher ^ byte(r>>8)
+		r = (uint16(cipher)+r)*c1 + c2
+	}
+	if len(plain) < 4 {
+		return nil
+	}
+	return plain[4:]
+}
+
+func isHexDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9' || ch >= 'a' && ch <= 'f' || ch >= 'A' && ch <= 'F'
+}
+
+func hexValue(ch byte) byte {
+	switch {
+	case ch >= 'a':
+		return ch - 'a' + 10
+	case ch >= 'A':
+		return ch - 'A' + 10
+	}
+	return ch - '0'
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Limits for pages served over HTTP.
+const (
+	maxHandlerDPI     = 600
+	maxHandlerEntries = 32 // Rendered pages kept in memory
+)
+
+// pageKey identifies one rendered page.
+type pageKey struct {
+	page   int
+	dpi    float64
+	format string
+}
+
+// pageHandler serves rendered pages. The Document serializes rendering
+// itself, so mu guards only the cache and is not held while rendering.
+type pageHandler struct {
+	doc   *Document
+	opts  RenderOptions
+	docID [16]byte // Random, so ETags differ between documents
+
+	mu    sync.Mutex
+	cache map[pageKey][]byte
+	order []pageKey // Oldest first, for eviction
+}
+
+// NewPageHandler returns a handler that serves pages of doc as images. It
+// answers GET /?page=N&dpi=150&format=png, where page is 0-indexed and dpi
+// and format default to opts.DPI and png. Responses carry an ETag, and the
+// most recently rendered pages are cached in memory.
+func NewPageHandler(doc *Document, opts RenderOptions) http.Handler {
+	h := &pageHandler{
+		doc:   doc,
+		opts:  opts,
+		cache: make(map[pageKey][]byte),
+	}
+	rand.Read(h.docID[:])
+	return h
+}
+
+func (h *pageHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	key, err := h.parseQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	etag := h.etag(key)
+	if r.Header.Get("If-None-Match") == etag {
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	data, err := h.render(r, key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.Header().Set("Content-Type", contentTypes[key.format])
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	if r.Method == http.MethodHead {
+		return
+	}
+	w.Write(data)
+}
+
+// parseQuery reads and validates the page, dpi and format parameters.
+func (h *pageHandler) parseQuery(r *http.Request) (pageKey, error) {
+	query := r.URL.Query()
+	key := pageKey{dpi: h.opts.DPI, format: "png"}
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil {
+		return key, fmt.Errorf("invalid page: %q", query.Get("page"))
+	}
+	if page < 0 || page >= h.doc.PageCount() {
+		return key, fmt.Errorf("page %d out of range (0-%d)", page, h.doc.PageCount()-1)
+	}
+	key.page = page
+
+	if v := query.Get("dpi"); v != "" {
+		dpi, err := strconv.ParseFloat(v, 64)
+		if err != nil || dpi <= 0 || dpi > maxHandlerDPI {
+			return key, fmt.Errorf("invalid dpi: %q", v)
+		}
+		key.dpi = dpi
+	}
+
+	if v := query.Get("format"); v != "" {
+		key.format = strings.ToLower(v)
+		if key.format == "jpg" {
+			key.format = "jpeg"
+		}
+		if _, ok := contentTypes[key.format]; !ok {
+			return key, fmt.Errorf("unsupported format: %q", v)
+		}
+	}
+
+	return key, nil
+}
+
+// etag hashes the handler's document ID and the page parameters. A path
+// would not do: documents opened from bytes or a URL have none.
+func (h *pageHandler) etag(key pageKey) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%x\x00%d\x00%g\x00%s", h.docID, key.page, key.dpi, key.format)))
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// render returns the encoded page, from the cache if possible. The cache
+// is not locked while rendering, so cached pages are served meanwhile, but
+// the document renders one page at a time and only encoding runs
+// concurrently. Two requests for the same uncached page may both render it.
+func (h *pageHandler) render(r *http.Request, key pageKey) ([]byte, error) {
+	h.mu.Lock()
+	data, ok := h.cache[key]
+	h.mu.Unlock()
+	if ok {
+		return data, nil
+	}
+
+	opts := h.opts
+	opts.DPI = key.dpi
+
+	var buf bytes.Buffer
+	if err := h.doc.RenderPageTo(r.Context(), key.page, &buf, key.format, opts); err != nil {
+		return nil, err
+	}
+	data = buf.Bytes()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.cache[key]; ok {
+		// Rendered by another request meanwhile
+		return data, nil
+	}
+	if len(h.order) >= maxHandlerEntries {
+		delete(h.cache, h.order[0])
+		h.order = h.order[1:]
+	}
+	h.cache[key] = data
+	h.order = append(h.order, key)
+
+	return data, nil
+}
@@ -0,0 +1,73 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"gumgum/pkg/api"
+)
+
+// TestPageHandlerETag checks that documents opened from bytes, which have
+// no path, are served with different ETags.
+func TestPageHandlerETag(t *testing.T) {
+	data := writeShapesPDF(t, 1)
+	etag := func() string {
+		doc, err := api.OpenBytes(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer doc.Close()
+
+		rec := httptest.NewRecorder()
+		h := api.NewPageHandler(doc, api.NewRenderOptions(api.DPI(72)))
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?page=0", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", rec.Code, rec.Body)
+		}
+		return rec.Header().Get("ETag")
+	}
+
+	first, second := etag(), etag()
+	if first == "" || first == second {
+		t.Errorf("ETags %s and %s, want two different ones", first, second)
+	}
+}
+
+// TestPageHandlerConcurrent serves several pages at once, each requested
+// twice, the second time with its ETag.
+func TestPageHandlerConcurrent(t *testing.T) {
+	const pages = 4
+	doc, err := api.OpenBytes(writeShapesPDF(t, pages))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer doc.Close()
+	h := api.NewPageHandler(doc, api.NewRenderOptions(api.DPI(72)))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2*pages; i++ {
+		wg.Add(1)
+		go func(page int) {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			url := "/?page=" + strconv.Itoa(page)
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+			if rec.Code != http.StatusOK {
+				t.Errorf("page %d: status %d: %s", page, rec.Code, rec.Body)
+			}
+
+			// A repeat request with the ETag is not modified
+			req := httptest.NewRequest(http.MethodGet, url, nil)
+			req.Header.Set("If-None-Match", rec.Header().Get("ETag"))
+			rec = httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != http.StatusNotModified {
+				t.Errorf("page %d: repeat status %d, want 304", page, rec.Code)
+			}
+		}(i % pages)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+	"unicode/utf16"
+
+	"gumgum/pkg/cos"
+)
+
+// SetInfo replaces the document metadata. ModDate is set to the current
+// time and empty fields and zero dates are removed. The change is kept in
+// memory until WriteTo, which appends it to the original file as an
+// incremental update, so existing signatures still cover the bytes they
+// signed. Other entries of the original Info dictionary are kept.
+func (d *Document) SetInfo(info *DocumentInfo) error {
+	if info == nil {
+		return fmt.Errorf("info is nil")
+	}
+	d.renderMu.Lock()
+	defer d.renderMu.Unlock()
+
+	// New strings would have to be encrypted with the document key
+	if d.reader.IsEncrypted() {
+		return fmt.Errorf("cannot update info of an encrypted document")
+	}
+
+	updated := *info
+	updated.ModDate = time.Now()
+	d.infoOnce.Do(func() {}) // The document's own info is no longer needed
+	d.info = &updated
+	d.infoChanged = true
+	return nil
+}
+
+// writeInfoUpdate writes the original file followed by an incremental
+// update holding the new Info dictionary. The caller holds renderMu.
+func (d *Document) writeInfoUpdate(w io.Writer) (int64, error) {
+	data, err := d.reader.Bytes()
+	if err != nil {
+		return 0, err
+	}
+
+	// Keep entries of the old dictionary that DocumentInfo does not cover
+	infoDict := cos.Dict{}
+	if old, err := d.reader.Info(); err == nil && old != nil {
+		for k, v := range old {
+			infoDict[k] = v
+		}
+	}
+	fields := []struct {
+		key   string
+		value string
+	}{
+		{"Title", d.info.Title},
+		{"Author", d.info.Author},
+		{"Subject", d.info.Subject},
+		{"Keywords", d.info.Keywords},
+		{"Creator", d.info.Creator},
+		{"Producer", d.info.Producer},
+		{"CreationDate", formatInfoDate(d.info.CreationDate)},
+		{"ModDate", formatInfoDate(d.info.ModDate)},
+	}
+	for _, field := range fields {
+		if field.value == "" {
+			delete(infoDict, cos.Name(field.key))
+		} else {
+			infoDict[cos.Name(field.key)] = cos.String(encodeTextString(field.value))
+		}
+	}
+
+	// New objects are numbered after every existing one
+	size, _ := d.reader.Trailer().GetInt("Size")
+	for _, num := range d.reader.ObjectNumbers() {
+		if int64(num) >= size {
+			size = int64(num) + 1
+		}
+	}
+
+	var buf bytes.Buffer
+	pw, err := cos.NewIncrementalWriter(&buf, data, int(size), d.reader.StartXref())
+	if err != nil {
+		return 0, err
+	}
+	infoRef, err := pw.AddObject(infoDict)
+	if err != nil {
+		return 0, fmt.Errorf("failed to write info: %w", err)
+	}
+
+	// The last trailer may be an xref stream dictionary, so only carry over
+	// the entries a trailer needs
+	trailer := cos.Dict{
+		"Root": d.reader.Trailer().Get("Root"),
+		"Info": infoRef,
+	}
+	if id := d.reader.Trailer().Get("ID"); id != nil {
+		trailer["ID"] = id
+	}
+	if err := pw.Close(trailer); err != nil {
+		return 0, fmt.Errorf("failed to write trailer: %w", err)
+	}
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// encodeTextString encodes a PDF text string: ASCII as is, anything else
+// as UTF-16BE with a byte order mark. It is the inverse of
+// decodeTextString.
+func encodeTextString(s string) string {
+	ascii := true
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return s
+	}
+
+	units := utf16.Encode([]rune(s))
+	buf := make([]byte, 2, 2+2*len(units))
+	buf[0], buf[1] = 0xFE, 0xFF
+	for _, u := range units {
+		buf = append(buf, byte(u>>8), byte(u))
+	}
+	return string(buf)
+}
+
+// formatInfoDate formats an Info date, giving "" for the zero time.
+func formatInfoDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return cos.FormatPDFDate(t)
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"gumgum/pkg/cos"
+)
+
+// Link represents a link annotation on a page.
+type Link struct {
+	// Rect is the clickable area in PDF user space (x1, y1, x2, y2).
+	Rect [4]float64
+
+	// URI is the target of a URI action, empty for internal links.
+	URI string
+
+	// DestPage is the destination page (0-indexed) of a GoTo link,
+	// or -1 when the link does not point inside the document.
+	DestPage int
+}
+
+// IsInternal returns true if the link navigates within the document.
+func (l Link) IsInternal() bool {
+	return l.DestPage >= 0
+}
+
+// Contains returns true if the point (in PDF user space) is inside the link.
+func (l Link) Contains(x, y float64) bool {
+	return x >= l.Rect[0] && x <= l.Rect[2] && y >= l.Rect[1] && y <= l.Rect[3]
+}
+
+// Links returns the link annotations on the page.
+func (p *Page) Links() ([]Link, error) {
+	reader := p.doc.reader
+
+	annots := p.dict.Get("Annots")
+	if annots == nil {
+		return nil, nil
+	}
+
+	arr, err := reader.ResolveArray(annots)
+	if err != nil {
+		return nil, err
+	}
+
+	var links []Link
+	for _, item := range arr {
+		annot, err := reader.ResolveDict(item)
+		if err != nil {
+			continue
+		}
+
+		if subtype, _ := annot.GetName("Subtype"); subtype != "Link" {
+			continue
+		}
+
+		rect, err := reader.ResolveArray(annot.Get("Rect"))
+		if err != nil || len(rect) < 4 {
+			continue
+		}
+
+		link := Link{DestPage: -1}
+		x1, y1 := toFloat(rect[0]), toFloat(rect[1])
+		x2, y2 := toFloat(rect[2]), toFloat(rect[3])
+		if x1 > x2 {
+			x1, x2 = x2, x1
+		}
+		if y1 > y2 {
+			y1, y2 = y2, y1
+		}
+		link.Rect = [4]float64{x1, y1, x2, y2}
+
+		// Action dictionary takes precedence over Dest
+		if action, err := reader.ResolveDict(annot.Get("A")); err == nil {
+			switch s, _ := action.GetName("S"); s {
+			case "URI":
+				if uri, err := reader.Resolve(action.Get("URI")); err == nil {
+					if str, ok := uri.(cos.String); ok {
+						link.URI = string(str)
+					}
+				}
+			case "GoTo":
+				link.DestPage = p.resolveDest(action.Get("D"))
+			}
+		} else if dest := annot.Get("Dest"); dest != nil {
+			link.DestPage = p.resolveDest(dest)
+		}
+
+		if link.URI == "" && link.DestPage < 0 {
+			continue
+		}
+		links = append(links, link)
+	}
+
+	return links, nil
+}
+
+// resolveDest returns the page index targeted by an explicit destination,
+// or -1 if it cannot be determined.
+func (p *Page) resolveDest(dest cos.Object) int {
+	reader := p.doc.reader
+
+	arr, err := reader.ResolveArray(dest)
+	if err != nil || len(arr) == 0 {
+		return -1
+	}
+
+	switch target := arr[0].(type) {
+	case *cos.Reference:
+		// A lookup in the reader's page list, built once per document
+		index, err := reader.PageIndex(target.ObjectNumber)
+		if err != nil {
+			return -1
+		}
+		return index
+	case cos.Integer:
+		// Remote-style destinations use a page number directly
+		if int(target) >= 0 && int(target) < p.doc.pageCount {
+			return int(target)
+		}
+	}
+
+	return -1
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"image/color"
+	"time"
 )
 
 // RenderOptions configures rendering behavior.
@@ -38,9 +39,33 @@ type RenderOptions struct {
 	// Default: true
 	RenderAnnotations bool
 
+	// Invert inverts the colors of the rendered page, giving light content
+	// on a dark background.
+	// Default: false
+	Invert bool
+
 	// PageRange specifies which pages to render (for batch operations).
 	// nil means all pages.
 	PageRange *PageRange
+
+	// Workers is the number of goroutines RenderPages renders pages with.
+	// Default: 1
+	Workers int
+
+	// Prefetch is the number of pages after the one requested that
+	// RenderWithOptions renders in the background, so that reading a
+	// document in order does not wait for rendering. Prefetched pages are
+	// kept until requested with the same DPI and Invert or until they fall
+	// out of the range being prefetched.
+	// Default: 0
+	Prefetch int
+
+	// Profiler, if set, is called after rendering a page with the time
+	// spent in each stage: ParseXref, ParseContent, RasterizePaths and
+	// RasterizeText. ParseXref is the time taken when the document was
+	// opened. Profiling slows rendering slightly.
+	// Default: nil
+	Profiler func(stage string, d time.Duration)
 }
 
 // PageRange specifies a range of pages.
@@ -60,6 +85,7 @@ func DefaultRenderOptions() RenderOptions {
 		RenderText:        true,
 		RenderImages:      true,
 		RenderAnnotations: true,
+		Workers:           1,
 	}
 }
 
@@ -150,6 +176,13 @@ func NoAntiAlias() Option {
 	}
 }
 
+// Invert inverts the rendered colors.
+func Invert() Option {
+	return func(o *RenderOptions) {
+		o.Invert = true
+	}
+}
+
 // Pages sets the page range.
 func Pages(start, end int) Option {
 	return func(o *RenderOptions) {
@@ -157,6 +190,20 @@ func Pages(start, end int) Option {
 	}
 }
 
+// Workers sets the number of pages rendered at once.
+func Workers(n int) Option {
+	return func(o *RenderOptions) {
+		o.Workers = n
+	}
+}
+
+// Prefetch sets the number of following pages rendered ahead.
+func Prefetch(n int) Option {
+	return func(o *RenderOptions) {
+		o.Prefetch = n
+	}
+}
+
 // NewRenderOptions creates options from functional options.
 func NewRenderOptions(opts ...Option) RenderOptions {
 	o := DefaultRenderOptions()
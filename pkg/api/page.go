@@ -4,6 +4,7 @@ import (
 	"image"
 
 	"gumgum/pkg/cos"
+	"gumgum/pkg/raster"
 )
 
 // Page represents a single page in a PDF document.
@@ -11,8 +12,9 @@ type Page struct {
 	doc      *Document
 	pageNum  int
 	dict     cos.Dict
-	size     PageSize
+	size     PageSize // MediaBox size, before rotation
 	rotation int
+	userUnit float64
 }
 
 // PageSize contains page dimensions.
@@ -50,14 +52,32 @@ func newPage(doc *Document, pageNum int, dict cos.Dict) *Page {
 		}
 	}
 
-	// Parse Rotation
+	// Sizes are in points; page space units may be larger
+	userUnit := raster.PageUserUnit(dict)
+	p.size.Width *= userUnit
+	p.size.Height *= userUnit
+	p.userUnit = userUnit
+
+	// Parse Rotation, which may be any multiple of 90
 	if rot, ok := dict.GetInt("Rotate"); ok {
-		p.rotation = int(rot)
+		p.rotation = (int(rot)%360 + 360) % 360
 	}
 
 	return p
 }
 
+// NormalizePageSize returns the size of a page as a reader sees it once
+// it is turned by rotation degrees clockwise: width and height are swapped
+// for quarter turns, so Width is always the horizontal dimension on screen
+// or paper.
+func NormalizePageSize(size PageSize, rotation int) PageSize {
+	switch (rotation%360 + 360) % 360 {
+	case 90, 270:
+		return PageSize{Width: size.Height, Height: size.Width}
+	}
+	return size
+}
+
 func toFloat(obj cos.Object) float64 {
 	switch v := obj.(type) {
 	case cos.Integer:
@@ -73,19 +93,33 @@ func (p *Page) Number() int {
 	return p.pageNum
 }
 
-// Size returns the page dimensions in points.
+// Size returns the page dimensions in points as the page is displayed,
+// that is after its Rotate entry is applied. See NormalizePageSize.
 func (p *Page) Size() PageSize {
+	return NormalizePageSize(p.size, p.rotation)
+}
+
+// PhysicalSize returns the dimensions of the MediaBox in points, before
+// rotation. These match the page's coordinate system, such as the Rect of
+// its annotations, and the images Render produces, which are not rotated.
+func (p *Page) PhysicalSize() PageSize {
 	return p.size
 }
 
-// Width returns the page width in points.
+// Width returns the displayed page width in points.
 func (p *Page) Width() float64 {
-	return p.size.Width
+	return p.Size().Width
 }
 
-// Height returns the page height in points.
+// Height returns the displayed page height in points.
 func (p *Page) Height() float64 {
-	return p.size.Height
+	return p.Size().Height
+}
+
+// UserUnit returns the size of a unit of page space in points, normally 1.
+// Page boxes are in page space units; Size is in points.
+func (p *Page) UserUnit() float64 {
+	return p.userUnit
 }
 
 // Rotation returns the page rotation in degrees (0, 90, 180, 270).
@@ -93,17 +127,19 @@ func (p *Page) Rotation() int {
 	return p.rotation
 }
 
-// AspectRatio returns the width/height ratio.
+// AspectRatio returns the width/height ratio of the displayed page.
 func (p *Page) AspectRatio() float64 {
-	if p.size.Height == 0 {
+	size := p.Size()
+	if size.Height == 0 {
 		return 1
 	}
-	return p.size.Width / p.size.Height
+	return size.Width / size.Height
 }
 
-// IsLandscape returns true if width > height.
+// IsLandscape returns true if the displayed page is wider than high.
 func (p *Page) IsLandscape() bool {
-	return p.size.Width > p.size.Height
+	size := p.Size()
+	return size.Width > size.Height
 }
 
 // Render renders the page with default options.
@@ -116,7 +152,9 @@ func (p *Page) RenderWithOptions(opts RenderOptions) (*image.RGBA, error) {
 	return p.doc.RenderWithOptions(p.pageNum, opts)
 }
 
-// SizeInPixels returns the page size in pixels at the given DPI.
+// SizeInPixels returns the size in pixels of the page rendered at the
+// given DPI. Rendering does not apply rotation, so this is based on
+// PhysicalSize.
 func (p *Page) SizeInPixels(dpi float64) (width, height int) {
 	width = int(p.size.Width * dpi / 72)
 	height = int(p.size.Height * dpi / 72)
@@ -133,6 +171,16 @@ func (p *Page) Dict() cos.Dict {
 	return p.dict
 }
 
+// MediaBox returns the media box, the page boundaries in page space units.
+// It defaults to US Letter if missing.
+func (p *Page) MediaBox() (x1, y1, x2, y2 float64) {
+	if mediaBox, ok := p.dict.GetArray("MediaBox"); ok && len(mediaBox) >= 4 {
+		return toFloat(mediaBox[0]), toFloat(mediaBox[1]),
+			toFloat(mediaBox[2]), toFloat(mediaBox[3])
+	}
+	return 0, 0, 612, 792
+}
+
 // CropBox returns the crop box if set, otherwise the media box.
 func (p *Page) CropBox() (x1, y1, x2, y2 float64) {
 	// Try CropBox first
@@ -141,14 +189,7 @@ func (p *Page) CropBox() (x1, y1, x2, y2 float64) {
 			toFloat(cropBox[2]), toFloat(cropBox[3])
 	}
 
-	// Fall back to MediaBox
-	if mediaBox, ok := p.dict.GetArray("MediaBox"); ok && len(mediaBox) >= 4 {
-		return toFloat(mediaBox[0]), toFloat(mediaBox[1]),
-			toFloat(mediaBox[2]), toFloat(mediaBox[3])
-	}
-
-	// Default to Letter
-	return 0, 0, 612, 792
+	return p.MediaBox()
 }
 
 // BleedBox returns the bleed box if set.
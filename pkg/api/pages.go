@@ -0,0 +1,142 @@
+package api
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+
+	"gumgum/pkg/cos"
+)
+
+// ReorderPages returns a new document with the pages of doc in the given
+// order. Pages are 0-indexed and may be repeated or left out. Page content
+// and resources are copied; document-level structures such as outlines and
+// forms are not. With streams.Compress set, copied streams that have no
+// filter are compressed at streams.Level.
+func ReorderPages(doc *Document, order []int, streams cos.StreamOptions) (*Document, error) {
+	doc.renderMu.Lock()
+	defer doc.renderMu.Unlock()
+
+	return doc.extractPages(order, streams)
+}
+
+// SplitByRange returns one new document for each range of pages. Streams
+// are copied as for ReorderPages.
+func SplitByRange(doc *Document, ranges []PageRange, streams cos.StreamOptions) ([]*Document, error) {
+	doc.renderMu.Lock()
+	defer doc.renderMu.Unlock()
+
+	docs := make([]*Document, 0, len(ranges))
+	for _, r := range ranges {
+		if r.Start >= r.End {
+			return nil, fmt.Errorf("empty page range %d-%d", r.Start, r.End)
+		}
+		order := make([]int, 0, r.End-r.Start)
+		for i := r.Start; i < r.End; i++ {
+			order = append(order, i)
+		}
+		part, err := doc.extractPages(order, streams)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract pages %d-%d: %w", r.Start, r.End-1, err)
+		}
+		docs = append(docs, part)
+	}
+	return docs, nil
+}
+
+// WriteTo writes the document's PDF data to w. After SetInfo the new
+// metadata is appended as an incremental update.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	d.renderMu.Lock()
+	if d.infoChanged {
+		defer d.renderMu.Unlock()
+		return d.writeInfoUpdate(w)
+	}
+	data, err := d.reader.Bytes()
+	d.renderMu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// extractPages writes a document holding the given pages and opens it.
+func (d *Document) extractPages(order []int, streams cos.StreamOptions) (*Document, error) {
+	if len(order) == 0 {
+		return nil, fmt.Errorf("no pages to extract")
+	}
+	if streams.Compress && (streams.Level < zlib.DefaultCompression || streams.Level > zlib.BestCompression) {
+		return nil, fmt.Errorf("invalid compression level %d", streams.Level)
+	}
+	pageRefs, err := d.reader.PageRefs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pages: %w", err)
+	}
+	for _, index := range order {
+		if index < 0 || index >= len(pageRefs) {
+			return nil, fmt.Errorf("page %d out of range (0-%d)", index, len(pageRefs)-1)
+		}
+	}
+
+	var buf bytes.Buffer
+	w, err := cos.NewWriter(&buf, "1.7")
+	if err != nil {
+		return nil, err
+	}
+	copier, err := newObjectCopier(d.reader, w, streams)
+	if err != nil {
+		return nil, err
+	}
+
+	// Number every page first so links between copied pages resolve.
+	// Pages stored as direct objects (object number 0) cannot be linked to
+	pagesRef := w.Reserve()
+	kids := make(cos.Array, len(order))
+	for i, index := range order {
+		ref := w.Reserve()
+		kids[i] = ref
+		if _, ok := copier.pages[pageRefs[index]]; !ok && pageRefs[index] != 0 {
+			copier.pages[pageRefs[index]] = ref
+		}
+	}
+	for i, index := range order {
+		page, err := d.reader.GetPage(index)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read page %d: %w", index, err)
+		}
+		if err := copier.copyPage(page, kids[i].(*cos.Reference), pagesRef); err != nil {
+			return nil, err
+		}
+	}
+
+	err = w.WriteObject(pagesRef.ObjectNumber, cos.Dict{
+		"Type":  cos.Name("Pages"),
+		"Kids":  kids,
+		"Count": cos.Integer(len(kids)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := w.AddObject(cos.Dict{
+		"Type":  cos.Name("Catalog"),
+		"Pages": pagesRef,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	trailer := cos.Dict{"Root": catalog}
+	if info := d.reader.Trailer().Get("Info"); info != nil {
+		trailer["Info"] = copier.copy(info)
+		if err := copier.flush(); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(trailer); err != nil {
+		return nil, err
+	}
+
+	return OpenBytes(buf.Bytes())
+}
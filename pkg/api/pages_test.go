@@ -0,0 +1,116 @@
+package api_test
+
+import (
+	"bytes"
+	"testing"
+
+	"gumgum/pkg/api"
+	"gumgum/pkg/cos"
+)
+
+// TestReorderDirectPages checks that pages stored as direct objects in the
+// Kids array, rather than by reference, are copied.
+func TestReorderDirectPages(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := cos.NewWriter(&buf, "1.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pagesRef := w.Reserve()
+	page := func(width int) cos.Dict {
+		return cos.Dict{
+			"Type":     cos.Name("Page"),
+			"Parent":   pagesRef,
+			"MediaBox": cos.Array{cos.Integer(0), cos.Integer(0), cos.Integer(width), cos.Integer(100)},
+		}
+	}
+	if err := w.WriteObject(pagesRef.ObjectNumber, cos.Dict{
+		"Type":  cos.Name("Pages"),
+		"Kids":  cos.Array{page(100), page(200)},
+		"Count": cos.Integer(2),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	catalog, err := w.AddObject(cos.Dict{"Type": cos.Name("Catalog"), "Pages": pagesRef})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(cos.Dict{"Root": catalog}); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := api.OpenBytes(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer doc.Close()
+
+	reordered, err := api.ReorderPages(doc, []int{1, 0, 1}, cos.StreamOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reordered.Close()
+
+	if got := reordered.PageCount(); got != 3 {
+		t.Fatalf("page count = %d, want 3", got)
+	}
+	for i, want := range []float64{200, 100, 200} {
+		page, err := reordered.Page(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if width := page.Width(); width != want {
+			t.Errorf("page %d width = %g, want %g", i, width, want)
+		}
+	}
+}
+
+// TestSplitCompressesStreams checks that copied streams without a filter
+// are compressed at the requested level, including level 0, which stores
+// them, and that their content survives.
+func TestSplitCompressesStreams(t *testing.T) {
+	doc, err := api.OpenBytes(writeShapesPDF(t, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer doc.Close()
+	page, err := doc.Page(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := page.Contents()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, streams := range []cos.StreamOptions{
+		{},
+		{Compress: true, Level: 0},
+		{Compress: true, Level: 9},
+	} {
+		parts, err := api.SplitByRange(doc, []api.PageRange{{Start: 1, End: 2}}, streams)
+		if err != nil {
+			t.Fatalf("%+v: %v", streams, err)
+		}
+		copied, err := parts[0].Page(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		obj, err := parts[0].Reader().Resolve(copied.Dict().Get("Contents"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		filter, _ := obj.(*cos.Stream).Dict.GetName("Filter")
+		if (filter == "FlateDecode") != streams.Compress {
+			t.Errorf("%+v: Filter = %q", streams, filter)
+		}
+		if got, err := copied.Contents(); err != nil || !bytes.Equal(got, want) {
+			t.Errorf("%+v: contents = %q, %v, want %q", streams, got, err, want)
+		}
+	}
+
+	if _, err := api.SplitByRange(doc, []api.PageRange{{Start: 0, End: 1}}, cos.StreamOptions{Compress: true, Level: 10}); err == nil {
+		t.Error("level 10 accepted")
+	}
+}
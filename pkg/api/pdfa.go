@@ -0,0 +1,254 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"gumgum/pkg/cos"
+	"gumgum/pkg/raster"
+)
+
+// PDFAReport is the result of a PDF/A-1b conformance check.
+type PDFAReport struct {
+	Conformant bool
+	Violations []string
+}
+
+// pdfaNamespace is the XMP namespace of the PDF/A identification schema.
+const pdfaNamespace = "http://www.aiim.org/pdfa/ns/id/"
+
+// pdfaPart matches the pdfaid:part property as an element or attribute.
+var pdfaPart = regexp.MustCompile(`pdfaid:part\s*(?:>|=\s*["'])\s*(\d+)`)
+
+// CheckPDFA checks the document against the main requirements of PDF/A-1b:
+// embedded fonts, no encryption, no transparency, no JavaScript, PDF/A
+// identification in the XMP metadata, a PDF/A output intent and no
+// references to external content. It does not check every clause of the
+// standard, so a conformant report is necessary but not sufficient.
+func CheckPDFA(doc *Document) (*PDFAReport, error) {
+	doc.renderMu.Lock()
+	defer doc.renderMu.Unlock()
+
+	reader := doc.reader
+	c := &pdfaChecker{reader: reader, seen: make(map[string]bool)}
+
+	if reader.IsEncrypted() || reader.Trailer().Get("Encrypt") != nil {
+		c.report("document is encrypted")
+	}
+
+	catalog, err := reader.Catalog()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catalog: %w", err)
+	}
+	c.checkMetadata(catalog)
+	c.checkOutputIntents(catalog)
+	if names, err := reader.ResolveDict(catalog.Get("Names")); err == nil {
+		if names.Get("JavaScript") != nil {
+			c.report("document has a JavaScript name tree")
+		}
+		if names.Get("EmbeddedFiles") != nil {
+			c.report("document has embedded files")
+		}
+	}
+
+	err = reader.ForEachObject(func(num int, obj cos.Object) error {
+		c.checkObject(num, obj)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &PDFAReport{
+		Conformant: len(c.violations) == 0,
+		Violations: c.violations,
+	}, nil
+}
+
+// pdfaChecker collects PDF/A violations without duplicates.
+type pdfaChecker struct {
+	reader     *cos.Reader
+	violations []string
+	seen       map[string]bool
+}
+
+func (c *pdfaChecker) report(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if !c.seen[msg] {
+		c.seen[msg] = true
+		c.violations = append(c.violations, msg)
+	}
+}
+
+// checkMetadata requires an XMP stream identifying the file as PDF/A-1.
+func (c *pdfaChecker) checkMetadata(catalog cos.Dict) {
+	obj, err := c.reader.Resolve(catalog.Get("Metadata"))
+	metadata, ok := obj.(*cos.Stream)
+	if err != nil || !ok {
+		c.report("catalog has no XMP metadata stream")
+		return
+	}
+	data, err := c.reader.DecodeStream(metadata)
+	if err != nil {
+		c.report("XMP metadata cannot be decoded: %v", err)
+		return
+	}
+	if !bytes.Contains(data, []byte(pdfaNamespace)) {
+		c.report("XMP metadata lacks the PDF/A identification schema %s", pdfaNamespace)
+		return
+	}
+	m := pdfaPart.FindSubmatch(data)
+	if m == nil {
+		c.report("XMP metadata has no pdfaid:part")
+	} else if string(m[1]) != "1" {
+		c.report("XMP metadata declares PDF/A part %s, not 1", m[1])
+	}
+}
+
+// checkOutputIntents requires a GTS_PDFA1 output intent with a usable ICC
+// profile.
+func (c *pdfaChecker) checkOutputIntents(catalog cos.Dict) {
+	intents, err := c.reader.ResolveArray(catalog.Get("OutputIntents"))
+	if err != nil || len(intents) == 0 {
+		c.report("catalog has no OutputIntents")
+		return
+	}
+
+	for _, item := range intents {
+		intent, err := c.reader.ResolveDict(item)
+		if err != nil {
+			continue
+		}
+		if s, _ := intent.GetName("S"); s != "GTS_PDFA1" {
+			continue
+		}
+
+		obj, err := c.reader.Resolve(intent.Get("DestOutputProfile"))
+		profileStream, ok := obj.(*cos.Stream)
+		if err != nil || !ok {
+			c.report("PDF/A output intent has no DestOutputProfile")
+			return
+		}
+		data, err := c.reader.DecodeStream(profileStream)
+		if err != nil {
+			c.report("output intent profile cannot be decoded: %v", err)
+			return
+		}
+		profile, err := raster.LoadICCProfile(data)
+		if err != nil {
+			c.report("output intent profile is invalid: %v", err)
+			return
+		}
+		if profile.Version > 2 {
+			c.report("output intent profile is ICC version %d; PDF/A-1 allows version 2", profile.Version)
+		}
+		if profile.ColorSpace == raster.ICCSpaceLab {
+			c.report("output intent profile has a Lab color space")
+		}
+		return
+	}
+	c.report("OutputIntents has no GTS_PDFA1 entry")
+}
+
+// checkObject checks an object and the direct objects nested in it.
+func (c *pdfaChecker) checkObject(num int, obj cos.Object) {
+	switch v := obj.(type) {
+	case *cos.Stream:
+		if v.Dict.Get("F") != nil {
+			c.report("stream (object %d) refers to an external file", num)
+		}
+		c.checkDict(num, v.Dict)
+	case cos.Dict:
+		c.checkDict(num, v)
+	case cos.Array:
+		for _, item := range v {
+			c.checkObject(num, item)
+		}
+	}
+}
+
+// checkDict checks a dictionary by the entries that identify fonts, graphics
+// states, XObjects and actions.
+func (c *pdfaChecker) checkDict(num int, dict cos.Dict) {
+	typ, _ := dict.GetName("Type")
+	subtype, _ := dict.GetName("Subtype")
+
+	if typ == "Font" {
+		c.checkFont(num, dict, subtype)
+	}
+
+	// Transparency
+	for _, key := range []string{"CA", "ca"} {
+		if alpha, ok := dict.GetReal(key); ok && alpha != 1 {
+			c.report("object %d uses transparency (%s %g)", num, key, alpha)
+		}
+	}
+	if bm, ok := dict.GetName("BM"); ok && bm != "Normal" && bm != "Compatible" {
+		c.report("object %d uses blend mode %s", num, bm)
+	}
+	if smask := dict.Get("SMask"); smask != nil {
+		if name, ok := smask.(cos.Name); !ok || name != "None" {
+			c.report("object %d uses a soft mask", num)
+		}
+	}
+	if group, err := c.reader.ResolveDict(dict.Get("Group")); err == nil {
+		if s, _ := group.GetName("S"); s == "Transparency" {
+			c.report("object %d has a transparency group", num)
+		}
+	}
+
+	// External content
+	if subtype == "PS" {
+		c.report("object %d is a PostScript XObject", num)
+	}
+	if subtype == "Form" && dict.Get("Ref") != nil {
+		c.report("object %d is a reference XObject", num)
+	}
+
+	// Actions
+	if dict.Get("JS") != nil {
+		c.report("object %d has JavaScript", num)
+	}
+	switch s, _ := dict.GetName("S"); s {
+	case "JavaScript":
+		c.report("object %d is a JavaScript action", num)
+	case "Launch", "GoToR", "ImportData", "Movie", "Sound":
+		c.report("object %d is a %s action", num, s)
+	}
+
+	keys := make([]string, 0, len(dict))
+	for key := range dict {
+		keys = append(keys, string(key))
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		switch value := dict.Get(key).(type) {
+		case cos.Dict, cos.Array:
+			c.checkObject(num, value)
+		}
+	}
+}
+
+// checkFont requires the font program to be embedded. Type 3 fonts are
+// defined in content streams, and Type 0 fonts through their descendant
+// CIDFont, so neither needs a font file of its own.
+func (c *pdfaChecker) checkFont(num int, font cos.Dict, subtype cos.Name) {
+	if subtype == "Type3" || subtype == "Type0" {
+		return
+	}
+	name, _ := font.GetName("BaseFont")
+
+	descriptor, err := c.reader.ResolveDict(font.Get("FontDescriptor"))
+	if err != nil {
+		c.report("font %s (object %d) is not embedded", name, num)
+		return
+	}
+	for _, key := range []string{"FontFile", "FontFile2", "FontFile3"} {
+		if descriptor.Get(key) != nil {
+			return
+		}
+	}
+	c.report("font %s (object %d) is not embedded", name, num)
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"image"
+	"sync"
+)
+
+// prefetchKey identifies a prefetched page by the options that change how
+// it renders.
+type prefetchKey struct {
+	page   int
+	dpi    float64
+	invert bool
+}
+
+// prefetcher holds pages rendered ahead of RenderWithOptions calls.
+type prefetcher struct {
+	mu       sync.Mutex
+	pages    map[prefetchKey]*image.RGBA
+	inFlight map[prefetchKey]chan struct{} // Closed when the page is done
+	gen      int                           // Bumped by each prefetch run; older runs stop
+	from, to int                           // Pages of the latest run, inclusive
+
+	// Callers of RenderWithOptions waiting for renderMu, which prefetching
+	// lets go first; idle is signalled when the last of them has it
+	waiting int
+	idle    *sync.Cond
+}
+
+func newPrefetchKey(pageNum int, opts RenderOptions) prefetchKey {
+	return prefetchKey{page: pageNum, dpi: opts.DPI, invert: opts.Invert}
+}
+
+// takePrefetched returns a prefetched page, waiting for it if it is being
+// rendered, and removes it from the cache. It returns nil if the page was
+// not prefetched.
+func (d *Document) takePrefetched(pageNum int, opts RenderOptions) *image.RGBA {
+	key := newPrefetchKey(pageNum, opts)
+	p := &d.prefetch
+
+	p.mu.Lock()
+	done, ok := p.inFlight[key]
+	p.mu.Unlock()
+	if ok {
+		select {
+		case <-done:
+		case <-d.ctx.Done():
+			return nil
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	img := p.pages[key]
+	delete(p.pages, key)
+	return img
+}
+
+// startPrefetch renders the opts.Prefetch pages after pageNum in the
+// background. Pages cached for other ranges are dropped, and an earlier
+// prefetch run stops before its next page.
+func (d *Document) startPrefetch(pageNum int, opts RenderOptions) {
+	p := &d.prefetch
+	last := min(pageNum+opts.Prefetch, d.pageCount-1)
+
+	p.mu.Lock()
+	p.gen++
+	gen := p.gen
+	p.from, p.to = pageNum+1, last
+	for key := range p.pages {
+		if !p.wanted(key) {
+			delete(p.pages, key)
+		}
+	}
+	p.mu.Unlock()
+
+	opts.Prefetch = 0
+	opts.Profiler = nil
+	go func() {
+		for i := pageNum + 1; i <= last; i++ {
+			if !d.prefetchPage(i, opts, gen) {
+				return
+			}
+		}
+	}()
+}
+
+// prefetchPage renders a page into the cache unless it is there already.
+// It reports false if prefetching should stop, because the document was
+// closed or a newer run started.
+func (d *Document) prefetchPage(pageNum int, opts RenderOptions, gen int) bool {
+	key := newPrefetchKey(pageNum, opts)
+	p := &d.prefetch
+
+	p.mu.Lock()
+	if p.gen != gen || d.ctx.Err() != nil {
+		p.mu.Unlock()
+		return false
+	}
+	_, cached := p.pages[key]
+	_, busy := p.inFlight[key]
+	if cached || busy {
+		p.mu.Unlock()
+		return true
+	}
+	if p.inFlight == nil {
+		p.inFlight = make(map[prefetchKey]chan struct{})
+	}
+	done := make(chan struct{})
+	p.inFlight[key] = done
+	p.mu.Unlock()
+
+	// Render at low priority: only when no caller is waiting
+	p.mu.Lock()
+	for p.waiting > 0 {
+		p.idleCond().Wait()
+	}
+	p.mu.Unlock()
+	var img *image.RGBA
+	var err error
+	if d.ctx.Err() == nil {
+		d.renderMu.Lock()
+		img, err = d.renderLocked(pageNum, opts)
+		d.renderMu.Unlock()
+	}
+
+	// A newer run may still want the page
+	p.mu.Lock()
+	delete(p.inFlight, key)
+	if err == nil && img != nil && p.wanted(key) && d.ctx.Err() == nil {
+		if p.pages == nil {
+			p.pages = make(map[prefetchKey]*image.RGBA)
+		}
+		p.pages[key] = img
+	}
+	p.mu.Unlock()
+	close(done)
+	return true
+}
+
+// lockRender locks renderMu for a caller that prefetching gives way to.
+func (d *Document) lockRender() {
+	p := &d.prefetch
+	p.mu.Lock()
+	p.waiting++
+	p.mu.Unlock()
+
+	d.renderMu.Lock()
+
+	p.mu.Lock()
+	p.waiting--
+	if p.waiting == 0 {
+		p.idleCond().Broadcast()
+	}
+	p.mu.Unlock()
+}
+
+// idleCond returns the condition signalled when no caller is waiting for
+// renderMu; the caller holds mu.
+func (p *prefetcher) idleCond() *sync.Cond {
+	if p.idle == nil {
+		p.idle = sync.NewCond(&p.mu)
+	}
+	return p.idle
+}
+
+// wanted reports whether a page is in the range of the latest run; the
+// caller holds mu.
+func (p *prefetcher) wanted(key prefetchKey) bool {
+	return key.page >= p.from && key.page <= p.to
+}
+
+// clear drops all prefetched pages.
+func (p *prefetcher) clear() {
+	p.mu.Lock()
+	p.pages = nil
+	p.mu.Unlock()
+}
@@ -0,0 +1,63 @@
+package api_test
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"gumgum/pkg/api"
+)
+
+// TestPrefetchWithConcurrentRenders reads a document in order with
+// prefetching while other goroutines render pages at another DPI, so the
+// prefetcher repeatedly gives way to waiting callers.
+func TestPrefetchWithConcurrentRenders(t *testing.T) {
+	const pages = 12
+	data := writeShapesPDF(t, pages)
+
+	plain, err := api.OpenBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plain.Close()
+	want := make([][]byte, pages)
+	for i := range want {
+		img, err := plain.RenderWithOptions(i, api.NewRenderOptions(api.DPI(72)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want[i] = img.Pix
+	}
+
+	doc, err := api.OpenBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer doc.Close()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 3; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < pages; i++ {
+				pageNum := (i + g*pages/3) % pages
+				if _, err := doc.RenderWithOptions(pageNum, api.NewRenderOptions(api.DPI(36))); err != nil {
+					t.Errorf("page %d: %v", pageNum, err)
+				}
+			}
+		}(g)
+	}
+
+	opts := api.NewRenderOptions(api.DPI(72), api.Prefetch(3))
+	for i := 0; i < pages; i++ {
+		img, err := doc.RenderWithOptions(i, opts)
+		if err != nil {
+			t.Fatalf("page %d: %v", i, err)
+		}
+		if !bytes.Equal(img.Pix, want[i]) {
+			t.Errorf("page %d differs when prefetched", i)
+		}
+	}
+	wg.Wait()
+}
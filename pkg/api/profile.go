@@ -0,0 +1,65 @@
+package api
+
+import (
+	"image"
+	"runtime"
+	"time"
+
+	"gumgum/pkg/raster"
+)
+
+// RenderProfile describes the work done rendering a page.
+type RenderProfile struct {
+	OperatorCount    int
+	PathSegmentCount int
+	GlyphCount       int
+
+	// PeakAllocBytes is the number of bytes allocated while rendering, an
+	// upper bound on how much the heap grew.
+	PeakAllocBytes int64
+
+	Duration time.Duration
+}
+
+// RenderWithProfile renders a page like RenderWithOptions and reports the
+// work it took. Stage timings go to opts.Profiler if it is set.
+func (d *Document) RenderWithProfile(pageNum int, opts RenderOptions) (*image.RGBA, *RenderProfile, error) {
+	// Prefetching gives way to callers waiting here
+	d.lockRender()
+	defer d.renderMu.Unlock()
+
+	d.renderer.SetDPI(opts.DPI)
+	d.renderer.SetProfiling(true)
+	defer d.renderer.SetProfiling(false)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	img, err := d.renderer.RenderPage(pageNum)
+	if err == nil && opts.Invert {
+		InvertColors(img)
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	stats := d.renderer.Stats()
+	if opts.Profiler != nil {
+		d.reportStages(opts.Profiler, stats)
+	}
+	profile := &RenderProfile{
+		OperatorCount:    stats.Operators,
+		PathSegmentCount: stats.PathSegments,
+		GlyphCount:       stats.Glyphs,
+		PeakAllocBytes:   int64(after.TotalAlloc - before.TotalAlloc),
+		Duration:         elapsed,
+	}
+	return img, profile, err
+}
+
+// reportStages calls profiler with the time spent in each stage.
+func (d *Document) reportStages(profiler func(stage string, d time.Duration), stats raster.RenderStats) {
+	profiler("ParseXref", d.reader.XrefLoadTime())
+	profiler("ParseContent", stats.ParseContent)
+	profiler("RasterizePaths", stats.RasterizePaths)
+	profiler("RasterizeText", stats.RasterizeText)
+}
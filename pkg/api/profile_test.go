@@ -0,0 +1,38 @@
+package api_test
+
+import (
+	"bytes"
+	"testing"
+
+	"gumgum/pkg/api"
+)
+
+// TestRenderWithProfileMatchesRender checks that profiling a render gives
+// the same image as rendering with the same options.
+func TestRenderWithProfileMatchesRender(t *testing.T) {
+	doc, err := api.OpenBytes(writeShapesPDF(t, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer doc.Close()
+
+	for _, invert := range []bool{false, true} {
+		opts := api.NewRenderOptions(api.DPI(72))
+		opts.Invert = invert
+
+		want, err := doc.RenderWithOptions(0, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, profile, err := doc.RenderWithProfile(0, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got.Pix, want.Pix) {
+			t.Errorf("Invert %v: profiled image differs from RenderWithOptions", invert)
+		}
+		if profile.OperatorCount == 0 {
+			t.Errorf("Invert %v: profile counted no operators", invert)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package api
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"time"
+
+	"gumgum/pkg/cos"
+)
+
+// Signature describes a digital signature in a signature field.
+type Signature struct {
+	Signer    string // Common name of the signing certificate, or the Name entry
+	Time      time.Time
+	Reason    string
+	SubFilter string
+
+	// Verified is true if the signed byte ranges hash to the digest in the
+	// signature and the signer's certificate validates that digest. The
+	// certificate itself is not checked against any trust store.
+	Verified bool
+}
+
+// maxFieldDepth limits how deeply AcroForm fields may nest.
+const maxFieldDepth = 32
+
+// Signatures returns the signatures in the document's signature fields.
+// Unsigned signature fields are skipped.
+func (d *Document) Signatures() ([]Signature, error) {
+	d.renderMu.Lock()
+	defer d.renderMu.Unlock()
+
+	catalog, err := d.reader.Catalog()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catalog: %w", err)
+	}
+	form, err := d.reader.ResolveDict(catalog.Get("AcroForm"))
+	if err != nil {
+		return nil, nil
+	}
+	fields, err := d.reader.ResolveArray(form.Get("Fields"))
+	if err != nil {
+		return nil, nil
+	}
+
+	var sigs []Signature
+	for _, field := range fields {
+		d.collectSignatures(field, "", 0, &sigs)
+	}
+	return sigs, nil
+}
+
+// collectSignatures appends the signatures of a field and its kids. The
+// field type is inherited from parent fields.
+func (d *Document) collectSignatures(obj cos.Object, inheritedType cos.Name, depth int, sigs *[]Signature) {
+	if depth > maxFieldDepth {
+		return
+	}
+	field, err := d.reader.ResolveDict(obj)
+	if err != nil {
+		return
+	}
+
+	fieldType := inheritedType
+	if ft, ok := field.GetName("FT"); ok {
+		fieldType = ft
+	}
+	if fieldType == "Sig" {
+		if value, err := d.reader.ResolveDict(field.Get("V")); err == nil {
+			*sigs = append(*sigs, d.readSignature(value))
+		}
+	}
+
+	if kids, err := d.reader.ResolveArray(field.Get("Kids")); err == nil {
+		for _, kid := range kids {
+			d.collectSignatures(kid, fieldType, depth+1, sigs)
+		}
+	}
+}
+
+// readSignature reads a signature dictionary and verifies it.
+func (d *Document) readSignature(value cos.Dict) Signature {
+	sig := Signature{
+		Signer: d.resolveString(value.Get("Name")),
+		Reason: d.resolveString(value.Get("Reason")),
+	}
+	if subFilter, ok := value.GetName("SubFilter"); ok {
+		sig.SubFilter = string(subFilter)
+	}
+	if t, err := cos.ParsePDFDate(d.resolveString(value.Get("M"))); err == nil {
+		sig.Time = t
+	}
+
+	signed, contents, err := d.signedBytes(value)
+	if err != nil {
+		return sig
+	}
+
+	switch sig.SubFilter {
+	case "adbe.x509.rsa_sha1":
+		verifyPKCS1(&sig, signed, contents, d.signatureCert(value))
+	default:
+		verifyPKCS7(&sig, signed, contents)
+	}
+	return sig
+}
+
+// signedBytes returns the bytes covered by the ByteRange of a signature and
+// the signature blob. The blob is read from the gap between the ranges,
+// where the raw hex string sits, so it is not affected by decryption.
+func (d *Document) signedBytes(value cos.Dict) ([]byte, []byte, error) {
+	ranges, err := d.reader.ResolveArray(value.Get("ByteRange"))
+	if err != nil || len(ranges) < 4 || len(ranges)%2 != 0 {
+		return nil, nil, fmt.Errorf("invalid ByteRange")
+	}
+	data, err := d.reader.Bytes()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var signed []byte
+	for i := 0; i < len(ranges); i += 2 {
+		start, ok1 := ranges[i].(cos.Integer)
+		length, ok2 := ranges[i+1].(cos.Integer)
+		if !ok1 || !ok2 || start < 0 || length < 0 || int64(start)+int64(length) > int64(len(data)) {
+			return nil, nil, fmt.Errorf("invalid ByteRange")
+		}
+		signed = append(signed, data[start:start+length]...)
+	}
+
+	gapStart := int(ranges[0].(cos.Integer) + ranges[1].(cos.Integer))
+	gapEnd := int(ranges[2].(cos.Integer))
+	if gapStart < gapEnd {
+		gap := bytes.TrimSpace(data[gapStart:gapEnd])
+		if len(gap) >= 2 && gap[0] == '<' && gap[len(gap)-1] == '>' {
+			if contents, err := hex.DecodeString(string(gap[1 : len(gap)-1])); err == nil {
+				return signed, contents, nil
+			}
+		}
+	}
+
+	// Fall back to the parsed Contents string
+	if obj, err := d.reader.Resolve(value.Get("Contents")); err == nil {
+		if s, ok := obj.(cos.String); ok {
+			return signed, []byte(s), nil
+		}
+	}
+	return nil, nil, fmt.Errorf("missing Contents")
+}
+
+// signatureCert returns the signing certificate of an
+// adbe.x509.rsa_sha1 signature, the first entry of Cert.
+func (d *Document) signatureCert(value cos.Dict) *x509.Certificate {
+	obj, err := d.reader.Resolve(value.Get("Cert"))
+	if err != nil {
+		return nil
+	}
+	if arr, ok := obj.(cos.Array); ok && len(arr) > 0 {
+		obj, _ = d.reader.Resolve(arr[0])
+	}
+	s, ok := obj.(cos.String)
+	if !ok {
+		return nil
+	}
+	cert, err := x509.ParseCertificate([]byte(s))
+	if err != nil {
+		return nil
+	}
+	return cert
+}
+
+func (d *Document) resolveString(obj cos.Object) string {
+	resolved, err := d.reader.Resolve(obj)
+	if err != nil {
+		return ""
+	}
+	if s, ok := resolved.(cos.String); ok {
+		return string(s)
+	}
+	return ""
+}
+
+// verifyPKCS1 checks an adbe.x509.rsa_sha1 signature: an RSA signature,
+// wrapped in an OCTET STRING, over the SHA-1 of the signed bytes.
+func verifyPKCS1(sig *Signature, signed, contents []byte, cert *x509.Certificate) {
+	if cert == nil {
+		return
+	}
+	sig.Signer = cert.Subject.CommonName
+
+	var signature []byte
+	if _, err := asn1.Unmarshal(contents, &signature); err != nil {
+		return
+	}
+	sig.Verified = cert.CheckSignature(x509.SHA1WithRSA, signed, signature) == nil
+}
+
+// PKCS #7 / CMS structures (RFC 5652), reduced to the fields needed to
+// check a detached signature.
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      asn1.RawValue
+	Certificates     asn1.RawValue     `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue     `asn1:"optional,tag:1"`
+	SignerInfos      []pkcs7SignerInfo `asn1:"set"`
+}
+
+type pkcs7SignerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type pkcs7IssuerAndSerial struct {
+	Issuer asn1.RawValue
+	Serial *big.Int
+}
+
+type pkcs7Attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+var (
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidSigningTime   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+)
+
+// digestAlgorithms maps digest algorithm OIDs to hashes.
+var digestAlgorithms = map[string]crypto.Hash{
+	"1.3.14.3.2.26":          crypto.SHA1,
+	"2.16.840.1.101.3.4.2.1": crypto.SHA256,
+	"2.16.840.1.101.3.4.2.2": crypto.SHA384,
+	"2.16.840.1.101.3.4.2.3": crypto.SHA512,
+}
+
+// verifyPKCS7 checks a detached CMS signature such as adbe.pkcs7.detached
+// or ETSI.CAdES.detached. The signed attributes must hold the digest of the
+// signed bytes, and the signer's key must validate the attributes.
+func verifyPKCS7(sig *Signature, signed, contents []byte) {
+	var info pkcs7ContentInfo
+	if _, err := asn1.Unmarshal(contents, &info); err != nil || !info.ContentType.Equal(oidSignedData) {
+		return
+	}
+	var sd pkcs7SignedData
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &sd); err != nil || len(sd.SignerInfos) == 0 {
+		return
+	}
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil || len(certs) == 0 {
+		return
+	}
+
+	si := sd.SignerInfos[0]
+	cert := signerCertificate(certs, si.SID)
+	sig.Signer = cert.Subject.CommonName
+
+	hash, ok := digestAlgorithms[si.DigestAlgorithm.Algorithm.String()]
+	if !ok || !hash.Available() {
+		return
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	if len(si.SignedAttrs.Bytes) == 0 {
+		// Without signed attributes the signature covers the content digest
+		sig.Verified = checkDigestSignature(cert, hash, digest, si.Signature) == nil
+		return
+	}
+
+	// The attributes are signed as a SET, not with their implicit tag
+	attrBytes := append([]byte{0x31}, si.SignedAttrs.FullBytes[1:]...)
+	var attrs []pkcs7Attribute
+	if _, err := asn1.UnmarshalWithParams(attrBytes, &attrs, "set"); err != nil {
+		return
+	}
+
+	var messageDigest []byte
+	for _, attr := range attrs {
+		if len(attr.Values) == 0 {
+			continue
+		}
+		switch {
+		case attr.Type.Equal(oidMessageDigest):
+			asn1.Unmarshal(attr.Values[0].FullBytes, &messageDigest)
+		case attr.Type.Equal(oidSigningTime):
+			var t time.Time
+			if _, err := asn1.Unmarshal(attr.Values[0].FullBytes, &t); err == nil {
+				sig.Time = t
+			}
+		}
+	}
+	if !bytes.Equal(messageDigest, digest) {
+		return
+	}
+
+	h = hash.New()
+	h.Write(attrBytes)
+	sig.Verified = checkDigestSignature(cert, hash, h.Sum(nil), si.Signature) == nil
+}
+
+// signerCertificate returns the certificate named by a signer identifier,
+// or the first certificate if none matches.
+func signerCertificate(certs []*x509.Certificate, sid asn1.RawValue) *x509.Certificate {
+	var ias pkcs7IssuerAndSerial
+	if _, err := asn1.Unmarshal(sid.FullBytes, &ias); err == nil && ias.Serial != nil {
+		for _, cert := range certs {
+			if cert.SerialNumber.Cmp(ias.Serial) == 0 && bytes.Equal(cert.RawIssuer, ias.Issuer.FullBytes) {
+				return cert
+			}
+		}
+	}
+	return certs[0]
+}
+
+// checkDigestSignature verifies a signature over a precomputed digest with
+// the certificate's RSA or ECDSA key.
+func checkDigestSignature(cert *x509.Certificate, hash crypto.Hash, digest, signature []byte) error {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, hash, digest, signature)
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest, signature) {
+			return fmt.Errorf("ECDSA verification failed")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported public key type %T", cert.PublicKey)
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"fmt"
+
+	"gumgum/pkg/cos"
+	"gumgum/pkg/graphics"
+)
+
+// PageObjectStats counts the objects drawn by a page, to show what makes
+// it slow to render. Form XObjects are walked and their contents counted
+// each time they are drawn.
+type PageObjectStats struct {
+	PathCount                 int `json:"pathCount"`                 // Path construction operators: m, l, c, v, y, h and re
+	TextOperatorCount         int `json:"textOperatorCount"`         // Text showing operators: Tj, TJ, ' and "
+	ImageCount                int `json:"imageCount"`                // Image XObjects drawn and inline images
+	ExternalGraphicStateCount int `json:"externalGraphicStateCount"` // gs operators
+	FontCount                 int `json:"fontCount"`                 // Distinct fonts selected with Tf
+}
+
+// pathOperators are the operators counted as path objects.
+var pathOperators = map[string]bool{
+	"m": true, "l": true, "c": true, "v": true, "y": true, "h": true, "re": true,
+}
+
+// ObjectStats parses the page's content stream and counts the objects it
+// draws by category.
+func (p *Page) ObjectStats() (PageObjectStats, error) {
+	p.doc.renderMu.Lock()
+	defer p.doc.renderMu.Unlock()
+
+	contents, err := p.doc.reader.GetPageContents(p.dict)
+	if err != nil {
+		return PageObjectStats{}, fmt.Errorf("failed to get contents of page %d: %w", p.pageNum, err)
+	}
+	resources, _ := p.doc.reader.GetPageResources(p.dict)
+
+	c := &statsCounter{
+		reader: p.doc.reader,
+		fonts:  make(map[fontKey]bool),
+		forms:  make(map[int]bool),
+	}
+	if err := c.count(contents, resources); err != nil {
+		return PageObjectStats{}, fmt.Errorf("failed to parse contents of page %d: %w", p.pageNum, err)
+	}
+	c.stats.FontCount = len(c.fonts)
+	return c.stats, nil
+}
+
+// fontKey identifies a font: by object number when it is a reference,
+// otherwise by resource name.
+type fontKey struct {
+	num  int
+	name string
+}
+
+// statsCounter accumulates PageObjectStats over a content stream and the
+// forms it draws.
+type statsCounter struct {
+	reader *cos.Reader
+	stats  PageObjectStats
+	fonts  map[fontKey]bool
+	forms  map[int]bool // Forms being walked, to stop at cycles
+}
+
+func (c *statsCounter) count(contents []byte, resources cos.Dict) error {
+	ops, err := graphics.ParseContentStream(contents)
+	if err != nil {
+		return err
+	}
+
+	for _, op := range ops {
+		switch {
+		case pathOperators[op.Name]:
+			c.stats.PathCount++
+		case op.Name == "Tj", op.Name == "TJ", op.Name == "'", op.Name == "\"":
+			c.stats.TextOperatorCount++
+		case op.Name == "BI":
+			c.stats.ImageCount++
+		case op.Name == "gs":
+			c.stats.ExternalGraphicStateCount++
+		case op.Name == "Tf" && len(op.Operands) >= 1:
+			c.fonts[c.fontKey(resources, operandName(op.Operands[0]))] = true
+		case op.Name == "Do" && len(op.Operands) >= 1:
+			c.xobject(resources, operandName(op.Operands[0]))
+		}
+	}
+	return nil
+}
+
+// fontKey finds the font a Tf operand names in resources.
+func (c *statsCounter) fontKey(resources cos.Dict, name string) fontKey {
+	if fonts, err := c.reader.ResolveDict(resources.Get("Font")); err == nil {
+		if ref, ok := fonts.Get(name).(*cos.Reference); ok {
+			return fontKey{num: ref.ObjectNumber}
+		}
+	}
+	return fontKey{num: -1, name: name}
+}
+
+// xobject counts an XObject drawn with Do, walking it if it is a form.
+func (c *statsCounter) xobject(resources cos.Dict, name string) {
+	xobjects, err := c.reader.ResolveDict(resources.Get("XObject"))
+	if err != nil {
+		return
+	}
+	obj := xobjects.Get(name)
+	resolved, err := c.reader.Resolve(obj)
+	if err != nil {
+		return
+	}
+	xobj, ok := resolved.(*cos.Stream)
+	if !ok {
+		return
+	}
+
+	switch subtype, _ := xobj.Dict.GetName("Subtype"); subtype {
+	case "Image":
+		c.stats.ImageCount++
+	case "Form":
+		ref, ok := obj.(*cos.Reference)
+		if !ok || c.forms[ref.ObjectNumber] {
+			return
+		}
+		data, err := c.reader.DecodeStream(xobj)
+		if err != nil {
+			return
+		}
+		// Forms without resources use those of the content drawing them
+		formResources := resources
+		if res, err := c.reader.ResolveDict(xobj.Dict.Get("Resources")); err == nil {
+			formResources = res
+		}
+		c.forms[ref.ObjectNumber] = true
+		c.count(data, formResources)
+		delete(c.forms, ref.ObjectNumber)
+	}
+}
+
+// operandName returns a name operand as a string.
+func operandName(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case []byte:
+		return string(x)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"image"
+	"sync"
+
+	"gumgum/pkg/raster"
+)
+
+// PageResult is one page delivered by RenderPages.
+type PageResult struct {
+	PageNum int
+	Image   *image.RGBA
+	Err     error
+}
+
+// RenderPages renders the pages in opts.PageRange (all pages if nil) and
+// sends each result on the returned channel as soon as it is ready, so
+// callers can process early pages while later ones render. With one worker
+// pages arrive in order; with opts.Workers > 1 they arrive as they finish.
+// Each extra worker renders with a renderer of its own, so pages render in
+// parallel; the document's shared renderer and its prefetched pages are
+// only used with one worker.
+// The channel is closed when all pages are done or ctx is cancelled.
+func (d *Document) RenderPages(ctx context.Context, opts RenderOptions) <-chan PageResult {
+	start, end := 0, d.pageCount
+	if opts.PageRange != nil {
+		start, end = opts.PageRange.Start, opts.PageRange.End
+		if start < 0 {
+			start = 0
+		}
+		if end > d.pageCount {
+			end = d.pageCount
+		}
+	}
+
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	pages := make(chan int)
+	results := make(chan PageResult, workers)
+
+	go func() {
+		defer close(pages)
+		for pageNum := start; pageNum < end; pageNum++ {
+			select {
+			case pages <- pageNum:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			render := d.RenderWithOptions
+			if workers > 1 {
+				renderer := raster.NewRenderer(d.reader)
+				render = func(pageNum int, opts RenderOptions) (*image.RGBA, error) {
+					return d.renderWith(renderer, pageNum, opts)
+				}
+			}
+			for pageNum := range pages {
+				if ctx.Err() != nil {
+					return
+				}
+				img, err := render(pageNum, opts)
+				select {
+				case results <- PageResult{PageNum: pageNum, Image: img, Err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
@@ -0,0 +1,89 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"gumgum/pkg/api"
+	"gumgum/pkg/cos"
+)
+
+// writeShapesPDF writes a document of n small pages, each filling a
+// rectangle whose position and color depend on the page number.
+func writeShapesPDF(t *testing.T, n int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := cos.NewWriter(&buf, "1.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pagesRef := w.Reserve()
+	kids := make(cos.Array, n)
+	for i := range kids {
+		content := fmt.Sprintf("%.2f 0 0 rg %d %d 40 40 re f", float64(i)/float64(n), 10+i*5, 10+i*3)
+		contents, err := w.WriteStream(cos.Dict{}, []byte(content), cos.StreamOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		kids[i], err = w.AddObject(cos.Dict{
+			"Type":     cos.Name("Page"),
+			"Parent":   pagesRef,
+			"MediaBox": cos.Array{cos.Integer(0), cos.Integer(0), cos.Integer(200), cos.Integer(200)},
+			"Contents": contents,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.WriteObject(pagesRef.ObjectNumber, cos.Dict{
+		"Type":  cos.Name("Pages"),
+		"Kids":  kids,
+		"Count": cos.Integer(n),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	catalog, err := w.AddObject(cos.Dict{"Type": cos.Name("Catalog"), "Pages": pagesRef})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(cos.Dict{"Root": catalog}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRenderPagesWorkers(t *testing.T) {
+	const pages = 16
+	doc, err := api.OpenBytes(writeShapesPDF(t, pages))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer doc.Close()
+
+	render := func(workers int) map[int][]byte {
+		opts := api.NewRenderOptions(api.DPI(72), api.Workers(workers))
+		images := make(map[int][]byte)
+		for result := range doc.RenderPages(context.Background(), opts) {
+			if result.Err != nil {
+				t.Fatalf("page %d: %v", result.PageNum, result.Err)
+			}
+			images[result.PageNum] = result.Image.Pix
+		}
+		return images
+	}
+
+	want := render(1)
+	got := render(4)
+	if len(want) != pages || len(got) != pages {
+		t.Fatalf("rendered %d pages with one worker and %d with four, want %d", len(want), len(got), pages)
+	}
+	for pageNum := range want {
+		if !bytes.Equal(got[pageNum], want[pageNum]) {
+			t.Errorf("page %d differs when rendered by several workers", pageNum)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"gumgum/pkg/textextract"
+)
+
+// ExtractStructuredText returns the text on the page with its position,
+// grouped into lines, words and paragraphs with the default layout
+// parameters.
+func (p *Page) ExtractStructuredText() (*textextract.Result, error) {
+	p.doc.renderMu.Lock()
+	defer p.doc.renderMu.Unlock()
+
+	extractor := textextract.NewExtractor(p.doc.reader)
+	if err := extractor.ExtractPage(p.dict); err != nil {
+		return nil, fmt.Errorf("failed to extract text from page %d: %w", p.pageNum, err)
+	}
+	return extractor.Result(), nil
+}
+
+// SearchResult is an occurrence of a search query on a page.
+type SearchResult struct {
+	Page int // 0-indexed
+	textextract.SearchHit
+}
+
+// SearchText finds every occurrence of query in the document, ignoring
+// case. DeviceRect of each result is in the pixels of the page rendered at
+// dpi, so it can be drawn over the rendered image as is.
+func (d *Document) SearchText(query string, dpi float64) ([]SearchResult, error) {
+	return d.search(context.Background(), dpi, func(e *textextract.Extractor) []textextract.SearchHit {
+		return e.SearchText(query)
+	})
+}
+
+// SearchRegex finds every match of re in the document. The text of each
+// line is joined with single spaces between words before matching, so a
+// match may cross spans but not lines. DeviceRect of each result is in the
+// pixels of the page rendered at the default 150 DPI.
+func (d *Document) SearchRegex(ctx context.Context, re *regexp.Regexp) ([]SearchResult, error) {
+	return d.search(ctx, textextract.DefaultDPI, func(e *textextract.Extractor) []textextract.SearchHit {
+		return e.SearchRegex(re)
+	})
+}
+
+// search extracts the text of each page at dpi and collects the hits find
+// returns for it, stopping early if ctx is cancelled.
+func (d *Document) search(ctx context.Context, dpi float64, find func(*textextract.Extractor) []textextract.SearchHit) ([]SearchResult, error) {
+	d.renderMu.Lock()
+	defer d.renderMu.Unlock()
+
+	var results []SearchResult
+	for i := 0; i < d.pageCount; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		page, err := d.reader.GetPage(i)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get page %d: %w", i, err)
+		}
+		extractor := textextract.NewExtractor(d.reader)
+		extractor.DPI = dpi
+		if err := extractor.ExtractPage(page); err != nil {
+			return nil, fmt.Errorf("failed to extract text from page %d: %w", i, err)
+		}
+		for _, hit := range find(extractor) {
+			results = append(results, SearchResult{Page: i, SearchHit: hit})
+		}
+	}
+	return results, nil
+}
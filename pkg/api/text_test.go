@@ -0,0 +1,133 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"testing"
+
+	"gumgum/pkg/api"
+	"gumgum/pkg/cos"
+)
+
+// writeTextPDF writes a document with one Letter page per content stream,
+// each with 12 point Helvetica as /F1.
+func writeTextPDF(t *testing.T, contents ...string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := cos.NewWriter(&buf, "1.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	font, err := w.AddObject(cos.Dict{
+		"Type":     cos.Name("Font"),
+		"Subtype":  cos.Name("Type1"),
+		"BaseFont": cos.Name("Helvetica"),
+		"Encoding": cos.Name("WinAnsiEncoding"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pagesRef := w.Reserve()
+	kids := make(cos.Array, len(contents))
+	for i, content := range contents {
+		stream, err := w.WriteStream(cos.Dict{}, []byte(content), cos.StreamOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		kids[i], err = w.AddObject(cos.Dict{
+			"Type":      cos.Name("Page"),
+			"Parent":    pagesRef,
+			"MediaBox":  cos.Array{cos.Integer(0), cos.Integer(0), cos.Integer(612), cos.Integer(792)},
+			"Resources": cos.Dict{"Font": cos.Dict{"F1": font}},
+			"Contents":  stream,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.WriteObject(pagesRef.ObjectNumber, cos.Dict{
+		"Type":  cos.Name("Pages"),
+		"Kids":  kids,
+		"Count": cos.Integer(len(contents)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	catalog, err := w.AddObject(cos.Dict{"Type": cos.Name("Catalog"), "Pages": pagesRef})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(cos.Dict{"Root": catalog}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestSearchRegexDates(t *testing.T) {
+	data := writeTextPDF(t,
+		// Two dates on one line, the second in its own span
+		"BT /F1 12 Tf 72 700 Td (Issued 2024-03-15, due) Tj ( 2024-04-01.) Tj ET",
+		// A date split across spans, and one that is not a full date
+		"BT /F1 12 Tf 72 700 Td (Signed 2023-1) Tj (2-31) Tj 0 -20 Td (Version 2024-3-5) Tj ET",
+	)
+	doc, err := api.OpenBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer doc.Close()
+
+	results, err := doc.SearchRegex(context.Background(), regexp.MustCompile(`\d{4}-\d{2}-\d{2}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		page int
+		text string
+	}{
+		{0, "2024-03-15"},
+		{0, "2024-04-01"},
+		{1, "2023-12-31"},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("SearchRegex found %d dates, want %d: %+v", len(results), len(want), results)
+	}
+	for i, w := range want {
+		r := results[i]
+		if r.Page != w.page || r.Text != w.text {
+			t.Errorf("result %d = page %d %q, want page %d %q", i, r.Page, r.Text, w.page, w.text)
+		}
+		if r.DeviceRect.Empty() {
+			t.Errorf("result %d (%q) has an empty DeviceRect", i, r.Text)
+		}
+	}
+
+	// The dates on the first page are in order along the same baseline
+	first, second := results[0].DeviceRect, results[1].DeviceRect
+	if second.Min.X <= first.Max.X || second.Min.Y != first.Min.Y {
+		t.Errorf("second date at %v should follow the first at %v on the same line", second, first)
+	}
+
+	// The split date covers both of its spans, so it is about as wide as
+	// the dates on the first page
+	split := results[2].DeviceRect
+	if split.Dx() < 2*first.Dx()/3 {
+		t.Errorf("date split across spans is %d pixels wide, want about %d", split.Dx(), first.Dx())
+	}
+}
+
+func TestSearchRegexCancelled(t *testing.T) {
+	doc, err := api.OpenBytes(writeTextPDF(t, "BT /F1 12 Tf 72 700 Td (1999-12-31) Tj ET"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer doc.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := doc.SearchRegex(ctx, regexp.MustCompile(`\d+`)); err != context.Canceled {
+		t.Errorf("SearchRegex with a cancelled context: err = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gumgum/pkg/cos"
+)
+
+// tailSize is how much of the end of a remote PDF is fetched first, enough
+// to hold startxref.
+const tailSize = 1024
+
+// OpenURL opens a PDF over HTTP. If the server supports range requests
+// only the parts of the file that are used are fetched: the end of the file
+// first, then the cross-reference tables, then objects as they are needed.
+// Otherwise the whole file is downloaded. ctx applies to every request,
+// including those made later while rendering.
+func OpenURL(ctx context.Context, url string) (*Document, error) {
+	// A suffix range request both tests for range support and returns the
+	// total size
+	resp, err := get(ctx, url, fmt.Sprintf("bytes=-%d", tailSize))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		size, err := contentRangeSize(resp.Header.Get("Content-Range"))
+		if err != nil {
+			return nil, err
+		}
+		src := &httpRangeReader{ctx: ctx, url: url, size: size}
+		reader, err := cos.NewReaderFromRange(src, cos.DefaultReaderOptions())
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse PDF: %w", err)
+		}
+		return newDocument(reader)
+
+	case http.StatusOK:
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download %s: %w", url, err)
+		}
+		return OpenBytes(data)
+
+	default:
+		return nil, fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+}
+
+// get requests url with a Range header.
+func get(ctx context.Context, url, byteRange string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Range", byteRange)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	return resp, nil
+}
+
+// contentRangeSize returns the total size from a Content-Range header such
+// as "bytes 1000-1999/2000".
+func contentRangeSize(header string) (int64, error) {
+	slash := strings.LastIndexByte(header, '/')
+	if !strings.HasPrefix(header, "bytes ") || slash < 0 {
+		return 0, fmt.Errorf("invalid Content-Range %q", header)
+	}
+	size, err := strconv.ParseInt(header[slash+1:], 10, 64)
+	if err != nil || size <= 0 {
+		return 0, fmt.Errorf("Content-Range %q has no size", header)
+	}
+	return size, nil
+}
+
+// httpRangeReader reads parts of a remote file with range requests.
+type httpRangeReader struct {
+	ctx  context.Context
+	url  string
+	size int64
+}
+
+func (r *httpRangeReader) Size() int64 {
+	return r.size
+}
+
+func (r *httpRangeReader) ReadAt(p []byte, off int64) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	resp, err := get(r.ctx, r.url, fmt.Sprintf("bytes=%d-%d", off, off+int64(len(p))-1))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return 0, fmt.Errorf("range request for %s: %s", r.url, resp.Status)
+	}
+	n, err := io.ReadFull(resp.Body, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
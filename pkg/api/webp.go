@@ -0,0 +1,145 @@
+package api
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// maxWebPSize is the largest width or height a lossless WebP can store.
+const maxWebPSize = 1 << 14
+
+// encodeWebP writes img as a lossless (VP8L) WebP. The encoder applies no
+// transforms and uses fixed 8-bit codes for every channel, so files are
+// about the size of the raw pixels; it trades compression for simplicity.
+func encodeWebP(w io.Writer, img image.Image) error {
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	if width < 1 || height < 1 || width > maxWebPSize || height > maxWebPSize {
+		return fmt.Errorf("image size %dx%d not supported by WebP", width, height)
+	}
+
+	bw := &bitWriter{}
+
+	// Header: signature, size and alpha hint, version 0
+	bw.writeBits(0x2f, 8)
+	bw.writeBits(uint32(width-1), 14)
+	bw.writeBits(uint32(height-1), 14)
+	bw.writeBits(1, 1)
+	bw.writeBits(0, 3)
+
+	// No transforms, no color cache, a single prefix code group
+	bw.writeBits(0, 1)
+	bw.writeBits(0, 1)
+	bw.writeBits(0, 1)
+
+	// Green (with length and cache symbols), red, blue and alpha codes give
+	// every byte value an 8-bit code
+	writeByteCode(bw, 256+24)
+	writeByteCode(bw, 256)
+	writeByteCode(bw, 256)
+	writeByteCode(bw, 256)
+
+	// Distance code: a single unused symbol
+	bw.writeBits(1, 1) // Simple code
+	bw.writeBits(0, 1) // One symbol
+	bw.writeBits(0, 1) // 1-bit symbol
+	bw.writeBits(0, 1) // Symbol 0
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			bw.writeCode(c.G)
+			bw.writeCode(c.R)
+			bw.writeCode(c.B)
+			bw.writeCode(c.A)
+		}
+	}
+
+	data := bw.bytes()
+	chunkSize := len(data)
+	padding := chunkSize & 1
+
+	out := bufio.NewWriter(w)
+	var header [20]byte
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(4+8+chunkSize+padding))
+	copy(header[8:12], "WEBP")
+	copy(header[12:16], "VP8L")
+	binary.LittleEndian.PutUint32(header[16:20], uint32(chunkSize))
+	if _, err := out.Write(header[:]); err != nil {
+		return err
+	}
+	if _, err := out.Write(data); err != nil {
+		return err
+	}
+	if padding == 1 {
+		if err := out.WriteByte(0); err != nil {
+			return err
+		}
+	}
+	return out.Flush()
+}
+
+// writeByteCode writes a normal prefix code over an alphabet where symbols
+// 0-255 have length 8 and the rest are unused. The code lengths are
+// themselves coded with 1-bit codes: "0" for length 0 and "1" for length 8.
+func writeByteCode(bw *bitWriter, alphabetSize int) {
+	bw.writeBits(0, 1) // Normal code
+
+	// Code length code lengths, in the order the format stores them:
+	// 17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8. Only 0 and 8 are used.
+	lengths := []uint32{0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 1}
+	bw.writeBits(uint32(len(lengths)-4), 4)
+	for _, l := range lengths {
+		bw.writeBits(l, 3)
+	}
+
+	bw.writeBits(0, 1) // Lengths for the whole alphabet follow
+	for i := 0; i < alphabetSize; i++ {
+		if i < 256 {
+			bw.writeBits(1, 1)
+		} else {
+			bw.writeBits(0, 1)
+		}
+	}
+}
+
+// bitWriter packs bits least significant first, as VP8L requires.
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nbits uint
+}
+
+func (bw *bitWriter) writeBits(v uint32, n uint) {
+	bw.acc |= uint64(v) << bw.nbits
+	bw.nbits += n
+	for bw.nbits >= 8 {
+		bw.buf = append(bw.buf, byte(bw.acc))
+		bw.acc >>= 8
+		bw.nbits -= 8
+	}
+}
+
+// writeCode writes the 8-bit prefix code for a byte. Prefix codes are read
+// one bit at a time starting with the most significant bit.
+func (bw *bitWriter) writeCode(v uint8) {
+	var reversed uint32
+	for i := 0; i < 8; i++ {
+		reversed |= uint32((v>>i)&1) << (7 - i)
+	}
+	bw.writeBits(reversed, 8)
+}
+
+func (bw *bitWriter) bytes() []byte {
+	if bw.nbits > 0 {
+		bw.buf = append(bw.buf, byte(bw.acc))
+		bw.acc = 0
+		bw.nbits = 0
+	}
+	return bw.buf
+}
@@ -0,0 +1,69 @@
+package api_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/image/webp"
+
+	"gumgum/pkg/api"
+)
+
+// TestWebPRoundTrip checks that lossless WebP output decodes to exactly
+// the pixels that were encoded, alpha included.
+func TestWebPRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := func(w, h int) *image.NRGBA {
+		img := image.NewNRGBA(image.Rect(0, 0, w, h))
+		rng.Read(img.Pix)
+		return img
+	}
+	uniform := func(c color.NRGBA) *image.NRGBA {
+		img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+		img.SetNRGBA(0, 0, c)
+		return img
+	}
+
+	tests := []struct {
+		name string
+		img  *image.NRGBA
+	}{
+		{"1x1 opaque", uniform(color.NRGBA{200, 100, 50, 255})},
+		{"1x1 translucent", uniform(color.NRGBA{10, 20, 30, 128})},
+		{"1x1 transparent", uniform(color.NRGBA{255, 0, 255, 0})},
+		{"odd size", random(7, 3)},
+		{"tall", random(1, 33)},
+		{"larger", random(129, 65)},
+		{"offset bounds", random(20, 20).SubImage(image.Rect(3, 5, 16, 14)).(*image.NRGBA)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := api.EncodeImage(&buf, tt.img, "webp"); err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := webp.Decode(&buf)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+
+			b := tt.img.Bounds()
+			if decoded.Bounds().Dx() != b.Dx() || decoded.Bounds().Dy() != b.Dy() {
+				t.Fatalf("decoded size %v, want %dx%d", decoded.Bounds().Size(), b.Dx(), b.Dy())
+			}
+			d := decoded.Bounds().Min
+			for y := 0; y < b.Dy(); y++ {
+				for x := 0; x < b.Dx(); x++ {
+					want := tt.img.NRGBAAt(b.Min.X+x, b.Min.Y+y)
+					got := color.NRGBAModel.Convert(decoded.At(d.X+x, d.Y+y)).(color.NRGBA)
+					if got != want {
+						t.Fatalf("pixel (%d, %d) = %v, want %v", x, y, got, want)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package cos
+
+import (
+	"container/list"
+)
+
+// DefaultMaxCachedObjects is the default capacity of the object cache.
+const DefaultMaxCachedObjects = 1024
+
+// CacheStats reports object cache usage.
+type CacheStats struct {
+	Hits    int
+	Misses  int
+	Entries int
+
+	// ObjectStreams is the number of object streams decoded and parsed.
+	// Each is parsed at most once.
+	ObjectStreams int
+}
+
+// HitRate returns the fraction of lookups served from the cache.
+func (s CacheStats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// cacheEntry is an element of the LRU list.
+type cacheEntry struct {
+	objNum int
+	obj    Object
+}
+
+// objectCache is an LRU cache of resolved objects keyed by object number.
+type objectCache struct {
+	capacity int
+	order    *list.List // Front is most recently used
+	items    map[int]*list.Element
+
+	hits   int
+	misses int
+}
+
+// newObjectCache creates a cache holding at most capacity objects.
+// A capacity of zero or less disables eviction.
+func newObjectCache(capacity int) *objectCache {
+	return &objectCache{
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[int]*list.Element),
+	}
+}
+
+// get returns the cached object and marks it as recently used.
+func (c *objectCache) get(objNum int) (Object, bool) {
+	elem, ok := c.items[objNum]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).obj, true
+}
+
+// put adds an object, evicting the least recently used entry if full.
+func (c *objectCache) put(objNum int, obj Object) {
+	if elem, ok := c.items[objNum]; ok {
+		elem.Value.(*cacheEntry).obj = obj
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[objNum] = c.order.PushFront(&cacheEntry{objNum: objNum, obj: obj})
+
+	if c.capacity > 0 && c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).objNum)
+	}
+}
+
+// stats returns the current cache statistics.
+func (c *objectCache) stats() CacheStats {
+	return CacheStats{Hits: c.hits, Misses: c.misses, Entries: c.order.Len()}
+}
+
+// objStmEntry is an element of the object stream LRU list.
+type objStmEntry struct {
+	objNum  int
+	objects map[int]Object
+}
+
+// objStmCache is an LRU cache of the parsed objects of object streams,
+// keyed by the stream's object number. It holds at most budget objects in
+// all, so decoded object streams are bounded like the object cache, but
+// always keeps the most recently used stream however large.
+type objStmCache struct {
+	budget int        // Zero or less disables eviction
+	size   int        // Objects held
+	order  *list.List // Front is most recently used
+	items  map[int]*list.Element
+}
+
+// newObjStmCache creates a cache holding at most budget objects.
+func newObjStmCache(budget int) *objStmCache {
+	return &objStmCache{
+		budget: budget,
+		order:  list.New(),
+		items:  make(map[int]*list.Element),
+	}
+}
+
+// get returns the objects of a stream and marks it as recently used.
+func (c *objStmCache) get(objNum int) (map[int]Object, bool) {
+	elem, ok := c.items[objNum]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*objStmEntry).objects, true
+}
+
+// put adds the objects of a stream, evicting the least recently used
+// streams until the cache is within its budget.
+func (c *objStmCache) put(objNum int, objects map[int]Object) {
+	if elem, ok := c.items[objNum]; ok {
+		c.size -= len(elem.Value.(*objStmEntry).objects)
+		c.order.Remove(elem)
+	}
+
+	c.items[objNum] = c.order.PushFront(&objStmEntry{objNum: objNum, objects: objects})
+	c.size += len(objects)
+
+	for c.budget > 0 && c.size > c.budget && c.order.Len() > 1 {
+		oldest := c.order.Back()
+		entry := c.order.Remove(oldest).(*objStmEntry)
+		delete(c.items, entry.objNum)
+		c.size -= len(entry.objects)
+	}
+}
@@ -0,0 +1,113 @@
+package cos
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestObjectCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newObjectCache(2)
+	c.put(1, Integer(1))
+	c.put(2, Integer(2))
+	c.get(1) // 2 is now the least recently used
+	c.put(3, Integer(3))
+
+	if _, ok := c.get(2); ok {
+		t.Error("object 2 was not evicted")
+	}
+	for _, num := range []int{1, 3} {
+		if obj, ok := c.get(num); !ok || obj != Integer(num) {
+			t.Errorf("get(%d) = %v, %v", num, obj, ok)
+		}
+	}
+	if stats := c.stats(); stats.Entries != 2 {
+		t.Errorf("Entries = %d, want 2", stats.Entries)
+	}
+}
+
+func TestObjStmCacheEvictsToBudget(t *testing.T) {
+	stream := func(n int) map[int]Object {
+		objects := make(map[int]Object, n)
+		for i := 0; i < n; i++ {
+			objects[i] = Integer(i)
+		}
+		return objects
+	}
+
+	c := newObjStmCache(10)
+	c.put(1, stream(4))
+	c.put(2, stream(4))
+	c.get(1) // 2 is now the least recently used
+	c.put(3, stream(4))
+
+	if _, ok := c.get(2); ok {
+		t.Error("stream 2 was not evicted")
+	}
+	for _, num := range []int{1, 3} {
+		if _, ok := c.get(num); !ok {
+			t.Errorf("stream %d was evicted", num)
+		}
+	}
+	if c.size != 8 {
+		t.Errorf("size = %d, want 8", c.size)
+	}
+
+	// A stream over the whole budget is still kept, alone
+	c.put(4, stream(20))
+	if _, ok := c.get(4); !ok || c.order.Len() != 1 || c.size != 20 {
+		t.Errorf("after a large stream: kept %v, %d streams of %d objects", ok, c.order.Len(), c.size)
+	}
+}
+
+func TestObjectStreamsBounded(t *testing.T) {
+	const objects = 50
+	r, err := NewReaderWithOptions(writeObjStmPDF(t, objects), ReaderOptions{MaxCachedObjects: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < objects; i++ {
+		if _, err := r.GetObject(4 + i); err != nil {
+			t.Fatalf("object %d: %v", 4+i, err)
+		}
+	}
+
+	// The one stream is larger than the budget but stays cached
+	if got := r.CacheStats().ObjectStreams; got != 1 {
+		t.Errorf("object stream decoded %d times, want once", got)
+	}
+}
+
+// BenchmarkObjectCache reads every page of a 500-page document the way the
+// renderer does, reporting the object cache hit rate for several sizes.
+func BenchmarkObjectCache(b *testing.B) {
+	data := writeTestPDF(b, 500)
+
+	for _, size := range []int{0, DefaultMaxCachedObjects, 64} {
+		b.Run(fmt.Sprintf("max=%d", size), func(b *testing.B) {
+			var stats CacheStats
+			for i := 0; i < b.N; i++ {
+				r, err := NewReaderWithOptions(data, ReaderOptions{MaxCachedObjects: size})
+				if err != nil {
+					b.Fatal(err)
+				}
+				// Two passes, as when paging forward and back again
+				for pass := 0; pass < 2; pass++ {
+					for page := 0; page < 500; page++ {
+						dict, err := r.GetPage(page)
+						if err != nil {
+							b.Fatal(err)
+						}
+						if _, err := r.GetPageContents(dict); err != nil {
+							b.Fatal(err)
+						}
+						resources, _ := r.GetPageResources(dict)
+						fonts, _ := r.ResolveDict(resources.Get("Font"))
+						r.ResolveDict(fonts.Get("F1"))
+					}
+				}
+				stats = r.CacheStats()
+			}
+			b.ReportMetric(100*stats.HitRate(), "%hit")
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package cos
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+)
+
+// CMap maps character codes of a composite font to CIDs.
+type CMap struct {
+	Name     string
+	Vertical bool // WMode 1
+
+	identity  bool // Two-byte codes that are their own CIDs
+	codespace []codespaceRange
+	cidRanges []cidRange
+}
+
+// CharCode is one character code read from a string by a CMap.
+type CharCode struct {
+	Code   uint32
+	Length int // Bytes in the code
+	CID    uint32
+}
+
+type codespaceRange struct {
+	low, high []byte
+}
+
+// cidRange maps codes low..high of a given byte length to consecutive CIDs.
+type cidRange struct {
+	low, high uint32
+	length    int
+	cid       uint32
+}
+
+// predefinedCMap returns a predefined CMap by name. Only the Identity CMaps
+// are built in.
+func predefinedCMap(name string) (*CMap, error) {
+	switch name {
+	case "Identity-H", "Identity-V":
+		return &CMap{
+			Name:      name,
+			Vertical:  name == "Identity-V",
+			identity:  true,
+			codespace: []codespaceRange{{low: []byte{0, 0}, high: []byte{0xff, 0xff}}},
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported predefined CMap: %s", name)
+}
+
+// Decode splits a string into character codes using the codespace ranges.
+// Bytes that match no range are read as single-byte codes with CID 0.
+func (c *CMap) Decode(data []byte) []CharCode {
+	var codes []CharCode
+	for i := 0; i < len(data); {
+		n := c.matchCodespace(data[i:])
+		if n == 0 {
+			codes = append(codes, CharCode{Code: uint32(data[i]), Length: 1})
+			i++
+			continue
+		}
+
+		var code uint32
+		for _, b := range data[i : i+n] {
+			code = code<<8 | uint32(b)
+		}
+		codes = append(codes, CharCode{Code: code, Length: n, CID: c.lookup(code, n)})
+		i += n
+	}
+	return codes
+}
+
+// matchCodespace returns the length of the code at the start of data, or 0
+// if no codespace range matches.
+func (c *CMap) matchCodespace(data []byte) int {
+	for n := 1; n <= 4 && n <= len(data); n++ {
+		for _, r := range c.codespace {
+			if len(r.low) != n {
+				continue
+			}
+			inRange := true
+			for k := 0; k < n; k++ {
+				if data[k] < r.low[k] || data[k] > r.high[k] {
+					inRange = false
+					break
+				}
+			}
+			if inRange {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// lookup returns the CID for a code, or 0 if it is not mapped.
+func (c *CMap) lookup(code uint32, length int) uint32 {
+	if c.identity {
+		return code
+	}
+	for _, r := range c.cidRanges {
+		if r.length == length && code >= r.low && code <= r.high {
+			return r.cid + code - r.low
+		}
+	}
+	return 0
+}
+
+// parseCMap reads an embedded CMap program. Only the codespace and CID
+// mapping sections are interpreted.
+func parseCMap(data []byte) (*CMap, error) {
+	cmap := &CMap{}
+	tokens := tokenizeCMap(data)
+
+	// hexOperand returns the bytes of a hex string token
+	hexOperand := func(tok string) ([]byte, bool) {
+		if len(tok) < 2 || tok[0] != '<' || tok[len(tok)-1] != '>' {
+			return nil, false
+		}
+		digits := tok[1 : len(tok)-1]
+		if len(digits)%2 == 1 {
+			digits += "0"
+		}
+		b, err := hex.DecodeString(digits)
+		return b, err == nil
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "/CMapName":
+			if i+1 < len(tokens) {
+				cmap.Name = trimName(tokens[i+1])
+			}
+		case "/WMode":
+			if i+1 < len(tokens) {
+				cmap.Vertical = tokens[i+1] == "1"
+			}
+		case "begincodespacerange":
+			for i+2 < len(tokens) && tokens[i+1] != "endcodespacerange" {
+				low, ok1 := hexOperand(tokens[i+1])
+				high, ok2 := hexOperand(tokens[i+2])
+				if ok1 && ok2 && len(low) == len(high) && len(low) > 0 && len(low) <= 4 {
+					cmap.codespace = append(cmap.codespace, codespaceRange{low: low, high: high})
+				}
+				i += 2
+			}
+		case "begincidrange":
+			for i+3 < len(tokens) && tokens[i+1] != "endcidrange" {
+				low, ok1 := hexOperand(tokens[i+1])
+				high, ok2 := hexOperand(tokens[i+2])
+				cid, err := strconv.ParseUint(tokens[i+3], 10, 32)
+				if ok1 && ok2 && err == nil && len(low) <= 4 && len(high) <= 4 {
+					cmap.cidRanges = append(cmap.cidRanges, cidRange{
+						low:    bytesToCode(low),
+						high:   bytesToCode(high),
+						length: len(low),
+						cid:    uint32(cid),
+					})
+				}
+				i += 3
+			}
+		case "begincidchar":
+			for i+2 < len(tokens) && tokens[i+1] != "endcidchar" {
+				code, ok := hexOperand(tokens[i+1])
+				cid, err := strconv.ParseUint(tokens[i+2], 10, 32)
+				if ok && err == nil && len(code) <= 4 {
+					c := bytesToCode(code)
+					cmap.cidRanges = append(cmap.cidRanges, cidRange{
+						low: c, high: c, length: len(code), cid: uint32(cid),
+					})
+				}
+				i += 2
+			}
+		}
+	}
+
+	if len(cmap.codespace) == 0 {
+		return nil, fmt.Errorf("CMap has no codespace ranges")
+	}
+	return cmap, nil
+}
+
+// tokenizeCMap splits a CMap program into hex strings, names, numbers and
+// keywords. Comments and literal strings are dropped.
+func tokenizeCMap(data []byte) []string {
+	var tokens []string
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case isWhitespace(c):
+			i++
+		case c == '%':
+			for i < len(data) && data[i] != '\n' && data[i] != '\r' {
+				i++
+			}
+		case c == '<' && i+1 < len(data) && data[i+1] == '<',
+			c == '>' && i+1 < len(data) && data[i+1] == '>':
+			tokens = append(tokens, string(data[i:i+2]))
+			i += 2
+		case c == '<':
+			end := bytes.IndexByte(data[i:], '>')
+			if end < 0 {
+				return tokens
+			}
+			hexData := bytes.Map(func(r rune) rune {
+				if isWhitespace(byte(r)) {
+					return -1
+				}
+				return r
+			}, data[i:i+end+1])
+			tokens = append(tokens, string(hexData))
+			i += end + 1
+		case c == '(':
+			// Skip literal strings such as Registry and Ordering
+			depth := 0
+			for ; i < len(data); i++ {
+				if data[i] == '\\' {
+					i++
+					continue
+				}
+				if data[i] == '(' {
+					depth++
+				} else if data[i] == ')' {
+					depth--
+					if depth == 0 {
+						i++
+						break
+					}
+				}
+			}
+		case c == '[' || c == ']' || c == '{' || c == '}':
+			tokens = append(tokens, string(c))
+			i++
+		default:
+			start := i
+			i++
+			for i < len(data) && !isWhitespace(data[i]) && !isDelimiter(data[i]) {
+				i++
+			}
+			tokens = append(tokens, string(data[start:i]))
+		}
+	}
+	return tokens
+}
+
+func bytesToCode(b []byte) uint32 {
+	var code uint32
+	for _, v := range b {
+		code = code<<8 | uint32(v)
+	}
+	return code
+}
+
+func trimName(tok string) string {
+	if len(tok) > 0 && tok[0] == '/' {
+		return tok[1:]
+	}
+	return tok
+}
@@ -0,0 +1,77 @@
+package cos
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ParsePDFDate parses a date string of the form D:YYYYMMDDHHmmSSOHH'mm',
+// where O is +, - or Z. Fields after the year are optional; a date without
+// a time zone is taken as UTC. The D: prefix may be left out.
+func ParsePDFDate(s string) (time.Time, error) {
+	if len(s) >= 2 && s[:2] == "D:" {
+		s = s[2:]
+	}
+	if len(s) < 4 {
+		return time.Time{}, fmt.Errorf("invalid date %q", s)
+	}
+
+	// Year, month, day, hour, minute, second
+	fields := []int{0, 1, 1, 0, 0, 0}
+	widths := []int{4, 2, 2, 2, 2, 2}
+	pos := 0
+	for i, w := range widths {
+		if pos+w > len(s) || s[pos] < '0' || s[pos] > '9' {
+			break
+		}
+		v, err := strconv.Atoi(s[pos : pos+w])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q", s)
+		}
+		fields[i] = v
+		pos += w
+	}
+	if pos == 0 {
+		return time.Time{}, fmt.Errorf("invalid date %q", s)
+	}
+
+	loc := time.UTC
+	if pos < len(s) && (s[pos] == '+' || s[pos] == '-') {
+		sign := 1
+		if s[pos] == '-' {
+			sign = -1
+		}
+		var hh, mm int
+		rest := s[pos+1:]
+		if len(rest) >= 2 {
+			hh, _ = strconv.Atoi(rest[:2])
+		}
+		// Minutes follow an apostrophe, or directly in some writers' output
+		switch {
+		case len(rest) >= 5 && rest[2] == '\'':
+			mm, _ = strconv.Atoi(rest[3:5])
+		case len(rest) >= 4 && rest[2] >= '0' && rest[2] <= '9':
+			mm, _ = strconv.Atoi(rest[2:4])
+		}
+		loc = time.FixedZone("", sign*(hh*3600+mm*60))
+	}
+
+	return time.Date(fields[0], time.Month(fields[1]), fields[2], fields[3], fields[4], fields[5], 0, loc), nil
+}
+
+// FormatPDFDate formats a time as a PDF date string,
+// D:YYYYMMDDHHmmSSOHH'mm', writing Z for UTC.
+func FormatPDFDate(t time.Time) string {
+	date := "D:" + t.Format("20060102150405")
+	_, offset := t.Zone()
+	if offset == 0 {
+		return date + "Z"
+	}
+	sign := '+'
+	if offset < 0 {
+		sign = '-'
+		offset = -offset
+	}
+	return fmt.Sprintf("%s%c%02d'%02d'", date, sign, offset/3600, offset/60%60)
+}
@@ -0,0 +1,74 @@
+package cos
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePDFDate(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   string // RFC 3339
+		offset int    // Seconds east of UTC
+	}{
+		{"D:20240315093000Z", "2024-03-15T09:30:00Z", 0},
+		{"D:20240315093000Z00'00'", "2024-03-15T09:30:00Z", 0},
+		{"D:20240315093000+05'30'", "2024-03-15T09:30:00+05:30", 5*3600 + 30*60},
+		{"D:20240315093000-08'00'", "2024-03-15T09:30:00-08:00", -8 * 3600},
+		{"D:20240315093000-0330", "2024-03-15T09:30:00-03:30", -(3*3600 + 30*60)},
+		{"D:20240315093000+09", "2024-03-15T09:30:00+09:00", 9 * 3600},
+		{"20240315093000+01'00'", "2024-03-15T09:30:00+01:00", 3600},
+		{"D:20240315", "2024-03-15T00:00:00Z", 0},
+		{"D:2024", "2024-01-01T00:00:00Z", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParsePDFDate(tt.in)
+			if err != nil {
+				t.Fatalf("ParsePDFDate(%q): %v", tt.in, err)
+			}
+			if s := got.Format(time.RFC3339); s != tt.want {
+				t.Errorf("ParsePDFDate(%q) = %s, want %s", tt.in, s, tt.want)
+			}
+			if _, offset := got.Zone(); offset != tt.offset {
+				t.Errorf("ParsePDFDate(%q) offset = %d, want %d", tt.in, offset, tt.offset)
+			}
+		})
+	}
+}
+
+func TestParsePDFDateInvalid(t *testing.T) {
+	for _, in := range []string{"", "D:", "D:20", "D:abcd0101", "yesterday"} {
+		if got, err := ParsePDFDate(in); err == nil {
+			t.Errorf("ParsePDFDate(%q) = %v, want an error", in, got)
+		}
+	}
+}
+
+func TestFormatPDFDate(t *testing.T) {
+	tests := []struct {
+		in   time.Time
+		want string
+	}{
+		{time.Date(2024, 3, 15, 9, 30, 0, 0, time.UTC), "D:20240315093000Z"},
+		{time.Date(2024, 3, 15, 9, 30, 0, 0, time.FixedZone("", 5*3600+30*60)), "D:20240315093000+05'30'"},
+		{time.Date(2024, 3, 15, 9, 30, 0, 0, time.FixedZone("", -(9*3600+30*60))), "D:20240315093000-09'30'"},
+		{time.Date(1999, 12, 31, 23, 59, 59, 0, time.FixedZone("", -5*3600)), "D:19991231235959-05'00'"},
+	}
+	for _, tt := range tests {
+		got := FormatPDFDate(tt.in)
+		if got != tt.want {
+			t.Errorf("FormatPDFDate(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+
+		// Formatting and parsing again keeps the instant and the offset
+		back, err := ParsePDFDate(got)
+		if err != nil {
+			t.Fatalf("ParsePDFDate(%q): %v", got, err)
+		}
+		_, wantOffset := tt.in.Zone()
+		if _, offset := back.Zone(); !back.Equal(tt.in) || offset != wantOffset {
+			t.Errorf("ParsePDFDate(FormatPDFDate(%v)) = %v", tt.in, back)
+		}
+	}
+}
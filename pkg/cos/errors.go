@@ -0,0 +1,33 @@
+package cos
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrCircularReference is returned when resolving an object requires
+// resolving itself, which happens in malformed or crafted files.
+var ErrCircularReference = errors.New("circular reference")
+
+// ErrEncrypted is returned when opening a document that is encrypted.
+// Use errors.As with *EncryptedPDFError for details.
+var ErrEncrypted = errors.New("this PDF is encrypted; password entry is not yet supported")
+
+// EncryptedPDFError describes the encryption of a document that could not
+// be opened.
+type EncryptedPDFError struct {
+	Filter               string // Security handler name, e.g. Standard
+	UserPasswordRequired bool   // A user password is needed to open the document
+	OwnerPasswordSet     bool   // Permissions are restricted by an owner password
+	Revision             int    // Security handler revision (R)
+	Permissions          uint32 // Access permission flags (P)
+}
+
+func (e *EncryptedPDFError) Error() string {
+	return fmt.Sprintf("%v (%s handler, revision %d)", ErrEncrypted, e.Filter, e.Revision)
+}
+
+// Unwrap allows errors.Is(err, ErrEncrypted) to match.
+func (e *EncryptedPDFError) Unwrap() error {
+	return ErrEncrypted
+}
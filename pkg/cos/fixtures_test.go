@@ -0,0 +1,87 @@
+package cos_test
+
+import (
+	"testing"
+
+	"gumgum/pkg/cos"
+	"gumgum/pkg/testutil"
+)
+
+func TestFixturesParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		pages      int
+		encrypted  bool
+		linearized bool
+	}{
+		{testutil.SimpleText, 1, false, false},
+		{testutil.Images, 1, false, false},
+		{testutil.Form, 1, false, false},
+		{testutil.Rotated, 1, false, false},
+		{testutil.Encrypted, 1, true, false},
+		{testutil.Linearized, 2, false, true},
+		{testutil.XrefStream, 1, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := cos.NewReader(testutil.FixtureBytes(t, tt.name))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got, err := r.PageCount(); err != nil || got != tt.pages {
+				t.Errorf("PageCount() = %d, %v; want %d", got, err, tt.pages)
+			}
+			if got := r.IsEncrypted(); got != tt.encrypted {
+				t.Errorf("IsEncrypted() = %v, want %v", got, tt.encrypted)
+			}
+			if got := r.IsLinearized(); got != tt.linearized {
+				t.Errorf("IsLinearized() = %v, want %v", got, tt.linearized)
+			}
+
+			// Every object in the cross-reference table must resolve
+			objects := 0
+			err = r.ForEachObject(func(num int, obj cos.Object) error {
+				objects++
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if objects == 0 {
+				t.Error("ForEachObject visited no objects")
+			}
+
+			for pageNum := 0; pageNum < tt.pages; pageNum++ {
+				page, err := r.GetPage(pageNum)
+				if err != nil {
+					t.Fatalf("page %d: %v", pageNum, err)
+				}
+				if _, err := r.GetPageContents(page); err != nil {
+					t.Errorf("page %d contents: %v", pageNum, err)
+				}
+			}
+		})
+	}
+}
+
+func TestXrefStreamFixtureObjects(t *testing.T) {
+	r, err := cos.NewReader(testutil.FixtureBytes(t, testutil.XrefStream))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The catalog, font, page tree and page are in the object stream
+	for objNum := 1; objNum <= 4; objNum++ {
+		obj, err := r.GetObject(objNum)
+		if err != nil {
+			t.Fatalf("object %d: %v", objNum, err)
+		}
+		if _, ok := obj.(cos.Dict); !ok {
+			t.Errorf("object %d is %T, want a dictionary", objNum, obj)
+		}
+	}
+	if got := r.CacheStats().ObjectStreams; got != 1 {
+		t.Errorf("object stream decoded %d times, want once", got)
+	}
+}
@@ -0,0 +1,45 @@
+package cos
+
+import "sync"
+
+// flightGroup runs one call per key at a time: callers asking for a key
+// that is already being computed wait for that call and share its result,
+// like golang.org/x/sync/singleflight.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[int]*flightCall
+}
+
+type flightCall struct {
+	wg      sync.WaitGroup
+	objects map[int]Object
+	err     error
+}
+
+// do runs fn for key unless a call for key is in flight, in which case it
+// waits for that call instead. shared reports whether the result came from
+// another caller's fn.
+func (g *flightGroup) do(key int, fn func() (map[int]Object, error)) (objects map[int]Object, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[int]*flightCall)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.objects, c.err, true
+	}
+	c := &flightCall{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		c.wg.Done()
+	}()
+	c.objects, c.err = fn()
+	return c.objects, c.err, false
+}
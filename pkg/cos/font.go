@@ -0,0 +1,213 @@
+package cos
+
+import (
+	"fmt"
+)
+
+// Type0Font is a composite font: character codes map through a CMap to
+// CIDs, which select glyphs in the descendant CIDFont.
+type Type0Font struct {
+	BaseFont string
+	CMap     *CMap
+
+	// CIDFont is the descendant font dictionary and Descriptor its font
+	// descriptor, which holds any embedded font program.
+	CIDFont    Dict
+	Descriptor Dict
+
+	cidToGID     []uint16 // nil for the identity mapping
+	defaultWidth float64
+	widths       map[uint32]float64
+}
+
+// Type0Font reads a Type 0 font dictionary.
+func (r *Reader) Type0Font(fontDict Dict) (*Type0Font, error) {
+	return r.resolveType0Font(fontDict)
+}
+
+// resolveType0Font reads the CMap, descendant CIDFont, glyph widths and
+// CIDToGIDMap of a Type 0 font.
+func (r *Reader) resolveType0Font(fontDict Dict) (*Type0Font, error) {
+	if subtype, _ := fontDict.GetName("Subtype"); subtype != "Type0" {
+		return nil, fmt.Errorf("not a Type 0 font: %s", subtype)
+	}
+
+	font := &Type0Font{defaultWidth: 1000}
+	if name, ok := fontDict.GetName("BaseFont"); ok {
+		font.BaseFont = string(name)
+	}
+
+	encoding, err := r.Resolve(fontDict.Get("Encoding"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve Encoding: %w", err)
+	}
+	switch enc := encoding.(type) {
+	case Name:
+		font.CMap, err = predefinedCMap(string(enc))
+	case *Stream:
+		font.CMap, err = r.embeddedCMap(enc)
+	default:
+		err = fmt.Errorf("invalid Encoding: %T", encoding)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	descendants, err := r.ResolveArray(fontDict.Get("DescendantFonts"))
+	if err != nil || len(descendants) == 0 {
+		return nil, fmt.Errorf("missing DescendantFonts")
+	}
+	font.CIDFont, err = r.ResolveDict(descendants[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve CIDFont: %w", err)
+	}
+	font.Descriptor, _ = r.ResolveDict(font.CIDFont.Get("FontDescriptor"))
+
+	if dw, ok := r.resolveNumber(font.CIDFont.Get("DW")); ok {
+		font.defaultWidth = dw
+	}
+	font.widths = r.parseCIDWidths(font.CIDFont.Get("W"))
+
+	if err := r.parseCIDToGIDMap(font); err != nil {
+		return nil, err
+	}
+
+	return font, nil
+}
+
+// embeddedCMap parses a CMap stream, applying a UseCMap it names.
+func (r *Reader) embeddedCMap(s *Stream) (*CMap, error) {
+	data, err := r.DecodeStream(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode CMap: %w", err)
+	}
+	cmap, err := parseCMap(data)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := s.Dict.GetInt("WMode"); ok {
+		cmap.Vertical = v == 1
+	}
+
+	// Mappings of the parent CMap apply where this one has none
+	if use, ok := s.Dict.GetName("UseCMap"); ok {
+		if parent, err := predefinedCMap(string(use)); err == nil {
+			cmap.codespace = append(cmap.codespace, parent.codespace...)
+			if parent.identity && len(cmap.cidRanges) == 0 {
+				cmap.identity = true
+			}
+		}
+	}
+	return cmap, nil
+}
+
+// parseCIDWidths reads a W array, which mixes "c [w1 w2 ...]" entries for
+// consecutive CIDs with "cfirst clast w" entries for ranges.
+func (r *Reader) parseCIDWidths(obj Object) map[uint32]float64 {
+	widths := make(map[uint32]float64)
+	if obj == nil {
+		return widths
+	}
+	arr, err := r.ResolveArray(obj)
+	if err != nil {
+		return widths
+	}
+
+	for i := 0; i+1 < len(arr); {
+		first, ok := r.resolveNumber(arr[i])
+		if !ok || first < 0 {
+			break
+		}
+
+		next, _ := r.Resolve(arr[i+1])
+		if list, ok := next.(Array); ok {
+			for j, item := range list {
+				if w, ok := r.resolveNumber(item); ok {
+					widths[uint32(first)+uint32(j)] = w
+				}
+			}
+			i += 2
+			continue
+		}
+
+		if i+2 >= len(arr) {
+			break
+		}
+		last, ok1 := r.resolveNumber(arr[i+1])
+		w, ok2 := r.resolveNumber(arr[i+2])
+		if !ok1 || !ok2 || last < first || last-first > maxCIDRange {
+			break
+		}
+		for cid := uint32(first); cid <= uint32(last); cid++ {
+			widths[cid] = w
+		}
+		i += 3
+	}
+	return widths
+}
+
+// maxCIDRange limits the size of a single W range entry.
+const maxCIDRange = 65535
+
+// parseCIDToGIDMap reads the CIDToGIDMap of a CIDFontType2 font, a stream
+// of two-byte glyph IDs indexed by CID, or the name Identity.
+func (r *Reader) parseCIDToGIDMap(font *Type0Font) error {
+	obj := font.CIDFont.Get("CIDToGIDMap")
+	if obj == nil {
+		return nil
+	}
+	resolved, err := r.Resolve(obj)
+	if err != nil {
+		return fmt.Errorf("failed to resolve CIDToGIDMap: %w", err)
+	}
+
+	s, ok := resolved.(*Stream)
+	if !ok {
+		// Identity
+		return nil
+	}
+	data, err := r.DecodeStream(s)
+	if err != nil {
+		return fmt.Errorf("failed to decode CIDToGIDMap: %w", err)
+	}
+	font.cidToGID = make([]uint16, len(data)/2)
+	for i := range font.cidToGID {
+		font.cidToGID[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+	}
+	return nil
+}
+
+// GlyphID returns the glyph index of a CID in the embedded font program.
+func (f *Type0Font) GlyphID(cid uint32) uint16 {
+	if f.cidToGID == nil {
+		return uint16(cid)
+	}
+	if int(cid) < len(f.cidToGID) {
+		return f.cidToGID[cid]
+	}
+	return 0
+}
+
+// Width returns the horizontal advance of a CID in thousandths of text
+// space.
+func (f *Type0Font) Width(cid uint32) float64 {
+	if w, ok := f.widths[cid]; ok {
+		return w
+	}
+	return f.defaultWidth
+}
+
+// resolveNumber resolves an integer or real.
+func (r *Reader) resolveNumber(obj Object) (float64, bool) {
+	resolved, err := r.Resolve(obj)
+	if err != nil {
+		return 0, false
+	}
+	switch v := resolved.(type) {
+	case Integer:
+		return float64(v), true
+	case Real:
+		return float64(v), true
+	}
+	return 0, false
+}
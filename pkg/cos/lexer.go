@@ -12,6 +12,12 @@ type Lexer struct {
 	data []byte
 	pos  int
 	size int
+
+	// Line tracking: line is the line number at byte offset lineOff, and
+	// lineStart the offset at which that line begins
+	line      int
+	lineOff   int
+	lineStart int
 }
 
 // NewLexer creates a new lexer from a byte slice.
@@ -20,6 +26,7 @@ func NewLexer(data []byte) *Lexer {
 		data: data,
 		pos:  0,
 		size: len(data),
+		line: 1,
 	}
 }
 
@@ -37,6 +44,43 @@ func (l *Lexer) Position() int {
 	return l.pos
 }
 
+// Line returns the line number of the current position, counting from 1.
+func (l *Lexer) Line() int {
+	return l.positionAt(l.pos).Line
+}
+
+// Column returns the column of the current position within its line,
+// counting from 1.
+func (l *Lexer) Column() int {
+	return l.positionAt(l.pos).Column
+}
+
+// positionAt returns the line and column of a byte offset. Lines are
+// counted incrementally from the last offset asked for, so scanning forward
+// costs time proportional to the bytes passed.
+func (l *Lexer) positionAt(offset int) Position {
+	if offset > l.size {
+		offset = l.size
+	}
+
+	if offset >= l.lineOff {
+		segment := l.data[l.lineOff:offset]
+		if n := bytes.Count(segment, []byte{'\n'}); n > 0 {
+			l.line += n
+			l.lineStart = l.lineOff + bytes.LastIndexByte(segment, '\n') + 1
+		}
+	} else {
+		// Moved back, as after PeekToken
+		if n := bytes.Count(l.data[offset:l.lineOff], []byte{'\n'}); n > 0 {
+			l.line -= n
+			l.lineStart = bytes.LastIndexByte(l.data[:offset], '\n') + 1
+		}
+	}
+	l.lineOff = offset
+
+	return Position{Offset: int64(offset), Line: l.line, Column: offset - l.lineStart + 1}
+}
+
 // SetPosition sets the lexer position.
 func (l *Lexer) SetPosition(pos int) {
 	if pos >= 0 && pos <= l.size {
@@ -114,7 +158,7 @@ func (l *Lexer) NextToken() Token {
 	l.skipWhitespace()
 
 	if l.pos >= l.size {
-		return Token{Type: TokenEOF, Pos: int64(l.pos)}
+		return Token{Type: TokenEOF, Pos: l.positionAt(l.pos)}
 	}
 
 	startPos := l.pos
@@ -126,10 +170,10 @@ func (l *Lexer) NextToken() Token {
 		switch two {
 		case "<<":
 			l.pos += 2
-			return Token{Type: TokenDictBegin, Value: "<<", Pos: int64(startPos)}
+			return Token{Type: TokenDictBegin, Value: "<<", Pos: l.positionAt(startPos)}
 		case ">>":
 			l.pos += 2
-			return Token{Type: TokenDictEnd, Value: ">>", Pos: int64(startPos)}
+			return Token{Type: TokenDictEnd, Value: ">>", Pos: l.positionAt(startPos)}
 		}
 	}
 
@@ -137,10 +181,10 @@ func (l *Lexer) NextToken() Token {
 	switch b {
 	case '[':
 		l.pos++
-		return Token{Type: TokenArrayBegin, Value: "[", Pos: int64(startPos)}
+		return Token{Type: TokenArrayBegin, Value: "[", Pos: l.positionAt(startPos)}
 	case ']':
 		l.pos++
-		return Token{Type: TokenArrayEnd, Value: "]", Pos: int64(startPos)}
+		return Token{Type: TokenArrayEnd, Value: "]", Pos: l.positionAt(startPos)}
 	case '/':
 		return l.scanName()
 	case '(':
@@ -161,7 +205,7 @@ func (l *Lexer) NextToken() Token {
 
 	// Unknown character
 	l.pos++
-	return Token{Type: TokenError, Value: fmt.Sprintf("unexpected character: %c", b), Pos: int64(startPos)}
+	return Token{Type: TokenError, Value: fmt.Sprintf("unexpected character: %c", b), Pos: l.positionAt(startPos)}
 }
 
 // scanName scans a PDF name (e.g., /Type, /Font).
@@ -188,7 +232,7 @@ func (l *Lexer) scanName() Token {
 		l.pos++
 	}
 
-	return Token{Type: TokenName, Value: buf.String(), Pos: int64(startPos)}
+	return Token{Type: TokenName, Value: buf.String(), Pos: l.positionAt(startPos)}
 }
 
 // scanLiteralString scans a parentheses-delimited string, handling escapes and nested parens.
@@ -267,7 +311,7 @@ func (l *Lexer) scanLiteralString() Token {
 		l.pos++
 	}
 
-	return Token{Type: TokenString, Value: buf.String(), Pos: int64(startPos)}
+	return Token{Type: TokenString, Value: buf.String(), Pos: l.positionAt(startPos)}
 }
 
 // scanHexString scans a hex-encoded string <48656C6C6F>.
@@ -301,7 +345,7 @@ func (l *Lexer) scanHexString() Token {
 		}
 	}
 
-	return Token{Type: TokenString, Value: result.String(), Pos: int64(startPos)}
+	return Token{Type: TokenString, Value: result.String(), Pos: l.positionAt(startPos)}
 }
 
 // scanNumber scans an integer or real number.
@@ -330,7 +374,7 @@ func (l *Lexer) scanNumber() Token {
 	}
 
 	numStr := buf.String()
-	tok := Token{Type: TokenNumber, Value: numStr, Pos: int64(startPos)}
+	tok := Token{Type: TokenNumber, Value: numStr, Pos: l.positionAt(startPos)}
 
 	if hasDecimal {
 		tok.IsFloat = true
@@ -359,7 +403,7 @@ func (l *Lexer) scanKeyword() Token {
 	}
 
 	word := buf.String()
-	tok := Token{Value: word, Pos: int64(startPos)}
+	tok := Token{Value: word, Pos: l.positionAt(startPos)}
 
 	switch word {
 	case "obj":
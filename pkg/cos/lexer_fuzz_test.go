@@ -0,0 +1,39 @@
+package cos
+
+import "testing"
+
+// lexerSeeds are representative snippets of PDF syntax.
+var lexerSeeds = []string{
+	"0", "-12", "+3.5", ".25", "4.", "--1",
+	"(literal) (nested (parens) \\) escaped) (\\101\\n\\r\\t\\b\\f\\\\)",
+	"<48656C6C6F> <4> <> < 48 65 >",
+	"/Name /A#20B /#2F /",
+	"true false null R obj endobj stream endstream xref trailer startxref",
+	"[1 2 0 R /N (s)] << /Type /Page /Kids [3 0 R] >>",
+	"<< /Length 5 >>\nstream\nhello\nendstream",
+	"% comment\n1 0 obj\n<< >>\nendobj",
+	"\x00\x09\x0a\x0c\x0d\x20",
+	"(unterminated", "<unterminated", "<<<>>>", ")",
+}
+
+func FuzzLexer(f *testing.F) {
+	for _, seed := range lexerSeeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		l := NewLexer(data)
+		// Every token consumes at least one byte, so the input runs out
+		for i := 0; i <= len(data)+1; i++ {
+			pos := l.Position()
+			tok := l.NextToken()
+			if tok.Type == TokenEOF {
+				return
+			}
+			if l.Position() <= pos {
+				t.Fatalf("token %s at %d did not advance the lexer", tok.Type, pos)
+			}
+		}
+		t.Fatalf("lexer did not reach the end of %d bytes", len(data))
+	})
+}
@@ -0,0 +1,105 @@
+package cos
+
+// linearizationWindow is how far into the file the linearization parameter
+// dictionary may appear. The spec requires it within the first 1024 bytes.
+const linearizationWindow = 1024
+
+// LinearizedHints holds the linearization parameters of a linearized PDF.
+type LinearizedHints struct {
+	Version         float64 // Linearization version (Linearized value)
+	FileLength      int64   // Length of the entire file in bytes (L)
+	HintOffset      int64   // Offset of the primary hint stream (H[0])
+	HintLength      int64   // Length of the primary hint stream (H[1])
+	FirstPageObject int     // Object number of the first page (O)
+	FirstPageEnd    int64   // Offset of the end of the first page (E)
+	PageCount       int     // Number of pages in the document (N)
+	MainXrefOffset  int64   // Offset of the main xref table entry (T)
+}
+
+// parseLinearization reads the linearization parameter dictionary at the
+// start of the file. It returns nil if the file is not linearized or the
+// linearization is no longer valid (e.g. after an incremental update).
+func parseLinearization(data []byte) *LinearizedHints {
+	window := data
+	if len(window) > linearizationWindow {
+		window = window[:linearizationWindow]
+	}
+
+	// The header is a comment, so the first token is the object number
+	indirect, err := ParseObjectAt(window, 0)
+	if err != nil {
+		return nil
+	}
+
+	dict, ok := indirect.Object.(Dict)
+	if !ok {
+		return nil
+	}
+
+	version, ok := dict.GetReal("Linearized")
+	if !ok {
+		return nil
+	}
+
+	hints := &LinearizedHints{Version: version}
+	hints.FileLength, _ = dict.GetInt("L")
+	hints.FirstPageEnd, _ = dict.GetInt("E")
+	hints.MainXrefOffset, _ = dict.GetInt("T")
+
+	if o, ok := dict.GetInt("O"); ok {
+		hints.FirstPageObject = int(o)
+	}
+	if n, ok := dict.GetInt("N"); ok {
+		hints.PageCount = int(n)
+	}
+	if h, ok := dict.GetArray("H"); ok && len(h) >= 2 {
+		if off, ok := h[0].(Integer); ok {
+			hints.HintOffset = int64(off)
+		}
+		if length, ok := h[1].(Integer); ok {
+			hints.HintLength = int64(length)
+		}
+	}
+
+	// An incremental update invalidates the linearization
+	if hints.FileLength != int64(len(data)) {
+		return nil
+	}
+
+	return hints
+}
+
+// IsLinearized returns true if the document is linearized and the
+// linearization parameters match the file.
+func (r *Reader) IsLinearized() bool {
+	return r.linearized != nil
+}
+
+// LinearizedHints returns the linearization parameters, or nil if the
+// document is not linearized.
+func (r *Reader) LinearizedHints() *LinearizedHints {
+	return r.linearized
+}
+
+// FirstPage returns the first page dictionary. For linearized documents the
+// page is read directly using the linearization hints, without walking the
+// page tree.
+func (r *Reader) FirstPage() (Dict, error) {
+	return r.GetPage(0)
+}
+
+// linearizedFirstPage reads the first page named by the linearization
+// parameters, reporting false if there is none or it is not a page.
+func (r *Reader) linearizedFirstPage() (Dict, bool) {
+	if r.linearized == nil || r.linearized.FirstPageObject <= 0 {
+		return nil, false
+	}
+	page, err := r.ResolveDict(&Reference{ObjectNumber: r.linearized.FirstPageObject})
+	if err != nil {
+		return nil, false
+	}
+	if pageType, _ := page.GetName("Type"); pageType != "Page" {
+		return nil, false
+	}
+	return page, true
+}
@@ -0,0 +1,47 @@
+package cos
+
+import (
+	"os"
+	"testing"
+)
+
+func TestLinearizedFirstPageDefersMainXref(t *testing.T) {
+	data, err := os.ReadFile("../testutil/testdata/linearized.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewReader(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !r.IsLinearized() {
+		t.Fatal("IsLinearized() = false")
+	}
+	if n, err := r.PageCount(); err != nil || n != 2 {
+		t.Fatalf("PageCount() = %d, %v; want 2", n, err)
+	}
+
+	page, err := r.GetPage(0)
+	if err != nil {
+		t.Fatalf("GetPage(0): %v", err)
+	}
+	if _, err := r.GetPageContents(page); err != nil {
+		t.Fatalf("GetPageContents: %v", err)
+	}
+	if got := len(r.xref.Entries); got != 7 {
+		t.Errorf("after the first page %d xref entries are loaded, want the 7 of the first-page section", got)
+	}
+
+	page, err = r.GetPage(1)
+	if err != nil {
+		t.Fatalf("GetPage(1): %v", err)
+	}
+	contents, err := r.GetPageContents(page)
+	if err != nil || len(contents) == 0 {
+		t.Fatalf("GetPageContents(page 1) = %q, %v", contents, err)
+	}
+	if got := len(r.xref.Entries); got != 10 {
+		t.Errorf("after the second page %d xref entries are loaded, want 10", got)
+	}
+}
@@ -137,6 +137,53 @@ func (d Dict) GetRef(key string) (*Reference, bool) {
 	return nil, false
 }
 
+// Clone returns a copy of the dictionary. Direct sub-dictionaries and
+// arrays are copied as well; other values are shared.
+func (d Dict) Clone() Dict {
+	clone := make(Dict, len(d))
+	for k, v := range d {
+		clone[k] = cloneDirect(v)
+	}
+	return clone
+}
+
+// cloneDirect copies direct dictionaries and arrays.
+func cloneDirect(obj Object) Object {
+	switch o := obj.(type) {
+	case Dict:
+		return o.Clone()
+	case Array:
+		arr := make(Array, len(o))
+		for i, item := range o {
+			arr[i] = cloneDirect(item)
+		}
+		return arr
+	}
+	return obj
+}
+
+// MergeFrom adds entries from parent that are missing in d. When both
+// contain a direct sub-dictionary under the same key, the sub-dictionaries
+// are merged recursively, so child entries always take precedence. This
+// modifies d (and its direct sub-dictionaries); Clone dictionaries obtained
+// from a Reader first, since those are shared through its cache. Indirect
+// references are not resolved.
+func (d Dict) MergeFrom(parent Dict) {
+	for key, parentValue := range parent {
+		value, exists := d[key]
+		if !exists {
+			d[key] = parentValue
+			continue
+		}
+
+		child, childIsDict := value.(Dict)
+		parentDict, parentIsDict := parentValue.(Dict)
+		if childIsDict && parentIsDict {
+			child.MergeFrom(parentDict)
+		}
+	}
+}
+
 // Reference represents an indirect object reference (e.g., 5 0 R).
 type Reference struct {
 	ObjectNumber     int
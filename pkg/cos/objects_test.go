@@ -0,0 +1,159 @@
+package cos
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDictMergeFrom(t *testing.T) {
+	child := Dict{
+		"Font":    Dict{"F1": Name("ChildFont")},
+		"ProcSet": Array{Name("PDF")},
+	}
+	parent := Dict{
+		"Font":       Dict{"F1": Name("ParentFont"), "F2": Name("ParentFont2")},
+		"ProcSet":    Array{Name("PDF"), Name("Text")},
+		"ColorSpace": Dict{"CS0": Name("DeviceRGB")},
+	}
+
+	child.MergeFrom(parent)
+
+	fonts := child.Get("Font").(Dict)
+	if got, _ := fonts.GetName("F1"); got != "ChildFont" {
+		t.Errorf("F1 = %q, want the child's entry", got)
+	}
+	if got, _ := fonts.GetName("F2"); got != "ParentFont2" {
+		t.Errorf("F2 = %q, want the parent's entry", got)
+	}
+	if got := child.Get("ProcSet").(Array); len(got) != 1 {
+		t.Errorf("ProcSet = %v, want the child's array unchanged", got)
+	}
+	if child.Get("ColorSpace") == nil {
+		t.Error("ColorSpace was not inherited")
+	}
+	if len(parent.Get("Font").(Dict)) != 2 {
+		t.Error("MergeFrom modified the parent")
+	}
+}
+
+// writeInheritancePDF writes a root Pages node, an intermediate Pages node
+// and a page, each contributing Font, ColorSpace and XObject resources.
+// The intermediate node's sub-dictionaries are indirect objects.
+func writeInheritancePDF(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "1.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	add := func(obj Object) *Reference {
+		ref, err := w.AddObject(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ref
+	}
+
+	rootRef := w.Reserve()
+	middleRef := w.Reserve()
+
+	page := add(Dict{
+		"Type":     Name("Page"),
+		"Parent":   middleRef,
+		"MediaBox": Array{Integer(0), Integer(0), Integer(612), Integer(792)},
+		"Resources": Dict{
+			"Font": Dict{"F1": Name("PageFont")},
+		},
+	})
+
+	middleFonts := add(Dict{"F1": Name("MiddleFont"), "F2": Name("MiddleFont")})
+	middleSpaces := add(Dict{"CS1": Name("DeviceCMYK")})
+	if err := w.WriteObject(middleRef.ObjectNumber, Dict{
+		"Type":   Name("Pages"),
+		"Parent": rootRef,
+		"Kids":   Array{page},
+		"Count":  Integer(1),
+		"Resources": Dict{
+			"Font":       middleFonts,
+			"ColorSpace": middleSpaces,
+			"XObject":    Dict{"Im2": Name("MiddleImage")},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.WriteObject(rootRef.ObjectNumber, Dict{
+		"Type":  Name("Pages"),
+		"Kids":  Array{middleRef},
+		"Count": Integer(1),
+		"Resources": Dict{
+			"Font":       Dict{"F1": Name("RootFont"), "F3": Name("RootFont")},
+			"ColorSpace": Dict{"CS0": Name("DeviceRGB"), "CS1": Name("DeviceGray")},
+			"XObject":    Dict{"Im1": Name("RootImage")},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	catalog := add(Dict{"Type": Name("Catalog"), "Pages": rootRef})
+	if err := w.Close(Dict{"Root": catalog}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGetPageResourcesInheritance(t *testing.T) {
+	r, err := NewReader(writeInheritancePDF(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, err := r.GetPage(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resources, err := r.GetPageResources(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		category, key string
+		want          Name
+	}{
+		{"Font", "F1", "PageFont"},
+		{"Font", "F2", "MiddleFont"},
+		{"Font", "F3", "RootFont"},
+		{"ColorSpace", "CS0", "DeviceRGB"},
+		{"ColorSpace", "CS1", "DeviceCMYK"},
+		{"XObject", "Im1", "RootImage"},
+		{"XObject", "Im2", "MiddleImage"},
+	}
+	for _, tt := range tests {
+		category, err := r.ResolveDict(resources.Get(tt.category))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.category, err)
+		}
+		if got, _ := category.GetName(tt.key); got != tt.want {
+			t.Errorf("%s/%s = %q, want %q", tt.category, tt.key, got, tt.want)
+		}
+	}
+
+	// The shared parent objects must not pick up the page's entries
+	middle, err := r.ResolveDict(page.Get("Parent"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	middleResources, err := r.ResolveDict(middle.Get("Resources"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	middleFonts, err := r.ResolveDict(middleResources.Get("Font"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := middleFonts.GetName("F1"); got != "MiddleFont" || middleFonts.Get("F3") != nil {
+		t.Errorf("merging modified a cached object: %v", middleFonts)
+	}
+}
@@ -0,0 +1,27 @@
+package cos
+
+import "testing"
+
+func TestObjectStreamDecodedOnce(t *testing.T) {
+	const objects = 50
+	r, err := NewReader(writeObjStmPDF(t, objects))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for pass := 0; pass < 2; pass++ {
+		for i := 0; i < objects; i++ {
+			obj, err := r.GetObject(4 + i)
+			if err != nil {
+				t.Fatalf("object %d: %v", 4+i, err)
+			}
+			if index, _ := obj.(Dict).GetInt("Index"); index != int64(i) {
+				t.Fatalf("object %d has Index %d, want %d", 4+i, index, i)
+			}
+		}
+	}
+
+	if got := r.CacheStats().ObjectStreams; got != 1 {
+		t.Errorf("object stream decoded %d times for %d objects, want once", got, objects)
+	}
+}
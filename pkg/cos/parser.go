@@ -25,9 +25,9 @@ func (p *Parser) ParseObject() (Object, error) {
 func (p *Parser) parseObjectFromToken(tok Token) (Object, error) {
 	switch tok.Type {
 	case TokenEOF:
-		return nil, fmt.Errorf("unexpected end of file")
+		return nil, fmt.Errorf("unexpected end of file at %s", tok.Pos)
 	case TokenError:
-		return nil, fmt.Errorf("lexer error: %s", tok.Value)
+		return nil, fmt.Errorf("lexer error at %s: %s", tok.Pos, tok.Value)
 	case TokenNull:
 		return Null{}, nil
 	case TokenBoolean:
@@ -63,7 +63,7 @@ func (p *Parser) parseObjectFromToken(tok Token) (Object, error) {
 	case TokenDictBegin:
 		return p.parseDictOrStream()
 	default:
-		return nil, fmt.Errorf("unexpected token: %s (%s)", tok.Type, tok.Value)
+		return nil, fmt.Errorf("unexpected token at %s: %s (%s)", tok.Pos, tok.Type, tok.Value)
 	}
 }
 
@@ -78,7 +78,7 @@ func (p *Parser) parseArray() (Array, error) {
 			break
 		}
 		if tok.Type == TokenEOF {
-			return nil, fmt.Errorf("unexpected end of file in array")
+			return nil, fmt.Errorf("unexpected end of file in array at %s", tok.Pos)
 		}
 
 		obj, err := p.ParseObject()
@@ -117,16 +117,18 @@ func (p *Parser) parseDictOrStream() (Object, error) {
 
 			// Get stream length
 			var streamLen int64
-			if length, ok := dict.GetInt("Length"); ok {
+			if length, ok := dict.GetInt("Length"); ok && length >= 0 {
 				streamLen = length
+			} else if ok {
+				return nil, fmt.Errorf("stream with negative Length at %s", p.lexer.positionAt(pos))
 			} else {
-				return nil, fmt.Errorf("stream without Length")
+				return nil, fmt.Errorf("stream without Length at %s", p.lexer.positionAt(pos))
 			}
 
 			// Read stream data
 			streamStart := p.lexer.pos
 			streamEnd := streamStart + int(streamLen)
-			if streamEnd > p.lexer.size {
+			if streamLen > int64(p.lexer.size-streamStart) {
 				streamEnd = p.lexer.size
 			}
 
@@ -158,13 +160,13 @@ func (p *Parser) parseDict() (Dict, error) {
 			break
 		}
 		if tok.Type == TokenEOF {
-			return nil, fmt.Errorf("unexpected end of file in dictionary")
+			return nil, fmt.Errorf("unexpected end of file in dictionary at %s", tok.Pos)
 		}
 
 		// Key must be a name
 		tok = p.lexer.NextToken()
 		if tok.Type != TokenName {
-			return nil, fmt.Errorf("expected name as dictionary key, got %s", tok.Type)
+			return nil, fmt.Errorf("expected name as dictionary key at %s, got %s", tok.Pos, tok.Type)
 		}
 		key := Name(tok.Value)
 
@@ -185,21 +187,21 @@ func (p *Parser) ParseIndirectObject() (*IndirectObject, error) {
 	// Object number
 	tok := p.lexer.NextToken()
 	if tok.Type != TokenNumber || tok.IsFloat {
-		return nil, fmt.Errorf("expected object number, got %s", tok.Type)
+		return nil, fmt.Errorf("expected object number at %s, got %s", tok.Pos, tok.Type)
 	}
 	objNum := int(tok.Int)
 
 	// Generation number
 	tok = p.lexer.NextToken()
 	if tok.Type != TokenNumber || tok.IsFloat {
-		return nil, fmt.Errorf("expected generation number, got %s", tok.Type)
+		return nil, fmt.Errorf("expected generation number at %s, got %s", tok.Pos, tok.Type)
 	}
 	genNum := int(tok.Int)
 
 	// "obj" keyword
 	tok = p.lexer.NextToken()
 	if tok.Type != TokenObj {
-		return nil, fmt.Errorf("expected 'obj' keyword, got %s", tok.Type)
+		return nil, fmt.Errorf("expected 'obj' keyword at %s, got %s", tok.Pos, tok.Type)
 	}
 
 	// Parse the object content
@@ -228,13 +230,21 @@ func (p *Parser) ParseIndirectObject() (*IndirectObject, error) {
 
 // ParseObjectAt parses an indirect object at the given byte offset.
 func ParseObjectAt(data []byte, offset int64) (*IndirectObject, error) {
+	obj, _, err := parseObjectAt(data, offset)
+	return obj, err
+}
+
+// parseObjectAt parses an indirect object at the given byte offset and
+// returns the offset just past it.
+func parseObjectAt(data []byte, offset int64) (*IndirectObject, int64, error) {
 	if offset < 0 || int(offset) >= len(data) {
-		return nil, fmt.Errorf("offset %d out of range", offset)
+		return nil, 0, fmt.Errorf("offset %d out of range", offset)
 	}
 
 	lexer := NewLexer(data[offset:])
 	parser := NewParser(lexer)
-	return parser.ParseIndirectObject()
+	obj, err := parser.ParseIndirectObject()
+	return obj, offset + int64(lexer.Position()), err
 }
 
 // ParseObjectsFromStream parses objects from an object stream.
@@ -248,6 +258,9 @@ func ParseObjectsFromStream(streamData []byte, dict Dict) (map[int]Object, error
 	if !ok {
 		return nil, fmt.Errorf("object stream missing First")
 	}
+	if first < 0 || first > int64(len(streamData)) {
+		return nil, fmt.Errorf("object stream First %d out of range", first)
+	}
 
 	// Parse the header: pairs of (objNum, offset)
 	headerLexer := NewLexer(streamData[:first])
@@ -286,10 +299,10 @@ func ParseObjectsFromStream(streamData []byte, dict Dict) (map[int]Object, error
 			end = len(objectsData)
 		}
 
-		if entry.offset >= len(objectsData) {
+		if entry.offset < 0 || entry.offset >= len(objectsData) {
 			continue
 		}
-		if end > len(objectsData) {
+		if end > len(objectsData) || end < entry.offset {
 			end = len(objectsData)
 		}
 
@@ -0,0 +1,67 @@
+package cos
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// parserSeeds are objects of every type, including malformed ones.
+var parserSeeds = []string{
+	"null", "true", "42", "-3.5", "(string)", "<4142>", "/Name", "12 0 R",
+	"[1 [2 [3]] (a) /b 4 0 R]",
+	"<< /Type /Catalog /Pages 2 0 R /Nested << /A [1 2] >> >>",
+	"<< /Length 5 >>\nstream\nhello\nendstream",
+	"<< /Length -1 >>\nstream\nx\nendstream",
+	"<< /Length 999 >>\nstream\nshort\nendstream",
+	"1 0 obj\n<< /A 1 >>\nendobj",
+	"[[[[[[[[[[", "<< /A", "<< 1 2 >>", "]", ">>",
+}
+
+func FuzzParser(f *testing.F) {
+	for _, seed := range parserSeeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		p := NewParser(NewLexer(data))
+		for i := 0; i <= len(data); i++ {
+			pos := p.lexer.Position()
+			if _, err := p.ParseObject(); err != nil || p.lexer.Position() <= pos {
+				break
+			}
+		}
+
+		ParseObjectAt(data, 0)
+	})
+}
+
+// FuzzReader opens whole files, starting from the test fixtures. Errors are
+// expected for most inputs; the reader must not panic on any of them.
+func FuzzReader(f *testing.F) {
+	fixtures, _ := filepath.Glob("../testutil/testdata/*.pdf")
+	for _, name := range fixtures {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Add(writeObjStmPDF(f, 3))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		r, err := NewReader(data)
+		if err != nil {
+			return
+		}
+		r.ForEachObject(func(int, Object) error { return nil })
+		if n, err := r.PageCount(); err == nil {
+			for i := 0; i < n && i < 4; i++ {
+				if page, err := r.GetPage(i); err == nil {
+					r.GetPageContents(page)
+					r.GetPageResources(page)
+				}
+			}
+		}
+	})
+}
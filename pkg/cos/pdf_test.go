@@ -0,0 +1,159 @@
+package cos
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"testing"
+)
+
+// writeTestPDF writes a document of n Letter pages sharing one Helvetica
+// font, each showing its number from a content stream of its own.
+func writeTestPDF(tb testing.TB, n int) []byte {
+	tb.Helper()
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "1.7")
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	font, err := w.AddObject(Dict{
+		"Type":     Name("Font"),
+		"Subtype":  Name("Type1"),
+		"BaseFont": Name("Helvetica"),
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	resources := Dict{"Font": Dict{"F1": font}}
+
+	pagesRef := w.Reserve()
+	kids := make(Array, n)
+	for i := range kids {
+		content := fmt.Sprintf("BT /F1 24 Tf 72 700 Td (Page %d) Tj ET", i+1)
+		contents, err := w.WriteStream(Dict{}, []byte(content), StreamOptions{})
+		if err != nil {
+			tb.Fatal(err)
+		}
+		kids[i], err = w.AddObject(Dict{
+			"Type":      Name("Page"),
+			"Parent":    pagesRef,
+			"MediaBox":  Array{Integer(0), Integer(0), Integer(612), Integer(792)},
+			"Resources": resources,
+			"Contents":  contents,
+		})
+		if err != nil {
+			tb.Fatal(err)
+		}
+	}
+
+	if err := w.WriteObject(pagesRef.ObjectNumber, Dict{
+		"Type":  Name("Pages"),
+		"Kids":  kids,
+		"Count": Integer(n),
+	}); err != nil {
+		tb.Fatal(err)
+	}
+	catalog, err := w.AddObject(Dict{"Type": Name("Catalog"), "Pages": pagesRef})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if err := w.Close(Dict{"Root": catalog}); err != nil {
+		tb.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// writeObjStmPDF writes a one-page document whose catalog, page tree and n
+// further dictionaries are all stored in one object stream, indexed by a
+// cross-reference stream. Object 4+i is the dictionary << /Index i >>.
+func writeObjStmPDF(tb testing.TB, n int) []byte {
+	tb.Helper()
+
+	compressed := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+	}
+	for i := 0; i < n; i++ {
+		compressed = append(compressed, fmt.Sprintf("<< /Index %d >>", i))
+	}
+
+	var header, body bytes.Buffer
+	for i, obj := range compressed {
+		fmt.Fprintf(&header, "%d %d ", i+1, body.Len())
+		body.WriteString(obj + "\n")
+	}
+	objStmNum := len(compressed) + 1
+	xrefNum := objStmNum + 1
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.5\n")
+	objStmOffset := buf.Len()
+	fmt.Fprintf(&buf, "%d 0 obj\n<< /Type /ObjStm /N %d /First %d /Length %d >>\nstream\n",
+		objStmNum, len(compressed), header.Len(), header.Len()+body.Len())
+	buf.Write(header.Bytes())
+	buf.Write(body.Bytes())
+	buf.WriteString("\nendstream\nendobj\n")
+
+	// Entries are type (1 byte), offset or object stream (4), index (2)
+	xrefOffset := buf.Len()
+	var xref []byte
+	entry := func(typ byte, field2 uint32, field3 uint16) {
+		xref = append(xref, typ)
+		xref = binary.BigEndian.AppendUint32(xref, field2)
+		xref = binary.BigEndian.AppendUint16(xref, field3)
+	}
+	entry(0, 0, 65535)
+	for i := range compressed {
+		entry(2, uint32(objStmNum), uint16(i))
+	}
+	entry(1, uint32(objStmOffset), 0)
+	entry(1, uint32(xrefOffset), 0)
+
+	fmt.Fprintf(&buf, "%d 0 obj\n<< /Type /XRef /Size %d /W [1 4 2] /Root 1 0 R /Length %d >>\nstream\n",
+		xrefNum, xrefNum+1, len(xref))
+	buf.Write(xref)
+	fmt.Fprintf(&buf, "\nendstream\nendobj\nstartxref\n%d\n%%%%EOF\n", xrefOffset)
+	return buf.Bytes()
+}
+
+// writeIncrementalPDF writes a two-page document from writeTestPDF followed
+// by the given number of incremental updates, each with a classic xref
+// section whose Prev is the one before. Update i replaces the first page's
+// content stream, object 3, to show "Update i" and adds the dictionary
+// << /Update i >> as a new object.
+func writeIncrementalPDF(tb testing.TB, updates int) []byte {
+	tb.Helper()
+
+	data := writeTestPDF(tb, 2)
+	prev, err := findStartXref(data)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	base, err := ParseXref(data, prev)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	size, _ := base.Trailer.GetInt("Size")
+	root := base.Trailer.Get("Root").(*Reference)
+
+	buf := bytes.NewBuffer(data)
+	for i := 1; i <= updates; i++ {
+		content := fmt.Sprintf("BT /F1 24 Tf 72 700 Td (Update %d) Tj ET", i)
+		contentOffset := buf.Len()
+		fmt.Fprintf(buf, "3 0 obj\n<< /Length %d >>\nstream\n%s\nendstream\nendobj\n", len(content), content)
+		objNum := int(size)
+		size++
+		objOffset := buf.Len()
+		fmt.Fprintf(buf, "%d 0 obj\n<< /Update %d >>\nendobj\n", objNum, i)
+
+		xrefOffset := buf.Len()
+		fmt.Fprintf(buf, "xref\n3 1\n%010d 00000 n \n%d 1\n%010d 00000 n \n", contentOffset, objNum, objOffset)
+		fmt.Fprintf(buf, "trailer\n<< /Size %d /Root %d 0 R /Prev %d >>\nstartxref\n%d\n%%%%EOF\n",
+			size, root.ObjectNumber, prev, xrefOffset)
+		prev = int64(xrefOffset)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,106 @@
+package cos
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// prettyStringLimit is the number of bytes of a string PrettyPrint shows.
+const prettyStringLimit = 64
+
+// PrettyPrint formats an object as indented text for debugging. Dictionary
+// entries and array items go on lines of their own, nested one level (two
+// spaces) deeper than the brackets around them, and dictionary keys are
+// sorted. indent is the nesting level of the object itself. Strings longer
+// than 64 bytes are cut short with their length shown, strings that are not
+// printable text are shown in hex, and stream data is summarised by length.
+func PrettyPrint(obj Object, indent int) string {
+	var b strings.Builder
+	prettyPrint(&b, obj, indent)
+	return b.String()
+}
+
+func prettyPrint(b *strings.Builder, obj Object, indent int) {
+	pad := strings.Repeat("  ", indent)
+
+	switch o := obj.(type) {
+	case nil, Null:
+		b.WriteString("null")
+	case Real:
+		b.WriteString(strconv.FormatFloat(float64(o), 'f', -1, 64))
+	case String:
+		b.WriteString(prettyString(string(o)))
+	case Name:
+		b.WriteString(escapeName(string(o)))
+	case Array:
+		if len(o) == 0 {
+			b.WriteString("[]")
+			return
+		}
+		b.WriteString("[\n")
+		for _, item := range o {
+			b.WriteString(pad + "  ")
+			prettyPrint(b, item, indent+1)
+			b.WriteString("\n")
+		}
+		b.WriteString(pad + "]")
+	case Dict:
+		if len(o) == 0 {
+			b.WriteString("<< >>")
+			return
+		}
+		keys := make([]string, 0, len(o))
+		for k := range o {
+			keys = append(keys, string(k))
+		}
+		sort.Strings(keys)
+
+		b.WriteString("<<\n")
+		for _, k := range keys {
+			b.WriteString(pad + "  " + escapeName(k) + " ")
+			prettyPrint(b, o[Name(k)], indent+1)
+			b.WriteString("\n")
+		}
+		b.WriteString(pad + ">>")
+	case *Stream:
+		prettyPrint(b, o.Dict, indent)
+		fmt.Fprintf(b, "\n%sstream [%d bytes]", pad, len(o.Data))
+	case *IndirectObject:
+		fmt.Fprintf(b, "%d %d obj\n%s", o.ObjectNumber, o.GenerationNumber, pad)
+		prettyPrint(b, o.Object, indent)
+		b.WriteString("\n" + pad + "endobj")
+	default:
+		// Booleans, integers and references
+		b.WriteString(obj.String())
+	}
+}
+
+// prettyString formats a string as a literal, or in hex if it holds
+// control or non-ASCII bytes, shortened to prettyStringLimit bytes.
+func prettyString(s string) string {
+	shown := s
+	if len(shown) > prettyStringLimit {
+		shown = shown[:prettyStringLimit]
+	}
+
+	text := true
+	for i := 0; i < len(shown); i++ {
+		if c := shown[i]; (c < 0x20 && c != '\n' && c != '\r' && c != '\t') || c >= 0x7F {
+			text = false
+			break
+		}
+	}
+
+	var formatted string
+	if text {
+		formatted = escapeString(shown)
+	} else {
+		formatted = fmt.Sprintf("<%X>", shown)
+	}
+	if len(shown) < len(s) {
+		formatted += fmt.Sprintf("... (%d bytes)", len(s))
+	}
+	return formatted
+}
@@ -2,78 +2,326 @@ package cos
 
 import (
 	"compress/zlib"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
+	"sort"
+	"sync"
+	"time"
 )
 
+// maxPageTreeDepth bounds page tree recursion. Real documents are only a few
+// levels deep; anything beyond this is a Kids/Parent cycle.
+const maxPageTreeDepth = 64
+
 // Reader provides high-level access to a PDF document's object structure.
 type Reader struct {
-	data   []byte
-	xref   *XrefTable
-	cache  map[int]Object // Cache of resolved objects
-	objStm map[int]map[int]Object // Cache of objects from object streams
+	data []byte
+	xref *XrefTable
+
+	// xrefMu guards xref.Entries, which a deferred main xref section is
+	// merged into while other goroutines may be reading objects
+	xrefMu sync.RWMutex
+
+	// Offset of the main xref section of a linearized file, read on first
+	// need of an object outside the first-page section; 0 if none
+	deferredXref     int64
+	deferredXrefOnce sync.Once
+
+	// Loads data on demand, nil if data holds the whole file
+	source *rangeSource
+
+	// mu guards cache, objStm and objStmParsed so objects can be read from
+	// several goroutines. Cache lookups take the write lock, since they
+	// reorder the LRU list.
+	mu     sync.RWMutex
+	cache  *objectCache // LRU cache of resolved objects
+	objStm *objStmCache // LRU cache of objects from object streams
+
+	objStmParsed int // Object streams decoded and parsed, for CacheStats
+
+	// Decodes each object stream once when several goroutines miss objStm
+	objStmFlight flightGroup
+
+	linearized *LinearizedHints // Linearization parameters, nil if not linearized
+
+	xrefTime  time.Duration // Time taken to load the cross-reference tables
+	startXref int64         // Offset of the last cross-reference section
+
+	security   SecurityHandler // Decrypts strings and streams, nil if not encrypted
+	encryptObj int             // Object number of the Encrypt dictionary
+
+	// Object numbers of all pages in order, built by one walk of the page
+	// tree on first use
+	pageList     []int
+	pageIndex    map[int]int  // Page number by object number
+	pageDicts    map[int]Dict // Pages stored as direct objects, by page number
+	pageListErr  error
+	pageListOnce sync.Once
 }
 
 // Open opens a PDF file and creates a Reader.
 func Open(path string) (*Reader, error) {
-	data, err := os.ReadFile(path)
+	f, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
+	defer f.Close()
+	return ReadFrom(f)
+}
+
+// ReadFrom reads a whole PDF from r and creates a Reader. Unlike Open it
+// needs no file system, so it also works in WebAssembly.
+func ReadFrom(r io.Reader) (*Reader, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
 	return NewReader(data)
 }
 
+// ReaderOptions configures a Reader.
+type ReaderOptions struct {
+	// MaxCachedObjects is the maximum number of resolved objects kept in
+	// memory, and separately of objects kept from decoded object streams.
+	// Zero or less means unlimited.
+	MaxCachedObjects int
+
+	// Password is the user password tried for encrypted documents.
+	// Documents that only restrict permissions open with an empty password.
+	Password string
+}
+
+// DefaultReaderOptions returns the default reader options.
+func DefaultReaderOptions() ReaderOptions {
+	return ReaderOptions{
+		MaxCachedObjects: DefaultMaxCachedObjects,
+	}
+}
+
 // NewReader creates a Reader from PDF data.
 func NewReader(data []byte) (*Reader, error) {
-	r := &Reader{
+	return NewReaderWithOptions(data, DefaultReaderOptions())
+}
+
+// NewReaderWithOptions creates a Reader from PDF data with custom options.
+func NewReaderWithOptions(data []byte, opts ReaderOptions) (*Reader, error) {
+	r := newReader(data, opts)
+	if err := r.init(opts); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func newReader(data []byte, opts ReaderOptions) *Reader {
+	return &Reader{
 		data:   data,
-		cache:  make(map[int]Object),
-		objStm: make(map[int]map[int]Object),
+		cache:  newObjectCache(opts.MaxCachedObjects),
+		objStm: newObjStmCache(opts.MaxCachedObjects),
 	}
+}
+
+// init reads the linearization parameters, cross-reference tables and
+// security handler.
+func (r *Reader) init(opts ReaderOptions) error {
+	size := int64(len(r.data))
+	if err := r.ensure(0, linearizationWindow); err != nil {
+		return err
+	}
+	r.linearized = parseLinearization(r.data)
 
 	// Find startxref
-	startXref, err := findStartXref(data)
+	start := time.Now()
+	if err := r.ensure(size-1024, 1024); err != nil {
+		return err
+	}
+	startXref, err := findStartXref(r.data)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find startxref: %w", err)
+		return fmt.Errorf("failed to find startxref: %w", err)
 	}
 
 	// Parse xref table
-	r.xref, err = ParseXref(data, startXref)
+	r.startXref = startXref
+	r.xref, err = r.parseXref(startXref)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse xref: %w", err)
+		return fmt.Errorf("failed to parse xref: %w", err)
 	}
 
-	// Handle prev xref (for incremental updates)
+	// Handle prev xref (for incremental updates). Failures are non-fatal:
+	// the sections read so far are used. In a linearized file the Prev of
+	// the first-page section is the main section, which the first page
+	// does not need, so it is read later.
 	if prevOffset, ok := r.xref.Trailer.GetInt("Prev"); ok {
-		if err := r.loadPrevXref(prevOffset); err != nil {
-			// Non-fatal, continue with what we have
+		if r.linearized != nil {
+			r.deferredXref = prevOffset
+		} else {
+			if err := r.loadPrevXref(prevOffset); err != nil {
+				slog.Debug("failed to load previous xref sections", "offset", prevOffset, "error", err)
+			}
 		}
 	}
+	r.xrefTime = time.Since(start)
 
-	return r, nil
+	if encrypt := r.xref.Trailer.Get("Encrypt"); encrypt != nil {
+		if err := r.setupSecurity(encrypt, opts.Password); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseXref parses the xref table or stream at offset.
+func (r *Reader) parseXref(offset int64) (*XrefTable, error) {
+	var table *XrefTable
+	err := r.parseAt(offset, func(data []byte) (end int64, err error) {
+		table, end, err = parseXref(data, offset)
+		return end, err
+	})
+	return table, err
 }
 
-// loadPrevXref loads previous xref tables for incremental updates.
+// loadPrevXref loads the older xref sections of a file with incremental
+// updates, starting with the one at offset. The chain of Prev offsets is
+// followed first, reading only each section's trailer; the sections are
+// then parsed concurrently and merged newest first, so newer entries take
+// precedence. Sections that fail to parse are skipped; the chain ends at
+// a trailer that cannot be read or a Prev offset seen before. The returned
+// error describes what was skipped; the sections that were read are kept
+// either way.
 func (r *Reader) loadPrevXref(offset int64) error {
-	prevXref, err := ParseXref(r.data, offset)
-	if err != nil {
-		return err
+	sections, chainErr := r.xrefChain(offset)
+
+	tables := make([]*XrefTable, len(sections))
+	errs := make([]error, len(sections))
+	var wg sync.WaitGroup
+	for i, section := range sections {
+		wg.Add(1)
+		go func(i int, section xrefSection) {
+			defer wg.Done()
+			if section.stream != nil {
+				tables[i], errs[i] = xrefFromStream(section.stream)
+			} else {
+				tables[i], errs[i] = r.parseXref(section.offset)
+			}
+		}(i, section)
 	}
+	wg.Wait()
 
 	// Merge entries (current takes precedence)
-	for objNum, entry := range prevXref.Entries {
-		if _, exists := r.xref.Entries[objNum]; !exists {
-			r.xref.Entries[objNum] = entry
+	r.xrefMu.Lock()
+	defer r.xrefMu.Unlock()
+	for i, table := range tables {
+		if errs[i] != nil {
+			errs[i] = fmt.Errorf("skipped xref section at %d: %w", sections[i].offset, errs[i])
+			continue
+		}
+		for objNum, entry := range table.Entries {
+			if _, exists := r.xref.Entries[objNum]; !exists {
+				r.xref.Entries[objNum] = entry
+			}
 		}
 	}
 
-	// Recurse for older xrefs
-	if prevPrev, ok := prevXref.Trailer.GetInt("Prev"); ok {
-		return r.loadPrevXref(prevPrev)
+	return errors.Join(append(errs, chainErr)...)
+}
+
+// xrefEntry looks up an object in the xref table. An object missing from
+// the first-page section of a linearized file is looked up again once the
+// deferred main section is loaded.
+func (r *Reader) xrefEntry(objNum int) (*XrefEntry, bool) {
+	r.xrefMu.RLock()
+	entry, ok := r.xref.Entries[objNum]
+	r.xrefMu.RUnlock()
+	if ok || !r.loadDeferredXref() {
+		return entry, ok
 	}
 
-	return nil
+	r.xrefMu.RLock()
+	defer r.xrefMu.RUnlock()
+	entry, ok = r.xref.Entries[objNum]
+	return entry, ok
+}
+
+// loadDeferredXref loads the main xref section of a linearized file if it
+// was deferred, reporting whether there was one.
+func (r *Reader) loadDeferredXref() bool {
+	if r.deferredXref == 0 {
+		return false
+	}
+	r.deferredXrefOnce.Do(func() {
+		if err := r.loadPrevXref(r.deferredXref); err != nil {
+			slog.Debug("failed to load deferred xref sections", "offset", r.deferredXref, "error", err)
+		}
+	})
+	return true
+}
+
+// xrefSection is an xref section found by xrefChain.
+type xrefSection struct {
+	offset int64
+	stream *Stream // The encoded xref stream, nil for a table
+}
+
+// xrefChain returns the xref section at offset and every older section it
+// leads to through Prev, newest first.
+func (r *Reader) xrefChain(offset int64) ([]xrefSection, error) {
+	var sections []xrefSection
+	seen := make(map[int64]bool)
+	for {
+		if seen[offset] {
+			return sections, fmt.Errorf("xref Prev chain loops back to offset %d", offset)
+		}
+		seen[offset] = true
+
+		var trailer Dict
+		var stream *Stream
+		err := r.parseAt(offset, func(data []byte) (end int64, err error) {
+			trailer, stream, end, err = parseXrefTrailer(data, offset)
+			return end, err
+		})
+		if err != nil {
+			return sections, fmt.Errorf("failed to read xref trailer at offset %d: %w", offset, err)
+		}
+		sections = append(sections, xrefSection{offset: offset, stream: stream})
+
+		prev, ok := trailer.GetInt("Prev")
+		if !ok {
+			return sections, nil
+		}
+		offset = prev
+	}
+}
+
+// CacheStats returns hit and miss counts for the object cache and the
+// number of object streams parsed.
+func (r *Reader) CacheStats() CacheStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := r.cache.stats()
+	stats.ObjectStreams = r.objStmParsed
+	return stats
+}
+
+// XrefLoadTime returns how long finding and parsing the cross-reference
+// tables took when the Reader was created.
+func (r *Reader) XrefLoadTime() time.Duration {
+	return r.xrefTime
+}
+
+// StartXref returns the offset of the document's last cross-reference
+// section, which an incremental update names as Prev.
+func (r *Reader) StartXref() int64 {
+	return r.startXref
+}
+
+// IsEncrypted returns true if the document is encrypted. Strings and
+// streams are decrypted transparently.
+func (r *Reader) IsEncrypted() bool {
+	return r.security != nil
 }
 
 // Trailer returns the document trailer dictionary.
@@ -81,14 +329,39 @@ func (r *Reader) Trailer() Dict {
 	return r.xref.Trailer
 }
 
-// GetObject retrieves an object by its number, resolving references.
+// resolveChain lists the objects being resolved by one call to GetObject,
+// innermost first, for cycle detection. Each goroutine has its own chain.
+type resolveChain struct {
+	objNum int
+	parent *resolveChain
+}
+
+func (c *resolveChain) contains(objNum int) bool {
+	for ; c != nil; c = c.parent {
+		if c.objNum == objNum {
+			return true
+		}
+	}
+	return false
+}
+
+// GetObject retrieves an object by its number, resolving references. It is
+// safe for concurrent use.
 func (r *Reader) GetObject(objNum int) (Object, error) {
+	return r.getObject(objNum, nil)
+}
+
+// getObject retrieves an object while resolving the objects in chain.
+func (r *Reader) getObject(objNum int, chain *resolveChain) (Object, error) {
 	// Check cache
-	if obj, ok := r.cache[objNum]; ok {
+	r.mu.Lock()
+	obj, ok := r.cache.get(objNum)
+	r.mu.Unlock()
+	if ok {
 		return obj, nil
 	}
 
-	entry, ok := r.xref.Entries[objNum]
+	entry, ok := r.xrefEntry(objNum)
 	if !ok {
 		return nil, fmt.Errorf("object %d not found in xref", objNum)
 	}
@@ -97,15 +370,21 @@ func (r *Reader) GetObject(objNum int) (Object, error) {
 		return Null{}, nil
 	}
 
-	var obj Object
-	var err error
+	// Resolving this object (e.g. its stream Length or containing object
+	// stream) led back to itself
+	if chain.contains(objNum) {
+		slog.Debug("circular reference detected", "object", objNum)
+		return nil, fmt.Errorf("object %d: %w", objNum, ErrCircularReference)
+	}
+	chain = &resolveChain{objNum: objNum, parent: chain}
 
+	var err error
 	if entry.ObjectStreamNum > 0 {
 		// Object is in an object stream
-		obj, err = r.getObjectFromStream(entry.ObjectStreamNum, entry.IndexInStream, objNum)
+		obj, err = r.getObjectFromStream(entry.ObjectStreamNum, entry.IndexInStream, objNum, chain)
 	} else {
 		// Object is at file offset
-		obj, err = r.getObjectAtOffset(entry.Offset, objNum)
+		obj, err = r.getObjectAtOffset(entry.Offset, objNum, chain)
 	}
 
 	if err != nil {
@@ -113,13 +392,19 @@ func (r *Reader) GetObject(objNum int) (Object, error) {
 	}
 
 	// Cache the result
-	r.cache[objNum] = obj
+	r.mu.Lock()
+	r.cache.put(objNum, obj)
+	r.mu.Unlock()
 	return obj, nil
 }
 
 // getObjectAtOffset reads an indirect object at the given offset.
-func (r *Reader) getObjectAtOffset(offset int64, expectedObjNum int) (Object, error) {
-	indirect, err := ParseObjectAt(r.data, offset)
+func (r *Reader) getObjectAtOffset(offset int64, expectedObjNum int, chain *resolveChain) (Object, error) {
+	var indirect *IndirectObject
+	err := r.parseAt(offset, func(data []byte) (end int64, err error) {
+		indirect, end, err = parseObjectAt(data, offset)
+		return end, err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse object at offset %d: %w", offset, err)
 	}
@@ -127,7 +412,7 @@ func (r *Reader) getObjectAtOffset(offset int64, expectedObjNum int) (Object, er
 	// Handle streams that need decompression for Length reference
 	if stream, ok := indirect.Object.(*Stream); ok {
 		if ref, ok := stream.Dict.Get("Length").(*Reference); ok {
-			lengthObj, err := r.GetObject(ref.ObjectNumber)
+			lengthObj, err := r.getObject(ref.ObjectNumber, chain)
 			if err == nil {
 				if length, ok := lengthObj.(Integer); ok {
 					// Re-read with correct length
@@ -137,20 +422,57 @@ func (r *Reader) getObjectAtOffset(offset int64, expectedObjNum int) (Object, er
 		}
 	}
 
+	if r.security != nil && expectedObjNum != r.encryptObj {
+		return r.decryptObject(indirect.Object, indirect.ObjectNumber, indirect.GenerationNumber)
+	}
+
 	return indirect.Object, nil
 }
 
-// getObjectFromStream retrieves an object from an object stream.
-func (r *Reader) getObjectFromStream(streamObjNum, index, targetObjNum int) (Object, error) {
-	// Check if we've already parsed this object stream
-	if objects, ok := r.objStm[streamObjNum]; ok {
-		if obj, ok := objects[targetObjNum]; ok {
-			return obj, nil
+// getObjectFromStream retrieves an object from an object stream. The
+// stream is decoded and parsed on first access and its objects kept for
+// later lookups until the stream falls out of the LRU cache. Goroutines
+// missing the cache at the same time wait for a single decode.
+func (r *Reader) getObjectFromStream(streamObjNum, index, targetObjNum int, chain *resolveChain) (Object, error) {
+	// A stream already parsed has every object it will ever have. Lookups
+	// reorder the LRU list, so they take the write lock
+	r.mu.Lock()
+	objects, ok := r.objStm.get(streamObjNum)
+	r.mu.Unlock()
+
+	if !ok {
+		// Waiting on a decode this goroutine started would never return
+		if chain.contains(streamObjNum) {
+			return nil, fmt.Errorf("object stream %d: %w", streamObjNum, ErrCircularReference)
 		}
+		var err error
+		objects, err, _ = r.objStmFlight.do(streamObjNum, func() (map[int]Object, error) {
+			return r.parseObjectStream(streamObjNum, chain)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if obj, ok := objects[targetObjNum]; ok {
+		return obj, nil
+	}
+
+	return nil, fmt.Errorf("object %d not found in object stream %d", targetObjNum, streamObjNum)
+}
+
+// parseObjectStream decodes an object stream and caches its objects.
+func (r *Reader) parseObjectStream(streamObjNum int, chain *resolveChain) (map[int]Object, error) {
+	// The stream may have been parsed since the caller's cache miss
+	r.mu.Lock()
+	objects, ok := r.objStm.get(streamObjNum)
+	r.mu.Unlock()
+	if ok {
+		return objects, nil
 	}
 
 	// Get the object stream
-	streamObj, err := r.GetObject(streamObjNum)
+	streamObj, err := r.getObject(streamObjNum, chain)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get object stream %d: %w", streamObjNum, err)
 	}
@@ -161,34 +483,35 @@ func (r *Reader) getObjectFromStream(streamObjNum, index, targetObjNum int) (Obj
 	}
 
 	// Decode the stream
-	decoded, err := r.DecodeStream(stream)
+	decoded, err := r.decodeStream(stream, chain)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode object stream: %w", err)
 	}
 
 	// Parse objects from stream
-	objects, err := ParseObjectsFromStream(decoded, stream.Dict)
+	objects, err = ParseObjectsFromStream(decoded, stream.Dict)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse object stream contents: %w", err)
 	}
 
-	// Cache the parsed objects
-	r.objStm[streamObjNum] = objects
-
-	if obj, ok := objects[targetObjNum]; ok {
-		return obj, nil
-	}
-
-	return nil, fmt.Errorf("object %d not found in object stream %d", targetObjNum, streamObjNum)
+	r.mu.Lock()
+	r.objStm.put(streamObjNum, objects)
+	r.objStmParsed++
+	r.mu.Unlock()
+	return objects, nil
 }
 
 // Resolve resolves a reference to its actual object.
 func (r *Reader) Resolve(obj Object) (Object, error) {
+	return r.resolve(obj, nil)
+}
+
+func (r *Reader) resolve(obj Object, chain *resolveChain) (Object, error) {
 	ref, ok := obj.(*Reference)
 	if !ok {
 		return obj, nil
 	}
-	return r.GetObject(ref.ObjectNumber)
+	return r.getObject(ref.ObjectNumber, chain)
 }
 
 // ResolveDict resolves a reference and asserts it's a dictionary.
@@ -217,6 +540,11 @@ func (r *Reader) ResolveArray(obj Object) (Array, error) {
 
 // DecodeStream decodes a stream's data based on its Filter.
 func (r *Reader) DecodeStream(s *Stream) ([]byte, error) {
+	return r.decodeStream(s, nil)
+}
+
+// decodeStream decodes a stream, resolving its filters as part of chain.
+func (r *Reader) decodeStream(s *Stream, chain *resolveChain) ([]byte, error) {
 	data := s.Data
 
 	// Get filter(s)
@@ -226,7 +554,7 @@ func (r *Reader) DecodeStream(s *Stream) ([]byte, error) {
 	}
 
 	// Resolve if reference
-	filter, _ = r.Resolve(filter)
+	filter, _ = r.resolve(filter, chain)
 
 	// Handle single filter or array of filters
 	var filters []Name
@@ -235,7 +563,7 @@ func (r *Reader) DecodeStream(s *Stream) ([]byte, error) {
 		filters = []Name{f}
 	case Array:
 		for _, item := range f {
-			resolved, _ := r.Resolve(item)
+			resolved, _ := r.resolve(item, chain)
 			if n, ok := resolved.(Name); ok {
 				filters = append(filters, n)
 			}
@@ -554,78 +882,114 @@ func (r *Reader) Pages() (Dict, error) {
 	return r.ResolveDict(pagesRef)
 }
 
-// PageCount returns the total number of pages.
+// PageCount returns the total number of pages. For linearized documents
+// it is read from the linearization parameters without walking the page
+// tree.
 func (r *Reader) PageCount() (int, error) {
-	pages, err := r.Pages()
+	if r.linearized != nil && r.linearized.PageCount > 0 {
+		return r.linearized.PageCount, nil
+	}
+
+	list, err := r.pages()
 	if err != nil {
 		return 0, err
 	}
-	
-	count, ok := pages.GetInt("Count")
-	if !ok {
-		return 0, fmt.Errorf("no Count in Pages")
-	}
-	
-	return int(count), nil
+	return len(list), nil
 }
 
 // GetPage returns the dictionary for a specific page (0-indexed).
 func (r *Reader) GetPage(pageNum int) (Dict, error) {
-	pages, err := r.Pages()
+	if pageNum == 0 {
+		if page, ok := r.linearizedFirstPage(); ok {
+			return page, nil
+		}
+	}
+
+	list, err := r.pages()
 	if err != nil {
 		return nil, err
 	}
-	
-	return r.findPage(pages, pageNum, 0)
-}
-
-// findPage recursively searches the page tree for the given page number.
-func (r *Reader) findPage(node Dict, targetPage, currentPage int) (Dict, error) {
-	nodeType, _ := node.GetName("Type")
-	
-	if nodeType == "Page" {
-		if currentPage == targetPage {
-			return node, nil
-		}
-		return nil, fmt.Errorf("page not found")
+	if pageNum < 0 || pageNum >= len(list) {
+		return nil, fmt.Errorf("page %d not found", pageNum)
 	}
-	
-	// It's a Pages node
-	kids := node.Get("Kids")
-	if kids == nil {
-		return nil, fmt.Errorf("Pages node without Kids")
+	if list[pageNum] == 0 {
+		return r.pageDicts[pageNum], nil
 	}
-	
-	kidsArray, err := r.ResolveArray(kids)
+	return r.ResolveDict(&Reference{ObjectNumber: list[pageNum]})
+}
+
+// pages returns the page list, building it on first use.
+func (r *Reader) pages() ([]int, error) {
+	r.pageListOnce.Do(func() {
+		r.pageListErr = r.buildPageList()
+	})
+	return r.pageList, r.pageListErr
+}
+
+// buildPageList walks the whole page tree once and records the object
+// number of every page in order. Pages stored as direct objects, which the
+// format does not allow, are recorded as 0 and kept in pageDicts.
+func (r *Reader) buildPageList() error {
+	root, err := r.Pages()
 	if err != nil {
-		return nil, err
+		return err
 	}
-	
-	pageIndex := currentPage
-	for _, kid := range kidsArray {
-		kidDict, err := r.ResolveDict(kid)
+
+	var list []int
+	index := make(map[int]int)
+	direct := make(map[int]Dict)
+	visited := make(map[int]bool)
+
+	var walk func(node Dict, depth int) error
+	walk = func(node Dict, depth int) error {
+		if depth > maxPageTreeDepth {
+			return fmt.Errorf("page tree deeper than %d levels: %w", maxPageTreeDepth, ErrCircularReference)
+		}
+
+		kids, err := r.ResolveArray(node.Get("Kids"))
 		if err != nil {
-			continue
+			return fmt.Errorf("Pages node without Kids: %w", err)
 		}
-		
-		kidType, _ := kidDict.GetName("Type")
-		
-		if kidType == "Page" {
-			if pageIndex == targetPage {
-				return kidDict, nil
+
+		for _, kid := range kids {
+			objNum := 0
+			if ref, ok := kid.(*Reference); ok {
+				if visited[ref.ObjectNumber] {
+					return fmt.Errorf("page tree node %d visited twice: %w", ref.ObjectNumber, ErrCircularReference)
+				}
+				visited[ref.ObjectNumber] = true
+				objNum = ref.ObjectNumber
 			}
-			pageIndex++
-		} else {
-			// Pages node
-			count, _ := kidDict.GetInt("Count")
-			if pageIndex+int(count) > targetPage {
-				return r.findPage(kidDict, targetPage, pageIndex)
+
+			kidDict, err := r.ResolveDict(kid)
+			if err != nil {
+				continue
+			}
+
+			if kidType, _ := kidDict.GetName("Type"); kidType == "Page" {
+				if objNum == 0 {
+					direct[len(list)] = kidDict
+				} else {
+					index[objNum] = len(list)
+				}
+				list = append(list, objNum)
+				continue
+			}
+			if err := walk(kidDict, depth+1); err != nil {
+				return err
 			}
-			pageIndex += int(count)
 		}
+		return nil
 	}
-	
-	return nil, fmt.Errorf("page %d not found", targetPage)
+
+	if err := walk(root, 0); err != nil {
+		return err
+	}
+
+	r.pageList = list
+	r.pageIndex = index
+	r.pageDicts = direct
+	return nil
 }
 
 // GetPageContents returns the decoded content stream(s) for a page.
@@ -667,6 +1031,104 @@ func (r *Reader) GetPageContents(page Dict) ([]byte, error) {
 	}
 }
 
+// GetPageResources returns the Resources dictionary of a page merged with
+// the Resources of its ancestors in the page tree. Entries on the page take
+// precedence; ancestors fill in missing keys, including missing entries of
+// sub-dictionaries such as Font and XObject.
+func (r *Reader) GetPageResources(page Dict) (Dict, error) {
+	var levels []Dict
+	node := page
+	for depth := 0; depth < maxPageTreeDepth; depth++ {
+		if resources := node.Get("Resources"); resources != nil {
+			dict, err := r.ResolveDict(resources)
+			if err != nil {
+				return nil, err
+			}
+			levels = append(levels, dict)
+		}
+
+		parent := node.Get("Parent")
+		if parent == nil {
+			break
+		}
+
+		next, err := r.ResolveDict(parent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve page parent: %w", err)
+		}
+		node = next
+	}
+
+	switch len(levels) {
+	case 0:
+		return nil, nil
+	case 1:
+		return levels[0], nil
+	}
+
+	merged := r.resolvedResources(levels[0])
+	for _, parent := range levels[1:] {
+		merged.MergeFrom(r.resolvedResources(parent))
+	}
+	return merged, nil
+}
+
+// resolvedResources returns a copy of a Resources dictionary with indirect
+// sub-dictionaries resolved, so that MergeFrom can merge them by key.
+func (r *Reader) resolvedResources(resources Dict) Dict {
+	copied := make(Dict, len(resources))
+	for key, value := range resources {
+		if _, ok := value.(*Reference); ok {
+			if sub, err := r.ResolveDict(value); err == nil {
+				value = sub
+			}
+		}
+		copied[key] = cloneDirect(value)
+	}
+	return copied
+}
+
+// ResolveColorSpace resolves a color space operand or entry. Names other
+// than the device and Pattern families are looked up in the ColorSpace
+// resources. The result is a Name or an Array.
+func (r *Reader) ResolveColorSpace(obj Object, resources Dict) (Object, error) {
+	resolved, err := r.Resolve(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cs := resolved.(type) {
+	case Name:
+		switch cs {
+		case "DeviceGray", "DeviceRGB", "DeviceCMYK", "Pattern":
+			return cs, nil
+		}
+
+		spaces, err := r.ResolveDict(resources.Get("ColorSpace"))
+		if err != nil {
+			return nil, fmt.Errorf("unknown color space %s", cs)
+		}
+		named := spaces.Get(string(cs))
+		if named == nil {
+			return nil, fmt.Errorf("unknown color space %s", cs)
+		}
+
+		named, err = r.Resolve(named)
+		if err != nil {
+			return nil, err
+		}
+		switch named.(type) {
+		case Name, Array:
+			return named, nil
+		}
+		return nil, fmt.Errorf("invalid color space %s: %T", cs, named)
+	case Array:
+		return cs, nil
+	}
+
+	return nil, fmt.Errorf("invalid color space: %T", resolved)
+}
+
 // Info returns the document info dictionary if present.
 func (r *Reader) Info() (Dict, error) {
 	infoRef := r.xref.Trailer.Get("Info")
@@ -675,3 +1137,59 @@ func (r *Reader) Info() (Dict, error) {
 	}
 	return r.ResolveDict(infoRef)
 }
+
+// PageIndex returns the 0-indexed page number of the page object with the
+// given object number.
+func (r *Reader) PageIndex(objNum int) (int, error) {
+	if _, err := r.pages(); err != nil {
+		return -1, err
+	}
+	if index, ok := r.pageIndex[objNum]; ok {
+		return index, nil
+	}
+	return -1, fmt.Errorf("object %d is not a page", objNum)
+}
+
+// PageRefs returns the object numbers of all leaf pages in document order,
+// with 0 for a page stored as a direct object. The slice is shared and must
+// not be modified.
+func (r *Reader) PageRefs() ([]int, error) {
+	return r.pages()
+}
+
+// ObjectNumbers returns the object numbers listed in the xref table, sorted
+// in ascending order.
+func (r *Reader) ObjectNumbers() []int {
+	r.loadDeferredXref()
+
+	r.xrefMu.RLock()
+	nums := make([]int, 0, len(r.xref.Entries))
+	for objNum := range r.xref.Entries {
+		nums = append(nums, objNum)
+	}
+	r.xrefMu.RUnlock()
+
+	sort.Ints(nums)
+	return nums
+}
+
+// ForEachObject resolves every in-use object in the xref table in ascending
+// object number order and calls fn for each. Iteration stops at the first
+// error from fn or from resolving an object.
+func (r *Reader) ForEachObject(fn func(num int, obj Object) error) error {
+	for _, objNum := range r.ObjectNumbers() {
+		if entry, _ := r.xrefEntry(objNum); !entry.InUse {
+			continue
+		}
+
+		obj, err := r.GetObject(objNum)
+		if err != nil {
+			return fmt.Errorf("failed to resolve object %d: %w", objNum, err)
+		}
+
+		if err := fn(objNum, obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package cos
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// readConcurrently reads objects first..first+count-1 from 10 goroutines at
+// once, each in its own order, and reports objects that fail to resolve.
+func readConcurrently(t *testing.T, r *Reader, first, count int) {
+	t.Helper()
+
+	const goroutines = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, goroutines*count)
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < count; i++ {
+				objNum := first + (i+g*count/goroutines)%count
+				if _, err := r.GetObject(objNum); err != nil {
+					errs <- err
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestReaderConcurrentObjects(t *testing.T) {
+	t.Parallel()
+
+	// 100 pages give 200 objects: a content stream and a page each
+	r, err := NewReaderWithOptions(writeTestPDF(t, 100), ReaderOptions{MaxCachedObjects: 64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	readConcurrently(t, r, 3, 100)
+}
+
+func TestReaderConcurrentObjectStream(t *testing.T) {
+	t.Parallel()
+
+	const objects = 100
+	r, err := NewReader(writeObjStmPDF(t, objects))
+	if err != nil {
+		t.Fatal(err)
+	}
+	readConcurrently(t, r, 4, objects)
+
+	if got := r.CacheStats().ObjectStreams; got != 1 {
+		t.Errorf("object stream decoded %d times, want once", got)
+	}
+}
+
+func TestReaderIncrementalUpdates(t *testing.T) {
+	const updates = 15
+	data := writeIncrementalPDF(t, updates)
+	r, err := NewReader(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := r.GetPage(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents, err := r.GetPageContents(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := fmt.Sprintf("(Update %d)", updates); !strings.Contains(string(contents), want) {
+		t.Errorf("page 0 contents = %q, want the newest update %s", contents, want)
+	}
+
+	// Objects added by every update resolve, the oldest included
+	first, _ := r.xref.Trailer.GetInt("Size")
+	first -= updates
+	for i := 0; i < updates; i++ {
+		obj, err := r.GetObject(int(first) + i)
+		if err != nil {
+			t.Fatalf("object %d: %v", int(first)+i, err)
+		}
+		if n, _ := obj.(Dict).GetInt("Update"); n != int64(i+1) {
+			t.Errorf("object %d has /Update %d, want %d", int(first)+i, n, i+1)
+		}
+	}
+}
+
+func TestLoadPrevXrefReportsSkippedSections(t *testing.T) {
+	data := writeIncrementalPDF(t, 3)
+
+	// Break the xref section of the second update
+	sections := bytes.Split(data, []byte("\nxref\n"))
+	broken := bytes.Join(sections[:3], []byte("\nxref\n"))
+	broken = append(broken, "\nxrex\n"...)
+	broken = append(broken, bytes.Join(sections[3:], []byte("\nxref\n"))...)
+
+	r, err := NewReader(broken)
+	if err != nil {
+		t.Fatalf("NewReader should skip the broken section: %v", err)
+	}
+	prev, _ := r.xref.Trailer.GetInt("Prev")
+	if err := r.loadPrevXref(prev); err == nil {
+		t.Error("loadPrevXref over a broken section returned no error")
+	}
+	// The newest section is still used, though the chain ends before the
+	// original section
+	obj, err := r.GetObject(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, ok := obj.(*Stream); !ok || !bytes.Contains(s.Data, []byte("(Update 3)")) {
+		t.Errorf("object 3 = %v, want the newest update's content stream", obj)
+	}
+}
+
+// BenchmarkIncrementalXref opens a document with 15 incremental updates,
+// whose xref sections are chained through Prev.
+func BenchmarkIncrementalXref(b *testing.B) {
+	data := writeIncrementalPDF(b, 15)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewReader(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package cos
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rc4"
+	"fmt"
+)
+
+// passwordPadding is the padding string used by the Standard security
+// handler (PDF 32000-1, 7.6.3.3).
+var passwordPadding = []byte{
+	0x28, 0xBF, 0x4E, 0x5E, 0x4E, 0x75, 0x8A, 0x41,
+	0x64, 0x00, 0x4E, 0x56, 0xFF, 0xFA, 0x01, 0x08,
+	0x2E, 0x2E, 0x00, 0xB6, 0xD0, 0x68, 0x3E, 0x80,
+	0x2F, 0x0C, 0xA9, 0xFE, 0x64, 0x53, 0x69, 0x7A,
+}
+
+// SecurityHandler decrypts strings and streams of an encrypted document.
+type SecurityHandler interface {
+	// DecryptString decrypts a string belonging to the given object.
+	DecryptString(objNum, genNum int, data []byte) ([]byte, error)
+
+	// DecryptStream decrypts raw stream data belonging to the given object.
+	DecryptStream(objNum, genNum int, data []byte) ([]byte, error)
+}
+
+// StandardSecurityHandler implements the RC4-based Standard security handler
+// (revisions 2 and 3).
+type StandardSecurityHandler struct {
+	revision int
+	key      []byte // File encryption key
+}
+
+// NewStandardSecurityHandler authenticates the user password against the
+// Encrypt dictionary and derives the file encryption key. Pass an empty
+// password for documents that only restrict permissions.
+func NewStandardSecurityHandler(encrypt Dict, fileID []byte, password string) (*StandardSecurityHandler, error) {
+	encErr := newEncryptedPDFError(encrypt)
+
+	if encErr.Filter != "Standard" {
+		return nil, encErr
+	}
+	if encErr.Revision != 2 && encErr.Revision != 3 {
+		return nil, encErr
+	}
+
+	o, ok := encrypt.Get("O").(String)
+	if !ok || len(o) < 32 {
+		return nil, fmt.Errorf("invalid O entry in Encrypt dictionary")
+	}
+	u, ok := encrypt.Get("U").(String)
+	if !ok || len(u) < 32 {
+		return nil, fmt.Errorf("invalid U entry in Encrypt dictionary")
+	}
+	p, _ := encrypt.GetInt("P")
+
+	keyLen := 5
+	if encErr.Revision >= 3 {
+		if bits, ok := encrypt.GetInt("Length"); ok && bits >= 40 && bits <= 128 {
+			keyLen = int(bits / 8)
+		} else {
+			keyLen = 16
+		}
+	}
+
+	h := &StandardSecurityHandler{revision: encErr.Revision}
+	h.key = computeEncryptionKey([]byte(password), []byte(o), uint32(int32(p)), fileID, keyLen, encErr.Revision)
+
+	if !bytes.Equal(h.computeU(fileID), h.truncateU([]byte(u))) {
+		// The supplied (usually empty) password is not the user password
+		return nil, encErr
+	}
+
+	return h, nil
+}
+
+// computeEncryptionKey derives the file encryption key (Algorithm 2).
+func computeEncryptionKey(password, o []byte, p uint32, fileID []byte, keyLen, revision int) []byte {
+	hash := md5.New()
+	hash.Write(padPassword(password))
+	hash.Write(o[:32])
+	hash.Write([]byte{byte(p), byte(p >> 8), byte(p >> 16), byte(p >> 24)})
+	hash.Write(fileID)
+	sum := hash.Sum(nil)
+
+	if revision >= 3 {
+		for i := 0; i < 50; i++ {
+			next := md5.Sum(sum[:keyLen])
+			sum = next[:]
+		}
+	}
+
+	return sum[:keyLen]
+}
+
+// computeU computes the expected U value for the derived key
+// (Algorithm 4 for revision 2, Algorithm 5 for revision 3).
+func (h *StandardSecurityHandler) computeU(fileID []byte) []byte {
+	if h.revision == 2 {
+		return rc4Crypt(h.key, passwordPadding)
+	}
+
+	hash := md5.New()
+	hash.Write(passwordPadding)
+	hash.Write(fileID)
+	result := rc4Crypt(h.key, hash.Sum(nil))
+
+	// 19 further passes with the key XORed with the pass number
+	iterKey := make([]byte, len(h.key))
+	for i := 1; i <= 19; i++ {
+		for j := range h.key {
+			iterKey[j] = h.key[j] ^ byte(i)
+		}
+		result = rc4Crypt(iterKey, result)
+	}
+
+	return result
+}
+
+// truncateU returns the part of the stored U value that is compared. For
+// revision 3 only the first 16 bytes are significant.
+func (h *StandardSecurityHandler) truncateU(u []byte) []byte {
+	if h.revision >= 3 {
+		return u[:16]
+	}
+	return u[:32]
+}
+
+// objectKey derives the per-object RC4 key (Algorithm 1).
+func (h *StandardSecurityHandler) objectKey(objNum, genNum int) []byte {
+	buf := make([]byte, 0, len(h.key)+5)
+	buf = append(buf, h.key...)
+	buf = append(buf, byte(objNum), byte(objNum>>8), byte(objNum>>16))
+	buf = append(buf, byte(genNum), byte(genNum>>8))
+
+	sum := md5.Sum(buf)
+	n := len(h.key) + 5
+	if n > 16 {
+		n = 16
+	}
+	return sum[:n]
+}
+
+// DecryptString decrypts a string belonging to the given object.
+func (h *StandardSecurityHandler) DecryptString(objNum, genNum int, data []byte) ([]byte, error) {
+	return rc4Crypt(h.objectKey(objNum, genNum), data), nil
+}
+
+// DecryptStream decrypts raw stream data belonging to the given object.
+func (h *StandardSecurityHandler) DecryptStream(objNum, genNum int, data []byte) ([]byte, error) {
+	return rc4Crypt(h.objectKey(objNum, genNum), data), nil
+}
+
+// padPassword pads or truncates a password to 32 bytes.
+func padPassword(password []byte) []byte {
+	padded := make([]byte, 32)
+	n := copy(padded, password)
+	copy(padded[n:], passwordPadding)
+	return padded
+}
+
+// rc4Crypt encrypts or decrypts data with RC4. The operation is symmetric.
+func rc4Crypt(key, data []byte) []byte {
+	cipher, err := rc4.NewCipher(key)
+	if err != nil {
+		// Only possible for empty or oversized keys, which are never derived
+		return data
+	}
+	out := make([]byte, len(data))
+	cipher.XORKeyStream(out, data)
+	return out
+}
+
+// newEncryptedPDFError describes the encryption of a document.
+func newEncryptedPDFError(encrypt Dict) *EncryptedPDFError {
+	encErr := &EncryptedPDFError{
+		Filter: "Standard",
+		// Cannot be ruled out without attempting decryption
+		UserPasswordRequired: true,
+	}
+
+	if filter, ok := encrypt.GetName("Filter"); ok {
+		encErr.Filter = string(filter)
+	}
+	if rev, ok := encrypt.GetInt("R"); ok {
+		encErr.Revision = int(rev)
+	}
+	if p, ok := encrypt.GetInt("P"); ok {
+		encErr.Permissions = uint32(int32(p))
+	}
+
+	// Bits 3-6 (print, modify, copy, annotate) are set when unrestricted
+	const basicPermissions = 0x3C
+	encErr.OwnerPasswordSet = encErr.Permissions&basicPermissions != basicPermissions
+
+	return encErr
+}
+
+// setupSecurity installs a security handler for the document's Encrypt
+// dictionary, trying the given user password.
+func (r *Reader) setupSecurity(encrypt Object, password string) error {
+	dict, err := r.ResolveDict(encrypt)
+	if err != nil {
+		return fmt.Errorf("failed to read Encrypt dictionary: %w", err)
+	}
+
+	// The Encrypt dictionary itself is never encrypted
+	if ref, ok := encrypt.(*Reference); ok {
+		r.encryptObj = ref.ObjectNumber
+	}
+
+	var fileID []byte
+	if ids, ok := r.xref.Trailer.GetArray("ID"); ok && len(ids) > 0 {
+		if id, ok := ids[0].(String); ok {
+			fileID = []byte(id)
+		}
+	}
+
+	handler, err := NewStandardSecurityHandler(dict, fileID, password)
+	if err != nil {
+		return err
+	}
+
+	r.security = handler
+	return nil
+}
+
+// decryptObject decrypts all strings and stream data within an object read
+// from the given indirect object.
+func (r *Reader) decryptObject(obj Object, objNum, genNum int) (Object, error) {
+	switch o := obj.(type) {
+	case String:
+		plain, err := r.security.DecryptString(objNum, genNum, []byte(o))
+		if err != nil {
+			return nil, err
+		}
+		return String(plain), nil
+	case Array:
+		for i, item := range o {
+			dec, err := r.decryptObject(item, objNum, genNum)
+			if err != nil {
+				return nil, err
+			}
+			o[i] = dec
+		}
+		return o, nil
+	case Dict:
+		// The Contents of a signature is stored in the clear (PDF 7.6.2)
+		sigType, _ := o.GetName("Type")
+		signature := sigType == "Sig" || sigType == "DocTimeStamp"
+		for key, value := range o {
+			if signature && key == "Contents" {
+				continue
+			}
+			dec, err := r.decryptObject(value, objNum, genNum)
+			if err != nil {
+				return nil, err
+			}
+			o[key] = dec
+		}
+		return o, nil
+	case *Stream:
+		// Cross-reference streams are stored in the clear
+		if streamType, _ := o.Dict.GetName("Type"); streamType == "XRef" {
+			return o, nil
+		}
+		if _, err := r.decryptObject(o.Dict, objNum, genNum); err != nil {
+			return nil, err
+		}
+		data, err := r.security.DecryptStream(objNum, genNum, o.Data)
+		if err != nil {
+			return nil, err
+		}
+		o.Data = data
+		return o, nil
+	}
+	return obj, nil
+}
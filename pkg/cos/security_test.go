@@ -0,0 +1,55 @@
+package cos
+
+import "testing"
+
+// xorHandler "encrypts" by flipping every bit, so decrypted values are easy
+// to tell from ones left alone.
+type xorHandler struct{}
+
+func (xorHandler) DecryptString(objNum, genNum int, data []byte) ([]byte, error) {
+	return flipBits(data), nil
+}
+
+func (xorHandler) DecryptStream(objNum, genNum int, data []byte) ([]byte, error) {
+	return flipBits(data), nil
+}
+
+func flipBits(data []byte) []byte {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[i] = ^b
+	}
+	return out
+}
+
+func TestDecryptSignatureContents(t *testing.T) {
+	r := &Reader{security: xorHandler{}}
+	encrypted := String(flipBits([]byte("text")))
+	signature := String("\x30\x82\x01\x00")
+
+	tests := []struct {
+		name         string
+		dict         Dict
+		wantContents String
+	}{
+		{"signature", Dict{"Type": Name("Sig"), "Contents": signature, "Name": encrypted}, signature},
+		{"timestamp", Dict{"Type": Name("DocTimeStamp"), "Contents": signature, "Name": encrypted}, signature},
+		{"annotation", Dict{"Type": Name("Annot"), "Contents": encrypted, "Name": encrypted}, String("text")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := r.decryptObject(Array{tt.dict}, 1, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dict := obj.(Array)[0].(Dict)
+			if got := dict["Contents"].(String); got != tt.wantContents {
+				t.Errorf("Contents = %x, want %x", got, tt.wantContents)
+			}
+			// Other strings are still decrypted
+			if got := dict["Name"].(String); string(got) != "text" {
+				t.Errorf("Name = %q, want %q", got, "text")
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package cos
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Serialize writes an object tree in PDF syntax. Dictionary keys are
+// written in sorted order so output is deterministic. A stream's Length is
+// written as the actual data length unless it is an indirect reference.
+func Serialize(obj Object, w io.Writer) error {
+	s := &serializer{w: w}
+	s.object(obj)
+	return s.err
+}
+
+// serializer writes PDF syntax, remembering the first write error.
+type serializer struct {
+	w   io.Writer
+	err error
+}
+
+func (s *serializer) write(str string) {
+	if s.err == nil {
+		_, s.err = io.WriteString(s.w, str)
+	}
+}
+
+func (s *serializer) object(obj Object) {
+	switch o := obj.(type) {
+	case nil, Null:
+		s.write("null")
+	case Boolean:
+		s.write(o.String())
+	case Integer:
+		s.write(strconv.FormatInt(int64(o), 10))
+	case Real:
+		f := float64(o)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			if s.err == nil {
+				s.err = fmt.Errorf("cannot serialize real number %v", f)
+			}
+			return
+		}
+		// PDF does not allow exponent notation; keep a decimal point so the
+		// value reads back as a real
+		str := strconv.FormatFloat(f, 'f', -1, 64)
+		if !strings.Contains(str, ".") {
+			str += ".0"
+		}
+		s.write(str)
+	case String:
+		s.write(escapeString(string(o)))
+	case Name:
+		s.write(escapeName(string(o)))
+	case *Reference:
+		s.write(o.String())
+	case Array:
+		s.write("[")
+		for i, item := range o {
+			if i > 0 {
+				s.write(" ")
+			}
+			s.object(item)
+		}
+		s.write("]")
+	case Dict:
+		s.dict(o)
+	case *Stream:
+		dict := o.Dict
+		if _, indirect := dict.Get("Length").(*Reference); !indirect {
+			dict = make(Dict, len(o.Dict)+1)
+			for k, v := range o.Dict {
+				dict[k] = v
+			}
+			dict[Name("Length")] = Integer(len(o.Data))
+		}
+		s.dict(dict)
+		s.write("\nstream\n")
+		s.write(string(o.Data))
+		s.write("\nendstream")
+	default:
+		if s.err == nil {
+			s.err = fmt.Errorf("cannot serialize object of type %T", obj)
+		}
+	}
+}
+
+func (s *serializer) dict(d Dict) {
+	keys := make([]string, 0, len(d))
+	for k := range d {
+		keys = append(keys, string(k))
+	}
+	sort.Strings(keys)
+
+	s.write("<<")
+	for _, k := range keys {
+		s.write(" ")
+		s.write(escapeName(k))
+		s.write(" ")
+		s.object(d[Name(k)])
+	}
+	s.write(" >>")
+}
+
+// escapeString formats a string as a PDF literal string.
+func escapeString(str string) string {
+	buf := make([]byte, 0, len(str)+2)
+	buf = append(buf, '(')
+	for i := 0; i < len(str); i++ {
+		switch c := str[i]; c {
+		case '(', ')', '\\':
+			buf = append(buf, '\\', c)
+		case '\r':
+			// Bare line endings are normalised by readers, so escape them
+			buf = append(buf, '\\', 'r')
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		default:
+			buf = append(buf, c)
+		}
+	}
+	buf = append(buf, ')')
+	return string(buf)
+}
+
+// escapeName formats a name, using #xx escapes for characters that are not
+// allowed to appear literally.
+func escapeName(name string) string {
+	const hexDigits = "0123456789ABCDEF"
+
+	buf := make([]byte, 0, len(name)+1)
+	buf = append(buf, '/')
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c < '!' || c > '~' || c == '#' || isDelimiter(c) {
+			buf = append(buf, '#', hexDigits[c>>4], hexDigits[c&0x0F])
+		} else {
+			buf = append(buf, c)
+		}
+	}
+	return string(buf)
+}
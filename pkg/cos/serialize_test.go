@@ -0,0 +1,78 @@
+package cos
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func parseTestObject(t *testing.T, src []byte) Object {
+	t.Helper()
+	obj, err := NewParser(NewLexer(src)).ParseObject()
+	if err != nil {
+		t.Fatalf("failed to parse %q: %v", src, err)
+	}
+	return obj
+}
+
+func TestSerializeRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"null", "null"},
+		{"boolean", "true"},
+		{"integer", "-42"},
+		{"real", "3.25"},
+		{"whole real", "2.0"},
+		{"string", "(Hello, World)"},
+		{"escaped string", `(parens \( \) and \\ backslash\r\n)`},
+		{"hex string", "<48656C6C6F00FF>"},
+		{"name", "/Type"},
+		{"escaped name", "/A#20B#23C#2F"},
+		{"reference", "12 0 R"},
+		{"array", "[1 2.5 /Name (str) [true null] << /K 3 0 R >>]"},
+		{"dict", "<< /Type /Page /MediaBox [0 0 612 792] /Resources << /Font << /F1 5 0 R >> >> >>"},
+		{"stream", "<< /Length 11 /Filter /FlateDecode >>\nstream\nhello world\nendstream"},
+		{"binary stream", "<< /Length 4 >>\nstream\n\x00\r\n\xff\nendstream"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := parseTestObject(t, []byte(tt.src))
+
+			var buf bytes.Buffer
+			if err := Serialize(want, &buf); err != nil {
+				t.Fatalf("Serialize: %v", err)
+			}
+			got := parseTestObject(t, buf.Bytes())
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip of %q through %q gave %#v, want %#v", tt.src, buf.String(), got, want)
+			}
+		})
+	}
+}
+
+func TestSerializeStreamLength(t *testing.T) {
+	stream := &Stream{Dict: Dict{"Length": Integer(99)}, Data: []byte("abc")}
+
+	var buf bytes.Buffer
+	if err := Serialize(stream, &buf); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := parseTestObject(t, buf.Bytes()).(*Stream)
+	if !ok {
+		t.Fatalf("parsed %q as %T, want a stream", buf.String(), got)
+	}
+	if string(got.Data) != "abc" {
+		t.Errorf("stream data = %q, want %q", got.Data, "abc")
+	}
+}
+
+func TestSerializeRejectsNonFiniteReal(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Serialize(Array{Real(1), Real(math.Inf(1))}, &buf); err == nil {
+		t.Error("Serialize accepted an infinite real")
+	}
+}
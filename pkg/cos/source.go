@@ -0,0 +1,179 @@
+package cos
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// RangeReader gives random access to a PDF that is not held in memory,
+// such as a remote file read with HTTP range requests.
+type RangeReader interface {
+	io.ReaderAt
+	Size() int64
+}
+
+// rangeChunkSize is the granularity at which a RangeReader is read.
+const rangeChunkSize = 64 << 10
+
+// rangeSource reads parts of a RangeReader into a buffer the size of the
+// whole file as they are needed. Chunks are only written before they are
+// marked loaded, and parsers only see loaded chunks, so loading one part
+// while another is parsed is safe. The lock is not held while reading, so
+// callers needing different chunks fetch them at the same time; callers
+// needing a chunk that is being read wait for that read.
+type rangeSource struct {
+	src     RangeReader
+	data    []byte
+	mu      sync.Mutex
+	loaded  []bool               // Whether each chunk has been read
+	pending map[int64]*rangeRead // Reads in flight, by chunk
+}
+
+// rangeRead is a read of the chunks first..last in flight.
+type rangeRead struct {
+	first, last int64
+	done        chan struct{} // Closed when the read has finished
+	err         error
+}
+
+// NewReaderFromRange creates a Reader that reads the parts of src it needs
+// when it needs them: the end of the file, the cross-reference tables and
+// then each object as it is resolved.
+func NewReaderFromRange(src RangeReader, opts ReaderOptions) (*Reader, error) {
+	size := src.Size()
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid PDF size %d", size)
+	}
+	rs := &rangeSource{
+		src:     src,
+		data:    make([]byte, size),
+		loaded:  make([]bool, (size+rangeChunkSize-1)/rangeChunkSize),
+		pending: make(map[int64]*rangeRead),
+	}
+	r := newReader(rs.data, opts)
+	r.source = rs
+	if err := r.init(opts); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// load reads the chunks covering [offset, offset+length) that have not
+// been read yet, and waits for those another caller is reading.
+func (s *rangeSource) load(offset, length int64) error {
+	if offset < 0 {
+		length += offset
+		offset = 0
+	}
+	end := min(offset+length, int64(len(s.data)))
+
+	// Claim the missing chunks that nobody is reading
+	var claimed, waiting []*rangeRead
+	s.mu.Lock()
+	for first := offset / rangeChunkSize; first*rangeChunkSize < end; first++ {
+		if s.loaded[first] {
+			continue
+		}
+		if read, ok := s.pending[first]; ok {
+			waiting = append(waiting, read)
+			continue
+		}
+		// Read runs of missing chunks in one request
+		last := first
+		for (last+1)*rangeChunkSize < end && !s.loaded[last+1] && s.pending[last+1] == nil {
+			last++
+		}
+		read := &rangeRead{first: first, last: last, done: make(chan struct{})}
+		for i := first; i <= last; i++ {
+			s.pending[i] = read
+		}
+		claimed = append(claimed, read)
+		first = last
+	}
+	s.mu.Unlock()
+
+	var err error
+	for _, read := range claimed {
+		from := read.first * rangeChunkSize
+		to := min((read.last+1)*rangeChunkSize, int64(len(s.data)))
+		if err == nil {
+			if _, rerr := s.src.ReadAt(s.data[from:to], from); rerr != nil && rerr != io.EOF {
+				err = fmt.Errorf("failed to read bytes %d-%d: %w", from, to-1, rerr)
+			}
+		}
+
+		s.mu.Lock()
+		for i := read.first; i <= read.last; i++ {
+			delete(s.pending, i)
+			s.loaded[i] = err == nil
+		}
+		s.mu.Unlock()
+		read.err = err
+		close(read.done)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, read := range waiting {
+		<-read.done
+		if read.err != nil {
+			return read.err
+		}
+	}
+	return nil
+}
+
+// window returns the file data up to the end of the loaded chunks that
+// follow offset.
+func (s *rangeSource) window(offset int64) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chunk := offset / rangeChunkSize
+	for chunk < int64(len(s.loaded)) && s.loaded[chunk] {
+		chunk++
+	}
+	return s.data[:min(chunk*rangeChunkSize, int64(len(s.data)))]
+}
+
+// ensure makes [offset, offset+length) of the file available.
+func (r *Reader) ensure(offset, length int64) error {
+	if r.source == nil {
+		return nil
+	}
+	return r.source.load(offset, length)
+}
+
+// parseAt runs parse on the file data for something starting at offset.
+// parse returns the offset just past what it parsed. If the data may have
+// been cut short, more of the file is read and parse runs again.
+func (r *Reader) parseAt(offset int64, parse func(data []byte) (int64, error)) error {
+	if r.source == nil {
+		_, err := parse(r.data)
+		return err
+	}
+
+	length := int64(rangeChunkSize)
+	for {
+		if err := r.source.load(offset, length); err != nil {
+			return err
+		}
+		window := r.source.window(offset)
+		end, err := parse(window)
+		complete := end < int64(len(window)) && err == nil
+		if complete || len(window) == len(r.data) {
+			return err
+		}
+		length = 2 * (int64(len(window)) - offset)
+	}
+}
+
+// Bytes returns the whole file, reading any parts not loaded yet.
+func (r *Reader) Bytes() ([]byte, error) {
+	if err := r.ensure(0, int64(len(r.data))); err != nil {
+		return nil, err
+	}
+	return r.data, nil
+}
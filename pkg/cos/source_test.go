@@ -0,0 +1,73 @@
+package cos
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingRange is a RangeReader whose reads wait until release is closed.
+type blockingRange struct {
+	size    int64
+	started chan int64
+	release chan struct{}
+	reads   atomic.Int32
+}
+
+func (b *blockingRange) Size() int64 { return b.size }
+
+func (b *blockingRange) ReadAt(p []byte, off int64) (int, error) {
+	b.reads.Add(1)
+	b.started <- off
+	<-b.release
+	return len(p), nil
+}
+
+func newTestRangeSource(src *blockingRange) *rangeSource {
+	return &rangeSource{
+		src:     src,
+		data:    make([]byte, src.size),
+		loaded:  make([]bool, (src.size+rangeChunkSize-1)/rangeChunkSize),
+		pending: make(map[int64]*rangeRead),
+	}
+}
+
+func TestRangeSourceConcurrentLoads(t *testing.T) {
+	src := &blockingRange{
+		size:    4 * rangeChunkSize,
+		started: make(chan int64, 4),
+		release: make(chan struct{}),
+	}
+	s := newTestRangeSource(src)
+
+	// Two callers for chunk 0 and one for chunk 2
+	var wg sync.WaitGroup
+	for _, offset := range []int64{0, 0, 2 * rangeChunkSize} {
+		wg.Add(1)
+		go func(offset int64) {
+			defer wg.Done()
+			if err := s.load(offset, 1); err != nil {
+				t.Errorf("load(%d): %v", offset, err)
+			}
+		}(offset)
+	}
+
+	// Both chunks must be requested before either read returns
+	for i := 0; i < 2; i++ {
+		select {
+		case <-src.started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("reads of different chunks did not run at the same time")
+		}
+	}
+	close(src.release)
+	wg.Wait()
+
+	if got := src.reads.Load(); got != 2 {
+		t.Errorf("%d reads, want 2: callers of a chunk being read should share it", got)
+	}
+	if !s.loaded[0] || s.loaded[1] || !s.loaded[2] {
+		t.Errorf("loaded = %v, want chunks 0 and 2", s.loaded)
+	}
+}
@@ -3,6 +3,8 @@
 // can reference each other by ID.
 package cos
 
+import "fmt"
+
 // TokenType represents the type of a PDF token.
 type TokenType int
 
@@ -44,7 +46,20 @@ type Token struct {
 	Int     int64
 	Float   float64
 	IsFloat bool
-	Pos     int64 // Byte position in file
+	Pos     Position
+}
+
+// Position is the location of a token in the lexer input. Lines and
+// columns count from 1; a line ends at each \n byte.
+type Position struct {
+	Offset int64 // Byte offset
+	Line   int
+	Column int
+}
+
+// String returns the position as "line N, column M".
+func (p Position) String() string {
+	return fmt.Sprintf("line %d, column %d", p.Line, p.Column)
 }
 
 // String returns a human-readable representation of the token type.
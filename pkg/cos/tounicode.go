@@ -0,0 +1,113 @@
+package cos
+
+import (
+	"encoding/hex"
+	"fmt"
+	"unicode/utf16"
+)
+
+// ToUnicode maps character codes of a font to the text they represent.
+type ToUnicode struct {
+	text map[uint32]string
+}
+
+// FontToUnicode reads the ToUnicode CMap of a font. It returns nil if the
+// font has none.
+func (r *Reader) FontToUnicode(fontDict Dict) (*ToUnicode, error) {
+	obj, err := r.Resolve(fontDict.Get("ToUnicode"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve ToUnicode: %w", err)
+	}
+	s, ok := obj.(*Stream)
+	if !ok {
+		return nil, nil
+	}
+	data, err := r.DecodeStream(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode ToUnicode: %w", err)
+	}
+	return ParseToUnicode(data), nil
+}
+
+// ParseToUnicode reads the bfchar and bfrange sections of a ToUnicode CMap.
+// Destinations are UTF-16BE strings; a range with a string destination
+// increments its last code unit, and one with an array of strings lists
+// the text of each code.
+func ParseToUnicode(data []byte) *ToUnicode {
+	m := &ToUnicode{text: make(map[uint32]string)}
+	tokens := tokenizeCMap(data)
+
+	hexBytes := func(tok string) ([]byte, bool) {
+		if len(tok) < 2 || tok[0] != '<' || tok[len(tok)-1] != '>' {
+			return nil, false
+		}
+		digits := tok[1 : len(tok)-1]
+		if len(digits)%2 == 1 {
+			digits += "0"
+		}
+		b, err := hex.DecodeString(digits)
+		return b, err == nil
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "beginbfchar":
+			for i+2 < len(tokens) && tokens[i+1] != "endbfchar" {
+				code, ok1 := hexBytes(tokens[i+1])
+				dst, ok2 := hexBytes(tokens[i+2])
+				if ok1 && ok2 && len(code) <= 4 {
+					m.text[bytesToCode(code)] = decodeUTF16(dst)
+				}
+				i += 2
+			}
+		case "beginbfrange":
+			for i+3 < len(tokens) && tokens[i+1] != "endbfrange" {
+				low, ok1 := hexBytes(tokens[i+1])
+				high, ok2 := hexBytes(tokens[i+2])
+				if !ok1 || !ok2 || len(low) > 4 || len(high) > 4 {
+					i += 3
+					continue
+				}
+				first, last := bytesToCode(low), bytesToCode(high)
+				if tokens[i+3] == "[" {
+					// One destination string per code
+					i += 4
+					for code := first; i < len(tokens) && tokens[i] != "]"; i++ {
+						if dst, ok := hexBytes(tokens[i]); ok && code <= last {
+							m.text[code] = decodeUTF16(dst)
+						}
+						code++
+					}
+					continue
+				}
+				if dst, ok := hexBytes(tokens[i+3]); ok && len(dst) >= 2 && last >= first && last-first < 0x10000 {
+					for code := first; code <= last; code++ {
+						m.text[code] = decodeUTF16(dst)
+						// The last code unit increments for each code
+						dst = append([]byte(nil), dst...)
+						n := len(dst)
+						unit := uint16(dst[n-2])<<8 | uint16(dst[n-1]) + 1
+						dst[n-2], dst[n-1] = byte(unit>>8), byte(unit)
+					}
+				}
+				i += 3
+			}
+		}
+	}
+	return m
+}
+
+// Text returns the text of a character code.
+func (m *ToUnicode) Text(code uint32) (string, bool) {
+	s, ok := m.text[code]
+	return s, ok
+}
+
+// decodeUTF16 decodes UTF-16BE text. An odd trailing byte is dropped.
+func decodeUTF16(b []byte) string {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
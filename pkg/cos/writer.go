@@ -0,0 +1,245 @@
+package cos
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+
+	"gumgum/pkg/stream"
+)
+
+// StreamOptions controls how a stream is written.
+type StreamOptions struct {
+	// Compress applies FlateDecode to streams that have no Filter yet.
+	Compress bool
+
+	// Level is the zlib compression level used when Compress is set, from
+	// 0 (stored uncompressed) to 9, or zlib.DefaultCompression (-1).
+	Level int
+}
+
+// Writer writes a PDF file object by object.
+type Writer struct {
+	buf     *bufio.Writer
+	out     *countingWriter // Wraps buf, tracking the file offset
+	offsets map[int]int64   // Byte offset of each written object
+	nextObj int
+
+	// Offset of the previous cross-reference section when appending an
+	// incremental update, zero when writing a whole file
+	prevXref int64
+}
+
+// NewWriter creates a Writer and writes the PDF header.
+func NewWriter(w io.Writer, version string) (*Writer, error) {
+	buf := bufio.NewWriter(w)
+	pw := &Writer{
+		buf:     buf,
+		out:     &countingWriter{w: buf},
+		offsets: make(map[int]int64),
+		nextObj: 1,
+	}
+
+	// The binary comment marks the file as binary for transfer tools
+	if err := pw.writeString("%PDF-" + version + "\n%\xE2\xE3\xCF\xD3\n"); err != nil {
+		return nil, err
+	}
+
+	return pw, nil
+}
+
+// NewIncrementalWriter creates a Writer that appends an incremental update
+// to original, a complete PDF whose last cross-reference section is at
+// prevXref and whose trailer Size is size. New objects are numbered from
+// size; writing an existing object number replaces that object.
+func NewIncrementalWriter(w io.Writer, original []byte, size int, prevXref int64) (*Writer, error) {
+	buf := bufio.NewWriter(w)
+	pw := &Writer{
+		buf:      buf,
+		out:      &countingWriter{w: buf},
+		offsets:  make(map[int]int64),
+		nextObj:  size,
+		prevXref: prevXref,
+	}
+
+	if _, err := pw.out.Write(original); err != nil {
+		return nil, err
+	}
+	// The update must start on a new line
+	if len(original) > 0 && original[len(original)-1] != '\n' && original[len(original)-1] != '\r' {
+		if err := pw.writeString("\n"); err != nil {
+			return nil, err
+		}
+	}
+
+	return pw, nil
+}
+
+// Reserve allocates an object number without writing the object. Use it for
+// objects that must be referenced before they are written.
+func (w *Writer) Reserve() *Reference {
+	ref := &Reference{ObjectNumber: w.nextObj}
+	w.nextObj++
+	return ref
+}
+
+// AddObject writes an object under a new object number.
+func (w *Writer) AddObject(obj Object) (*Reference, error) {
+	ref := w.Reserve()
+	if err := w.WriteObject(ref.ObjectNumber, obj); err != nil {
+		return nil, err
+	}
+	return ref, nil
+}
+
+// WriteObject writes an indirect object with the given number.
+func (w *Writer) WriteObject(objNum int, obj Object) error {
+	if objNum >= w.nextObj {
+		w.nextObj = objNum + 1
+	}
+	w.offsets[objNum] = w.out.n
+
+	if err := w.writeString(fmt.Sprintf("%d 0 obj\n", objNum)); err != nil {
+		return err
+	}
+	if err := Serialize(obj, w.out); err != nil {
+		return err
+	}
+	return w.writeString("\nendobj\n")
+}
+
+// WriteStream writes a stream object under a new object number. The Length
+// entry is set automatically.
+func (w *Writer) WriteStream(dict Dict, data []byte, opts StreamOptions) (*Reference, error) {
+	streamDict := make(Dict, len(dict)+2)
+	for k, v := range dict {
+		streamDict[k] = v
+	}
+
+	if opts.Compress && streamDict.Get("Filter") == nil {
+		compressed, err := stream.EncodeFlateDecode(data, opts.Level)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compress stream: %w", err)
+		}
+		data = compressed
+		streamDict[Name("Filter")] = Name("FlateDecode")
+	}
+
+	streamDict[Name("Length")] = Integer(len(data))
+
+	return w.AddObject(&Stream{Dict: streamDict, Data: data})
+}
+
+// Close writes the cross-reference table and trailer and flushes output.
+// Size is filled in automatically, and so is Prev for incremental updates.
+func (w *Writer) Close(trailer Dict) error {
+	xrefOffset := w.out.n
+
+	size := w.nextObj
+	var err error
+	if w.prevXref > 0 {
+		err = w.writeUpdateXref()
+	} else {
+		err = w.writeXref(size)
+	}
+	if err != nil {
+		return err
+	}
+
+	final := make(Dict, len(trailer)+1)
+	for k, v := range trailer {
+		final[k] = v
+	}
+	final[Name("Size")] = Integer(size)
+	if w.prevXref > 0 {
+		final[Name("Prev")] = Integer(w.prevXref)
+	} else {
+		delete(final, Name("Prev"))
+	}
+
+	if err := w.writeString("trailer\n"); err != nil {
+		return err
+	}
+	if err := Serialize(final, w.out); err != nil {
+		return err
+	}
+	if err := w.writeString(fmt.Sprintf("\nstartxref\n%d\n%%%%EOF\n", xrefOffset)); err != nil {
+		return err
+	}
+
+	return w.buf.Flush()
+}
+
+// writeXref writes a cross-reference table covering every object number
+// below size.
+func (w *Writer) writeXref(size int) error {
+	if err := w.writeString(fmt.Sprintf("xref\n0 %d\n", size)); err != nil {
+		return err
+	}
+	if err := w.writeString("0000000000 65535 f \n"); err != nil {
+		return err
+	}
+
+	for objNum := 1; objNum < size; objNum++ {
+		var entry string
+		if offset, ok := w.offsets[objNum]; ok {
+			entry = fmt.Sprintf("%010d 00000 n \n", offset)
+		} else {
+			// Reserved but never written
+			entry = "0000000000 65535 f \n"
+		}
+		if err := w.writeString(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeUpdateXref writes a cross-reference table listing only the objects
+// written, one subsection per run of consecutive object numbers.
+func (w *Writer) writeUpdateXref() error {
+	nums := make([]int, 0, len(w.offsets))
+	for objNum := range w.offsets {
+		nums = append(nums, objNum)
+	}
+	sort.Ints(nums)
+
+	if err := w.writeString("xref\n"); err != nil {
+		return err
+	}
+	for start := 0; start < len(nums); {
+		end := start + 1
+		for end < len(nums) && nums[end] == nums[end-1]+1 {
+			end++
+		}
+		if err := w.writeString(fmt.Sprintf("%d %d\n", nums[start], end-start)); err != nil {
+			return err
+		}
+		for _, objNum := range nums[start:end] {
+			if err := w.writeString(fmt.Sprintf("%010d 00000 n \n", w.offsets[objNum])); err != nil {
+				return err
+			}
+		}
+		start = end
+	}
+	return nil
+}
+
+// writeString writes raw bytes and tracks the file offset.
+func (w *Writer) writeString(s string) error {
+	_, err := io.WriteString(w.out, s)
+	return err
+}
+
+// countingWriter tracks the number of bytes written, giving object offsets.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
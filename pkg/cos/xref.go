@@ -74,7 +74,13 @@ func findStartXref(data []byte) (int64, error) {
 }
 
 // parseXrefTable parses a traditional xref table (not a stream).
-func parseXrefTable(data []byte, offset int64) (*XrefTable, error) {
+func parseXrefTable(data []byte, offset int64) (*XrefTable, int64, error) {
+	return scanXrefTable(data, offset, true)
+}
+
+// scanXrefTable parses a traditional xref table, skipping its entries
+// unless withEntries is set.
+func scanXrefTable(data []byte, offset int64, withEntries bool) (*XrefTable, int64, error) {
 	table := NewXrefTable()
 	pos := int(offset)
 
@@ -85,7 +91,7 @@ func parseXrefTable(data []byte, offset int64) (*XrefTable, error) {
 
 	if pos+4 > len(data) || string(data[pos:pos+4]) != "xref" {
 		// Might be an xref stream instead
-		return nil, fmt.Errorf("xref keyword not found at offset %d", offset)
+		return nil, 0, fmt.Errorf("xref keyword not found at offset %d", offset)
 	}
 	pos += 4
 
@@ -108,7 +114,8 @@ func parseXrefTable(data []byte, offset int64) (*XrefTable, error) {
 
 		// Parse "start count" line
 		var startObj, count int
-		n, err := fmt.Sscanf(string(data[pos:]), "%d %d", &startObj, &count)
+		// Only the line is converted; the rest of the file may be large
+		n, err := fmt.Sscanf(string(data[pos:min(pos+64, len(data))]), "%d %d", &startObj, &count)
 		if err != nil || n != 2 {
 			break
 		}
@@ -121,6 +128,11 @@ func parseXrefTable(data []byte, offset int64) (*XrefTable, error) {
 			pos++
 		}
 
+		if !withEntries {
+			pos = min(pos+20*count, len(data))
+			continue
+		}
+
 		// Parse entries
 		for i := 0; i < count && pos < len(data); i++ {
 			// Each entry is exactly 20 bytes: "nnnnnnnnnn ggggg n \n"
@@ -163,45 +175,84 @@ func parseXrefTable(data []byte, offset int64) (*XrefTable, error) {
 
 	lexer := NewLexer(data[pos:])
 	parser := &Parser{lexer: lexer}
+	// Without a trailer the table may have been cut short
+	end := int64(len(data))
 	if obj, err := parser.ParseObject(); err == nil {
 		if dict, ok := obj.(Dict); ok {
 			table.Trailer = dict
+			end = int64(pos + lexer.Position())
 		}
 	}
 
-	return table, nil
+	return table, end, nil
 }
 
 // ParseXref attempts to parse the xref table or stream at the given offset.
 func ParseXref(data []byte, offset int64) (*XrefTable, error) {
+	table, _, err := parseXref(data, offset)
+	return table, err
+}
+
+// parseXref parses the xref table or stream at the given offset and
+// returns the offset just past it.
+func parseXref(data []byte, offset int64) (*XrefTable, int64, error) {
+	if offset < 0 || offset >= int64(len(data)) {
+		return nil, 0, fmt.Errorf("xref offset %d out of range", offset)
+	}
+
 	// First try traditional xref table
-	table, err := parseXrefTable(data, offset)
+	table, end, err := parseXrefTable(data, offset)
 	if err == nil {
-		return table, nil
+		return table, end, nil
 	}
 
 	// If that fails, try xref stream (PDF 1.5+)
 	return parseXrefStream(data, offset)
 }
 
-// parseXrefStream parses an xref stream (PDF 1.5+).
-func parseXrefStream(data []byte, offset int64) (*XrefTable, error) {
-	// Position at the object
-	lexer := NewLexer(data[offset:])
-	parser := &Parser{lexer: lexer}
+// parseXrefTrailer reads the trailer of the xref table or stream at the
+// given offset without parsing its entries, which is much quicker, and
+// returns the offset just past the section. For an xref stream, whose
+// dictionary is the trailer, the still encoded stream is returned too.
+func parseXrefTrailer(data []byte, offset int64) (Dict, *Stream, int64, error) {
+	if offset < 0 || offset >= int64(len(data)) {
+		return nil, nil, 0, fmt.Errorf("xref offset %d out of range", offset)
+	}
+
+	if table, end, err := scanXrefTable(data, offset, false); err == nil {
+		return table.Trailer, nil, end, nil
+	}
 
+	indirect, end, err := parseObjectAt(data, offset)
+	if err != nil {
+		return nil, nil, end, fmt.Errorf("failed to parse xref stream object: %w", err)
+	}
+	stream, ok := indirect.Object.(*Stream)
+	if !ok {
+		return nil, nil, end, fmt.Errorf("expected stream at xref stream offset")
+	}
+	return stream.Dict, stream, end, nil
+}
+
+// parseXrefStream parses an xref stream (PDF 1.5+).
+func parseXrefStream(data []byte, offset int64) (*XrefTable, int64, error) {
 	// Parse the indirect object
-	indirect, err := parser.ParseIndirectObject()
+	indirect, end, err := parseObjectAt(data, offset)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse xref stream object: %w", err)
+		return nil, end, fmt.Errorf("failed to parse xref stream object: %w", err)
 	}
 
 	stream, ok := indirect.Object.(*Stream)
 	if !ok {
-		return nil, fmt.Errorf("expected stream at xref stream offset")
+		return nil, end, fmt.Errorf("expected stream at xref stream offset")
 	}
 
-	// Decompress the stream data before parsing
+	table, err := xrefFromStream(stream)
+	return table, end, err
+}
+
+// xrefFromStream decompresses an xref stream and decodes its entries.
+func xrefFromStream(stream *Stream) (*XrefTable, error) {
 	decodedData, err := decodeStreamData(stream)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode xref stream: %w", err)
@@ -222,9 +273,14 @@ func decodeXrefStream(stream *Stream) (*XrefTable, error) {
 		return nil, fmt.Errorf("missing or invalid W array in xref stream")
 	}
 
+	// Fields are big-endian numbers of at most 8 bytes; negative widths
+	// would make entries shorter than the bytes read for them
 	var w [3]int
 	for i := 0; i < 3; i++ {
 		if n, ok := wArray[i].(Integer); ok {
+			if n < 0 || n > 8 {
+				return nil, fmt.Errorf("invalid W array: field width %d", n)
+			}
 			w[i] = int(n)
 		}
 	}
@@ -373,66 +429,48 @@ func decodeFlate(data []byte, dict Dict) ([]byte, error) {
 
 	columns, _ := paramsDict.GetInt("Columns")
 	if columns == 0 {
-		columns = 1
+		columns = xrefEntrySize(dict)
 	}
 
 	// PNG predictor (10-15)
 	if predictor >= 10 {
-		return applyPNGPredictorXref(decoded, int(columns))
+		return applyPNGPredictorXref(decoded, int(columns), paramsDict)
 	}
 
 	return decoded, nil
 }
 
-// applyPNGPredictorXref decodes PNG-filtered data for xref streams.
-func applyPNGPredictorXref(data []byte, columns int) ([]byte, error) {
-	rowSize := columns
-	inputRowSize := rowSize + 1 // +1 for filter byte
-
-	if len(data) == 0 || inputRowSize == 0 {
-		return data, nil
-	}
-
-	numRows := len(data) / inputRowSize
-	if numRows == 0 {
-		return data, nil
+// xrefEntrySize returns the byte size of one xref stream entry, the sum of
+// the W field widths, or 1 if W is missing.
+func xrefEntrySize(dict Dict) int64 {
+	wArray, ok := dict.GetArray("W")
+	if !ok {
+		return 1
 	}
-
-	result := make([]byte, 0, numRows*rowSize)
-	prevRow := make([]byte, rowSize)
-
-	for row := 0; row < numRows; row++ {
-		start := row * inputRowSize
-		if start >= len(data) {
-			break
-		}
-
-		filterType := data[start]
-		rowData := make([]byte, rowSize)
-		
-		srcStart := start + 1
-		srcEnd := srcStart + rowSize
-		if srcEnd > len(data) {
-			srcEnd = len(data)
-		}
-		copy(rowData, data[srcStart:srcEnd])
-
-		switch filterType {
-		case 0: // None
-			// No change
-		case 1: // Sub
-			for i := 1; i < len(rowData); i++ {
-				rowData[i] += rowData[i-1]
-			}
-		case 2: // Up
-			for i := 0; i < len(rowData); i++ {
-				rowData[i] += prevRow[i]
-			}
+	var size int64
+	for _, v := range wArray {
+		if n, ok := v.(Integer); ok && n > 0 {
+			size += int64(n)
 		}
-
-		result = append(result, rowData...)
-		copy(prevRow, rowData)
 	}
+	if size == 0 {
+		return 1
+	}
+	return size
+}
 
-	return result, nil
+// applyPNGPredictorXref decodes PNG-filtered data for xref streams. Each
+// row is one entry of Columns bytes. All five PNG filter types are
+// supported; Sub, Average and Paeth look back one pixel of
+// Colors * BitsPerComponent bits, which is one byte by default.
+func applyPNGPredictorXref(data []byte, columns int, params Dict) ([]byte, error) {
+	colors, _ := params.GetInt("Colors")
+	if colors <= 0 {
+		colors = 1
+	}
+	bpc, _ := params.GetInt("BitsPerComponent")
+	if bpc <= 0 {
+		bpc = 8
+	}
+	return applyPNGPredictor(data, columns, int(colors), int(bpc))
 }
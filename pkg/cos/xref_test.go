@@ -0,0 +1,25 @@
+package cos
+
+import "testing"
+
+func TestDecodeXrefStreamRejectsBadWidths(t *testing.T) {
+	tests := []struct {
+		name string
+		w    Array
+	}{
+		{"negative", Array{Integer(1), Integer(-2), Integer(1)}},
+		{"too wide", Array{Integer(1), Integer(9), Integer(1)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := &Stream{
+				Dict: Dict{"W": tt.w, "Size": Integer(4)},
+				Data: make([]byte, 56),
+			}
+			if _, err := decodeXrefStream(stream); err == nil {
+				t.Fatalf("decodeXrefStream with W %v: expected error", tt.w)
+			}
+		})
+	}
+}
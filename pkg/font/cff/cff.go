@@ -0,0 +1,309 @@
+// Package cff parses Compact Font Format (CFF) fonts, as embedded in PDFs
+// (FontFile3 with Subtype Type1C or CIDFontType0C) or wrapped in an
+// OpenType file, and converts their Type 2 charstrings to paths.
+package cff
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"gumgum/pkg/font/glyphlist"
+	"gumgum/pkg/graphics"
+)
+
+// Font is a parsed CFF font.
+type Font struct {
+	Name       string
+	FontMatrix graphics.Matrix
+	UnitsPerEm uint16
+	NumGlyphs  int
+
+	// IsCID reports a CID-keyed font, whose charset holds CIDs instead of
+	// glyph names.
+	IsCID bool
+
+	strings     [][]byte
+	charStrings [][]byte
+	globalSubrs [][]byte
+	privates    []privateDict
+	fdSelect    []uint8 // Private dict of each glyph (CID fonts only)
+	charset     []uint16
+	glyphIDs    map[rune]uint16
+}
+
+// privateDict holds the Private DICT values used by charstrings.
+type privateDict struct {
+	subrs         [][]byte
+	defaultWidthX float64
+	nominalWidthX float64
+}
+
+// Glyph is a glyph outline in font units, y up.
+type Glyph struct {
+	Path    *graphics.Path
+	Advance float64
+}
+
+// DICT operators, with two-byte operators as 1200 + second byte.
+const (
+	opCharset     = 15
+	opCharStrings = 17
+	opPrivate     = 18
+	opSubrs       = 19
+	opDefaultWX   = 20
+	opNominalWX   = 21
+	opFontMatrix  = 1207
+	opROS         = 1230
+	opFDArray     = 1236
+	opFDSelect    = 1237
+)
+
+// Parse parses a bare CFF font or an OpenType font with a CFF table.
+func Parse(data []byte) (*Font, error) {
+	if len(data) >= 4 && string(data[:4]) == "OTTO" {
+		table, err := findTable(data, "CFF ")
+		if err != nil {
+			return nil, err
+		}
+		data = table
+	}
+	if len(data) < 4 {
+		return nil, fmt.Errorf("CFF data too short")
+	}
+	if data[0] != 1 {
+		return nil, fmt.Errorf("unsupported CFF version %d", data[0])
+	}
+
+	p := &parser{data: data, pos: int(data[2])}
+	names, err := p.index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Name INDEX: %w", err)
+	}
+	topDicts, err := p.index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Top DICT INDEX: %w", err)
+	}
+	strs, err := p.index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read String INDEX: %w", err)
+	}
+	gsubrs, err := p.index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Global Subr INDEX: %w", err)
+	}
+	if len(names) == 0 || len(topDicts) == 0 {
+		return nil, fmt.Errorf("CFF has no fonts")
+	}
+
+	f := &Font{
+		Name:        string(names[0]),
+		FontMatrix:  graphics.Matrix{0.001, 0, 0, 0.001, 0, 0},
+		strings:     strs,
+		globalSubrs: gsubrs,
+	}
+
+	top, err := parseDict(topDicts[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Top DICT: %w", err)
+	}
+	if m := top[opFontMatrix]; len(m) == 6 {
+		copy(f.FontMatrix[:], m)
+	}
+	f.UnitsPerEm = 1000
+	if f.FontMatrix[0] > 0 {
+		f.UnitsPerEm = uint16(math.Round(1 / f.FontMatrix[0]))
+	}
+
+	csOffset, ok := top.int(opCharStrings)
+	if !ok {
+		return nil, fmt.Errorf("CFF has no CharStrings")
+	}
+	if f.charStrings, err = p.indexAt(csOffset); err != nil {
+		return nil, fmt.Errorf("failed to read CharStrings INDEX: %w", err)
+	}
+	f.NumGlyphs = len(f.charStrings)
+
+	_, f.IsCID = top[opROS]
+	if f.IsCID {
+		err = f.parseCIDPrivates(p, top)
+	} else {
+		var private privateDict
+		private, err = p.privateDict(top)
+		f.privates = []privateDict{private}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	charsetOffset, _ := top.int(opCharset)
+	if f.charset, err = p.charset(charsetOffset, f.NumGlyphs); err != nil {
+		return nil, fmt.Errorf("failed to read charset: %w", err)
+	}
+	f.buildGlyphIDs()
+
+	return f, nil
+}
+
+// parseCIDPrivates reads the Private DICT of each Font DICT in FDArray and
+// the FDSelect table choosing one for each glyph.
+func (f *Font) parseCIDPrivates(p *parser, top dict) error {
+	fdArrayOffset, ok := top.int(opFDArray)
+	if !ok {
+		return fmt.Errorf("CID font has no FDArray")
+	}
+	fds, err := p.indexAt(fdArrayOffset)
+	if err != nil {
+		return fmt.Errorf("failed to read FDArray: %w", err)
+	}
+	for i, fd := range fds {
+		fdDict, err := parseDict(fd)
+		if err != nil {
+			return fmt.Errorf("failed to parse Font DICT %d: %w", i, err)
+		}
+		private, err := p.privateDict(fdDict)
+		if err != nil {
+			return err
+		}
+		f.privates = append(f.privates, private)
+	}
+	if len(f.privates) == 0 {
+		return fmt.Errorf("CID font has an empty FDArray")
+	}
+
+	fdSelectOffset, ok := top.int(opFDSelect)
+	if !ok {
+		return fmt.Errorf("CID font has no FDSelect")
+	}
+	if f.fdSelect, err = p.fdSelect(fdSelectOffset, f.NumGlyphs); err != nil {
+		return fmt.Errorf("failed to read FDSelect: %w", err)
+	}
+	return nil
+}
+
+// buildGlyphIDs maps code points to glyphs by their names in the charset.
+func (f *Font) buildGlyphIDs() {
+	f.glyphIDs = make(map[rune]uint16)
+	if f.IsCID {
+		return
+	}
+	for gid := f.NumGlyphs - 1; gid > 0; gid-- {
+		if r, ok := nameToUnicode(f.GlyphName(uint16(gid))); ok {
+			f.glyphIDs[r] = uint16(gid)
+		}
+	}
+}
+
+// nameToUnicode resolves a glyph name with the Adobe Glyph List or the
+// uniXXXX and uXXXX[XX] naming conventions.
+func nameToUnicode(name string) (rune, bool) {
+	if r, ok := glyphlist.ToUnicode(name); ok {
+		return r, true
+	}
+	var hex string
+	switch {
+	case strings.HasPrefix(name, "uni") && len(name) == 7:
+		hex = name[3:]
+	case strings.HasPrefix(name, "u") && len(name) >= 5 && len(name) <= 7:
+		hex = name[1:]
+	default:
+		return 0, false
+	}
+	cp, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	return rune(cp), true
+}
+
+// GetGlyphID returns the glyph for a Unicode code point, or 0 (.notdef).
+// CID-keyed fonts have no glyph names, so they always return 0; use
+// GlyphForCID instead.
+func (f *Font) GetGlyphID(r rune) uint16 {
+	return f.glyphIDs[r]
+}
+
+// GlyphForCID returns the glyph of a CID in a CID-keyed font, or 0.
+func (f *Font) GlyphForCID(cid uint16) uint16 {
+	if !f.IsCID {
+		return 0
+	}
+	for gid, c := range f.charset {
+		if c == cid {
+			return uint16(gid)
+		}
+	}
+	return 0
+}
+
+// GlyphName returns the name of a glyph in a name-keyed font.
+func (f *Font) GlyphName(glyphID uint16) string {
+	if f.IsCID || int(glyphID) >= len(f.charset) {
+		return ""
+	}
+	return f.stringAt(f.charset[glyphID])
+}
+
+// stringAt returns a string by its SID.
+func (f *Font) stringAt(sid uint16) string {
+	if int(sid) < len(standardStrings) {
+		return standardStrings[sid]
+	}
+	if i := int(sid) - len(standardStrings); i < len(f.strings) {
+		return string(f.strings[i])
+	}
+	return ""
+}
+
+// GetGlyph interprets the charstring of a glyph.
+func (f *Font) GetGlyph(glyphID uint16) (*Glyph, error) {
+	if int(glyphID) >= len(f.charStrings) {
+		return nil, fmt.Errorf("glyph %d out of range", glyphID)
+	}
+	private := f.privates[0]
+	if f.fdSelect != nil {
+		fd := int(f.fdSelect[glyphID])
+		if fd >= len(f.privates) {
+			return nil, fmt.Errorf("glyph %d uses missing Font DICT %d", glyphID, fd)
+		}
+		private = f.privates[fd]
+	}
+
+	interp := &charstringInterpreter{
+		font:    f,
+		private: private,
+		path:    graphics.NewPath(),
+		width:   private.defaultWidthX,
+	}
+	if err := interp.run(f.charStrings[glyphID], 0); err != nil {
+		return nil, fmt.Errorf("glyph %d: %w", glyphID, err)
+	}
+	interp.closePath()
+	return &Glyph{Path: interp.path, Advance: interp.width}, nil
+}
+
+// findTable returns a table of an OpenType font.
+func findTable(data []byte, tag string) ([]byte, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("OpenType data too short")
+	}
+	numTables := int(binary.BigEndian.Uint16(data[4:6]))
+	for i := 0; i < numTables; i++ {
+		entry := 12 + 16*i
+		if entry+16 > len(data) {
+			break
+		}
+		if string(data[entry:entry+4]) != tag {
+			continue
+		}
+		offset := int(binary.BigEndian.Uint32(data[entry+8:]))
+		length := int(binary.BigEndian.Uint32(data[entry+12:]))
+		if offset < 0 || length < 0 || offset > len(data) || length > len(data)-offset {
+			return nil, fmt.Errorf("table %q out of range", tag)
+		}
+		return data[offset : offset+length], nil
+	}
+	return nil, fmt.Errorf("table %q not found", tag)
+}
@@ -0,0 +1,140 @@
+package cff
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"gumgum/pkg/graphics"
+)
+
+// cffTestFont is CFFTest.otf from golang.org/x/image/font/testdata, an
+// OpenType font with a CFF table holding .notdef, zero, one, Q and uni4E2D.
+const cffTestFont = "testdata/CFFTest.otf"
+
+// readTestFont returns the data of cffTestFont.
+func readTestFont(t testing.TB) []byte {
+	t.Helper()
+	data, err := os.ReadFile(cffTestFont)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// outline describes a path as moveto, lineto, curveto and closepath
+// operators with their points, such as "M 0 0 L 10 0 Z".
+func outline(p *graphics.Path) string {
+	var sb strings.Builder
+	for _, seg := range p.Segments {
+		if sb.Len() > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteByte("MLCZ"[seg.Op])
+		for _, pt := range seg.Points {
+			fmt.Fprintf(&sb, " %g %g", pt.X, pt.Y)
+		}
+	}
+	return sb.String()
+}
+
+func TestParseOpenType(t *testing.T) {
+	f, err := Parse(readTestFont(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Name != "CFFTest" || f.NumGlyphs != 5 || f.IsCID || f.UnitsPerEm != 1000 {
+		t.Errorf("font %q has %d glyphs, IsCID %v, UnitsPerEm %d; want CFFTest, 5, false, 1000",
+			f.Name, f.NumGlyphs, f.IsCID, f.UnitsPerEm)
+	}
+}
+
+func TestGlyphOutlines(t *testing.T) {
+	f, err := Parse(readTestFont(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The outlines match those golang.org/x/image/font/sfnt reads from the
+	// same font, with each contour closed by Z
+	tests := []struct {
+		r       rune
+		name    string
+		advance float64
+		want    string
+	}{
+		{'0', "zero", 600, "M 300 700 C 380 700 420 580 420 500 C 420 350 390 100 300 100 " +
+			"C 220 100 180 220 180 300 C 180 450 210 700 300 700 Z " +
+			"M 300 800 C 200 800 100 580 100 400 C 100 220 200 0 300 0 " +
+			"C 400 0 500 220 500 400 C 500 580 400 800 300 800 Z"},
+		{'1', "one", 400, "M 100 0 L 300 0 L 300 800 L 100 800 Z"},
+		{'Q', "Q", 1000, "M 657 237 L 289 387 L 519 615 Z " +
+			"M 792 169 C 867 263 926 502 791 665 C 645 840 380 831 228 673 " +
+			"C 71 509 110 231 242 93 C 369 -39 641 18 722 93 L 802 3 L 864 83 Z"},
+		{0x4E2D, "uni4E2D", 600, "M 141 520 L 137 356 L 245 400 L 331 26 L 355 414 " +
+			"L 463 434 L 453 620 L 341 592 L 331 758 L 243 752 L 235 562 Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gid := f.GetGlyphID(tt.r)
+			if gid == 0 {
+				t.Fatalf("GetGlyphID(%q) = 0", tt.r)
+			}
+			if name := f.GlyphName(gid); name != tt.name {
+				t.Errorf("GlyphName(%d) = %q, want %q", gid, name, tt.name)
+			}
+
+			glyph, err := f.GetGlyph(gid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if glyph.Advance != tt.advance {
+				t.Errorf("Advance = %g, want %g", glyph.Advance, tt.advance)
+			}
+			if got := outline(glyph.Path); got != tt.want {
+				t.Errorf("outline:\n got %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGlyphOutOfRange(t *testing.T) {
+	f, err := Parse(readTestFont(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.GetGlyph(uint16(f.NumGlyphs)); err == nil {
+		t.Error("GetGlyph past the last glyph succeeded")
+	}
+}
+
+// FuzzParse parses fonts and interprets every glyph, starting from the test
+// font, its bare CFF table and truncations of both. Errors are expected for
+// most inputs; the parser and interpreter must not panic or hang.
+func FuzzParse(f *testing.F) {
+	data := readTestFont(f)
+	table, err := findTable(data, "CFF ")
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, seed := range [][]byte{data, table} {
+		f.Add(seed)
+		f.Add(seed[:len(seed)/2])
+		f.Add(seed[:len(seed)-1])
+	}
+	f.Add([]byte("OTTO"))
+	f.Add([]byte{1, 0, 4, 1})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		font, err := Parse(data)
+		if err != nil {
+			return
+		}
+		for gid := 0; gid < font.NumGlyphs; gid++ {
+			font.GetGlyph(uint16(gid))
+		}
+		font.GetGlyphID('A')
+		font.GlyphForCID(1)
+	})
+}
@@ -0,0 +1,367 @@
+package cff
+
+import (
+	"fmt"
+	"math"
+
+	"gumgum/pkg/graphics"
+)
+
+const (
+	// maxStack is the Type 2 argument stack limit.
+	maxStack = 48
+
+	// maxSubrDepth is the Type 2 subroutine nesting limit.
+	maxSubrDepth = 10
+)
+
+// charstringInterpreter runs a Type 2 charstring, building its outline.
+type charstringInterpreter struct {
+	font    *Font
+	private privateDict
+	path    *graphics.Path
+
+	stack    []float64
+	x, y     float64
+	open     bool // A subpath has been started
+	nStems   int
+	width    float64
+	widthSet bool // The first stack-clearing operator has been seen
+	done     bool // endchar was reached
+}
+
+// run interprets one charstring or subroutine.
+func (c *charstringInterpreter) run(code []byte, depth int) error {
+	if depth > maxSubrDepth {
+		return fmt.Errorf("subroutines nested too deeply")
+	}
+
+	for i := 0; i < len(code) && !c.done; {
+		b0 := code[i]
+		i++
+
+		// Operands
+		switch {
+		case b0 == 28:
+			if i+2 > len(code) {
+				return fmt.Errorf("truncated operand")
+			}
+			c.push(float64(int16(uint16(code[i])<<8 | uint16(code[i+1]))))
+			i += 2
+			continue
+		case b0 >= 32 && b0 <= 246:
+			c.push(float64(int(b0) - 139))
+			continue
+		case b0 >= 247 && b0 <= 254:
+			if i >= len(code) {
+				return fmt.Errorf("truncated operand")
+			}
+			if b0 <= 250 {
+				c.push(float64((int(b0)-247)*256 + int(code[i]) + 108))
+			} else {
+				c.push(float64(-(int(b0)-251)*256 - int(code[i]) - 108))
+			}
+			i++
+			continue
+		case b0 == 255:
+			if i+4 > len(code) {
+				return fmt.Errorf("truncated operand")
+			}
+			v := int32(uint32(code[i])<<24 | uint32(code[i+1])<<16 | uint32(code[i+2])<<8 | uint32(code[i+3]))
+			c.push(float64(v) / 65536)
+			i += 4
+			continue
+		}
+		if len(c.stack) > maxStack {
+			return fmt.Errorf("argument stack overflow")
+		}
+
+		// Operators
+		switch b0 {
+		case 1, 3, 18, 23: // hstem, vstem, hstemhm, vstemhm
+			c.stems()
+		case 19, 20: // hintmask, cntrmask
+			// Pending arguments are an implicit vstem
+			c.stems()
+			i += (c.nStems + 7) / 8
+		case 21: // rmoveto
+			c.takeWidth(2)
+			if len(c.stack) < 2 {
+				return fmt.Errorf("rmoveto: stack underflow")
+			}
+			c.moveTo(c.stack[0], c.stack[1])
+		case 22: // hmoveto
+			c.takeWidth(1)
+			if len(c.stack) < 1 {
+				return fmt.Errorf("hmoveto: stack underflow")
+			}
+			c.moveTo(c.stack[0], 0)
+		case 4: // vmoveto
+			c.takeWidth(1)
+			if len(c.stack) < 1 {
+				return fmt.Errorf("vmoveto: stack underflow")
+			}
+			c.moveTo(0, c.stack[0])
+		case 5: // rlineto
+			for a := c.stack; len(a) >= 2; a = a[2:] {
+				c.lineTo(a[0], a[1])
+			}
+		case 6, 7: // hlineto, vlineto
+			horizontal := b0 == 6
+			for _, d := range c.stack {
+				if horizontal {
+					c.lineTo(d, 0)
+				} else {
+					c.lineTo(0, d)
+				}
+				horizontal = !horizontal
+			}
+		case 8: // rrcurveto
+			for a := c.stack; len(a) >= 6; a = a[6:] {
+				c.curveTo(a[0], a[1], a[2], a[3], a[4], a[5])
+			}
+		case 24: // rcurveline
+			a := c.stack
+			for ; len(a) >= 8; a = a[6:] {
+				c.curveTo(a[0], a[1], a[2], a[3], a[4], a[5])
+			}
+			if len(a) >= 2 {
+				c.lineTo(a[0], a[1])
+			}
+		case 25: // rlinecurve
+			a := c.stack
+			for ; len(a) >= 8; a = a[2:] {
+				c.lineTo(a[0], a[1])
+			}
+			if len(a) >= 6 {
+				c.curveTo(a[0], a[1], a[2], a[3], a[4], a[5])
+			}
+		case 26: // vvcurveto
+			a := c.stack
+			dx1 := 0.0
+			if len(a)%2 == 1 {
+				dx1, a = a[0], a[1:]
+			}
+			for ; len(a) >= 4; a = a[4:] {
+				c.curveTo(dx1, a[0], a[1], a[2], 0, a[3])
+				dx1 = 0
+			}
+		case 27: // hhcurveto
+			a := c.stack
+			dy1 := 0.0
+			if len(a)%2 == 1 {
+				dy1, a = a[0], a[1:]
+			}
+			for ; len(a) >= 4; a = a[4:] {
+				c.curveTo(a[0], dy1, a[1], a[2], a[3], 0)
+				dy1 = 0
+			}
+		case 30, 31: // vhcurveto, hvcurveto
+			c.alternatingCurves(b0 == 31)
+		case 10, 29: // callsubr, callgsubr
+			if len(c.stack) < 1 {
+				return fmt.Errorf("callsubr: stack underflow")
+			}
+			subrs := c.private.subrs
+			if b0 == 29 {
+				subrs = c.font.globalSubrs
+			}
+			n := int(c.stack[len(c.stack)-1]) + subrBias(len(subrs))
+			c.stack = c.stack[:len(c.stack)-1]
+			if n < 0 || n >= len(subrs) {
+				return fmt.Errorf("subroutine %d out of range", n)
+			}
+			if err := c.run(subrs[n], depth+1); err != nil {
+				return err
+			}
+			continue
+		case 11: // return
+			return nil
+		case 14: // endchar
+			// Four remaining arguments are a deprecated seac accent, which
+			// is not supported
+			c.takeWidth(0)
+			c.done = true
+		case 12:
+			if i >= len(code) {
+				return fmt.Errorf("truncated operator")
+			}
+			b1 := code[i]
+			i++
+			if err := c.escape(b1); err != nil {
+				return err
+			}
+			continue
+		default:
+			return fmt.Errorf("unknown operator %d", b0)
+		}
+		c.stack = c.stack[:0]
+	}
+	return nil
+}
+
+func (c *charstringInterpreter) push(v float64) {
+	c.stack = append(c.stack, v)
+}
+
+// takeWidth pops the advance width the first stack-clearing operator may
+// carry before its n arguments.
+func (c *charstringInterpreter) takeWidth(n int) {
+	if c.widthSet {
+		return
+	}
+	c.widthSet = true
+	if len(c.stack) > n && len(c.stack)%2 != n%2 {
+		c.width = c.private.nominalWidthX + c.stack[0]
+		c.stack = c.stack[1:]
+	}
+}
+
+// stems counts stem hints, whose pairs of arguments may follow a width.
+func (c *charstringInterpreter) stems() {
+	if !c.widthSet && len(c.stack)%2 == 1 {
+		c.takeWidth(len(c.stack) - 1)
+	}
+	c.widthSet = true
+	c.nStems += len(c.stack) / 2
+}
+
+// escape runs a two-byte operator; only flex and a few arithmetic operators
+// are supported.
+func (c *charstringInterpreter) escape(op byte) error {
+	a := c.stack
+	switch op {
+	case 35: // flex
+		if len(a) < 13 {
+			return fmt.Errorf("flex: stack underflow")
+		}
+		c.curveTo(a[0], a[1], a[2], a[3], a[4], a[5])
+		c.curveTo(a[6], a[7], a[8], a[9], a[10], a[11])
+	case 34: // hflex
+		if len(a) < 7 {
+			return fmt.Errorf("hflex: stack underflow")
+		}
+		c.curveTo(a[0], 0, a[1], a[2], a[3], 0)
+		c.curveTo(a[4], 0, a[5], -a[2], a[6], 0)
+	case 36: // hflex1
+		if len(a) < 9 {
+			return fmt.Errorf("hflex1: stack underflow")
+		}
+		c.curveTo(a[0], a[1], a[2], a[3], a[4], 0)
+		c.curveTo(a[5], 0, a[6], a[7], a[8], -(a[1] + a[3] + a[7]))
+	case 37: // flex1
+		if len(a) < 11 {
+			return fmt.Errorf("flex1: stack underflow")
+		}
+		dx := a[0] + a[2] + a[4] + a[6] + a[8]
+		dy := a[1] + a[3] + a[5] + a[7] + a[9]
+		c.curveTo(a[0], a[1], a[2], a[3], a[4], a[5])
+		if math.Abs(dx) > math.Abs(dy) {
+			c.curveTo(a[6], a[7], a[8], a[9], a[10], -dy)
+		} else {
+			c.curveTo(a[6], a[7], a[8], a[9], -dx, a[10])
+		}
+	case 9, 14, 18: // abs, neg, drop
+		if len(a) < 1 {
+			return fmt.Errorf("operator 12 %d: stack underflow", op)
+		}
+		top := &c.stack[len(a)-1]
+		switch op {
+		case 9:
+			*top = math.Abs(*top)
+		case 14:
+			*top = -*top
+		case 18:
+			c.stack = a[:len(a)-1]
+		}
+		return nil
+	case 10, 11, 12, 24: // add, sub, div, mul
+		if len(a) < 2 {
+			return fmt.Errorf("operator 12 %d: stack underflow", op)
+		}
+		x, y := a[len(a)-2], a[len(a)-1]
+		var v float64
+		switch op {
+		case 10:
+			v = x + y
+		case 11:
+			v = x - y
+		case 12:
+			if y != 0 {
+				v = x / y
+			}
+		case 24:
+			v = x * y
+		}
+		c.stack = append(a[:len(a)-2], v)
+		return nil
+	default:
+		// Hint and other operators without drawing effect
+	}
+	c.stack = c.stack[:0]
+	return nil
+}
+
+// alternatingCurves runs vhcurveto or hvcurveto, whose curves alternate
+// between starting horizontally and vertically.
+func (c *charstringInterpreter) alternatingCurves(horizontal bool) {
+	a := c.stack
+	for len(a) >= 4 {
+		last := 0.0
+		if len(a) == 5 {
+			last = a[4]
+		}
+		if horizontal {
+			c.curveTo(a[0], 0, a[1], a[2], last, a[3])
+		} else {
+			c.curveTo(0, a[0], a[1], a[2], a[3], last)
+		}
+		a = a[4:]
+		if len(a) == 1 {
+			break
+		}
+		horizontal = !horizontal
+	}
+}
+
+func (c *charstringInterpreter) moveTo(dx, dy float64) {
+	c.closePath()
+	c.x += dx
+	c.y += dy
+	c.path.MoveTo(c.x, c.y)
+	c.open = true
+}
+
+func (c *charstringInterpreter) lineTo(dx, dy float64) {
+	c.x += dx
+	c.y += dy
+	c.path.LineTo(c.x, c.y)
+}
+
+func (c *charstringInterpreter) curveTo(dx1, dy1, dx2, dy2, dx3, dy3 float64) {
+	x1, y1 := c.x+dx1, c.y+dy1
+	x2, y2 := x1+dx2, y1+dy2
+	c.x, c.y = x2+dx3, y2+dy3
+	c.path.CurveTo(x1, y1, x2, y2, c.x, c.y)
+}
+
+// closePath closes the open subpath; Type 2 subpaths are closed implicitly.
+func (c *charstringInterpreter) closePath() {
+	if c.open {
+		c.path.Close()
+		c.open = false
+	}
+}
+
+// subrBias is added to subroutine numbers, which are stored biased so
+// that small numbers encode in fewer bytes.
+func subrBias(count int) int {
+	switch {
+	case count < 1240:
+		return 107
+	case count < 33900:
+		return 1131
+	default:
+		return 32768
+	}
+}
@@ -0,0 +1,320 @@
+package cff
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// parser reads structures from CFF data.
+type parser struct {
+	data []byte
+	pos  int
+}
+
+// index reads the INDEX at the current position and moves past it.
+func (p *parser) index() ([][]byte, error) {
+	if p.pos < 0 || p.pos+2 > len(p.data) {
+		return nil, fmt.Errorf("INDEX at %d out of range", p.pos)
+	}
+	count := int(p.data[p.pos])<<8 | int(p.data[p.pos+1])
+	if count == 0 {
+		p.pos += 2
+		return nil, nil
+	}
+	if p.pos+3 > len(p.data) {
+		return nil, fmt.Errorf("truncated INDEX")
+	}
+	offSize := int(p.data[p.pos+2])
+	if offSize < 1 || offSize > 4 {
+		return nil, fmt.Errorf("invalid INDEX offset size %d", offSize)
+	}
+	offsetsStart := p.pos + 3
+	dataStart := offsetsStart + (count+1)*offSize
+	if dataStart > len(p.data) {
+		return nil, fmt.Errorf("truncated INDEX offsets")
+	}
+
+	// Offsets are relative to the byte before the object data
+	offset := func(i int) int {
+		v := 0
+		for _, b := range p.data[offsetsStart+i*offSize : offsetsStart+(i+1)*offSize] {
+			v = v<<8 | int(b)
+		}
+		return dataStart - 1 + v
+	}
+
+	items := make([][]byte, count)
+	start := offset(0)
+	for i := 0; i < count; i++ {
+		end := offset(i + 1)
+		if start < dataStart || end < start || end > len(p.data) {
+			return nil, fmt.Errorf("INDEX object %d out of range", i)
+		}
+		items[i] = p.data[start:end]
+		start = end
+	}
+	p.pos = start
+	return items, nil
+}
+
+// indexAt reads the INDEX at an offset from the start of the CFF data.
+func (p *parser) indexAt(offset int) ([][]byte, error) {
+	sub := &parser{data: p.data, pos: offset}
+	return sub.index()
+}
+
+// privateDict reads the Private DICT referenced by a Top or Font DICT,
+// with its local subroutines.
+func (p *parser) privateDict(d dict) (privateDict, error) {
+	var private privateDict
+	operands := d[opPrivate]
+	if len(operands) != 2 {
+		return private, nil
+	}
+	size, offset := int(operands[0]), int(operands[1])
+	if size < 0 || offset < 0 || offset > len(p.data) || size > len(p.data)-offset {
+		return private, fmt.Errorf("Private DICT out of range")
+	}
+	pd, err := parseDict(p.data[offset : offset+size])
+	if err != nil {
+		return private, fmt.Errorf("failed to parse Private DICT: %w", err)
+	}
+	if v, ok := pd.number(opDefaultWX); ok {
+		private.defaultWidthX = v
+	}
+	if v, ok := pd.number(opNominalWX); ok {
+		private.nominalWidthX = v
+	}
+	// Subrs is relative to the start of the Private DICT
+	if subrs, ok := pd.int(opSubrs); ok {
+		if private.subrs, err = p.indexAt(offset + subrs); err != nil {
+			return private, fmt.Errorf("failed to read local Subrs: %w", err)
+		}
+	}
+	return private, nil
+}
+
+// charset reads the SID (or CID) of each glyph. Offsets 0-2 select the
+// predefined charsets, which only the ISOAdobe one is common enough to
+// matter; the others fall back to it.
+func (p *parser) charset(offset, numGlyphs int) ([]uint16, error) {
+	charset := make([]uint16, numGlyphs)
+	if offset <= 2 {
+		for gid := range charset {
+			if gid < len(standardStrings) {
+				charset[gid] = uint16(gid)
+			}
+		}
+		return charset, nil
+	}
+	if offset >= len(p.data) {
+		return nil, fmt.Errorf("charset offset %d out of range", offset)
+	}
+
+	format := p.data[offset]
+	pos := offset + 1
+	read16 := func() (uint16, bool) {
+		if pos+2 > len(p.data) {
+			return 0, false
+		}
+		v := uint16(p.data[pos])<<8 | uint16(p.data[pos+1])
+		pos += 2
+		return v, true
+	}
+
+	// Glyph 0 is always .notdef and is not listed
+	switch format {
+	case 0:
+		for gid := 1; gid < numGlyphs; gid++ {
+			sid, ok := read16()
+			if !ok {
+				return nil, fmt.Errorf("truncated charset")
+			}
+			charset[gid] = sid
+		}
+	case 1, 2:
+		for gid := 1; gid < numGlyphs; {
+			first, ok := read16()
+			if !ok {
+				return nil, fmt.Errorf("truncated charset")
+			}
+			var left int
+			if format == 1 {
+				if pos >= len(p.data) {
+					return nil, fmt.Errorf("truncated charset")
+				}
+				left = int(p.data[pos])
+				pos++
+			} else {
+				n, ok := read16()
+				if !ok {
+					return nil, fmt.Errorf("truncated charset")
+				}
+				left = int(n)
+			}
+			for i := 0; i <= left && gid < numGlyphs; i++ {
+				charset[gid] = first + uint16(i)
+				gid++
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown charset format %d", format)
+	}
+	return charset, nil
+}
+
+// fdSelect reads the Font DICT index of each glyph.
+func (p *parser) fdSelect(offset, numGlyphs int) ([]uint8, error) {
+	if offset < 0 || offset >= len(p.data) {
+		return nil, fmt.Errorf("FDSelect offset %d out of range", offset)
+	}
+	fds := make([]uint8, numGlyphs)
+	data := p.data[offset+1:]
+
+	switch p.data[offset] {
+	case 0:
+		if len(data) < numGlyphs {
+			return nil, fmt.Errorf("truncated FDSelect")
+		}
+		copy(fds, data)
+	case 3:
+		if len(data) < 2 {
+			return nil, fmt.Errorf("truncated FDSelect")
+		}
+		nRanges := int(data[0])<<8 | int(data[1])
+		// Each range is a first glyph and an FD; a sentinel glyph ends the last
+		if len(data) < 2+3*nRanges+2 {
+			return nil, fmt.Errorf("truncated FDSelect")
+		}
+		for i := 0; i < nRanges; i++ {
+			r := data[2+3*i:]
+			first := int(r[0])<<8 | int(r[1])
+			next := int(r[3])<<8 | int(r[4])
+			for gid := first; gid < next && gid < numGlyphs; gid++ {
+				fds[gid] = r[2]
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown FDSelect format %d", p.data[offset])
+	}
+	return fds, nil
+}
+
+// dict maps DICT operators to their operands.
+type dict map[int][]float64
+
+func (d dict) number(op int) (float64, bool) {
+	operands := d[op]
+	if len(operands) == 0 {
+		return 0, false
+	}
+	return operands[len(operands)-1], true
+}
+
+func (d dict) int(op int) (int, bool) {
+	v, ok := d.number(op)
+	return int(v), ok
+}
+
+// parseDict decodes a DICT: operands followed by their operator.
+func parseDict(data []byte) (dict, error) {
+	d := make(dict)
+	var operands []float64
+
+	for i := 0; i < len(data); {
+		b0 := data[i]
+		switch {
+		case b0 <= 21:
+			op := int(b0)
+			i++
+			if b0 == 12 {
+				if i >= len(data) {
+					return nil, fmt.Errorf("truncated operator")
+				}
+				op = 1200 + int(data[i])
+				i++
+			}
+			d[op] = operands
+			operands = nil
+		case b0 == 30:
+			v, n, err := parseReal(data[i+1:])
+			if err != nil {
+				return nil, err
+			}
+			operands = append(operands, v)
+			i += 1 + n
+		default:
+			v, n, err := parseInt(data[i:])
+			if err != nil {
+				return nil, err
+			}
+			operands = append(operands, float64(v))
+			i += n
+		}
+	}
+	return d, nil
+}
+
+// parseInt decodes a DICT integer operand, returning it and its length.
+func parseInt(data []byte) (int32, int, error) {
+	b0 := int32(data[0])
+	switch {
+	case b0 >= 32 && b0 <= 246:
+		return b0 - 139, 1, nil
+	case b0 >= 247 && b0 <= 254:
+		if len(data) < 2 {
+			return 0, 0, fmt.Errorf("truncated operand")
+		}
+		b1 := int32(data[1])
+		if b0 <= 250 {
+			return (b0-247)*256 + b1 + 108, 2, nil
+		}
+		return -(b0-251)*256 - b1 - 108, 2, nil
+	case b0 == 28:
+		if len(data) < 3 {
+			return 0, 0, fmt.Errorf("truncated operand")
+		}
+		return int32(int16(uint16(data[1])<<8 | uint16(data[2]))), 3, nil
+	case b0 == 29:
+		if len(data) < 5 {
+			return 0, 0, fmt.Errorf("truncated operand")
+		}
+		return int32(uint32(data[1])<<24 | uint32(data[2])<<16 | uint32(data[3])<<8 | uint32(data[4])), 5, nil
+	}
+	return 0, 0, fmt.Errorf("invalid operand byte %d", b0)
+}
+
+// parseReal decodes the nibbles of a real operand after its 30 prefix.
+func parseReal(data []byte) (float64, int, error) {
+	var s []byte
+	for i, b := range data {
+		for _, nibble := range [2]byte{b >> 4, b & 0x0F} {
+			switch {
+			case nibble <= 9:
+				s = append(s, '0'+nibble)
+			case nibble == 0xA:
+				s = append(s, '.')
+			case nibble == 0xB:
+				s = append(s, 'E')
+			case nibble == 0xC:
+				s = append(s, 'E', '-')
+			case nibble == 0xE:
+				s = append(s, '-')
+			case nibble == 0xF:
+				v, err := strconv.ParseFloat(string(s), 64)
+				if err != nil {
+					if len(s) == 0 {
+						return 0, i + 1, nil
+					}
+					return 0, 0, fmt.Errorf("invalid real %q", s)
+				}
+				if math.IsInf(v, 0) || math.IsNaN(v) {
+					return 0, 0, fmt.Errorf("invalid real %q", s)
+				}
+				return v, i + 1, nil
+			}
+		}
+	}
+	return 0, 0, fmt.Errorf("unterminated real")
+}
@@ -0,0 +1,54 @@
+// Package glyphlist maps PostScript glyph names to Unicode with a subset of
+// the Adobe Glyph List covering the Latin, Greek, Cyrillic, Hebrew and
+// Arabic names simple fonts use. uniXXXX and uXXXX names are resolved by
+// callers such as cos.GlyphText.
+package glyphlist
+
+import (
+	_ "embed"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:embed glyphlist.txt
+var glyphListData string
+
+var (
+	glyphListOnce sync.Once
+	glyphList     map[string]rune
+)
+
+// ToUnicode returns the code point of a glyph name such as "Aacute", "fi"
+// or "endash". Names listed with several code points return the first.
+func ToUnicode(name string) (rune, bool) {
+	glyphListOnce.Do(loadGlyphList)
+	r, ok := glyphList[name]
+	return r, ok
+}
+
+// loadGlyphList parses the embedded list. Each line is a glyph name and a
+// hexadecimal code point separated by a semicolon; lines starting with #
+// are comments.
+func loadGlyphList() {
+	glyphList = make(map[string]rune)
+	for _, line := range strings.Split(glyphListData, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ";")
+		if !ok {
+			continue
+		}
+		// The first code point is preferred for names listed more than once
+		if _, exists := glyphList[name]; exists {
+			continue
+		}
+		cp, err := strconv.ParseUint(value, 16, 32)
+		if err != nil {
+			continue
+		}
+		glyphList[name] = rune(cp)
+	}
+}
@@ -0,0 +1,43 @@
+package glyphlist
+
+import "testing"
+
+func TestToUnicode(t *testing.T) {
+	tests := []struct {
+		name string
+		want rune
+	}{
+		{"A", 'A'},
+		{"Aacute", 'Á'},
+		{"fi", 'ﬁ'},
+		{"endash", '–'},
+		{"afii10017", 'А'},
+		{"afii10023", 'Ё'},
+		{"afii10071", 'ё'},
+		{"afii10097", 'я'},
+		{"afii10110", 'ў'},
+		{"afii57664", 'א'},
+		{"afii57690", 'ת'},
+		{"afii57409", 'ء'},
+		{"afii57458", 'ْ'},
+		{"afii57392", '٠'},
+		{"gravecomb", '̀'},
+	}
+	for _, tt := range tests {
+		got, ok := ToUnicode(tt.name)
+		if !ok || got != tt.want {
+			t.Errorf("ToUnicode(%q) = %U, %v; want %U", tt.name, got, ok, tt.want)
+		}
+	}
+
+	if _, ok := ToUnicode("notaglyphname"); ok {
+		t.Error("ToUnicode accepted an unknown name")
+	}
+}
+
+func TestGlyphListParses(t *testing.T) {
+	glyphListOnce.Do(loadGlyphList)
+	if len(glyphList) < 800 {
+		t.Errorf("loaded %d glyph names, the embedded list is not being parsed", len(glyphList))
+	}
+}
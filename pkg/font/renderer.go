@@ -2,15 +2,21 @@
 package font
 
 import (
+	"fmt"
+	"image/color"
+
 	"gumgum/pkg/font/ttf"
 	"gumgum/pkg/graphics"
 )
 
 // Renderer converts font glyphs to graphics paths.
 type Renderer struct {
-	font   *ttf.Font
-	scale  float64
-	hScale float64 // Horizontal scaling (text state)
+	font      *ttf.Font
+	scale     float64
+	hScale    float64 // Horizontal scaling (text state)
+	charSpace float64 // Extra advance after every glyph (Tc)
+	wordSpace float64 // Extra advance after space characters (Tw)
+	rise      float64 // Baseline shift (Ts)
 }
 
 // NewRenderer creates a new font renderer.
@@ -32,15 +38,61 @@ func (r *Renderer) SetHorizontalScale(percentage float64) {
 	r.hScale = percentage / 100.0
 }
 
+// SetCharSpacing sets the extra space added after each character, in
+// unscaled text space units (Tc).
+func (r *Renderer) SetCharSpacing(charSpace float64) {
+	r.charSpace = charSpace
+}
+
+// SetWordSpacing sets the extra space added after each space character, in
+// unscaled text space units (Tw).
+func (r *Renderer) SetWordSpacing(wordSpace float64) {
+	r.wordSpace = wordSpace
+}
+
+// SetRise sets the baseline shift in unscaled text space units (Ts). Positive
+// values move glyphs up for superscripts, negative values down for subscripts.
+func (r *Renderer) SetRise(rise float64) {
+	r.rise = rise
+}
+
+// SetTextState configures size, spacing and scaling from a PDF text state.
+func (r *Renderer) SetTextState(ts graphics.TextState) {
+	r.SetScale(ts.FontSize)
+	r.SetHorizontalScale(ts.HScale)
+	r.SetCharSpacing(ts.CharSpace)
+	r.SetWordSpacing(ts.WordSpace)
+	r.SetRise(ts.Rise)
+}
+
+// spacing returns the extra advance for a character from Tc and Tw.
+func (r *Renderer) spacing(runeValue rune) float64 {
+	extra := r.charSpace
+	if runeValue == ' ' {
+		extra += r.wordSpace
+	}
+	return extra
+}
+
+// maxCompoundDepth limits nesting of compound glyphs.
+const maxCompoundDepth = 8
+
 // GlyphToPath converts a glyph to a graphics path.
 func (r *Renderer) GlyphToPath(glyphID uint16) (*graphics.Path, error) {
+	return r.glyphToPath(glyphID, 0)
+}
+
+func (r *Renderer) glyphToPath(glyphID uint16, depth int) (*graphics.Path, error) {
 	glyph, err := r.font.GetGlyph(glyphID)
 	if err != nil {
 		return nil, err
 	}
 
 	if glyph.IsCompound() {
-		return r.compoundGlyphToPath(glyph)
+		if depth >= maxCompoundDepth {
+			return nil, fmt.Errorf("compound glyph %d nested too deeply", glyphID)
+		}
+		return r.compoundGlyphToPath(glyph, depth)
 	}
 
 	return r.simpleGlyphToPath(glyph), nil
@@ -138,70 +190,105 @@ func (r *Renderer) simpleGlyphToPath(glyph *ttf.Glyph) *graphics.Path {
 }
 
 // compoundGlyphToPath converts a compound glyph to a path.
-func (r *Renderer) compoundGlyphToPath(glyph *ttf.Glyph) (*graphics.Path, error) {
+func (r *Renderer) compoundGlyphToPath(glyph *ttf.Glyph, depth int) (*graphics.Path, error) {
 	result := graphics.NewPath()
 
+	// Components placed by point matching need the outline points of the
+	// components before them
+	var placed []graphics.Point
+	matchesPoints := false
+	for _, comp := range glyph.Components {
+		matchesPoints = matchesPoints || comp.MatchesPoints()
+	}
+
 	for _, comp := range glyph.Components {
 		// Get component glyph
-		compPath, err := r.GlyphToPath(comp.GlyphIndex)
+		compPath, err := r.glyphToPath(comp.GlyphIndex, depth+1)
 		if err != nil {
 			continue
 		}
 
-		// Apply transformation
-		scale := r.scale
-		hScale := r.hScale
+		// Component transformation (scale/rotation), then translation
+		linear := componentMatrix(comp)
 
-		dx := float64(comp.Arg1) * scale * hScale
-		dy := float64(comp.Arg2) * scale
+		dx, dy := float64(comp.Arg1), float64(comp.Arg2)
+		if matchesPoints {
+			points := transformPoints(r.glyphPoints(comp.GlyphIndex, depth+1), linear)
+			dx, dy = componentOffset(comp, placed, points)
+			placed = append(placed, transformPoints(points, graphics.Translate(dx, dy))...)
+		}
 
-		// Create transformation matrix
-		m := graphics.Identity()
+		m := linear.Multiply(graphics.Translate(dx*r.scale*r.hScale, dy*r.scale))
+		result.Append(compPath.Transform(m))
+	}
 
-		// Apply component transformation (scale/rotation)
-		if comp.Scale != 1.0 || comp.ScaleX != 1.0 || comp.ScaleY != 1.0 ||
-			comp.Scale01 != 0 || comp.Scale10 != 0 {
+	return result, nil
+}
 
-			tm := graphics.Matrix{
-				comp.ScaleX, comp.Scale01,
-				comp.Scale10, comp.ScaleY,
-				0, 0,
-			}
-			m = m.Multiply(tm)
-		}
+// componentMatrix returns the scale or 2x2 transformation of a compound
+// glyph component.
+func componentMatrix(comp ttf.GlyphComponent) graphics.Matrix {
+	return graphics.Matrix{
+		comp.ScaleX, comp.Scale01,
+		comp.Scale10, comp.ScaleY,
+		0, 0,
+	}
+}
 
-		// Apply translation
-		m = m.Multiply(graphics.Translate(dx, dy))
+// componentOffset returns the translation of a component in font units.
+// For point matching it moves the component's point onto the parent's;
+// placed holds the points of the components before it and points those of
+// the component after its linear transformation.
+func componentOffset(comp ttf.GlyphComponent, placed, points []graphics.Point) (float64, float64) {
+	if !comp.MatchesPoints() {
+		return float64(comp.Arg1), float64(comp.Arg2)
+	}
+	parent, child := comp.PointNumbers()
+	if parent >= len(placed) || child >= len(points) {
+		return 0, 0
+	}
+	return placed[parent].X - points[child].X, placed[parent].Y - points[child].Y
+}
 
-		// Transform component path
-		transformed := compPath.Transform(m)
+// glyphPoints returns the outline points of a glyph in font units, in
+// point number order. Compound glyphs list the points of their components
+// in turn, placed as they are drawn.
+func (r *Renderer) glyphPoints(glyphID uint16, depth int) []graphics.Point {
+	glyph, err := r.font.GetGlyph(glyphID)
+	if err != nil {
+		return nil
+	}
 
-		// Add to result
-		for _, seg := range transformed.Segments {
-			switch seg.Op {
-			case graphics.PathOpMoveTo:
-				if len(seg.Points) > 0 {
-					result.MoveTo(seg.Points[0].X, seg.Points[0].Y)
-				}
-			case graphics.PathOpLineTo:
-				if len(seg.Points) > 0 {
-					result.LineTo(seg.Points[0].X, seg.Points[0].Y)
-				}
-			case graphics.PathOpCurveTo:
-				if len(seg.Points) >= 3 {
-					result.CurveTo(
-						seg.Points[0].X, seg.Points[0].Y,
-						seg.Points[1].X, seg.Points[1].Y,
-						seg.Points[2].X, seg.Points[2].Y,
-					)
-				}
-			case graphics.PathOpClose:
-				result.Close()
+	if !glyph.IsCompound() {
+		points := make([]graphics.Point, len(glyph.XCoordinates))
+		for i := range points {
+			points[i] = graphics.Point{X: float64(glyph.XCoordinates[i])}
+			if i < len(glyph.YCoordinates) {
+				points[i].Y = float64(glyph.YCoordinates[i])
 			}
 		}
+		return points
+	}
+	if depth >= maxCompoundDepth {
+		return nil
 	}
 
-	return result, nil
+	var placed []graphics.Point
+	for _, comp := range glyph.Components {
+		points := transformPoints(r.glyphPoints(comp.GlyphIndex, depth+1), componentMatrix(comp))
+		dx, dy := componentOffset(comp, placed, points)
+		placed = append(placed, transformPoints(points, graphics.Translate(dx, dy))...)
+	}
+	return placed
+}
+
+// transformPoints returns the points transformed by m.
+func transformPoints(points []graphics.Point, m graphics.Matrix) []graphics.Point {
+	out := make([]graphics.Point, len(points))
+	for i, p := range points {
+		out[i] = m.TransformPoint(p)
+	}
+	return out
 }
 
 // RenderString renders a string to a path at the given position.
@@ -209,6 +296,9 @@ func (r *Renderer) RenderString(s string, x, y float64) *graphics.Path {
 	result := graphics.NewPath()
 	currentX := x
 
+	// Rise is in text space; the text matrix applied by the caller scales it
+	baseline := y + r.rise
+
 	for _, runeValue := range s {
 		glyphID := r.font.GetGlyphID(runeValue)
 
@@ -216,7 +306,7 @@ func (r *Renderer) RenderString(s string, x, y float64) *graphics.Path {
 		glyphPath, err := r.GlyphToPath(glyphID)
 		if err == nil && !glyphPath.IsEmpty() {
 			// Translate glyph to current position
-			translated := glyphPath.Transform(graphics.Translate(currentX, y))
+			translated := glyphPath.Transform(graphics.Translate(currentX, baseline))
 
 			// Append to result
 			for _, seg := range translated.Segments {
@@ -244,13 +334,79 @@ func (r *Renderer) RenderString(s string, x, y float64) *graphics.Path {
 		}
 
 		// Advance position
-		advanceWidth := float64(r.font.GetAdvanceWidth(glyphID)) * r.scale * r.hScale
-		currentX += advanceWidth
+		// Horizontal scaling applies to the spacing as well as the glyph width
+		advanceWidth := float64(r.font.GetAdvanceWidth(glyphID)) * r.scale
+		currentX += (advanceWidth + r.spacing(runeValue)) * r.hScale
 	}
 
 	return result
 }
 
+// ColorPath is one layer of rendered color glyphs. Foreground layers take
+// the text color and have no Color of their own.
+type ColorPath struct {
+	Path       *graphics.Path
+	Color      color.RGBA
+	Foreground bool
+}
+
+// HasColorGlyphs reports whether the font has COLR color layers.
+func (r *Renderer) HasColorGlyphs() bool {
+	return r.font.Colr != nil
+}
+
+// RenderColorString renders a string like RenderString, splitting glyphs
+// with COLR layers into one path per layer in drawing order. Glyphs without
+// layers are foreground paths.
+func (r *Renderer) RenderColorString(s string, x, y float64) []ColorPath {
+	var paths []ColorPath
+	currentX := x
+	baseline := y + r.rise
+
+	addGlyph := func(glyphID uint16, cp ColorPath) {
+		glyphPath, err := r.GlyphToPath(glyphID)
+		if err != nil || glyphPath.IsEmpty() {
+			return
+		}
+		cp.Path = glyphPath.Transform(graphics.Translate(currentX, baseline))
+		paths = append(paths, cp)
+	}
+
+	for _, runeValue := range s {
+		glyphID := r.font.GetGlyphID(runeValue)
+
+		// Broken layer tables fall back to the plain glyph
+		layers, _ := r.font.GetColorLayers(glyphID)
+		if len(layers) == 0 {
+			addGlyph(glyphID, ColorPath{Foreground: true})
+		}
+		for _, layer := range layers {
+			addGlyph(layer.GlyphIndex, ColorPath{
+				Color:      layer.Color,
+				Foreground: layer.PaletteIndex == ttf.ForegroundPalette,
+			})
+		}
+
+		advanceWidth := float64(r.font.GetAdvanceWidth(glyphID)) * r.scale
+		currentX += (advanceWidth + r.spacing(runeValue)) * r.hScale
+	}
+
+	return paths
+}
+
+// Advance returns the distance RenderString moves the pen for a string, in
+// scaled units. Unlike GetStringWidth it leaves out kerning, which PDF text
+// positioning does not apply.
+func (r *Renderer) Advance(s string) float64 {
+	var advance float64
+	for _, runeValue := range s {
+		glyphID := r.font.GetGlyphID(runeValue)
+		advanceWidth := float64(r.font.GetAdvanceWidth(glyphID)) * r.scale
+		advance += (advanceWidth + r.spacing(runeValue)) * r.hScale
+	}
+	return advance
+}
+
 // GetStringWidth returns the width of a string in scaled units.
 func (r *Renderer) GetStringWidth(s string) float64 {
 	var width float64
@@ -266,8 +422,8 @@ func (r *Renderer) GetStringWidth(s string) float64 {
 		}
 
 		// Add advance width
-		advance := float64(r.font.GetAdvanceWidth(glyphID)) * r.scale * r.hScale
-		width += advance
+		advance := float64(r.font.GetAdvanceWidth(glyphID)) * r.scale
+		width += (advance + r.spacing(runeValue)) * r.hScale
 
 		prevGlyphID = glyphID
 	}
@@ -0,0 +1,96 @@
+package font
+
+import (
+	"math"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+
+	"gumgum/pkg/font/ttf"
+	"gumgum/pkg/graphics"
+)
+
+func newTestRenderer(t *testing.T, ts graphics.TextState) *Renderer {
+	t.Helper()
+	f, err := ttf.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRenderer(f)
+	r.SetTextState(ts)
+	return r
+}
+
+func plainTextState() graphics.TextState {
+	return graphics.TextState{FontSize: 10, HScale: 100}
+}
+
+func TestCharAndWordSpacing(t *testing.T) {
+	const text = "a b c"
+	plain := newTestRenderer(t, plainTextState())
+
+	ts := plainTextState()
+	ts.CharSpace = 2
+	ts.WordSpace = 5
+	spaced := newTestRenderer(t, ts)
+
+	// Tc after each of the 5 characters and Tw after each of the 2 spaces
+	want := 5*2.0 + 2*5.0
+	if got := spaced.Advance(text) - plain.Advance(text); math.Abs(got-want) > 1e-9 {
+		t.Errorf("Advance grew by %v, want %v", got, want)
+	}
+	if got := spaced.GetStringWidth(text) - plain.GetStringWidth(text); math.Abs(got-want) > 1e-9 {
+		t.Errorf("GetStringWidth grew by %v, want %v", got, want)
+	}
+
+	// The last glyph starts further right by the spacing before it
+	plainBounds := plain.RenderString(text, 0, 0).Bounds()
+	spacedBounds := spaced.RenderString(text, 0, 0).Bounds()
+	shift := (spacedBounds.X + spacedBounds.Width) - (plainBounds.X + plainBounds.Width)
+	if want := 4*2.0 + 2*5.0; math.Abs(shift-want) > 1e-9 {
+		t.Errorf("right edge moved by %v, want %v", shift, want)
+	}
+}
+
+func TestTextRise(t *testing.T) {
+	plain := newTestRenderer(t, plainTextState())
+
+	ts := plainTextState()
+	ts.Rise = 3
+	raised := newTestRenderer(t, ts)
+
+	plainBounds := plain.RenderString("H", 0, 0).Bounds()
+	raisedBounds := raised.RenderString("H", 0, 0).Bounds()
+	if got := raisedBounds.Y - plainBounds.Y; math.Abs(got-3) > 1e-9 {
+		t.Errorf("baseline moved by %v, want 3", got)
+	}
+	if raisedBounds.X != plainBounds.X {
+		t.Errorf("rise moved the glyph horizontally")
+	}
+	if raised.Advance("H") != plain.Advance("H") {
+		t.Errorf("rise changed the advance")
+	}
+}
+
+func TestHorizontalScale(t *testing.T) {
+	ts := plainTextState()
+	ts.CharSpace = 1
+	plain := newTestRenderer(t, ts)
+
+	ts.HScale = 50
+	condensed := newTestRenderer(t, ts)
+
+	const text = "Hello"
+	if got, want := condensed.Advance(text), plain.Advance(text)/2; math.Abs(got-want) > 1e-9 {
+		t.Errorf("Advance = %v, want %v", got, want)
+	}
+
+	plainBounds := plain.RenderString("H", 0, 0).Bounds()
+	condensedBounds := condensed.RenderString("H", 0, 0).Bounds()
+	if got, want := condensedBounds.Width, plainBounds.Width/2; math.Abs(got-want) > 1e-9 {
+		t.Errorf("glyph width = %v, want %v", got, want)
+	}
+	if condensedBounds.Height != plainBounds.Height {
+		t.Errorf("glyph height = %v, want %v", condensedBounds.Height, plainBounds.Height)
+	}
+}
@@ -0,0 +1,76 @@
+package ttf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// criticalTables are the tables ParseVerified requires to be intact.
+var criticalTables = []string{"cmap", "glyf", "loca"}
+
+// VerifyChecksums returns the tags of the tables whose data does not match
+// the checksum stored in the table directory, in directory order. Tables
+// that extend past the end of the data are reported as mismatched.
+func VerifyChecksums(data []byte) []string {
+	if len(data) < 12 {
+		return nil
+	}
+
+	var mismatched []string
+	numTables := int(binary.BigEndian.Uint16(data[4:6]))
+	for i := 0; i < numTables; i++ {
+		entry := 12 + 16*i
+		if entry+16 > len(data) {
+			break
+		}
+
+		tag := string(data[entry : entry+4])
+		stored := binary.BigEndian.Uint32(data[entry+4 : entry+8])
+		offset := uint64(binary.BigEndian.Uint32(data[entry+8 : entry+12]))
+		length := uint64(binary.BigEndian.Uint32(data[entry+12 : entry+16]))
+		if offset+length > uint64(len(data)) {
+			mismatched = append(mismatched, tag)
+			continue
+		}
+
+		if tableChecksum(tag, data[offset:offset+length]) != stored {
+			mismatched = append(mismatched, tag)
+		}
+	}
+	return mismatched
+}
+
+// tableChecksum sums the table as big-endian 32-bit words, padding the
+// last word with zeros. The head table's checksumAdjustment field is
+// counted as zero, since it is computed after the table's checksum.
+func tableChecksum(tag string, table []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(table); i += 4 {
+		var word [4]byte
+		copy(word[:], table[i:])
+		if tag == "head" && i == 8 {
+			continue
+		}
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
+
+// ParseVerified parses a TrueType font like Parse, but first returns an
+// error if the checksum of a critical table (cmap, glyf or loca) does not
+// match. Mismatches in other tables are ignored.
+func ParseVerified(data []byte) (*Font, error) {
+	var bad []string
+	for _, tag := range VerifyChecksums(data) {
+		for _, critical := range criticalTables {
+			if tag == critical {
+				bad = append(bad, tag)
+			}
+		}
+	}
+	if len(bad) > 0 {
+		return nil, fmt.Errorf("checksum mismatch in tables: %s", strings.Join(bad, ", "))
+	}
+	return Parse(data)
+}
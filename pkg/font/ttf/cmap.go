@@ -26,6 +26,33 @@ type CmapFormat interface {
 	GetGlyphID(r rune) uint16
 }
 
+// CmapFormat0 maps single-byte codes through a 256-entry array.
+type CmapFormat0 struct {
+	GlyphIDs [256]uint8
+}
+
+// CmapFormat2 is the high-byte mapping used for mixed one- and two-byte
+// CJK encodings such as Shift-JIS. Its codes are in the subtable's
+// encoding rather than Unicode.
+type CmapFormat2 struct {
+	// SubHeaderKeys holds the subheader index for each high byte; 0 means
+	// the byte is a complete single-byte code.
+	SubHeaderKeys [256]uint16
+	SubHeaders    []CmapSubHeader
+
+	data []byte // Subtable bytes, holding the glyph index array
+}
+
+// CmapSubHeader maps a range of low bytes in format 2.
+type CmapSubHeader struct {
+	FirstCode     uint16
+	EntryCount    uint16
+	IDDelta       int16
+	IDRangeOffset uint16 // Bytes from the IDRangeOffset field to the first glyph index
+
+	rangeOffsetPos int // Position of the IDRangeOffset field in the subtable
+}
+
 // CmapFormat4 is the most common format for Unicode BMP.
 type CmapFormat4 struct {
 	SegCount      uint16
@@ -48,6 +75,18 @@ type CmapFormat12 struct {
 	Groups []CmapGroup
 }
 
+// CmapFormat10 is the 32-bit version of format 6.
+type CmapFormat10 struct {
+	StartCharCode uint32
+	GlyphIDs      []uint16
+}
+
+// CmapFormat13 maps ranges of characters each to a single glyph, such as
+// a last-resort font's.
+type CmapFormat13 struct {
+	Groups []CmapGroup
+}
+
 // CmapGroup represents a sequential map group in format 12.
 type CmapGroup struct {
 	StartCharCode uint32
@@ -126,17 +165,71 @@ func (f *Font) parseCmapSubtable(st *CmapSubtable, data []byte) error {
 	d := data[st.Offset:]
 
 	switch st.Format {
+	case 0:
+		return f.parseCmapFormat0(d)
+	case 2:
+		return f.parseCmapFormat2(d)
 	case 4:
 		return f.parseCmapFormat4(d)
 	case 6:
 		return f.parseCmapFormat6(d)
+	case 10:
+		return f.parseCmapFormat10(d)
 	case 12:
 		return f.parseCmapFormat12(d)
+	case 13:
+		return f.parseCmapFormat13(d)
 	default:
 		return fmt.Errorf("unsupported cmap format: %d", st.Format)
 	}
 }
 
+func (f *Font) parseCmapFormat0(d []byte) error {
+	if len(d) < 6+256 {
+		return fmt.Errorf("format 0 subtable too short")
+	}
+
+	cmap0 := &CmapFormat0{}
+	copy(cmap0.GlyphIDs[:], d[6:6+256])
+
+	f.Cmap.BestFormat = cmap0
+	return nil
+}
+
+func (f *Font) parseCmapFormat2(d []byte) error {
+	if len(d) < 6+512 {
+		return fmt.Errorf("format 2 subtable too short")
+	}
+
+	cmap2 := &CmapFormat2{data: d}
+	numSubHeaders := 0
+	for i := range cmap2.SubHeaderKeys {
+		key := binary.BigEndian.Uint16(d[6+2*i:]) / 8
+		cmap2.SubHeaderKeys[i] = key
+		if int(key)+1 > numSubHeaders {
+			numSubHeaders = int(key) + 1
+		}
+	}
+
+	offset := 6 + 512
+	for i := 0; i < numSubHeaders; i++ {
+		if offset+8 > len(d) {
+			return fmt.Errorf("format 2 subheader %d out of range", i)
+		}
+		cmap2.SubHeaders = append(cmap2.SubHeaders, CmapSubHeader{
+			FirstCode:      binary.BigEndian.Uint16(d[offset : offset+2]),
+			EntryCount:     binary.BigEndian.Uint16(d[offset+2 : offset+4]),
+			IDDelta:        int16(binary.BigEndian.Uint16(d[offset+4 : offset+6])),
+			IDRangeOffset:  binary.BigEndian.Uint16(d[offset+6 : offset+8]),
+			rangeOffsetPos: offset + 6,
+		})
+		offset += 8
+	}
+
+	f.Cmap.BestFormat = cmap2
+	return nil
+}
+
 func (f *Font) parseCmapFormat4(d []byte) error {
 	if len(d) < 14 {
 		return fmt.Errorf("format 4 subtable too short")
@@ -232,12 +325,15 @@ func (f *Font) parseCmapFormat12(d []byte) error {
 	}
 
 	numGroups := binary.BigEndian.Uint32(d[12:16])
+	if uint64(numGroups)*12 > uint64(len(d)-16) {
+		return fmt.Errorf("format 12 group count exceeds data")
+	}
 	cmap12 := &CmapFormat12{
 		Groups: make([]CmapGroup, numGroups),
 	}
 
 	offset := 16
-	for i := uint32(0); i < numGroups && offset+12 <= len(d); i++ {
+	for i := uint32(0); i < numGroups; i++ {
 		cmap12.Groups[i] = CmapGroup{
 			StartCharCode: binary.BigEndian.Uint32(d[offset : offset+4]),
 			EndCharCode:   binary.BigEndian.Uint32(d[offset+4 : offset+8]),
@@ -250,6 +346,37 @@ func (f *Font) parseCmapFormat12(d []byte) error {
 	return nil
 }
 
+func (f *Font) parseCmapFormat10(d []byte) error {
+	if len(d) < 20 {
+		return fmt.Errorf("format 10 subtable too short")
+	}
+
+	numChars := binary.BigEndian.Uint32(d[16:20])
+	if uint64(numChars)*2 > uint64(len(d)-20) {
+		return fmt.Errorf("format 10 character count exceeds data")
+	}
+
+	cmap10 := &CmapFormat10{
+		StartCharCode: binary.BigEndian.Uint32(d[12:16]),
+		GlyphIDs:      make([]uint16, numChars),
+	}
+	for i := range cmap10.GlyphIDs {
+		cmap10.GlyphIDs[i] = binary.BigEndian.Uint16(d[20+2*i:])
+	}
+
+	f.Cmap.BestFormat = cmap10
+	return nil
+}
+
+func (f *Font) parseCmapFormat13(d []byte) error {
+	// Same layout as format 12
+	if err := f.parseCmapFormat12(d); err != nil {
+		return fmt.Errorf("format 13: %w", err)
+	}
+	f.Cmap.BestFormat = &CmapFormat13{Groups: f.Cmap.BestFormat.(*CmapFormat12).Groups}
+	return nil
+}
+
 // GetGlyphID returns the glyph ID for a Unicode code point.
 func (f *Font) GetGlyphID(r rune) uint16 {
 	if f.Cmap == nil || f.Cmap.BestFormat == nil {
@@ -258,6 +385,54 @@ func (f *Font) GetGlyphID(r rune) uint16 {
 	return f.Cmap.BestFormat.GetGlyphID(r)
 }
 
+// GetGlyphID implements CmapFormat for format 0.
+func (c *CmapFormat0) GetGlyphID(r rune) uint16 {
+	if r < 0 || r > 0xFF {
+		return 0
+	}
+	return uint16(c.GlyphIDs[r])
+}
+
+// GetGlyphID implements CmapFormat for format 2. Codes up to 0xFF are
+// single bytes; larger codes are a high byte followed by a low byte.
+func (c *CmapFormat2) GetGlyphID(r rune) uint16 {
+	if r < 0 || r > 0xFFFF {
+		return 0
+	}
+
+	high, low := uint16(r)>>8, uint16(r)&0xFF
+	var key uint16
+	if high == 0 {
+		// Single-byte code, valid only if the byte has no subheader
+		if c.SubHeaderKeys[low] != 0 {
+			return 0
+		}
+		key = 0
+	} else {
+		key = c.SubHeaderKeys[high]
+		if key == 0 {
+			return 0
+		}
+	}
+	if int(key) >= len(c.SubHeaders) {
+		return 0
+	}
+
+	sh := c.SubHeaders[key]
+	if low < sh.FirstCode || low >= sh.FirstCode+sh.EntryCount {
+		return 0
+	}
+	pos := sh.rangeOffsetPos + int(sh.IDRangeOffset) + 2*int(low-sh.FirstCode)
+	if pos+2 > len(c.data) {
+		return 0
+	}
+	glyphID := binary.BigEndian.Uint16(c.data[pos:])
+	if glyphID == 0 {
+		return 0
+	}
+	return uint16(int(glyphID) + int(sh.IDDelta))
+}
+
 // GetGlyphID implements CmapFormat for format 4.
 func (c *CmapFormat4) GetGlyphID(r rune) uint16 {
 	if r > 0xFFFF {
@@ -330,3 +505,34 @@ func (c *CmapFormat12) GetGlyphID(r rune) uint16 {
 
 	return 0
 }
+
+// GetGlyphID implements CmapFormat for format 10.
+func (c *CmapFormat10) GetGlyphID(r rune) uint16 {
+	code := uint32(r)
+	if code < c.StartCharCode || code-c.StartCharCode >= uint32(len(c.GlyphIDs)) {
+		return 0
+	}
+	return c.GlyphIDs[code-c.StartCharCode]
+}
+
+// GetGlyphID implements CmapFormat for format 13. Every character of a
+// group maps to the same glyph.
+func (c *CmapFormat13) GetGlyphID(r rune) uint16 {
+	code := uint32(r)
+
+	lo, hi := 0, len(c.Groups)-1
+	for lo <= hi {
+		mid := (lo + hi) / 2
+		group := &c.Groups[mid]
+
+		if code > group.EndCharCode {
+			lo = mid + 1
+		} else if code < group.StartCharCode {
+			hi = mid - 1
+		} else {
+			return uint16(group.StartGlyphID)
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,220 @@
+package ttf
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// cmapFont returns a font whose cmap table holds a single subtable.
+func cmapFont(t *testing.T, platformID, encodingID uint16, subtable []byte) *Font {
+	t.Helper()
+
+	table := make([]byte, 0, 12+len(subtable))
+	table = binary.BigEndian.AppendUint16(table, 0) // version
+	table = binary.BigEndian.AppendUint16(table, 1) // numTables
+	table = binary.BigEndian.AppendUint16(table, platformID)
+	table = binary.BigEndian.AppendUint16(table, encodingID)
+	table = binary.BigEndian.AppendUint32(table, 12)
+	table = append(table, subtable...)
+
+	f := &Font{Tables: map[string]*Table{"cmap": {Tag: "cmap", Data: table}}}
+	if err := f.parseCmap(); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestCmapFormat0(t *testing.T) {
+	subtable := make([]byte, 6+256)
+	binary.BigEndian.PutUint16(subtable[0:], 0)
+	binary.BigEndian.PutUint16(subtable[2:], uint16(len(subtable)))
+	for code := 0x20; code < 0x7F; code++ {
+		subtable[6+code] = byte(code - 0x1D)
+	}
+	subtable[6+0xFF] = 200
+
+	f := cmapFont(t, 1, 0, subtable)
+	tests := []struct {
+		r    rune
+		want uint16
+	}{
+		{' ', 3},
+		{'A', 0x41 - 0x1D},
+		{'~', 0x7E - 0x1D},
+		{0x1F, 0},
+		{0xFF, 200},
+		{0x100, 0},
+		{-1, 0},
+	}
+	for _, tt := range tests {
+		if got := f.GetGlyphID(tt.r); got != tt.want {
+			t.Errorf("GetGlyphID(%#x) = %d, want %d", tt.r, got, tt.want)
+		}
+	}
+}
+
+func TestCmapFormat2(t *testing.T) {
+	// Subheader 0 maps every single byte; subheader 1 maps the two-byte
+	// codes 0x8140-0x8142 with a delta of 100
+	const (
+		keysOffset       = 6
+		subHeadersOffset = keysOffset + 512
+		singleOffset     = subHeadersOffset + 2*8
+		doubleOffset     = singleOffset + 2*256
+		length           = doubleOffset + 2*3
+	)
+	subtable := make([]byte, length)
+	binary.BigEndian.PutUint16(subtable[0:], 2)
+	binary.BigEndian.PutUint16(subtable[2:], length)
+	binary.BigEndian.PutUint16(subtable[keysOffset+2*0x81:], 8)
+
+	subHeader := func(i int, firstCode, entryCount uint16, delta int16, array int) {
+		pos := subHeadersOffset + 8*i
+		binary.BigEndian.PutUint16(subtable[pos:], firstCode)
+		binary.BigEndian.PutUint16(subtable[pos+2:], entryCount)
+		binary.BigEndian.PutUint16(subtable[pos+4:], uint16(delta))
+		binary.BigEndian.PutUint16(subtable[pos+6:], uint16(array-(pos+6)))
+	}
+	subHeader(0, 0, 256, 0, singleOffset)
+	subHeader(1, 0x40, 3, 100, doubleOffset)
+
+	for code := 0x20; code < 0x7F; code++ {
+		binary.BigEndian.PutUint16(subtable[singleOffset+2*code:], uint16(code+1))
+	}
+	binary.BigEndian.PutUint16(subtable[doubleOffset:], 5)
+	binary.BigEndian.PutUint16(subtable[doubleOffset+4:], 7)
+
+	f := cmapFont(t, 3, 2, subtable)
+	tests := []struct {
+		name string
+		code rune
+		want uint16
+	}{
+		{"single byte", 'A', 'A' + 1},
+		{"unmapped single byte", 0x10, 0},
+		{"lead byte alone", 0x81, 0},
+		{"two bytes", 0x8140, 105},
+		{"missing glyph ignores delta", 0x8141, 0},
+		{"last two-byte code", 0x8142, 107},
+		{"past entry count", 0x8143, 0},
+		{"below first code", 0x813F, 0},
+		{"lead byte without subheader", 0x8240, 0},
+		{"out of range", 0x10000, 0},
+	}
+	for _, tt := range tests {
+		if got := f.GetGlyphID(tt.code); got != tt.want {
+			t.Errorf("%s: GetGlyphID(%#x) = %d, want %d", tt.name, tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestCmapFormat2Truncated(t *testing.T) {
+	subtable := make([]byte, 6+512)
+	binary.BigEndian.PutUint16(subtable[0:], 2)
+	binary.BigEndian.PutUint16(subtable[6+2*0x81:], 8)
+
+	f := &Font{Cmap: &CmapTable{}}
+	if err := f.parseCmapFormat2(subtable); err == nil {
+		t.Error("parseCmapFormat2 accepted a subtable without its subheaders")
+	}
+}
+
+// cmapFormat10 returns a format 10 subtable mapping numChars codes from
+// startCode to consecutive glyphs from firstGlyph.
+func cmapFormat10(startCode, numChars uint32, firstGlyph uint16) []byte {
+	subtable := make([]byte, 0, 20+2*numChars)
+	subtable = binary.BigEndian.AppendUint16(subtable, 10)
+	subtable = binary.BigEndian.AppendUint16(subtable, 0)                     // reserved
+	subtable = binary.BigEndian.AppendUint32(subtable, uint32(20+2*numChars)) // length
+	subtable = binary.BigEndian.AppendUint32(subtable, 0)                     // language
+	subtable = binary.BigEndian.AppendUint32(subtable, startCode)
+	subtable = binary.BigEndian.AppendUint32(subtable, numChars)
+	for i := uint32(0); i < numChars; i++ {
+		subtable = binary.BigEndian.AppendUint16(subtable, firstGlyph+uint16(i))
+	}
+	return subtable
+}
+
+func TestCmapFormat10(t *testing.T) {
+	f := cmapFont(t, 3, 10, cmapFormat10(0x1F600, 4, 50))
+	tests := []struct {
+		name string
+		code rune
+		want uint16
+	}{
+		{"first code", 0x1F600, 50},
+		{"inside range", 0x1F602, 52},
+		{"last code", 0x1F603, 53},
+		{"below range", 0x1F5FF, 0},
+		{"above range", 0x1F604, 0},
+		{"BMP", 'A', 0},
+	}
+	for _, tt := range tests {
+		if got := f.GetGlyphID(tt.code); got != tt.want {
+			t.Errorf("%s: GetGlyphID(%#x) = %d, want %d", tt.name, tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestCmapFormat10Truncated(t *testing.T) {
+	subtable := cmapFormat10(0x1F600, 4, 50)
+
+	f := &Font{Cmap: &CmapTable{}}
+	err := f.parseCmapFormat10(subtable[:len(subtable)-1])
+	if err == nil || err.Error() != "format 10 character count exceeds data" {
+		t.Errorf("parseCmapFormat10 of a truncated subtable: err = %v", err)
+	}
+}
+
+// cmapFormat13 returns a format 13 subtable holding groups, each a start
+// code, end code and glyph ID.
+func cmapFormat13(groups ...[3]uint32) []byte {
+	subtable := make([]byte, 0, 16+12*len(groups))
+	subtable = binary.BigEndian.AppendUint16(subtable, 13)
+	subtable = binary.BigEndian.AppendUint16(subtable, 0)                         // reserved
+	subtable = binary.BigEndian.AppendUint32(subtable, uint32(16+12*len(groups))) // length
+	subtable = binary.BigEndian.AppendUint32(subtable, 0)                         // language
+	subtable = binary.BigEndian.AppendUint32(subtable, uint32(len(groups)))
+	for _, group := range groups {
+		for _, v := range group {
+			subtable = binary.BigEndian.AppendUint32(subtable, v)
+		}
+	}
+	return subtable
+}
+
+func TestCmapFormat13(t *testing.T) {
+	// A last-resort font maps whole blocks to one glyph each
+	f := cmapFont(t, 0, 6, cmapFormat13(
+		[3]uint32{0x0000, 0x007F, 1},
+		[3]uint32{0x0400, 0x04FF, 2},
+		[3]uint32{0x10000, 0x10FFFF, 3},
+	))
+	tests := []struct {
+		name string
+		code rune
+		want uint16
+	}{
+		{"start of group", 0x0000, 1},
+		{"middle of group", 'A', 1},
+		{"end of group", 0x007F, 1},
+		{"between groups", 0x0100, 0},
+		{"second group", 0x0430, 2},
+		{"supplementary plane", 0x1F600, 3},
+		{"last code point", 0x10FFFF, 3},
+	}
+	for _, tt := range tests {
+		if got := f.GetGlyphID(tt.code); got != tt.want {
+			t.Errorf("%s: GetGlyphID(%#x) = %d, want %d", tt.name, tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestCmapFormat13Truncated(t *testing.T) {
+	subtable := cmapFormat13([3]uint32{0x0000, 0x007F, 1}, [3]uint32{0x0400, 0x04FF, 2})
+
+	f := &Font{Cmap: &CmapTable{}}
+	if err := f.parseCmapFormat13(subtable[:len(subtable)-12]); err == nil {
+		t.Error("parseCmapFormat13 accepted a subtable missing a group")
+	}
+}
@@ -0,0 +1,160 @@
+package ttf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image/color"
+)
+
+// ColrTable holds the color layers of glyphs (COLR version 0).
+type ColrTable struct {
+	Version    uint16
+	BaseGlyphs map[uint16]ColrBaseGlyph
+	Layers     []ColrLayer
+}
+
+// ColrBaseGlyph locates the layers of a color glyph in ColrTable.Layers.
+type ColrBaseGlyph struct {
+	FirstLayer uint16
+	NumLayers  uint16
+}
+
+// ColrLayer is one layer of a color glyph: a glyph outline and the palette
+// entry to fill it with.
+type ColrLayer struct {
+	GlyphIndex   uint16
+	PaletteIndex uint16
+}
+
+// CpalTable holds the color palettes referenced by COLR layers.
+type CpalTable struct {
+	Version  uint16
+	Palettes [][]color.RGBA
+}
+
+// ForegroundPalette is the palette index of layers drawn in the text
+// color instead of a palette entry.
+const ForegroundPalette = 0xFFFF
+
+// ColorLayer is a layer of a color glyph with its resolved color. Color is
+// zero for layers using ForegroundPalette.
+type ColorLayer struct {
+	GlyphIndex   uint16
+	PaletteIndex uint16
+	Color        color.RGBA
+}
+
+func (f *Font) parseCOLR() error {
+	table := f.Tables["COLR"]
+	if table == nil || len(table.Data) < 14 {
+		return nil
+	}
+
+	d := table.Data
+	numBase := int(binary.BigEndian.Uint16(d[2:4]))
+	baseOffset := int(binary.BigEndian.Uint32(d[4:8]))
+	layerOffset := int(binary.BigEndian.Uint32(d[8:12]))
+	numLayers := int(binary.BigEndian.Uint16(d[12:14]))
+
+	if baseOffset+numBase*6 > len(d) || layerOffset+numLayers*4 > len(d) {
+		return fmt.Errorf("COLR records out of range")
+	}
+
+	f.Colr = &ColrTable{
+		Version:    binary.BigEndian.Uint16(d[0:2]),
+		BaseGlyphs: make(map[uint16]ColrBaseGlyph, numBase),
+		Layers:     make([]ColrLayer, numLayers),
+	}
+	for i := 0; i < numBase; i++ {
+		rec := d[baseOffset+i*6:]
+		f.Colr.BaseGlyphs[binary.BigEndian.Uint16(rec[0:2])] = ColrBaseGlyph{
+			FirstLayer: binary.BigEndian.Uint16(rec[2:4]),
+			NumLayers:  binary.BigEndian.Uint16(rec[4:6]),
+		}
+	}
+	for i := range f.Colr.Layers {
+		rec := d[layerOffset+i*4:]
+		f.Colr.Layers[i] = ColrLayer{
+			GlyphIndex:   binary.BigEndian.Uint16(rec[0:2]),
+			PaletteIndex: binary.BigEndian.Uint16(rec[2:4]),
+		}
+	}
+	return nil
+}
+
+func (f *Font) parseCPAL() error {
+	table := f.Tables["CPAL"]
+	if table == nil || len(table.Data) < 12 {
+		return nil
+	}
+
+	d := table.Data
+	numEntries := int(binary.BigEndian.Uint16(d[2:4]))
+	numPalettes := int(binary.BigEndian.Uint16(d[4:6]))
+	numRecords := int(binary.BigEndian.Uint16(d[6:8]))
+	recordsOffset := int(binary.BigEndian.Uint32(d[8:12]))
+
+	if 12+numPalettes*2 > len(d) || recordsOffset+numRecords*4 > len(d) {
+		return fmt.Errorf("CPAL records out of range")
+	}
+
+	f.Cpal = &CpalTable{
+		Version:  binary.BigEndian.Uint16(d[0:2]),
+		Palettes: make([][]color.RGBA, numPalettes),
+	}
+	for p := range f.Cpal.Palettes {
+		first := int(binary.BigEndian.Uint16(d[12+p*2:]))
+		if first+numEntries > numRecords {
+			return fmt.Errorf("CPAL palette %d out of range", p)
+		}
+		palette := make([]color.RGBA, numEntries)
+		for i := range palette {
+			// Records are stored blue, green, red, alpha; color.RGBA is
+			// premultiplied
+			rec := d[recordsOffset+(first+i)*4:]
+			a := uint16(rec[3])
+			palette[i] = color.RGBA{
+				R: uint8(uint16(rec[2]) * a / 255),
+				G: uint8(uint16(rec[1]) * a / 255),
+				B: uint8(uint16(rec[0]) * a / 255),
+				A: rec[3],
+			}
+		}
+		f.Cpal.Palettes[p] = palette
+	}
+	return nil
+}
+
+// GetColorLayers returns the layers of a color glyph, bottom first, with
+// colors from the first palette. It returns nil for glyphs without layers.
+func (f *Font) GetColorLayers(glyphID uint16) ([]ColorLayer, error) {
+	if f.Colr == nil {
+		return nil, nil
+	}
+	base, ok := f.Colr.BaseGlyphs[glyphID]
+	if !ok || base.NumLayers == 0 {
+		return nil, nil
+	}
+	end := int(base.FirstLayer) + int(base.NumLayers)
+	if end > len(f.Colr.Layers) {
+		return nil, fmt.Errorf("color layers of glyph %d out of range", glyphID)
+	}
+
+	var palette []color.RGBA
+	if f.Cpal != nil && len(f.Cpal.Palettes) > 0 {
+		palette = f.Cpal.Palettes[0]
+	}
+
+	layers := make([]ColorLayer, 0, base.NumLayers)
+	for _, l := range f.Colr.Layers[base.FirstLayer:end] {
+		layer := ColorLayer{GlyphIndex: l.GlyphIndex, PaletteIndex: l.PaletteIndex}
+		if l.PaletteIndex != ForegroundPalette {
+			if int(l.PaletteIndex) >= len(palette) {
+				return nil, fmt.Errorf("glyph %d uses missing palette entry %d", glyphID, l.PaletteIndex)
+			}
+			layer.Color = palette[l.PaletteIndex]
+		}
+		layers = append(layers, layer)
+	}
+	return layers, nil
+}
@@ -291,8 +291,14 @@ func (f *Font) parseCompoundGlyph(glyph *Glyph, d []byte) (*Glyph, error) {
 			}
 		} else {
 			if offset+2 <= len(d) {
-				comp.Arg1 = int16(int8(d[offset]))
-				comp.Arg2 = int16(int8(d[offset+1]))
+				if flags&compArgsAreXYValues != 0 {
+					comp.Arg1 = int16(int8(d[offset]))
+					comp.Arg2 = int16(int8(d[offset+1]))
+				} else {
+					// Point numbers are unsigned
+					comp.Arg1 = int16(d[offset])
+					comp.Arg2 = int16(d[offset+1])
+				}
 				offset += 2
 			}
 		}
@@ -331,6 +337,19 @@ func (f *Font) parseCompoundGlyph(glyph *Glyph, d []byte) (*Glyph, error) {
 	return glyph, nil
 }
 
+// MatchesPoints returns true if Arg1 and Arg2 are point numbers rather
+// than an offset: the component is moved so that its point Arg2 lands on
+// point Arg1 of the components before it.
+func (c GlyphComponent) MatchesPoints() bool {
+	return c.Flags&compArgsAreXYValues == 0
+}
+
+// PointNumbers returns the parent and component point numbers of a
+// component that matches points.
+func (c GlyphComponent) PointNumbers() (parent, child int) {
+	return int(uint16(c.Arg1)), int(uint16(c.Arg2))
+}
+
 // fixed2dot14ToFloat converts a 2.14 fixed-point number to float64.
 func fixed2dot14ToFloat(v uint16) float64 {
 	return float64(int16(v)) / 16384.0
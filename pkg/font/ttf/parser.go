@@ -26,6 +26,8 @@ type Font struct {
 	OS2    *OS2Table
 	Post   *PostTable
 	Kern   *KernTable
+	Colr   *ColrTable
+	Cpal   *CpalTable
 
 	// Font metrics
 	UnitsPerEm   uint16
@@ -109,8 +111,12 @@ func Parse(data []byte) (*Font, error) {
 		return nil, fmt.Errorf("failed to parse hmtx: %w", err)
 	}
 
-	if err := font.parseCmap(); err != nil {
-		return nil, fmt.Errorf("failed to parse cmap: %w", err)
+	// Fonts embedded in PDFs for CID-keyed use may omit cmap; glyphs are
+	// then addressed by ID only
+	if font.Tables["cmap"] != nil {
+		if err := font.parseCmap(); err != nil {
+			return nil, fmt.Errorf("failed to parse cmap: %w", err)
+		}
 	}
 
 	if err := font.parseLoca(); err != nil {
@@ -126,6 +132,8 @@ func Parse(data []byte) (*Font, error) {
 	font.parseOS2()
 	font.parsePost()
 	font.parseKern()
+	font.parseCOLR()
+	font.parseCPAL()
 
 	return font, nil
 }
@@ -14,11 +14,18 @@ const (
 	ColorSpaceCMYK       ColorSpace = "DeviceCMYK"
 	ColorSpacePattern    ColorSpace = "Pattern"
 	ColorSpaceSeparation ColorSpace = "Separation"
+	ColorSpaceDeviceN    ColorSpace = "DeviceN"
 	ColorSpaceIndexed    ColorSpace = "Indexed"
 	ColorSpaceLab        ColorSpace = "Lab"
 	ColorSpaceICCBased   ColorSpace = "ICCBased"
 )
 
+// ColorSpaceConverter converts components in a color space that is not a
+// device space, such as Separation or ICCBased, to a device color.
+type ColorSpaceConverter interface {
+	ToColor(comps []float64) Color
+}
+
 // Color represents a PDF color value.
 type Color struct {
 	Space      ColorSpace
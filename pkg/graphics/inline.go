@@ -0,0 +1,32 @@
+package graphics
+
+// inlineImageKeys maps abbreviated inline image keys to their full names.
+var inlineImageKeys = map[string]string{
+	"BPC": "BitsPerComponent",
+	"CS":  "ColorSpace",
+	"D":   "Decode",
+	"DP":  "DecodeParms",
+	"F":   "Filter",
+	"H":   "Height",
+	"IM":  "ImageMask",
+	"I":   "Interpolate",
+	"W":   "Width",
+}
+
+// ExpandInlineImageKeys returns a copy of an inline image dictionary with
+// abbreviated keys replaced by their full names. A key given in full takes
+// precedence over its abbreviation. Values, such as abbreviated filter
+// names, are left as they are.
+func ExpandInlineImageKeys(dict map[string]interface{}) map[string]interface{} {
+	expanded := make(map[string]interface{}, len(dict))
+	for key, value := range dict {
+		if full, ok := inlineImageKeys[key]; ok {
+			if _, exists := dict[full]; exists {
+				continue
+			}
+			key = full
+		}
+		expanded[key] = value
+	}
+	return expanded
+}
@@ -118,6 +118,34 @@ func (m Matrix) Rotation() float64 {
 	return math.Atan2(m[1], m[0])
 }
 
+// Compose builds a matrix from its components, applied in the order shear,
+// scale, rotation, translation. It is the inverse of Decompose.
+func Compose(tx, ty, sx, sy, angle, shearX float64) Matrix {
+	shear := Matrix{1, 0, shearX, 1, 0, 0}
+	return shear.Multiply(Scale(sx, sy)).Multiply(Rotate(angle)).Multiply(Translate(tx, ty))
+}
+
+// Decompose splits the matrix into translation, scale, rotation (radians)
+// and horizontal shear factor such that Compose returns the original
+// matrix. A reflection shows up as a negative sy. Degenerate matrices with
+// no horizontal extent return zero scale and rotation.
+func (m Matrix) Decompose() (tx, ty, sx, sy, angle, shearX float64) {
+	tx, ty = m[4], m[5]
+
+	// The first row is the x axis: scale and rotation only
+	sx = math.Sqrt(m[0]*m[0] + m[1]*m[1])
+	if sx == 0 {
+		return tx, ty, 0, 0, 0, 0
+	}
+	angle = math.Atan2(m[1], m[0])
+
+	// The second row is the sheared, scaled y axis
+	shearX = (m[0]*m[2] + m[1]*m[3]) / (sx * sx)
+	sy = m.Determinant() / sx
+
+	return tx, ty, sx, sy, angle, shearX
+}
+
 // Point represents a 2D point.
 type Point struct {
 	X, Y float64
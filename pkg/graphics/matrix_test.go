@@ -0,0 +1,92 @@
+package graphics
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func matricesClose(a, b Matrix) bool {
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDecomposeComponents(t *testing.T) {
+	tests := []struct {
+		name                          string
+		m                             Matrix
+		tx, ty, sx, sy, angle, shearX float64
+	}{
+		{"identity", Identity(), 0, 0, 1, 1, 0, 0},
+		{"translate", Translate(10, -5), 10, -5, 1, 1, 0, 0},
+		{"scale", Scale(12, 12), 0, 0, 12, 12, 0, 0},
+		{"rotate", RotateDeg(90), 0, 0, 1, 1, math.Pi / 2, 0},
+		{"flip", Scale(1, -1), 0, 0, 1, -1, 0, 0},
+		{"text matrix", Matrix{0, 10, -10, 0, 72, 700}, 72, 700, 10, 10, math.Pi / 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, ty, sx, sy, angle, shearX := tt.m.Decompose()
+			got := []float64{tx, ty, sx, sy, angle, shearX}
+			want := []float64{tt.tx, tt.ty, tt.sx, tt.sy, tt.angle, tt.shearX}
+			for i := range got {
+				if math.Abs(got[i]-want[i]) > 1e-9 {
+					t.Fatalf("Decompose() = %v, want %v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestComposeDecomposeRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		m := Matrix{
+			rng.Float64()*20 - 10, rng.Float64()*20 - 10,
+			rng.Float64()*20 - 10, rng.Float64()*20 - 10,
+			rng.Float64()*1000 - 500, rng.Float64()*1000 - 500,
+		}
+		if math.Abs(m.Determinant()) < 1e-3 {
+			continue
+		}
+
+		got := Compose(m.Decompose())
+		if !matricesClose(got, m) {
+			t.Fatalf("Compose(Decompose(%v)) = %v", m, got)
+		}
+	}
+}
+
+func TestDecomposeComposeRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for i := 0; i < 1000; i++ {
+		tx, ty := rng.Float64()*100, rng.Float64()*100
+		sx, sy := rng.Float64()*5+0.1, rng.Float64()*10-5
+		angle := rng.Float64()*2*math.Pi - math.Pi
+		shearX := rng.Float64()*2 - 1
+		if math.Abs(sy) < 0.1 {
+			continue
+		}
+
+		m := Compose(tx, ty, sx, sy, angle, shearX)
+		gtx, gty, gsx, gsy, gangle, gshear := m.Decompose()
+		got := []float64{gtx, gty, gsx, gsy, gangle, gshear}
+		want := []float64{tx, ty, sx, sy, angle, shearX}
+		for j := range got {
+			if math.Abs(got[j]-want[j]) > 1e-9 {
+				t.Fatalf("Decompose(Compose(%v)) = %v", want, got)
+			}
+		}
+	}
+}
+
+func TestDecomposeDegenerate(t *testing.T) {
+	tx, ty, sx, sy, angle, shearX := Matrix{0, 0, 3, 4, 5, 6}.Decompose()
+	if tx != 5 || ty != 6 || sx != 0 || sy != 0 || angle != 0 || shearX != 0 {
+		t.Errorf("Decompose() = %v %v %v %v %v %v, want translation only", tx, ty, sx, sy, angle, shearX)
+	}
+}
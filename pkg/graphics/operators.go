@@ -2,8 +2,10 @@ package graphics
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Operator represents a PDF graphics operator.
@@ -22,8 +24,14 @@ type Interpreter struct {
 	OnFill     func(path *Path, state *State, rule FillRule)
 	OnStroke   func(path *Path, state *State)
 	OnClip     func(path *Path, rule FillRule)
-	OnText     func(text string, state *State)
+	OnText     func(text string, state *State) // Advances the text matrix past the shown glyphs
 	OnImage    func(name string, state *State)
+	OnShading  func(name string, state *State)
+	OnInlineImage func(dict map[string]interface{}, data []byte, state *State)
+
+	// OnOperator, if set, is called after each operator with the time it
+	// took to execute, including any callbacks it made.
+	OnOperator func(op Operator, d time.Duration)
 }
 
 // Resources holds page resources (fonts, images, etc.)
@@ -44,6 +52,8 @@ func NewInterpreter() *Interpreter {
 			Fonts:     make(map[string]interface{}),
 			XObjects:  make(map[string]interface{}),
 			ExtGState: make(map[string]interface{}),
+			ColorSpaces: make(map[string]interface{}),
+			Patterns:  make(map[string]interface{}),
 		},
 	}
 }
@@ -53,6 +63,12 @@ func (i *Interpreter) State() *State {
 	return i.stack.Current()
 }
 
+// SetState replaces the graphics state with a copy of state. Forms use it to
+// start from the state in effect where they are drawn.
+func (i *Interpreter) SetState(state *State) {
+	i.stack.states = []*State{state.Clone()}
+}
+
 // Path returns the current path.
 func (i *Interpreter) Path() *Path {
 	return i.path
@@ -61,14 +77,34 @@ func (i *Interpreter) Path() *Path {
 // Execute runs a list of operators.
 func (i *Interpreter) Execute(ops []Operator) error {
 	for _, op := range ops {
-		if err := i.executeOp(op); err != nil {
-			// Log error but continue
-			fmt.Printf("Warning: operator %s: %v\n", op.Name, err)
-		}
+		i.run(op)
 	}
 	return nil
 }
 
+// ExecuteStream parses and runs a content stream one operator at a time.
+func (i *Interpreter) ExecuteStream(data []byte) error {
+	return ParseContentStreamFunc(data, func(op Operator) error {
+		i.run(op)
+		return nil
+	})
+}
+
+// run executes an operator, logging errors and timing it for OnOperator.
+func (i *Interpreter) run(op Operator) {
+	var start time.Time
+	if i.OnOperator != nil {
+		start = time.Now()
+	}
+	if err := i.executeOp(op); err != nil {
+		// Log error but continue
+		fmt.Printf("Warning: operator %s: %v\n", op.Name, err)
+	}
+	if i.OnOperator != nil {
+		i.OnOperator(op, time.Since(start))
+	}
+}
+
 // executeOp executes a single operator.
 func (i *Interpreter) executeOp(op Operator) error {
 	state := i.stack.Current()
@@ -89,7 +125,7 @@ func (i *Interpreter) executeOp(op Operator) error {
 				toFloat(op.Operands[4]),
 				toFloat(op.Operands[5]),
 			}
-			state.CTM = state.CTM.Multiply(m)
+			state.CTM = m.Multiply(state.CTM)
 		}
 	case "w":
 		if len(op.Operands) >= 1 {
@@ -237,39 +273,50 @@ func (i *Interpreter) executeOp(op Operator) error {
 		if i.OnClip != nil {
 			i.OnClip(i.path, FillRuleNonZero)
 		}
-		state.ClipPath = i.path.Clone()
+		intersectClip(state, i.path.Transform(state.CTM))
 	case "W*":
 		if i.OnClip != nil {
 			i.OnClip(i.path, FillRuleEvenOdd)
 		}
-		state.ClipPath = i.path.Clone()
+		intersectClip(state, i.path.Transform(state.CTM))
+		
+	// Shading operator
+	case "sh":
+		if len(op.Operands) >= 1 && i.OnShading != nil {
+			i.OnShading(toString(op.Operands[0]), state)
+		}
 		
 	// Color operators
 	case "CS":
 		if len(op.Operands) >= 1 {
 			state.StrokeColorSpace = ColorSpace(toString(op.Operands[0]))
+			state.StrokePattern = ""
 		}
 	case "cs":
 		if len(op.Operands) >= 1 {
 			state.FillColorSpace = ColorSpace(toString(op.Operands[0]))
+			state.FillPattern = ""
 		}
 	case "SC", "SCN":
-		state.StrokeColor = i.parseColor(state.StrokeColorSpace, op.Operands)
+		state.StrokePattern, state.StrokeColor = i.parsePatternColor(state.StrokeColorSpace, op.Operands)
 	case "sc", "scn":
-		state.FillColor = i.parseColor(state.FillColorSpace, op.Operands)
+		state.FillPattern, state.FillColor = i.parsePatternColor(state.FillColorSpace, op.Operands)
 	case "G":
 		if len(op.Operands) >= 1 {
 			state.StrokeColorSpace = ColorSpaceDeviceGray
+			state.StrokePattern = ""
 			state.StrokeColor = NewGray(toFloat(op.Operands[0]))
 		}
 	case "g":
 		if len(op.Operands) >= 1 {
 			state.FillColorSpace = ColorSpaceDeviceGray
+			state.FillPattern = ""
 			state.FillColor = NewGray(toFloat(op.Operands[0]))
 		}
 	case "RG":
 		if len(op.Operands) >= 3 {
 			state.StrokeColorSpace = ColorSpaceDeviceRGB
+			state.StrokePattern = ""
 			state.StrokeColor = NewRGB(
 				toFloat(op.Operands[0]),
 				toFloat(op.Operands[1]),
@@ -279,6 +326,7 @@ func (i *Interpreter) executeOp(op Operator) error {
 	case "rg":
 		if len(op.Operands) >= 3 {
 			state.FillColorSpace = ColorSpaceDeviceRGB
+			state.FillPattern = ""
 			state.FillColor = NewRGB(
 				toFloat(op.Operands[0]),
 				toFloat(op.Operands[1]),
@@ -288,6 +336,7 @@ func (i *Interpreter) executeOp(op Operator) error {
 	case "K":
 		if len(op.Operands) >= 4 {
 			state.StrokeColorSpace = ColorSpaceCMYK
+			state.StrokePattern = ""
 			state.StrokeColor = NewCMYK(
 				toFloat(op.Operands[0]),
 				toFloat(op.Operands[1]),
@@ -298,6 +347,7 @@ func (i *Interpreter) executeOp(op Operator) error {
 	case "k":
 		if len(op.Operands) >= 4 {
 			state.FillColorSpace = ColorSpaceCMYK
+			state.FillPattern = ""
 			state.FillColor = NewCMYK(
 				toFloat(op.Operands[0]),
 				toFloat(op.Operands[1]),
@@ -332,6 +382,7 @@ func (i *Interpreter) executeOp(op Operator) error {
 		if len(op.Operands) >= 2 {
 			state.TextState.FontName = toString(op.Operands[0])
 			state.TextState.FontSize = toFloat(op.Operands[1])
+			state.TextState.Font = nil
 		}
 	case "Tr":
 		if len(op.Operands) >= 1 {
@@ -378,15 +429,18 @@ func (i *Interpreter) executeOp(op Operator) error {
 	case "TJ":
 		if len(op.Operands) >= 1 {
 			if arr, ok := op.Operands[0].([]interface{}); ok {
-				var text string
 				for _, item := range arr {
-					if s, ok := item.(string); ok {
-						text += s
+					switch v := item.(type) {
+					case string:
+						if i.OnText != nil && v != "" {
+							i.OnText(v, state)
+						}
+					case float64:
+						// Kerning in thousandths of text space, negative moves right
+						tx := -(v / 1000) * state.TextState.FontSize * state.TextState.HScale / 100
+						state.TextState.TextMatrix = Translate(tx, 0).Multiply(state.TextState.TextMatrix)
 					}
 				}
-				if i.OnText != nil && text != "" {
-					i.OnText(text, state)
-				}
 			}
 		}
 	case "'":
@@ -408,6 +462,14 @@ func (i *Interpreter) executeOp(op Operator) error {
 			}
 		}
 		
+	// Inline images, assembled from BI ... ID ... EI by the parser
+	case "BI":
+		if len(op.Operands) >= 2 && i.OnInlineImage != nil {
+			dict, _ := op.Operands[0].(map[string]interface{})
+			data, _ := op.Operands[1].([]byte)
+			i.OnInlineImage(dict, data, state)
+		}
+		
 	// XObject operators
 	case "Do":
 		if len(op.Operands) >= 1 {
@@ -420,6 +482,39 @@ func (i *Interpreter) executeOp(op Operator) error {
 	return nil
 }
 
+// intersectClip narrows the clipping path of the state to the given path,
+// which must already be in page space.
+func intersectClip(state *State, path *Path) {
+	if state.ClipPath == nil {
+		state.ClipPath = path
+		return
+	}
+	state.ClipPath = PathIntersection(state.ClipPath, path)
+}
+
+// parsePatternColor reads SCN/scn operands. A trailing name selects a
+// pattern; any components before it are the color of an uncolored pattern.
+func (i *Interpreter) parsePatternColor(space ColorSpace, operands []interface{}) (string, Color) {
+	if len(operands) > 0 {
+		if name, ok := operands[len(operands)-1].(string); ok {
+			comps := operands[:len(operands)-1]
+			return name, i.parseColor(componentSpace(len(comps)), comps)
+		}
+	}
+	return "", i.parseColor(space, operands)
+}
+
+// componentSpace guesses the device color space from a component count.
+func componentSpace(n int) ColorSpace {
+	switch n {
+	case 1:
+		return ColorSpaceDeviceGray
+	case 4:
+		return ColorSpaceCMYK
+	}
+	return ColorSpaceDeviceRGB
+}
+
 // parseColor creates a Color from operands based on the color space.
 func (i *Interpreter) parseColor(space ColorSpace, operands []interface{}) Color {
 	switch space {
@@ -444,15 +539,123 @@ func (i *Interpreter) parseColor(space ColorSpace, operands []interface{}) Color
 				toFloat(operands[3]),
 			)
 		}
+	default:
+		// Named spaces are converted by the renderer's resources
+		comps := make([]float64, len(operands))
+		for n, op := range operands {
+			comps[n] = toFloat(op)
+		}
+		if conv, ok := i.Resources.ColorSpaces[string(space)].(ColorSpaceConverter); ok {
+			return conv.ToColor(comps)
+		}
+		if len(comps) > 0 {
+			return i.parseColor(componentSpace(len(comps)), operands)
+		}
 	}
 	return Black()
 }
 
-// applyExtGState applies an extended graphics state dictionary.
+// applyExtGState applies an extended graphics state dictionary from the
+// resources. Entries that are not recognized are ignored.
 func (i *Interpreter) applyExtGState(name string) {
-	// This would look up the ExtGState in Resources and apply it
-	// For now, just a placeholder
-	_ = name
+	gs, ok := i.Resources.ExtGState[name].(map[string]interface{})
+	if !ok {
+		return
+	}
+	
+	state := i.stack.Current()
+	
+	for key, value := range gs {
+		switch key {
+		case "LW":
+			state.LineWidth = toFloat(value)
+		case "LC":
+			state.LineCap = LineCap(toInt(value))
+		case "LJ":
+			state.LineJoin = LineJoin(toInt(value))
+		case "ML":
+			state.MiterLimit = toFloat(value)
+		case "D":
+			// [dashArray dashPhase]
+			if arr, ok := value.([]interface{}); ok && len(arr) >= 2 {
+				if dash, ok := arr[0].([]interface{}); ok {
+					state.DashPattern = make([]float64, len(dash))
+					for j, v := range dash {
+						state.DashPattern[j] = toFloat(v)
+					}
+				}
+				state.DashPhase = toFloat(arr[1])
+			}
+		case "ca":
+			state.FillAlpha = toFloat(value)
+		case "CA":
+			state.StrokeAlpha = toFloat(value)
+		case "BM":
+			state.BlendMode = parseBlendMode(value)
+		case "Font":
+			// [font size], where font is the font dictionary itself
+			if arr, ok := value.([]interface{}); ok && len(arr) >= 2 {
+				state.TextState.Font = arr[0]
+				state.TextState.FontSize = toFloat(arr[1])
+			}
+		case "SMask":
+			state.SoftMask = parseSoftMask(value, state.CTM)
+		case "RI":
+			state.RenderingIntent = toString(value)
+		case "FL":
+			state.Flatness = toFloat(value)
+		case "SM":
+			state.Smoothness = toFloat(value)
+		}
+	}
+}
+
+// parseSoftMask reads an SMask entry. The name None removes the mask.
+func parseSoftMask(value interface{}, ctm Matrix) *SoftMask {
+	dict, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	
+	mask := &SoftMask{
+		Subtype: toString(dict["S"]),
+		Group:   dict["G"],
+		CTM:     ctm,
+	}
+	if mask.Group == nil {
+		return nil
+	}
+	
+	if bc, ok := dict["BC"].([]interface{}); ok {
+		mask.Backdrop = make([]float64, len(bc))
+		for j, v := range bc {
+			mask.Backdrop[j] = toFloat(v)
+		}
+	}
+	
+	return mask
+}
+
+// parseBlendMode reads a BM entry. An array lists modes in order of
+// preference; the first supported one is used.
+func parseBlendMode(value interface{}) BlendMode {
+	names := []interface{}{value}
+	if arr, ok := value.([]interface{}); ok {
+		names = arr
+	}
+	
+	for _, n := range names {
+		mode := BlendMode(toString(n))
+		switch mode {
+		case "Compatible":
+			return BlendNormal
+		case BlendNormal, BlendMultiply, BlendScreen, BlendOverlay,
+			BlendDarken, BlendLighten, BlendColorDodge, BlendColorBurn,
+			BlendHardLight, BlendSoftLight, BlendDifference, BlendExclusion:
+			return mode
+		}
+	}
+	return BlendNormal
 }
 
 // Helper functions for type conversion
@@ -500,131 +703,215 @@ func toString(v interface{}) string {
 // ParseContentStream parses a PDF content stream into operators.
 func ParseContentStream(data []byte) ([]Operator, error) {
 	var ops []Operator
-	var operands []interface{}
 	
-	tokens := tokenize(string(data))
-	
-	for _, tok := range tokens {
-		if isOperator(tok) {
-			ops = append(ops, Operator{
-				Name:     tok,
-				Operands: operands,
-			})
-			operands = nil
-		} else {
-			operands = append(operands, parseOperand(tok))
-		}
-	}
+	err := ParseContentStreamFunc(data, func(op Operator) error {
+		ops = append(ops, op)
+		return nil
+	})
 	
-	return ops, nil
+	return ops, err
 }
 
-// tokenize splits content stream into tokens.
-func tokenize(s string) []string {
-	var tokens []string
-	var current strings.Builder
-	inString := false
-	parenDepth := 0
-	inHex := false
+// ParseContentStreamFunc parses a PDF content stream and calls fn for each
+// operator as soon as it is complete, without collecting them all first.
+// The stream is lexed in place, without copying it. Parsing stops at the
+// first error returned by fn.
+func ParseContentStreamFunc(data []byte, fn func(Operator) error) error {
+	var operands []interface{}
+	var arrays [][]interface{} // Arrays still open, innermost last
 	
-	for i := 0; i < len(s); i++ {
-		c := s[i]
+	// Inline image dictionary, set between ID and EI
+	var inlineDict map[string]interface{}
+	var inlineData []byte
+	
+	return tokenizeFunc(data, func(tok []byte) error {
+		if inlineDict != nil && inlineData == nil {
+			// The token after ID is the raw image data, copied as data
+			// may be reused by the caller
+			inlineData = append([]byte{}, tok...)
+			return nil
+		}
 		
-		if inString {
-			current.WriteByte(c)
-			if c == '\\' && i+1 < len(s) {
-				i++
-				current.WriteByte(s[i])
-				continue
+		switch string(tok) {
+		case "[":
+			arrays = append(arrays, []interface{}{})
+			return nil
+		case "]":
+			if len(arrays) == 0 {
+				// Unbalanced bracket, ignore it
+				return nil
 			}
-			if c == '(' {
-				parenDepth++
-			} else if c == ')' {
-				parenDepth--
-				if parenDepth == 0 {
-					tokens = append(tokens, current.String())
-					current.Reset()
-					inString = false
-				}
+			arr := arrays[len(arrays)-1]
+			arrays = arrays[:len(arrays)-1]
+			if len(arrays) > 0 {
+				arrays[len(arrays)-1] = append(arrays[len(arrays)-1], arr)
+			} else {
+				operands = append(operands, arr)
 			}
-			continue
+			return nil
+		}
+		
+		if len(arrays) > 0 {
+			arrays[len(arrays)-1] = append(arrays[len(arrays)-1], parseOperand(tok))
+			return nil
+		}
+		
+		name, ok := operatorNames[string(tok)]
+		if !ok {
+			operands = append(operands, parseOperand(tok))
+			return nil
 		}
 		
-		if inHex {
-			current.WriteByte(c)
-			if c == '>' {
-				tokens = append(tokens, current.String())
-				current.Reset()
-				inHex = false
+		switch name {
+		case "BI":
+			// Keys and values up to ID are collected as operands
+			operands = nil
+			return nil
+		case "ID":
+			inlineDict = make(map[string]interface{})
+			for j := 0; j+1 < len(operands); j += 2 {
+				inlineDict[toString(operands[j])] = operands[j+1]
 			}
-			continue
+			inlineDict = ExpandInlineImageKeys(inlineDict)
+			inlineData = nil
+			operands = nil
+			return nil
+		case "EI":
+			if inlineDict == nil {
+				return nil
+			}
+			op := Operator{
+				Name:     "BI",
+				Operands: []interface{}{inlineDict, inlineData},
+			}
+			inlineDict, inlineData = nil, nil
+			return fn(op)
 		}
 		
-		switch c {
+		op := Operator{
+			Name:     name,
+			Operands: operands,
+		}
+		operands = nil
+		return fn(op)
+	})
+}
+
+// tokenizeFunc splits content stream into tokens, calling emit for each one.
+// Tokens are slices of data. Tokenizing stops at the first error returned
+// by emit.
+func tokenizeFunc(data []byte, emit func([]byte) error) error {
+	var err error
+	start := -1 // Start of the token being read, -1 between tokens
+	
+	flush := func(end int) {
+		if start >= 0 && err == nil {
+			err = emit(data[start:end])
+		}
+		start = -1
+	}
+	
+	for i := 0; i < len(data) && err == nil; i++ {
+		switch c := data[i]; c {
 		case '(':
-			if current.Len() > 0 {
-				tokens = append(tokens, current.String())
-				current.Reset()
+			flush(i)
+			start = i
+			depth := 0
+			for ; i < len(data); i++ {
+				if data[i] == '\\' {
+					i++
+					continue
+				}
+				if data[i] == '(' {
+					depth++
+				} else if data[i] == ')' {
+					depth--
+					if depth == 0 {
+						break
+					}
+				}
+			}
+			if i < len(data) {
+				flush(i + 1)
 			}
-			current.WriteByte(c)
-			inString = true
-			parenDepth = 1
 		case '<':
-			if current.Len() > 0 {
-				tokens = append(tokens, current.String())
-				current.Reset()
+			flush(i)
+			start = i
+			for i < len(data) && data[i] != '>' {
+				i++
 			}
-			current.WriteByte(c)
-			inHex = true
-		case '[':
-			if current.Len() > 0 {
-				tokens = append(tokens, current.String())
-				current.Reset()
+			if i < len(data) {
+				flush(i + 1)
 			}
-			tokens = append(tokens, "[")
-		case ']':
-			if current.Len() > 0 {
-				tokens = append(tokens, current.String())
-				current.Reset()
+		case '[', ']':
+			flush(i)
+			if err == nil {
+				err = emit(data[i : i+1])
 			}
-			tokens = append(tokens, "]")
 		case ' ', '\t', '\r', '\n':
-			if current.Len() > 0 {
-				tokens = append(tokens, current.String())
-				current.Reset()
+			tokStart := start
+			flush(i)
+			if tokStart >= 0 && string(data[tokStart:i]) == "ID" && err == nil {
+				// Inline image data is binary and ends at EI
+				image, next := readInlineImageData(data, i+1)
+				err = emit(image)
+				if err == nil {
+					err = emit(eiToken)
+				}
+				i = next - 1
 			}
 		case '/':
-			if current.Len() > 0 {
-				tokens = append(tokens, current.String())
-				current.Reset()
-			}
+			flush(i)
 			// Read name
-			current.WriteByte(c)
-			for i+1 < len(s) && !isDelimiter(s[i+1]) && !isSpace(s[i+1]) {
+			start = i
+			for i+1 < len(data) && !isDelimiter(data[i+1]) && !isSpace(data[i+1]) {
 				i++
-				current.WriteByte(s[i])
 			}
-			tokens = append(tokens, current.String())
-			current.Reset()
+			flush(i + 1)
 		case '%':
 			// Skip comment
-			if current.Len() > 0 {
-				tokens = append(tokens, current.String())
-				current.Reset()
-			}
-			for i < len(s) && s[i] != '\n' && s[i] != '\r' {
+			flush(i)
+			for i < len(data) && data[i] != '\n' && data[i] != '\r' {
 				i++
 			}
 		default:
-			current.WriteByte(c)
+			if start < 0 {
+				start = i
+			}
 		}
 	}
 	
-	if current.Len() > 0 {
-		tokens = append(tokens, current.String())
-	}
+	// An unterminated string or hex string runs to the end
+	flush(len(data))
 	
-	return tokens
+	return err
+}
+
+// eiToken ends the inline image data, even if the stream ends first.
+var eiToken = []byte("EI")
+
+// readInlineImageData returns the inline image data starting at start and
+// the position after the EI operator that ends it.
+func readInlineImageData(data []byte, start int) ([]byte, int) {
+	for j := start; j+2 <= len(data); j++ {
+		if data[j] != 'E' || data[j+1] != 'I' {
+			continue
+		}
+		// EI must be a separate token
+		if j > start && !isSpace(data[j-1]) {
+			continue
+		}
+		if j+2 < len(data) && !isSpace(data[j+2]) && !isDelimiter(data[j+2]) {
+			continue
+		}
+		
+		end := j
+		if end > start {
+			end-- // Whitespace before EI
+		}
+		return data[start:end], j + 2
+	}
+	return data[start:], len(data)
 }
 
 func isDelimiter(c byte) bool {
@@ -636,28 +923,65 @@ func isSpace(c byte) bool {
 	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
 }
 
-// isOperator returns true if the token is a PDF operator.
-func isOperator(tok string) bool {
-	// Numbers and names are not operators
-	if len(tok) == 0 {
-		return false
-	}
-	if tok[0] == '/' || tok[0] == '(' || tok[0] == '<' || tok[0] == '[' || tok[0] == ']' {
-		return false
-	}
-	// Check if it's a number
-	if (tok[0] >= '0' && tok[0] <= '9') || tok[0] == '-' || tok[0] == '+' || tok[0] == '.' {
-		return false
+// operators holds every content stream operator in the PDF specification,
+// grouped as in its operator tables.
+var operators = map[string]bool{
+	// General graphics state
+	"w": true, "J": true, "j": true, "M": true, "d": true, "ri": true, "i": true, "gs": true,
+	// Special graphics state
+	"q": true, "Q": true, "cm": true,
+	// Path construction
+	"m": true, "l": true, "c": true, "v": true, "y": true, "h": true, "re": true,
+	// Path painting
+	"S": true, "s": true, "f": true, "F": true, "f*": true, "B": true, "B*": true,
+	"b": true, "b*": true, "n": true,
+	// Clipping paths
+	"W": true, "W*": true,
+	// Text objects, state, positioning and showing
+	"BT": true, "ET": true,
+	"Tc": true, "Tw": true, "Tz": true, "TL": true, "Tf": true, "Tr": true, "Ts": true,
+	"Td": true, "TD": true, "Tm": true, "T*": true,
+	"Tj": true, "TJ": true, "'": true, "\"": true,
+	// Type 3 fonts
+	"d0": true, "d1": true,
+	// Color
+	"CS": true, "cs": true, "SC": true, "SCN": true, "sc": true, "scn": true,
+	"G": true, "g": true, "RG": true, "rg": true, "K": true, "k": true,
+	// Shading patterns
+	"sh": true,
+	// Inline images
+	"BI": true, "ID": true, "EI": true,
+	// XObjects
+	"Do": true,
+	// Marked content
+	"MP": true, "DP": true, "BMC": true, "BDC": true, "EMC": true,
+	// Compatibility
+	"BX": true, "EX": true,
+}
+
+// operatorNames maps each operator to its name, so an operator lexed from
+// a stream can share the name instead of allocating a copy. Other keywords,
+// such as true, false and null, are operands.
+var operatorNames = func() map[string]string {
+	names := make(map[string]string, len(operators))
+	for name := range operators {
+		names[name] = name
 	}
-	// true, false, null are operands
-	if tok == "true" || tok == "false" || tok == "null" {
-		return false
+	return names
+}()
+
+// AllOperators returns the names of all content stream operators, sorted.
+func AllOperators() []string {
+	names := make([]string, 0, len(operators))
+	for name := range operators {
+		names = append(names, name)
 	}
-	return true
+	sort.Strings(names)
+	return names
 }
 
 // parseOperand converts a token to an operand value.
-func parseOperand(tok string) interface{} {
+func parseOperand(tok []byte) interface{} {
 	if len(tok) == 0 {
 		return nil
 	}
@@ -674,32 +998,28 @@ func parseOperand(tok string) interface{} {
 	
 	// Name
 	if tok[0] == '/' {
-		return tok[1:]
+		return string(tok[1:])
 	}
 	
-	// Boolean
-	if tok == "true" {
+	switch string(tok) {
+	case "true":
 		return true
-	}
-	if tok == "false" {
+	case "false":
 		return false
-	}
-	
-	// Null
-	if tok == "null" {
+	case "null":
 		return nil
 	}
 	
 	// Number
-	if f, err := strconv.ParseFloat(tok, 64); err == nil {
+	if f, err := strconv.ParseFloat(string(tok), 64); err == nil {
 		return f
 	}
 	
-	return tok
+	return string(tok)
 }
 
 // decodeString decodes escape sequences in a PDF string.
-func decodeString(s string) string {
+func decodeString(s []byte) string {
 	var result strings.Builder
 	
 	for i := 0; i < len(s); i++ {
@@ -746,7 +1066,7 @@ func decodeString(s string) string {
 }
 
 // decodeHexString decodes a hex-encoded PDF string.
-func decodeHexString(s string) string {
+func decodeHexString(s []byte) string {
 	var result strings.Builder
 	var hex byte
 	var hasNibble bool
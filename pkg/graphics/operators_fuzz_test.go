@@ -0,0 +1,36 @@
+package graphics
+
+import "testing"
+
+// contentSeeds are content stream fragments covering the operator families.
+var contentSeeds = []string{
+	"q 1 0 0 1 72 700 cm 0 0 m 100 100 l S Q",
+	"10 10 50 50 re f* 0.5 g 1 0 0 rg 0 0 1 0 k",
+	"BT /F1 12 Tf 14 TL 72 700 Td (Hello) Tj T* [(a) -250 (b)] TJ (x) ' 1 2 (y) \" ET",
+	"BT 2 Tc 3 Tw 90 Tz 4 Ts 3 Tr ET",
+	"/GS0 gs /Im1 Do /Sh0 sh /P0 scn /CS0 cs 0.1 0.2 0.3 sc",
+	"BI /W 2 /H 1 /BPC 8 /CS /G ID \x00\xff EI",
+	"BI /W 1 /H 1 ID", "/Tag <</MCID 0>> BDC EMC",
+	"<414243> Tj (unbalanced ( Tj [1 2 [3]] TJ",
+	"q q q Q Q Q Q Q", "0 0 m 1 1 2 2 3 3 c 4 4 5 5 v 6 6 7 7 y h W n",
+	"1e308 1e308 1e308 1e308 1e308 1e308 cm 0 0 0 0 re f",
+}
+
+func FuzzParseContentStream(f *testing.F) {
+	for _, seed := range contentSeeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ops, err := ParseContentStream(data)
+		if err != nil {
+			return
+		}
+
+		interp := NewInterpreter()
+		interp.OnFill = func(*Path, *State, FillRule) {}
+		interp.OnStroke = func(*Path, *State) {}
+		interp.OnText = func(string, *State) {}
+		interp.Execute(ops)
+	})
+}
@@ -0,0 +1,78 @@
+package graphics
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestParseContentStream(t *testing.T) {
+	data := []byte("q 1 0 0 1 72 700 cm % comment\n" +
+		"BT /F1 12 Tf (a\\(b\\)\\101) Tj [<4869> -250 (x)] TJ ET\n" +
+		"BI /W 2 /H 1 /BPC 8 /CS /G ID \x00\xff EI Q")
+
+	ops, err := ParseContentStream(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Operator{
+		{Name: "q"},
+		{Name: "cm", Operands: []interface{}{1.0, 0.0, 0.0, 1.0, 72.0, 700.0}},
+		{Name: "BT"},
+		{Name: "Tf", Operands: []interface{}{"F1", 12.0}},
+		{Name: "Tj", Operands: []interface{}{"a(b)A"}},
+		{Name: "TJ", Operands: []interface{}{[]interface{}{"Hi", -250.0, "x"}}},
+		{Name: "ET"},
+		{Name: "BI", Operands: []interface{}{
+			map[string]interface{}{"Width": 2.0, "Height": 1.0, "BitsPerComponent": 8.0, "ColorSpace": "G"},
+			[]byte{0x00, 0xff},
+		}},
+		{Name: "Q"},
+	}
+	if !reflect.DeepEqual(ops, want) {
+		t.Errorf("ParseContentStream:\n got %#v\nwant %#v", ops, want)
+	}
+}
+
+func TestParseContentStreamFuncStops(t *testing.T) {
+	stop := fmt.Errorf("stop")
+	var names []string
+	err := ParseContentStreamFunc([]byte("q Q q Q"), func(op Operator) error {
+		names = append(names, op.Name)
+		if len(names) == 2 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop {
+		t.Errorf("error = %v, want %v", err, stop)
+	}
+	if len(names) != 2 {
+		t.Errorf("got operators %v, want 2", names)
+	}
+}
+
+// benchmarkContent is a page of text and paths like typical body text.
+func benchmarkContent() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("q 0.5 0 0 0.5 0 0 cm 0 0 1 RG 2 w\n")
+	for i := 0; i < 500; i++ {
+		fmt.Fprintf(&buf, "%d %d m %d %d l S\n", i, i*2, i+100, i*2)
+		fmt.Fprintf(&buf, "BT /F1 10 Tf %d %d Td [(Line) -120 (number %d)] TJ ET\n", 72, 700-i, i)
+	}
+	buf.WriteString("Q\n")
+	return buf.Bytes()
+}
+
+func BenchmarkParseContentStream(b *testing.B) {
+	data := benchmarkContent()
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := ParseContentStreamFunc(data, func(Operator) error { return nil }); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package graphics
+
+// OperatorGroup identifies the kind of an OperatorNode.
+type OperatorGroup int
+
+const (
+	GroupNone  OperatorGroup = iota // A single operator
+	GroupState                      // Operators between q and Q
+	GroupText                       // A text block, operators between BT and ET
+)
+
+// OperatorNode is an operator in the tree built by GroupOperators. Group
+// nodes hold the opening q or BT as Op and the operators up to the matching
+// Q or ET as Children.
+type OperatorNode struct {
+	Index    int // Position of Op in the operator list
+	Op       Operator
+	Group    OperatorGroup
+	CTM      Matrix // Transformation in effect after Op
+	Children []*OperatorNode
+	End      int // Position of the closing Q or ET, -1 if never closed
+}
+
+// GroupOperators arranges a content stream's operators into a tree, nesting
+// the operators of each q/Q pair and BT/ET text block under a group node
+// and tracking the transformation matrix through q, Q and cm. A Q or ET
+// without a matching opener stays a single operator; one that skips an
+// unclosed group of the other kind closes that group too.
+func GroupOperators(ops []Operator) []*OperatorNode {
+	var roots []*OperatorNode
+	var open []*OperatorNode // Groups not closed yet, innermost last
+	ctms := []Matrix{Identity()}
+
+	add := func(node *OperatorNode) {
+		if len(open) > 0 {
+			parent := open[len(open)-1]
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+
+	// closeGroup closes the innermost open group of kind g and any opened
+	// inside it, reporting whether there was one
+	closeGroup := func(g OperatorGroup, index int) bool {
+		for j := len(open) - 1; j >= 0; j-- {
+			if open[j].Group != g {
+				continue
+			}
+			for _, node := range open[j:] {
+				node.End = index
+				if node.Group == GroupState && len(ctms) > 1 {
+					ctms = ctms[:len(ctms)-1]
+				}
+			}
+			open = open[:j]
+			return true
+		}
+		return false
+	}
+
+	for index, op := range ops {
+		ctm := ctms[len(ctms)-1]
+		node := &OperatorNode{Index: index, Op: op, End: -1}
+
+		switch op.Name {
+		case "q":
+			node.Group = GroupState
+			node.CTM = ctm
+			add(node)
+			open = append(open, node)
+			ctms = append(ctms, ctm)
+			continue
+		case "BT":
+			node.Group = GroupText
+			node.CTM = ctm
+			add(node)
+			open = append(open, node)
+			continue
+		case "Q", "ET":
+			g := GroupState
+			if op.Name == "ET" {
+				g = GroupText
+			}
+			if closeGroup(g, index) {
+				continue
+			}
+		case "cm":
+			if len(op.Operands) >= 6 {
+				var m Matrix
+				for k := range m {
+					m[k] = toFloat(op.Operands[k])
+				}
+				ctm = m.Multiply(ctm)
+				ctms[len(ctms)-1] = ctm
+			}
+		}
+		node.CTM = ctm
+		add(node)
+	}
+	return roots
+}
@@ -104,8 +104,16 @@ func (p *Path) Close() {
 	p.current = p.start
 }
 
-// Rect adds a rectangle to the path.
+// Rect adds a rectangle to the path. A negative width or height gives the
+// rectangle from the opposite corner; it is normalized so the subpath
+// always starts at the lower-left corner.
 func (p *Path) Rect(x, y, width, height float64) {
+	if width < 0 {
+		x, width = x+width, -width
+	}
+	if height < 0 {
+		y, height = y+height, -height
+	}
 	p.MoveTo(x, y)
 	p.LineTo(x+width, y)
 	p.LineTo(x+width, y+height)
@@ -113,6 +121,36 @@ func (p *Path) Rect(x, y, width, height float64) {
 	p.Close()
 }
 
+// Append adds copies of the segments of other to the end of the path. The
+// subpaths of other keep their own start points: a path that does not begin
+// with a MoveTo continues from the receiver's current point. Afterwards the
+// current point is that of the last appended segment. Appending an empty
+// path changes nothing.
+func (p *Path) Append(other *Path) {
+	if other == nil || len(other.Segments) == 0 {
+		return
+	}
+	for _, seg := range other.Segments {
+		points := make([]Point, len(seg.Points))
+		copy(points, seg.Points)
+		p.Segments = append(p.Segments, PathSegment{Op: seg.Op, Points: points})
+		if seg.Op == PathOpMoveTo {
+			p.start = points[0]
+		}
+	}
+	p.current = other.current
+}
+
+// CombinePaths returns a new path holding the segments of all the given
+// paths in order. Nil paths are skipped.
+func CombinePaths(paths ...*Path) *Path {
+	combined := NewPath()
+	for _, path := range paths {
+		combined.Append(path)
+	}
+	return combined
+}
+
 // Clear removes all segments from the path.
 func (p *Path) Clear() {
 	p.Segments = p.Segments[:0]
@@ -177,6 +215,67 @@ func (p *Path) Transform(m Matrix) *Path {
 	return result
 }
 
+// Reverse returns a new path with every subpath traversed in the opposite
+// direction. Each subpath starts at the point where it used to end, its
+// lines and curves are visited backwards and closed subpaths stay closed.
+// Subpaths keep their order. Reversing a subpath flips its winding, so a
+// reversed inner path appended to an outer one cuts a hole in it under the
+// non-zero rule.
+func (p *Path) Reverse() *Path {
+	result := NewPath()
+
+	var start Point
+	var segs []PathSegment
+	begun := false
+
+	// flush adds the subpath collected so far to result, reversed
+	flush := func(closed bool) {
+		if !begun {
+			return
+		}
+		// from[i] is the point segs[i] starts at
+		from := make([]Point, len(segs))
+		pt := start
+		for i, seg := range segs {
+			from[i] = pt
+			pt = seg.Points[len(seg.Points)-1]
+		}
+
+		result.MoveTo(pt.X, pt.Y)
+		for i := len(segs) - 1; i >= 0; i-- {
+			seg := segs[i]
+			if seg.Op == PathOpCurveTo {
+				result.CurveTo(seg.Points[1].X, seg.Points[1].Y,
+					seg.Points[0].X, seg.Points[0].Y, from[i].X, from[i].Y)
+			} else {
+				result.LineTo(from[i].X, from[i].Y)
+			}
+		}
+		if closed {
+			result.Close()
+		}
+		segs = segs[:0]
+		begun = false
+	}
+
+	for _, seg := range p.Segments {
+		switch seg.Op {
+		case PathOpMoveTo:
+			flush(false)
+			start = seg.Points[0]
+			begun = true
+		case PathOpClose:
+			flush(true)
+		default:
+			// A segment after Close continues from the subpath's start
+			begun = true
+			segs = append(segs, seg)
+		}
+	}
+	flush(false)
+	return result
+}
+
 // FillRule represents the fill rule for path filling.
 type FillRule int
 
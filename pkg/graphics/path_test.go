@@ -0,0 +1,60 @@
+package graphics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPathRectNegativeDimensions(t *testing.T) {
+	// Every rectangle covers x 10-30 and y 20-60
+	tests := []struct {
+		name                string
+		x, y, width, height float64
+	}{
+		{"positive", 10, 20, 20, 40},
+		{"negative width", 30, 20, -20, 40},
+		{"negative height", 10, 60, 20, -40},
+		{"negative both", 30, 60, -20, -40},
+	}
+	want := []PathSegment{
+		{Op: PathOpMoveTo, Points: []Point{{10, 20}}},
+		{Op: PathOpLineTo, Points: []Point{{30, 20}}},
+		{Op: PathOpLineTo, Points: []Point{{30, 60}}},
+		{Op: PathOpLineTo, Points: []Point{{10, 60}}},
+		{Op: PathOpClose},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPath()
+			p.Rect(tt.x, tt.y, tt.width, tt.height)
+
+			if got := p.Bounds(); got != (Rect{X: 10, Y: 20, Width: 20, Height: 40}) {
+				t.Errorf("Bounds() = %+v", got)
+			}
+			if !reflect.DeepEqual(p.Segments, want) {
+				t.Errorf("Segments = %+v, want %+v", p.Segments, want)
+			}
+		})
+	}
+}
+
+func TestRectOperatorNegativeDimensions(t *testing.T) {
+	var filled []Rect
+	interp := NewInterpreter()
+	interp.OnFill = func(path *Path, state *State, rule FillRule) {
+		filled = append(filled, path.Bounds())
+	}
+
+	if err := interp.ExecuteStream([]byte("30 20 -20 40 re f 10 60 20 -40 re f 30 60 -20 -40 re f")); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(filled) != 3 {
+		t.Fatalf("filled %d paths, want 3", len(filled))
+	}
+	for i, got := range filled {
+		if got != (Rect{X: 10, Y: 20, Width: 20, Height: 40}) {
+			t.Errorf("rectangle %d has bounds %+v", i, got)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package graphics
+
+import "math"
+
+const (
+	// lengthTolerance is the largest difference allowed between a curve
+	// piece's control polygon and its chord before it is subdivided.
+	lengthTolerance = 0.01
+
+	// maxLengthDepth limits curve subdivision for degenerate curves.
+	maxLengthDepth = 16
+)
+
+// lineRun is a straight piece of a measured path.
+type lineRun struct {
+	from, to Point
+	length   float64
+}
+
+// Length returns the arc length of the path. Curves are subdivided until
+// each piece is nearly straight. The gaps between subpaths do not count,
+// but the closing line of a closed subpath does.
+func (p *Path) Length() float64 {
+	total := 0.0
+	for _, run := range p.runs() {
+		total += run.length
+	}
+	return total
+}
+
+// PointAt returns the point at fraction t of the path's length, with t
+// clamped to [0, 1], and the angle in radians of the path's direction
+// there. An empty path gives the origin.
+func (p *Path) PointAt(t float64) (Point, float64) {
+	runs := p.runs()
+	if len(runs) == 0 {
+		if len(p.Segments) > 0 && len(p.Segments[0].Points) > 0 {
+			return p.Segments[0].Points[0], 0
+		}
+		return Point{}, 0
+	}
+
+	total := 0.0
+	for _, run := range runs {
+		total += run.length
+	}
+	target := math.Max(0, math.Min(t, 1)) * total
+
+	// Find the run holding target, skipping zero-length runs so the
+	// angle is always that of a real direction
+	var last lineRun
+	for _, run := range runs {
+		if run.length == 0 {
+			continue
+		}
+		last = run
+		if target <= run.length {
+			break
+		}
+		target -= run.length
+	}
+	if last.length == 0 {
+		return runs[0].from, 0
+	}
+
+	f := math.Min(target/last.length, 1)
+	pt := Point{
+		last.from.X + (last.to.X-last.from.X)*f,
+		last.from.Y + (last.to.Y-last.from.Y)*f,
+	}
+	return pt, math.Atan2(last.to.Y-last.from.Y, last.to.X-last.from.X)
+}
+
+// runs breaks the path into straight pieces in drawing order.
+func (p *Path) runs() []lineRun {
+	var runs []lineRun
+	var current, start Point
+
+	add := func(to Point) {
+		runs = append(runs, lineRun{from: current, to: to, length: to.Sub(current).Length()})
+		current = to
+	}
+
+	for _, seg := range p.Segments {
+		switch seg.Op {
+		case PathOpMoveTo:
+			current = seg.Points[0]
+			start = current
+		case PathOpLineTo:
+			add(seg.Points[0])
+		case PathOpCurveTo:
+			subdivideCubic(current, seg.Points[0], seg.Points[1], seg.Points[2], 0, add)
+		case PathOpClose:
+			if current != start {
+				add(start)
+			}
+		}
+	}
+	return runs
+}
+
+// subdivideCubic calls add with the end points of straight pieces
+// approximating the cubic Bezier curve from p0 to p3.
+func subdivideCubic(p0, p1, p2, p3 Point, depth int, add func(Point)) {
+	chord := p3.Sub(p0).Length()
+	polygon := p1.Sub(p0).Length() + p2.Sub(p1).Length() + p3.Sub(p2).Length()
+	if polygon-chord <= lengthTolerance || depth >= maxLengthDepth {
+		add(p3)
+		return
+	}
+
+	// Split at t = 0.5 (de Casteljau)
+	p01 := midpoint(p0, p1)
+	p12 := midpoint(p1, p2)
+	p23 := midpoint(p2, p3)
+	p012 := midpoint(p01, p12)
+	p123 := midpoint(p12, p23)
+	mid := midpoint(p012, p123)
+
+	subdivideCubic(p0, p01, p012, mid, depth+1, add)
+	subdivideCubic(mid, p123, p23, p3, depth+1, add)
+}
+
+func midpoint(a, b Point) Point {
+	return Point{(a.X + b.X) / 2, (a.Y + b.Y) / 2}
+}
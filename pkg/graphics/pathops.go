@@ -0,0 +1,538 @@
+package graphics
+
+import (
+	"math"
+)
+
+// Boolean path operations using the Greiner-Hormann polygon clipping
+// algorithm. Curves are flattened to line segments first, so the results
+// are always polygonal. Each subpath is treated as a simple polygon; paths
+// whose subpaths overlap each other are handled subpath by subpath.
+
+// boolOp selects the boolean operation performed by clipPolygons.
+type boolOp int
+
+const (
+	boolIntersection boolOp = iota
+	boolUnion
+	boolDifference
+)
+
+// curveFlattenSteps is the number of line segments used per Bezier curve.
+const curveFlattenSteps = 16
+
+// PathUnion returns a path covering the area of either a or b.
+func PathUnion(a, b *Path) *Path {
+	pool := append(flattenPath(a), flattenPath(b)...)
+	var holes [][]Point
+
+	// Repeatedly merge any two overlapping polygons until none overlap
+	for merged := true; merged; {
+		merged = false
+		for i := 0; i < len(pool) && !merged; i++ {
+			for j := i + 1; j < len(pool) && !merged; j++ {
+				if !polygonsOverlap(pool[i], pool[j]) {
+					continue
+				}
+
+				result := clipPolygons(pool[i], pool[j], boolUnion)
+				outer, inner := splitOuter(result)
+
+				rest := make([][]Point, 0, len(pool))
+				rest = append(rest, pool[:i]...)
+				rest = append(rest, pool[i+1:j]...)
+				rest = append(rest, pool[j+1:]...)
+				if outer != nil {
+					rest = append(rest, outer)
+				}
+				pool = rest
+				holes = append(holes, inner...)
+				merged = true
+			}
+		}
+	}
+
+	return polygonsToPath(append(pool, holes...))
+}
+
+// PathIntersection returns a path covering the area inside both a and b.
+func PathIntersection(a, b *Path) *Path {
+	var result [][]Point
+	for _, subject := range flattenPath(a) {
+		for _, clip := range flattenPath(b) {
+			result = append(result, clipPolygons(subject, clip, boolIntersection)...)
+		}
+	}
+	return polygonsToPath(result)
+}
+
+// PathDifference returns a path covering the area inside a but not b.
+func PathDifference(a, b *Path) *Path {
+	clips := flattenPath(b)
+
+	var result [][]Point
+	for _, subject := range flattenPath(a) {
+		pieces := [][]Point{subject}
+		var holes [][]Point
+
+		for _, clip := range clips {
+			var next [][]Point
+			for _, piece := range pieces {
+				outer, inner := splitOuter(clipPolygons(piece, clip, boolDifference))
+				if outer != nil {
+					next = append(next, outer)
+				}
+				// Polygons split apart by the clip are separate pieces, while
+				// holes punched into a piece stay with it
+				for _, poly := range inner {
+					if containsPoint(outer, poly[0]) {
+						holes = append(holes, poly)
+					} else {
+						next = append(next, poly)
+					}
+				}
+			}
+			pieces = next
+		}
+
+		result = append(result, pieces...)
+		result = append(result, holes...)
+	}
+
+	return polygonsToPath(result)
+}
+
+// flattenPath converts each subpath to a polygon, flattening curves.
+func flattenPath(p *Path) [][]Point {
+	var polygons [][]Point
+	var current []Point
+
+	finish := func() {
+		// Drop the explicit closing point if present
+		if n := len(current); n > 1 && current[0] == current[n-1] {
+			current = current[:n-1]
+		}
+		if len(current) >= 3 {
+			polygons = append(polygons, current)
+		}
+		current = nil
+	}
+
+	if p == nil {
+		return nil
+	}
+
+	for _, seg := range p.Segments {
+		switch seg.Op {
+		case PathOpMoveTo:
+			finish()
+			if len(seg.Points) > 0 {
+				current = []Point{seg.Points[0]}
+			}
+		case PathOpLineTo:
+			if len(seg.Points) > 0 {
+				current = append(current, seg.Points[0])
+			}
+		case PathOpCurveTo:
+			if len(seg.Points) >= 3 && len(current) > 0 {
+				p0 := current[len(current)-1]
+				for i := 1; i <= curveFlattenSteps; i++ {
+					t := float64(i) / curveFlattenSteps
+					current = append(current, cubicPoint(p0, seg.Points[0], seg.Points[1], seg.Points[2], t))
+				}
+			}
+		case PathOpClose:
+			finish()
+		}
+	}
+	finish()
+
+	return polygons
+}
+
+// cubicPoint evaluates a cubic Bezier curve at t.
+func cubicPoint(p0, p1, p2, p3 Point, t float64) Point {
+	mt := 1 - t
+	a := mt * mt * mt
+	b := 3 * mt * mt * t
+	c := 3 * mt * t * t
+	d := t * t * t
+	return Point{
+		a*p0.X + b*p1.X + c*p2.X + d*p3.X,
+		a*p0.Y + b*p1.Y + c*p2.Y + d*p3.Y,
+	}
+}
+
+// polygonsToPath builds a closed path from polygons, orienting them so
+// that holes fill correctly with either fill rule.
+func polygonsToPath(polygons [][]Point) *Path {
+	path := NewPath()
+
+	for i, poly := range polygons {
+		// Nesting depth decides whether this is an outline or a hole
+		depth := 0
+		for j, other := range polygons {
+			if i != j && containsPoint(other, poly[0]) {
+				depth++
+			}
+		}
+
+		ccw := signedArea(poly) > 0
+		if (depth%2 == 0) != ccw {
+			poly = reversePolygon(poly)
+		}
+
+		path.MoveTo(poly[0].X, poly[0].Y)
+		for _, pt := range poly[1:] {
+			path.LineTo(pt.X, pt.Y)
+		}
+		path.Close()
+	}
+
+	return path
+}
+
+// splitOuter separates the polygon that contains all others (if any) from
+// the rest.
+func splitOuter(polygons [][]Point) ([]Point, [][]Point) {
+	if len(polygons) == 0 {
+		return nil, nil
+	}
+
+	best := 0
+	for i := range polygons {
+		if math.Abs(signedArea(polygons[i])) > math.Abs(signedArea(polygons[best])) {
+			best = i
+		}
+	}
+
+	rest := make([][]Point, 0, len(polygons)-1)
+	rest = append(rest, polygons[:best]...)
+	rest = append(rest, polygons[best+1:]...)
+	return polygons[best], rest
+}
+
+// signedArea returns the polygon area, positive for counter-clockwise.
+func signedArea(poly []Point) float64 {
+	area := 0.0
+	for i := range poly {
+		j := (i + 1) % len(poly)
+		area += poly[i].X*poly[j].Y - poly[j].X*poly[i].Y
+	}
+	return area / 2
+}
+
+// reversePolygon returns the polygon with its vertex order reversed.
+func reversePolygon(poly []Point) []Point {
+	reversed := make([]Point, len(poly))
+	for i, pt := range poly {
+		reversed[len(poly)-1-i] = pt
+	}
+	return reversed
+}
+
+// containsPoint tests whether pt is inside the polygon (even-odd rule).
+func containsPoint(poly []Point, pt Point) bool {
+	inside := false
+	for i := range poly {
+		j := (i + len(poly) - 1) % len(poly)
+		if rayIntersectsLine(pt, poly[j], poly[i]) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// polygonsOverlap reports whether two polygons share any area or edges.
+func polygonsOverlap(a, b []Point) bool {
+	if containsPoint(a, b[0]) || containsPoint(b, a[0]) {
+		return true
+	}
+	for i := range a {
+		a1, a2 := a[i], a[(i+1)%len(a)]
+		for j := range b {
+			if _, _, ok := segmentIntersection(a1, a2, b[j], b[(j+1)%len(b)]); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// segmentIntersection returns the parameters along each segment at which
+// they cross, or false if they do not intersect or are parallel.
+func segmentIntersection(p1, p2, q1, q2 Point) (float64, float64, bool) {
+	d := (p2.X-p1.X)*(q2.Y-q1.Y) - (p2.Y-p1.Y)*(q2.X-q1.X)
+	if d == 0 {
+		return 0, 0, false
+	}
+
+	alphaP := ((q1.X-p1.X)*(q2.Y-q1.Y) - (q1.Y-p1.Y)*(q2.X-q1.X)) / d
+	alphaQ := ((q1.X-p1.X)*(p2.Y-p1.Y) - (q1.Y-p1.Y)*(p2.X-p1.X)) / d
+
+	if alphaP < 0 || alphaP > 1 || alphaQ < 0 || alphaQ > 1 {
+		return 0, 0, false
+	}
+	return alphaP, alphaQ, true
+}
+
+// ghVertex is a node in the circular vertex lists used by Greiner-Hormann.
+type ghVertex struct {
+	pt           Point
+	next, prev   *ghVertex
+	intersection bool
+	entry        bool
+	visited      bool
+	neighbor     *ghVertex // Matching intersection in the other polygon
+	alpha        float64   // Position along the original edge
+}
+
+// newGHList builds a circular list from a polygon.
+func newGHList(poly []Point) *ghVertex {
+	var first, last *ghVertex
+	for _, pt := range poly {
+		v := &ghVertex{pt: pt}
+		if first == nil {
+			first = v
+		} else {
+			last.next = v
+			v.prev = last
+		}
+		last = v
+	}
+	last.next = first
+	first.prev = last
+	return first
+}
+
+// insertIntersection places v between start and end, ordered by alpha.
+func insertIntersection(v, start, end *ghVertex) {
+	cur := start
+	for cur.next != end && cur.next.intersection && cur.next.alpha < v.alpha {
+		cur = cur.next
+	}
+	v.next = cur.next
+	v.prev = cur
+	cur.next.prev = v
+	cur.next = v
+}
+
+// nextOriginal returns the next non-intersection vertex.
+func nextOriginal(v *ghVertex) *ghVertex {
+	v = v.next
+	for v.intersection {
+		v = v.next
+	}
+	return v
+}
+
+// clipPolygons applies a boolean operation to two simple polygons.
+func clipPolygons(subject, clip []Point, op boolOp) [][]Point {
+	// Intersections exactly at vertices break the entry/exit labelling, so
+	// nudge the clip polygon slightly until none remain
+	var subjList, clipList *ghVertex
+	var found int
+	for attempt := 0; ; attempt++ {
+		var degenerate bool
+		subjList, clipList, found, degenerate = findIntersections(subject, clip)
+		if !degenerate || attempt >= 4 {
+			break
+		}
+		clip = perturbPolygon(clip, attempt+1)
+	}
+
+	if found == 0 {
+		return disjointResult(subject, clip, op)
+	}
+
+	// Label intersections as entering or leaving the other polygon
+	forwardSubject := op == boolIntersection
+	forwardClip := op != boolUnion
+	markEntries(subjList, clip, forwardSubject)
+	markEntries(clipList, subject, forwardClip)
+
+	var result [][]Point
+	for {
+		start := firstUnvisited(subjList)
+		if start == nil {
+			break
+		}
+
+		var poly []Point
+		cur := start
+		for {
+			cur.visited = true
+			if cur.neighbor != nil {
+				cur.neighbor.visited = true
+			}
+			poly = append(poly, cur.pt)
+
+			if cur.entry {
+				for cur = cur.next; !cur.intersection; cur = cur.next {
+					poly = append(poly, cur.pt)
+				}
+			} else {
+				for cur = cur.prev; !cur.intersection; cur = cur.prev {
+					poly = append(poly, cur.pt)
+				}
+			}
+
+			cur = cur.neighbor
+			if cur.visited {
+				break
+			}
+		}
+
+		if len(poly) >= 3 {
+			result = append(result, poly)
+		}
+	}
+
+	return result
+}
+
+// findIntersections builds the vertex lists for both polygons with all edge
+// intersections inserted. It reports whether any intersection fell on a
+// vertex, which the algorithm cannot label reliably.
+func findIntersections(subject, clip []Point) (*ghVertex, *ghVertex, int, bool) {
+	subjList := newGHList(subject)
+	clipList := newGHList(clip)
+
+	const eps = 1e-10
+	found := 0
+
+	s := subjList
+	for {
+		sNext := nextOriginal(s)
+		c := clipList
+		for {
+			cNext := nextOriginal(c)
+
+			alphaS, alphaC, ok := segmentIntersection(s.pt, sNext.pt, c.pt, cNext.pt)
+			if ok {
+				if alphaS < eps || alphaS > 1-eps || alphaC < eps || alphaC > 1-eps {
+					return subjList, clipList, found, true
+				}
+
+				pt := Point{
+					s.pt.X + alphaS*(sNext.pt.X-s.pt.X),
+					s.pt.Y + alphaS*(sNext.pt.Y-s.pt.Y),
+				}
+				vs := &ghVertex{pt: pt, intersection: true, alpha: alphaS}
+				vc := &ghVertex{pt: pt, intersection: true, alpha: alphaC}
+				vs.neighbor, vc.neighbor = vc, vs
+
+				insertIntersection(vs, s, sNext)
+				insertIntersection(vc, c, cNext)
+				found++
+			}
+
+			c = cNext
+			if c == clipList {
+				break
+			}
+		}
+
+		s = sNext
+		if s == subjList {
+			break
+		}
+	}
+
+	return subjList, clipList, found, false
+}
+
+// perturbPolygon shifts all vertices by a tiny amount relative to the
+// polygon size.
+func perturbPolygon(poly []Point, attempt int) []Point {
+	minX, minY := math.MaxFloat64, math.MaxFloat64
+	maxX, maxY := -math.MaxFloat64, -math.MaxFloat64
+	for _, pt := range poly {
+		minX, maxX = math.Min(minX, pt.X), math.Max(maxX, pt.X)
+		minY, maxY = math.Min(minY, pt.Y), math.Max(maxY, pt.Y)
+	}
+
+	size := math.Max(maxX-minX, maxY-minY)
+	if size == 0 {
+		size = 1
+	}
+	dx := size * 1e-7 * float64(attempt)
+	dy := dx * 0.7
+
+	moved := make([]Point, len(poly))
+	for i, pt := range poly {
+		moved[i] = Point{pt.X + dx, pt.Y + dy}
+	}
+	return moved
+}
+
+// markEntries labels each intersection in list as an entry or exit with
+// respect to the other polygon. When forward is false the labels are
+// inverted, which turns intersection into union or difference.
+func markEntries(list *ghVertex, other []Point, forward bool) {
+	inside := containsPoint(other, list.pt)
+	if !forward {
+		inside = !inside
+	}
+
+	v := list
+	for {
+		if v.intersection {
+			v.entry = !inside
+			inside = !inside
+		}
+		v = v.next
+		if v == list {
+			break
+		}
+	}
+}
+
+// firstUnvisited returns an intersection that has not been traversed yet.
+func firstUnvisited(list *ghVertex) *ghVertex {
+	v := list
+	for {
+		if v.intersection && !v.visited {
+			return v
+		}
+		v = v.next
+		if v == list {
+			return nil
+		}
+	}
+}
+
+// disjointResult handles polygons whose edges do not cross: one contains
+// the other or they are separate.
+func disjointResult(subject, clip []Point, op boolOp) [][]Point {
+	subjectInClip := containsPoint(clip, subject[0])
+	clipInSubject := containsPoint(subject, clip[0])
+
+	switch op {
+	case boolIntersection:
+		if subjectInClip {
+			return [][]Point{subject}
+		}
+		if clipInSubject {
+			return [][]Point{clip}
+		}
+		return nil
+	case boolUnion:
+		if subjectInClip {
+			return [][]Point{clip}
+		}
+		if clipInSubject {
+			return [][]Point{subject}
+		}
+		return [][]Point{subject, clip}
+	default:
+		if subjectInClip {
+			return nil
+		}
+		if clipInSubject {
+			// The clip punches a hole in the subject
+			return [][]Point{subject, clip}
+		}
+		return [][]Point{subject}
+	}
+}
@@ -0,0 +1,110 @@
+package graphics
+
+import (
+	"math"
+	"testing"
+)
+
+// rectPath returns a path holding one rectangle.
+func rectPath(x, y, width, height float64) *Path {
+	p := NewPath()
+	p.Rect(x, y, width, height)
+	return p
+}
+
+// pathArea returns the area a path covers, counting holes, which
+// polygonsToPath orients clockwise, as negative.
+func pathArea(p *Path) float64 {
+	area := 0.0
+	for _, poly := range flattenPath(p) {
+		area += signedArea(poly)
+	}
+	return area
+}
+
+func TestPathOps(t *testing.T) {
+	square := rectPath(0, 0, 10, 10)
+
+	// Vertices on edges are perturbed, so degenerate cases are only exact
+	// to within a small tolerance
+	const tolerance = 1e-3
+
+	tests := []struct {
+		name                      string
+		b                         *Path
+		intersection, union, diff float64
+	}{
+		{"disjoint", rectPath(20, 0, 10, 10), 0, 200, 100},
+		{"nested", rectPath(2, 2, 4, 4), 16, 100, 84},
+		{"containing", rectPath(-5, -5, 20, 20), 100, 400, 0},
+		{"partly overlapping", rectPath(5, 5, 10, 10), 25, 175, 75},
+		{"overlapping across", rectPath(-5, 3, 20, 4), 40, 140, 60},
+		{"identical", rectPath(0, 0, 10, 10), 100, 100, 0},
+		{"shared edge", rectPath(10, 0, 10, 10), 0, 200, 100},
+		{"shared part of edge", rectPath(10, 5, 10, 10), 0, 200, 100},
+		{"shared vertex", rectPath(10, 10, 10, 10), 0, 200, 100},
+		{"inside touching edge", rectPath(0, 2, 5, 5), 25, 100, 75},
+		{"overlapping with shared edge", rectPath(5, 0, 10, 10), 50, 150, 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops := []struct {
+				name string
+				fn   func(a, b *Path) *Path
+				want float64
+			}{
+				{"intersection", PathIntersection, tt.intersection},
+				{"union", PathUnion, tt.union},
+				{"difference", PathDifference, tt.diff},
+			}
+			for _, op := range ops {
+				if got := pathArea(op.fn(square, tt.b)); math.Abs(got-op.want) > tolerance {
+					t.Errorf("%s area = %g, want %g", op.name, got, op.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPathIntersectionBounds(t *testing.T) {
+	got := PathIntersection(rectPath(0, 0, 10, 10), rectPath(5, 4, 10, 10)).Bounds()
+	want := Rect{X: 5, Y: 4, Width: 5, Height: 6}
+	if math.Abs(got.X-want.X) > 1e-9 || math.Abs(got.Y-want.Y) > 1e-9 ||
+		math.Abs(got.Width-want.Width) > 1e-9 || math.Abs(got.Height-want.Height) > 1e-9 {
+		t.Errorf("Bounds = %+v, want %+v", got, want)
+	}
+}
+
+func TestPathDifferenceHole(t *testing.T) {
+	diff := PathDifference(rectPath(0, 0, 10, 10), rectPath(3, 3, 4, 4))
+	if n := len(flattenPath(diff)); n != 2 {
+		t.Fatalf("difference has %d subpaths, want an outline and a hole", n)
+	}
+	if b := diff.Bounds(); b != (Rect{X: 0, Y: 0, Width: 10, Height: 10}) {
+		t.Errorf("Bounds = %+v, want the outer square", b)
+	}
+
+	tests := []struct {
+		pt   Point
+		want bool
+	}{
+		{Point{1, 1}, true},
+		{Point{9, 5}, true},
+		{Point{5, 5}, false},
+		{Point{11, 5}, false},
+	}
+	for _, rule := range []FillRule{FillRuleNonZero, FillRuleEvenOdd} {
+		for _, tt := range tests {
+			if got := diff.Contains(tt.pt, rule); got != tt.want {
+				t.Errorf("rule %d: Contains(%v) = %v, want %v", rule, tt.pt, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestPathUnionBounds(t *testing.T) {
+	got := PathUnion(rectPath(0, 0, 10, 10), rectPath(10, 10, 10, 10)).Bounds()
+	if math.Abs(got.Width-20) > 1e-3 || math.Abs(got.Height-20) > 1e-3 {
+		t.Errorf("Bounds = %+v, want 20x20", got)
+	}
+}
@@ -0,0 +1,101 @@
+package graphics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// checkpoint records the interpreter state before an operator so it can be
+// put back if the operator fails.
+type checkpoint struct {
+	depth    int
+	top      *State
+	saved    State // Shallow copy of *top
+	segments int
+	current  Point
+	start    Point
+}
+
+func (i *Interpreter) checkpoint() checkpoint {
+	top := i.stack.Current()
+	return checkpoint{
+		depth:    len(i.stack.states),
+		top:      top,
+		saved:    *top,
+		segments: len(i.path.Segments),
+		current:  i.path.current,
+		start:    i.path.start,
+	}
+}
+
+// restore puts the state stack and current path back as they were at the
+// checkpoint.
+func (i *Interpreter) restore(c checkpoint) {
+	if len(i.stack.states) >= c.depth {
+		i.stack.states = i.stack.states[:c.depth]
+	} else {
+		// A Q popped the checkpointed state; it is still intact below
+		i.stack.states = append(i.stack.states, c.top)
+	}
+	*c.top = c.saved
+	i.stack.states[c.depth-1] = c.top
+
+	// Painting operators clear the path but keep its segments' storage,
+	// so reslicing brings back segments the failed operator cleared
+	if cap(i.path.Segments) >= c.segments {
+		i.path.Segments = i.path.Segments[:c.segments]
+	}
+	i.path.current = c.current
+	i.path.start = c.start
+}
+
+// ExecuteWithRecovery runs a list of operators like Execute, but an
+// operator that panics (such as a callback dereferencing nil) or leaves a
+// non-finite transformation matrix is undone: the state stack and current
+// path are restored to how they were before it, the problem is logged as a
+// warning and execution continues. Graphics states left pushed by
+// unbalanced q operators are popped at the end. The returned error joins
+// every recovered failure, or is nil if there were none.
+func (i *Interpreter) ExecuteWithRecovery(ops []Operator) error {
+	startDepth := len(i.stack.states)
+	var errs []error
+
+	for _, op := range ops {
+		c := i.checkpoint()
+		err := i.runRecovering(op)
+		if err == nil && !finiteMatrix(i.stack.Current().CTM) {
+			err = fmt.Errorf("non-finite transformation matrix")
+		}
+		if err != nil {
+			fmt.Printf("Warning: operator %s: %v\n", op.Name, err)
+			errs = append(errs, fmt.Errorf("operator %s: %w", op.Name, err))
+			i.restore(c)
+		}
+	}
+
+	if len(i.stack.states) > startDepth {
+		i.stack.states = i.stack.states[:startDepth]
+	}
+	return errors.Join(errs...)
+}
+
+// runRecovering runs an operator, converting a panic into an error.
+func (i *Interpreter) runRecovering(op Operator) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	i.run(op)
+	return nil
+}
+
+func finiteMatrix(m Matrix) bool {
+	for _, v := range m {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return true
+}
@@ -6,7 +6,7 @@ type State struct {
 	// Current Transformation Matrix
 	CTM Matrix
 	
-	// Clipping path (nil = no clipping)
+	// Clipping path in page space (nil = no clipping)
 	ClipPath *Path
 	
 	// Color state
@@ -15,6 +15,10 @@ type State struct {
 	StrokeColorSpace ColorSpace
 	FillColorSpace   ColorSpace
 	
+	// Pattern resource names set by SCN/scn ("" = plain color)
+	StrokePattern string
+	FillPattern   string
+	
 	// Line drawing parameters
 	LineWidth   float64
 	LineCap     LineCap
@@ -30,6 +34,7 @@ type State struct {
 	StrokeAlpha float64
 	FillAlpha   float64
 	BlendMode   BlendMode
+	SoftMask    *SoftMask // nil = no soft mask
 	
 	// Rendering intent
 	RenderingIntent string
@@ -59,6 +64,9 @@ type TextState struct {
 	FontName string
 	FontSize float64
 	
+	// Font set by an ExtGState Font entry instead of a named resource
+	Font interface{}
+	
 	// Text rendering mode (Tr)
 	RenderMode TextRenderMode
 	
@@ -72,6 +80,21 @@ type TextState struct {
 	LineMatrix Matrix
 }
 
+// SoftMask is a soft mask set by an ExtGState SMask entry.
+type SoftMask struct {
+	// Subtype is Alpha or Luminosity
+	Subtype string
+	
+	// Group is the transparency group XObject that provides the mask values
+	Group interface{}
+	
+	// Backdrop color components (BC) for Luminosity masks
+	Backdrop []float64
+	
+	// CTM in effect when the mask was set, used to draw the group
+	CTM Matrix
+}
+
 // TextRenderMode defines how text is rendered.
 type TextRenderMode int
 
@@ -128,10 +151,7 @@ func (s *State) Clone() *State {
 		copy(clone.DashPattern, s.DashPattern)
 	}
 	
-	// Clone clip path if present
-	if s.ClipPath != nil {
-		clone.ClipPath = s.ClipPath.Clone()
-	}
+	// The clip path is shared: W replaces it rather than modifying it
 	
 	return &clone
 }
@@ -0,0 +1,75 @@
+package graphics
+
+import (
+	"fmt"
+)
+
+// ValidationError describes a problem found in a content stream.
+type ValidationError struct {
+	Op      Operator
+	Index   int // Position of Op in the operator list
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("operator %d (%s): %s", e.Index, e.Op.Name, e.Message)
+}
+
+// ValidateContentStream checks a content stream for common structural
+// errors such as unbalanced q/Q and BT/ET pairs. Renderers generally
+// tolerate these, so the results are advisory.
+func ValidateContentStream(ops []Operator) []ValidationError {
+	var errs []ValidationError
+	report := func(index int, msg string) {
+		errs = append(errs, ValidationError{Op: ops[index], Index: index, Message: msg})
+	}
+
+	var saves []int    // Indices of unmatched q operators
+	textStart := -1    // Index of the open BT, or -1
+	underflow := false // A Q was seen with no matching q
+	pathStarted := false
+
+	for idx, op := range ops {
+		switch op.Name {
+		case "q":
+			saves = append(saves, idx)
+		case "Q":
+			if len(saves) == 0 {
+				report(idx, "Q without matching q")
+				underflow = true
+				continue
+			}
+			saves = saves[:len(saves)-1]
+		case "cm":
+			if underflow {
+				report(idx, "cm after unbalanced Q changes the initial page transform")
+			}
+		case "BT":
+			if textStart >= 0 {
+				report(idx, fmt.Sprintf("nested BT, previous BT at operator %d not closed", textStart))
+			}
+			textStart = idx
+		case "ET":
+			if textStart < 0 {
+				report(idx, "ET without matching BT")
+			}
+			textStart = -1
+		case "m", "re":
+			pathStarted = true
+		case "S", "s", "f", "F", "f*", "B", "B*", "b", "b*", "n":
+			if !pathStarted {
+				report(idx, "path painted without a preceding m or re")
+			}
+			pathStarted = false
+		}
+	}
+
+	for _, idx := range saves {
+		report(idx, "q without matching Q at end of stream")
+	}
+	if textStart >= 0 {
+		report(textStart, "BT without matching ET at end of stream")
+	}
+
+	return errs
+}
@@ -0,0 +1,526 @@
+package pdffunction
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"gumgum/pkg/cos"
+)
+
+// maxCalculatorStack is the operand stack limit for calculator functions.
+const maxCalculatorStack = 100
+
+// calculator is a Type 4 function, a program in a subset of PostScript.
+type calculator struct {
+	domain  []float64
+	rng     []float64
+	program []psToken
+}
+
+// psToken is a number, boolean, operator or nested procedure.
+type psToken struct {
+	op     string // Empty for operands and procedures
+	value  interface{}
+	proc   []psToken
+	isProc bool
+}
+
+func parseCalculator(reader *cos.Reader, s *cos.Stream) (*calculator, error) {
+	fn := &calculator{
+		domain: readNumbers(reader, s.Dict.Get("Domain")),
+		rng:    readNumbers(reader, s.Dict.Get("Range")),
+	}
+	if len(fn.domain) < 2 || len(fn.rng) < 2 {
+		return nil, fmt.Errorf("calculator function needs Domain and Range")
+	}
+
+	data, err := reader.DecodeStream(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode calculator program: %w", err)
+	}
+
+	words := splitPostScript(data)
+	if len(words) == 0 || words[0] != "{" {
+		return nil, fmt.Errorf("calculator program must start with {")
+	}
+	program, rest, err := parseProc(words[1:], 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("unexpected %q after calculator program", rest[0])
+	}
+	fn.program = program
+
+	return fn, nil
+}
+
+// splitPostScript splits a program into words, treating braces as words of
+// their own and skipping comments.
+func splitPostScript(data []byte) []string {
+	var words []string
+	start := -1
+	flush := func(end int) {
+		if start >= 0 {
+			words = append(words, string(data[start:end]))
+			start = -1
+		}
+	}
+
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; c {
+		case ' ', '\t', '\r', '\n', '\f', 0:
+			flush(i)
+		case '{', '}':
+			flush(i)
+			words = append(words, string(c))
+		case '%':
+			flush(i)
+			for i < len(data) && data[i] != '\n' && data[i] != '\r' {
+				i++
+			}
+		default:
+			if start < 0 {
+				start = i
+			}
+		}
+	}
+	flush(len(data))
+	return words
+}
+
+// parseProc parses words up to the closing brace of a procedure and
+// returns the remaining words.
+func parseProc(words []string, depth int) ([]psToken, []string, error) {
+	if depth > maxFunctionDepth {
+		return nil, nil, fmt.Errorf("calculator procedures nested too deeply")
+	}
+
+	var tokens []psToken
+	for len(words) > 0 {
+		word := words[0]
+		words = words[1:]
+
+		switch word {
+		case "}":
+			return tokens, words, nil
+		case "{":
+			proc, rest, err := parseProc(words, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			tokens = append(tokens, psToken{proc: proc, isProc: true})
+			words = rest
+		case "true", "false":
+			tokens = append(tokens, psToken{value: word == "true"})
+		default:
+			if v, err := strconv.ParseFloat(word, 64); err == nil {
+				tokens = append(tokens, psToken{value: v})
+				continue
+			}
+			if _, ok := psOperators[word]; !ok && word != "if" && word != "ifelse" {
+				return nil, nil, fmt.Errorf("unknown calculator operator: %s", word)
+			}
+			tokens = append(tokens, psToken{op: word})
+		}
+	}
+	return nil, nil, fmt.Errorf("calculator program has no closing brace")
+}
+
+func (f *calculator) Evaluate(input []float64) ([]float64, error) {
+	m := len(f.domain) / 2
+	if len(input) < m {
+		return nil, fmt.Errorf("calculator function needs %d inputs", m)
+	}
+
+	st := &psStack{}
+	for i := 0; i < m; i++ {
+		if err := st.push(clip(input[i], f.domain[2*i], f.domain[2*i+1])); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := st.execute(f.program); err != nil {
+		return nil, err
+	}
+
+	n := len(f.rng) / 2
+	if len(st.values) < n {
+		return nil, fmt.Errorf("calculator function left %d values, want %d", len(st.values), n)
+	}
+	results := st.values[len(st.values)-n:]
+
+	out := make([]float64, n)
+	for j, v := range results {
+		num, ok := v.(float64)
+		if !ok {
+			return nil, fmt.Errorf("calculator function returned a boolean")
+		}
+		out[j] = clip(num, f.rng[2*j], f.rng[2*j+1])
+	}
+	return out, nil
+}
+
+// psStack is the operand stack of a running calculator program. Values are
+// float64 or bool.
+type psStack struct {
+	values []interface{}
+}
+
+func (s *psStack) push(v interface{}) error {
+	if len(s.values) >= maxCalculatorStack {
+		return fmt.Errorf("calculator stack overflow")
+	}
+	s.values = append(s.values, v)
+	return nil
+}
+
+func (s *psStack) pop() (interface{}, error) {
+	if len(s.values) == 0 {
+		return nil, fmt.Errorf("calculator stack underflow")
+	}
+	v := s.values[len(s.values)-1]
+	s.values = s.values[:len(s.values)-1]
+	return v, nil
+}
+
+func (s *psStack) popNumber() (float64, error) {
+	v, err := s.pop()
+	if err != nil {
+		return 0, err
+	}
+	num, ok := v.(float64)
+	if !ok {
+		return 0, fmt.Errorf("calculator expected a number")
+	}
+	return num, nil
+}
+
+func (s *psStack) popBool() (bool, error) {
+	v, err := s.pop()
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("calculator expected a boolean")
+	}
+	return b, nil
+}
+
+// execute runs a procedure. The if and ifelse operators take the
+// procedures that precede them in the program.
+func (s *psStack) execute(program []psToken) error {
+	for i := 0; i < len(program); i++ {
+		tok := program[i]
+		switch {
+		case tok.isProc:
+			next := i + 1
+			if next < len(program) && program[next].op == "if" {
+				cond, err := s.popBool()
+				if err != nil {
+					return err
+				}
+				if cond {
+					if err := s.execute(tok.proc); err != nil {
+						return err
+					}
+				}
+				i = next
+				continue
+			}
+			if next+1 < len(program) && program[next].isProc && program[next+1].op == "ifelse" {
+				cond, err := s.popBool()
+				if err != nil {
+					return err
+				}
+				proc := program[next].proc
+				if cond {
+					proc = tok.proc
+				}
+				if err := s.execute(proc); err != nil {
+					return err
+				}
+				i = next + 1
+				continue
+			}
+			return fmt.Errorf("calculator procedure without if or ifelse")
+		case tok.op == "if" || tok.op == "ifelse":
+			return fmt.Errorf("calculator %s without procedures", tok.op)
+		case tok.op != "":
+			if err := psOperators[tok.op](s); err != nil {
+				return fmt.Errorf("%s: %w", tok.op, err)
+			}
+		default:
+			if err := s.push(tok.value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// psOperators implements the calculator operators other than if and
+// ifelse. Integers are represented as whole float64 values.
+var psOperators = map[string]func(s *psStack) error{
+	// Arithmetic
+	"abs":      unary(math.Abs),
+	"neg":      unary(func(x float64) float64 { return -x }),
+	"ceiling":  unary(math.Ceil),
+	"floor":    unary(math.Floor),
+	"round":    unary(func(x float64) float64 { return math.Floor(x + 0.5) }),
+	"truncate": unary(math.Trunc),
+	"cvi":      unary(math.Trunc),
+	"cvr":      unary(func(x float64) float64 { return x }),
+	"sqrt":     unary(math.Sqrt),
+	"sin":      unary(func(x float64) float64 { return math.Sin(x * math.Pi / 180) }),
+	"cos":      unary(func(x float64) float64 { return math.Cos(x * math.Pi / 180) }),
+	"ln":       unary(math.Log),
+	"log":      unary(math.Log10),
+	"add":      binary(func(a, b float64) float64 { return a + b }),
+	"sub":      binary(func(a, b float64) float64 { return a - b }),
+	"mul":      binary(func(a, b float64) float64 { return a * b }),
+	"exp":      binary(math.Pow),
+	"atan": binary(func(num, den float64) float64 {
+		angle := math.Atan2(num, den) * 180 / math.Pi
+		if angle < 0 {
+			angle += 360
+		}
+		return angle
+	}),
+	"div": func(s *psStack) error {
+		b, err := s.popNumber()
+		if err != nil {
+			return err
+		}
+		a, err := s.popNumber()
+		if err != nil {
+			return err
+		}
+		if b == 0 {
+			return fmt.Errorf("division by zero")
+		}
+		return s.push(a / b)
+	},
+	"idiv": integerBinary(func(a, b int64) (int64, error) {
+		if b == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return a / b, nil
+	}),
+	"mod": integerBinary(func(a, b int64) (int64, error) {
+		if b == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return a % b, nil
+	}),
+
+	// Relational, boolean and bitwise
+	"eq": compare(func(c int) bool { return c == 0 }),
+	"ne": compare(func(c int) bool { return c != 0 }),
+	"gt": compare(func(c int) bool { return c > 0 }),
+	"ge": compare(func(c int) bool { return c >= 0 }),
+	"lt": compare(func(c int) bool { return c < 0 }),
+	"le": compare(func(c int) bool { return c <= 0 }),
+	"and": logical(func(a, b bool) bool { return a && b },
+		func(a, b int64) int64 { return a & b }),
+	"or": logical(func(a, b bool) bool { return a || b },
+		func(a, b int64) int64 { return a | b }),
+	"xor": logical(func(a, b bool) bool { return a != b },
+		func(a, b int64) int64 { return a ^ b }),
+	"not": func(s *psStack) error {
+		v, err := s.pop()
+		if err != nil {
+			return err
+		}
+		if b, ok := v.(bool); ok {
+			return s.push(!b)
+		}
+		return s.push(float64(^int64(v.(float64))))
+	},
+	"bitshift": integerBinary(func(a, shift int64) (int64, error) {
+		if shift >= 0 {
+			return a << uint(shift), nil
+		}
+		return a >> uint(-shift), nil
+	}),
+
+	// Stack
+	"pop": func(s *psStack) error {
+		_, err := s.pop()
+		return err
+	},
+	"dup": func(s *psStack) error {
+		if len(s.values) == 0 {
+			return fmt.Errorf("calculator stack underflow")
+		}
+		return s.push(s.values[len(s.values)-1])
+	},
+	"exch": func(s *psStack) error {
+		n := len(s.values)
+		if n < 2 {
+			return fmt.Errorf("calculator stack underflow")
+		}
+		s.values[n-1], s.values[n-2] = s.values[n-2], s.values[n-1]
+		return nil
+	},
+	"copy": func(s *psStack) error {
+		count, err := s.popNumber()
+		if err != nil {
+			return err
+		}
+		n := int(count)
+		if n < 0 || n > len(s.values) {
+			return fmt.Errorf("invalid copy count: %d", n)
+		}
+		top := s.values[len(s.values)-n:]
+		for _, v := range top {
+			if err := s.push(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	"index": func(s *psStack) error {
+		index, err := s.popNumber()
+		if err != nil {
+			return err
+		}
+		n := int(index)
+		if n < 0 || n >= len(s.values) {
+			return fmt.Errorf("invalid index: %d", n)
+		}
+		return s.push(s.values[len(s.values)-1-n])
+	},
+	"roll": func(s *psStack) error {
+		shift, err := s.popNumber()
+		if err != nil {
+			return err
+		}
+		count, err := s.popNumber()
+		if err != nil {
+			return err
+		}
+		n, j := int(count), int(shift)
+		if n < 0 || n > len(s.values) {
+			return fmt.Errorf("invalid roll count: %d", n)
+		}
+		if n == 0 {
+			return nil
+		}
+		j = ((j % n) + n) % n
+
+		// Rotate the top n values up by j positions
+		top := s.values[len(s.values)-n:]
+		rotated := append(append([]interface{}{}, top[n-j:]...), top[:n-j]...)
+		copy(top, rotated)
+		return nil
+	},
+}
+
+func unary(f func(float64) float64) func(s *psStack) error {
+	return func(s *psStack) error {
+		x, err := s.popNumber()
+		if err != nil {
+			return err
+		}
+		return s.push(f(x))
+	}
+}
+
+func binary(f func(a, b float64) float64) func(s *psStack) error {
+	return func(s *psStack) error {
+		b, err := s.popNumber()
+		if err != nil {
+			return err
+		}
+		a, err := s.popNumber()
+		if err != nil {
+			return err
+		}
+		return s.push(f(a, b))
+	}
+}
+
+func integerBinary(f func(a, b int64) (int64, error)) func(s *psStack) error {
+	return func(s *psStack) error {
+		b, err := s.popNumber()
+		if err != nil {
+			return err
+		}
+		a, err := s.popNumber()
+		if err != nil {
+			return err
+		}
+		v, err := f(int64(a), int64(b))
+		if err != nil {
+			return err
+		}
+		return s.push(float64(v))
+	}
+}
+
+// compare pushes test applied to the ordering of the top two values.
+// Booleans can only be compared for equality.
+func compare(test func(c int) bool) func(s *psStack) error {
+	return func(s *psStack) error {
+		b, err := s.pop()
+		if err != nil {
+			return err
+		}
+		a, err := s.pop()
+		if err != nil {
+			return err
+		}
+
+		var c int
+		switch av := a.(type) {
+		case bool:
+			bv, ok := b.(bool)
+			if !ok {
+				return fmt.Errorf("cannot compare boolean and number")
+			}
+			if av != bv {
+				c = 1
+			}
+		case float64:
+			bv, ok := b.(float64)
+			if !ok {
+				return fmt.Errorf("cannot compare number and boolean")
+			}
+			switch {
+			case av < bv:
+				c = -1
+			case av > bv:
+				c = 1
+			}
+		}
+		return s.push(test(c))
+	}
+}
+
+// logical applies a boolean operator to booleans or a bitwise operator to
+// integers.
+func logical(boolOp func(a, b bool) bool, intOp func(a, b int64) int64) func(s *psStack) error {
+	return func(s *psStack) error {
+		b, err := s.pop()
+		if err != nil {
+			return err
+		}
+		a, err := s.pop()
+		if err != nil {
+			return err
+		}
+
+		ab, aBool := a.(bool)
+		bb, bBool := b.(bool)
+		switch {
+		case aBool && bBool:
+			return s.push(boolOp(ab, bb))
+		case !aBool && !bBool:
+			return s.push(float64(intOp(int64(a.(float64)), int64(b.(float64)))))
+		}
+		return fmt.Errorf("mixed boolean and number operands")
+	}
+}
@@ -0,0 +1,159 @@
+package pdffunction
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"gumgum/pkg/cos"
+)
+
+// calculatorFunction returns a Type 4 function with a one-input domain of
+// [-1000, 1000] and outputs, each with a range of [-1e9, 1e9].
+func calculatorFunction(program string, outputs int) *cos.Stream {
+	rng := cos.Array{}
+	for i := 0; i < outputs; i++ {
+		rng = append(rng, cos.Real(-1e9), cos.Real(1e9))
+	}
+	return &cos.Stream{
+		Dict: cos.Dict{
+			"FunctionType": cos.Integer(4),
+			"Domain":       cos.Array{cos.Integer(-1000), cos.Integer(1000)},
+			"Range":        rng,
+		},
+		Data: []byte(program),
+	}
+}
+
+func TestCalculator(t *testing.T) {
+	tests := []struct {
+		name    string
+		program string
+		input   float64
+		want    []float64
+	}{
+		{"arithmetic", "{ 2 mul 1 add }", 3, []float64{7}},
+		{"comments and line breaks", "{2 3 add%comment }\r\n mul}", 4, []float64{20}},
+		{"braces without spaces", "{0.5 gt{1}{0}ifelse}", 0.7, []float64{1}},
+		{"if taken", "{ dup 0.5 lt { 10 mul } if }", 0.2, []float64{2}},
+		{"if not taken", "{ dup 0.5 lt { 10 mul } if }", 0.8, []float64{0.8}},
+		{"ifelse true", "{ 0.5 gt { 1 } { 0 } ifelse }", 0.7, []float64{1}},
+		{"ifelse false", "{ 0.5 gt { 1 } { 0 } ifelse }", 0.3, []float64{0}},
+		{"nested ifelse outer", "{ dup 0.5 gt { dup 0.75 gt { pop 3 } { pop 2 } ifelse } { pop 1 } ifelse }", 0.1, []float64{1}},
+		{"nested ifelse inner false", "{ dup 0.5 gt { dup 0.75 gt { pop 3 } { pop 2 } ifelse } { pop 1 } ifelse }", 0.6, []float64{2}},
+		{"nested ifelse inner true", "{ dup 0.5 gt { dup 0.75 gt { pop 3 } { pop 2 } ifelse } { pop 1 } ifelse }", 0.9, []float64{3}},
+		{"if inside ifelse", "{ dup 0 lt { neg } { dup 1 gt { pop 1 } if } ifelse }", -3, []float64{3}},
+		{"cvi", "{ cvi }", 7.8, []float64{7}},
+		{"cvi negative", "{ cvi }", -2.5, []float64{-2}},
+		{"round half up", "{ pop -2.5 round }", 0, []float64{-2}},
+		{"truncate", "{ truncate }", -3.7, []float64{-3}},
+		{"floor and ceiling", "{ dup floor exch ceiling }", 1.5, []float64{1, 2}},
+		{"atan 45", "{ pop 1 1 atan }", 0, []float64{45}},
+		{"atan 90", "{ pop 1 0 atan }", 0, []float64{90}},
+		{"atan 180", "{ pop 0 -1 atan }", 0, []float64{180}},
+		{"atan 270", "{ pop -1 0 atan }", 0, []float64{270}},
+		{"sin in degrees", "{ sin }", 90, []float64{1}},
+		{"cos in degrees", "{ cos }", 180, []float64{-1}},
+		{"exp", "{ pop 2 10 exp }", 0, []float64{1024}},
+		{"sqrt", "{ sqrt }", 16, []float64{4}},
+		{"log and ln", "{ dup log exch ln }", 100, []float64{2, math.Log(100)}},
+		{"div", "{ 4 div }", 10, []float64{2.5}},
+		{"idiv", "{ pop 7 2 idiv }", 0, []float64{3}},
+		{"mod", "{ pop -7 2 mod }", 0, []float64{-1}},
+		{"bitshift left", "{ pop 1 4 bitshift }", 0, []float64{16}},
+		{"bitshift right", "{ pop 16 -2 bitshift }", 0, []float64{4}},
+		{"bitwise and", "{ pop 12 10 and }", 0, []float64{8}},
+		{"bitwise or", "{ pop 12 10 or }", 0, []float64{14}},
+		{"bitwise not", "{ pop 5 not }", 0, []float64{-6}},
+		{"boolean xor", "{ pop true false xor { 1 } { 0 } ifelse }", 0, []float64{1}},
+		{"boolean not", "{ pop false not { 1 } { 0 } ifelse }", 0, []float64{1}},
+		{"compare booleans", "{ pop true true eq { 1 } { 0 } ifelse }", 0, []float64{1}},
+		{"exch", "{ 2 exch }", 1, []float64{2, 1}},
+		{"roll up", "{ pop 1 2 3 3 1 roll }", 0, []float64{3, 1, 2}},
+		{"roll down", "{ pop 1 2 3 3 -1 roll }", 0, []float64{2, 3, 1}},
+		{"roll past count", "{ pop 1 2 3 3 4 roll }", 0, []float64{3, 1, 2}},
+		{"roll part of stack", "{ pop 1 2 3 2 1 roll }", 0, []float64{1, 3, 2}},
+		{"index", "{ pop 10 20 30 2 index }", 0, []float64{10}},
+		{"index 0 is dup", "{ 0 index add }", 4, []float64{8}},
+		{"copy", "{ pop 1 2 2 copy add add add }", 0, []float64{6}},
+		{"last values are outputs", "{ 1 2 3 }", 0, []float64{2, 3}},
+		{"domain clipped", "{ }", 5000, []float64{1000}},
+		{"fills stack", "{" + strings.Repeat(" 1", maxCalculatorStack-1) + " }", 0, []float64{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Evaluate(calculatorFunction(tt.program, len(tt.want)), []float64{tt.input})
+			if err != nil {
+				t.Fatalf("Evaluate: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Evaluate = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if math.Abs(got[i]-tt.want[i]) > 1e-9 {
+					t.Errorf("Evaluate = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestCalculatorRangeClipped(t *testing.T) {
+	fn := calculatorFunction("{ 100 mul }", 1)
+	fn.Dict["Range"] = cos.Array{cos.Integer(0), cos.Integer(10)}
+	got, err := Evaluate(fn, []float64{0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0] != 10 {
+		t.Errorf("Evaluate = %v, want [10]", got)
+	}
+}
+
+func TestCalculatorErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		program string
+	}{
+		{"empty", ""},
+		{"no opening brace", "1 2 add"},
+		{"no closing brace", "{ 1 2 add"},
+		{"text after program", "{ 1 } }"},
+		{"unknown operator", "{ foo }"},
+		{"nested too deeply", "{" + strings.Repeat(" {", maxFunctionDepth+2) + strings.Repeat(" }", maxFunctionDepth+3)},
+		{"stack underflow", "{ add }"},
+		{"stack overflow", "{" + strings.Repeat(" 1", maxCalculatorStack) + " }"},
+		{"stack overflow by copy", "{" + strings.Repeat(" 1", 59) + " 60 copy }"},
+		{"division by zero", "{ 0 div }"},
+		{"integer division by zero", "{ 0 idiv }"},
+		{"mod by zero", "{ 0 mod }"},
+		{"procedure without if", "{ pop { 1 } }"},
+		{"ifelse with one procedure", "{ pop true { 1 } ifelse }"},
+		{"if without procedure", "{ pop true if }"},
+		{"ifelse without procedures", "{ ifelse }"},
+		{"if on a number", "{ { 1 } if }"},
+		{"boolean result", "{ pop true }"},
+		{"number expected", "{ pop true 1 add }"},
+		{"mixed and", "{ pop true 1 and }"},
+		{"compare boolean and number", "{ pop true 1 eq }"},
+		{"compare number and boolean", "{ true eq }"},
+		{"roll count too large", "{ 5 1 roll }"},
+		{"roll count negative", "{ -1 1 roll }"},
+		{"index too large", "{ 3 index }"},
+		{"index negative", "{ -1 index }"},
+		{"copy too many", "{ 5 copy }"},
+		{"copy negative", "{ -1 copy }"},
+		{"dup on empty stack", "{ pop dup }"},
+		{"exch with one value", "{ exch }"},
+		{"pop on empty stack", "{ pop pop }"},
+		{"too few results", "{ pop }"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := Evaluate(calculatorFunction(tt.program, 1), []float64{1}); err == nil {
+				t.Errorf("Evaluate(%q) = %v, want an error", tt.program, got)
+			}
+		})
+	}
+}
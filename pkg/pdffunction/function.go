@@ -0,0 +1,470 @@
+// Package pdffunction evaluates PDF function objects.
+package pdffunction
+
+import (
+	"fmt"
+	"math"
+
+	"gumgum/pkg/cos"
+)
+
+// maxFunctionDepth limits nesting of stitching functions and function arrays.
+const maxFunctionDepth = 8
+
+// Function maps a set of input values to a set of output values.
+type Function interface {
+	// Evaluate computes the outputs for the given inputs. Inputs are clipped
+	// to the function's domain and outputs to its range.
+	Evaluate(input []float64) ([]float64, error)
+}
+
+// Evaluate parses a function object and evaluates it once. The object and
+// its entries must be direct objects; use Parse with a reader for functions
+// that contain references, or to evaluate a function many times.
+func Evaluate(fn cos.Object, input []float64) ([]float64, error) {
+	parsed, err := Parse(nil, fn)
+	if err != nil {
+		return nil, err
+	}
+	return parsed.Evaluate(input)
+}
+
+// Parse reads a function dictionary or stream. An array of functions is
+// treated as a single function whose outputs are concatenated, as used by
+// shadings with one function per color component. A nil reader can parse
+// functions made only of direct objects.
+func Parse(reader *cos.Reader, obj cos.Object) (Function, error) {
+	return parse(reader, obj, 0)
+}
+
+func parse(reader *cos.Reader, obj cos.Object, depth int) (Function, error) {
+	if depth > maxFunctionDepth {
+		return nil, fmt.Errorf("functions nested too deeply")
+	}
+
+	resolved, err := resolve(reader, obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve function: %w", err)
+	}
+
+	if arr, ok := resolved.(cos.Array); ok {
+		fns := make(multiFunction, 0, len(arr))
+		for _, item := range arr {
+			fn, err := parse(reader, item, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			fns = append(fns, fn)
+		}
+		return fns, nil
+	}
+
+	var dict cos.Dict
+	var stream *cos.Stream
+	switch v := resolved.(type) {
+	case cos.Dict:
+		dict = v
+	case *cos.Stream:
+		dict = v.Dict
+		stream = v
+	default:
+		return nil, fmt.Errorf("invalid function object: %T", resolved)
+	}
+
+	fnType, ok := dict.GetInt("FunctionType")
+	if !ok {
+		return nil, fmt.Errorf("function has no FunctionType")
+	}
+
+	switch fnType {
+	case 0:
+		if stream == nil {
+			return nil, fmt.Errorf("sampled function must be a stream")
+		}
+		return parseSampled(reader, stream)
+	case 2:
+		return parseExponential(reader, dict)
+	case 3:
+		return parseStitching(reader, dict, depth)
+	case 4:
+		if stream == nil {
+			return nil, fmt.Errorf("PostScript calculator function must be a stream")
+		}
+		return parseCalculator(reader, stream)
+	default:
+		return nil, fmt.Errorf("unsupported function type: %d", fnType)
+	}
+}
+
+// multiFunction evaluates several functions on the same input.
+type multiFunction []Function
+
+func (m multiFunction) Evaluate(input []float64) ([]float64, error) {
+	var out []float64
+	for _, fn := range m {
+		values, err := fn.Evaluate(input)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, values...)
+	}
+	return out, nil
+}
+
+// exponential is a Type 2 exponential interpolation function.
+type exponential struct {
+	domain []float64
+	rng    []float64 // Optional
+	c0, c1 []float64
+	n      float64
+}
+
+func parseExponential(reader *cos.Reader, dict cos.Dict) (*exponential, error) {
+	fn := &exponential{
+		domain: readNumbers(reader, dict.Get("Domain")),
+		rng:    readNumbers(reader, dict.Get("Range")),
+		c0:     readNumbers(reader, dict.Get("C0")),
+		c1:     readNumbers(reader, dict.Get("C1")),
+		n:      1,
+	}
+
+	if len(fn.domain) < 2 {
+		return nil, fmt.Errorf("exponential function has no Domain")
+	}
+	if n, ok := resolveNumber(reader, dict.Get("N")); ok {
+		fn.n = n
+	}
+	if fn.c0 == nil {
+		fn.c0 = []float64{0}
+	}
+	if fn.c1 == nil {
+		fn.c1 = []float64{1}
+	}
+	if len(fn.c0) != len(fn.c1) {
+		return nil, fmt.Errorf("exponential function C0 and C1 differ in length")
+	}
+
+	return fn, nil
+}
+
+func (f *exponential) Evaluate(input []float64) ([]float64, error) {
+	if len(input) < 1 {
+		return nil, fmt.Errorf("exponential function needs 1 input")
+	}
+
+	x := clip(input[0], f.domain[0], f.domain[1])
+	xn := math.Pow(x, f.n)
+
+	out := make([]float64, len(f.c0))
+	for j := range out {
+		out[j] = f.c0[j] + xn*(f.c1[j]-f.c0[j])
+		if 2*j+1 < len(f.rng) {
+			out[j] = clip(out[j], f.rng[2*j], f.rng[2*j+1])
+		}
+	}
+	return out, nil
+}
+
+// sampled is a Type 0 function interpolating a table of samples.
+type sampled struct {
+	domain  []float64
+	rng     []float64
+	encode  []float64
+	decode  []float64
+	size    []int
+	bps     int
+	samples []uint32 // Row-major, first dimension varying fastest
+	outputs int
+}
+
+func parseSampled(reader *cos.Reader, s *cos.Stream) (*sampled, error) {
+	dict := s.Dict
+	fn := &sampled{
+		domain: readNumbers(reader, dict.Get("Domain")),
+		rng:    readNumbers(reader, dict.Get("Range")),
+		encode: readNumbers(reader, dict.Get("Encode")),
+		decode: readNumbers(reader, dict.Get("Decode")),
+	}
+
+	inputs := len(fn.domain) / 2
+	fn.outputs = len(fn.rng) / 2
+	if inputs == 0 || fn.outputs == 0 {
+		return nil, fmt.Errorf("sampled function needs Domain and Range")
+	}
+
+	for _, v := range readNumbers(reader, dict.Get("Size")) {
+		fn.size = append(fn.size, int(v))
+	}
+	if len(fn.size) != inputs {
+		return nil, fmt.Errorf("sampled function Size has %d entries, want %d", len(fn.size), inputs)
+	}
+
+	bps, ok := resolveNumber(reader, dict.Get("BitsPerSample"))
+	if !ok {
+		return nil, fmt.Errorf("sampled function has no BitsPerSample")
+	}
+	fn.bps = int(bps)
+	switch fn.bps {
+	case 1, 2, 4, 8, 12, 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("invalid BitsPerSample: %d", fn.bps)
+	}
+
+	if fn.encode == nil {
+		for _, n := range fn.size {
+			fn.encode = append(fn.encode, 0, float64(n-1))
+		}
+	}
+	if fn.decode == nil {
+		fn.decode = fn.rng
+	}
+	if len(fn.encode) < 2*inputs || len(fn.decode) < 2*fn.outputs {
+		return nil, fmt.Errorf("sampled function Encode or Decode too short")
+	}
+
+	count := fn.outputs
+	for _, n := range fn.size {
+		if n < 1 {
+			return nil, fmt.Errorf("invalid sample table size: %d", n)
+		}
+		count *= n
+	}
+
+	data, err := reader.DecodeStream(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode samples: %w", err)
+	}
+	if len(data)*8 < count*fn.bps {
+		return nil, fmt.Errorf("sample table truncated")
+	}
+
+	fn.samples = make([]uint32, count)
+	var bitPos int
+	for i := range fn.samples {
+		fn.samples[i] = readBits(data, bitPos, fn.bps)
+		bitPos += fn.bps
+	}
+
+	return fn, nil
+}
+
+func (f *sampled) Evaluate(input []float64) ([]float64, error) {
+	m := len(f.size)
+	if len(input) < m {
+		return nil, fmt.Errorf("sampled function needs %d inputs", m)
+	}
+
+	// Position of the input in the sample table
+	index := make([]int, m)
+	frac := make([]float64, m)
+	for i := 0; i < m; i++ {
+		x := clip(input[i], f.domain[2*i], f.domain[2*i+1])
+		e := interpolate(x, f.domain[2*i], f.domain[2*i+1], f.encode[2*i], f.encode[2*i+1])
+		e = clip(e, 0, float64(f.size[i]-1))
+
+		index[i] = int(e)
+		if index[i] >= f.size[i]-1 {
+			index[i] = f.size[i] - 1
+		} else {
+			frac[i] = e - float64(index[i])
+		}
+	}
+
+	// Multilinear interpolation over the 2^m surrounding samples
+	out := make([]float64, f.outputs)
+	for corner := 0; corner < 1<<m; corner++ {
+		weight := 1.0
+		offset := 0
+		stride := 1
+		for i := 0; i < m; i++ {
+			idx := index[i]
+			if corner&(1<<i) != 0 {
+				if frac[i] == 0 {
+					weight = 0
+					break
+				}
+				idx++
+				weight *= frac[i]
+			} else {
+				weight *= 1 - frac[i]
+			}
+			offset += idx * stride
+			stride *= f.size[i]
+		}
+		if weight == 0 {
+			continue
+		}
+
+		for j := range out {
+			out[j] += weight * float64(f.samples[offset*f.outputs+j])
+		}
+	}
+
+	maxSample := math.Pow(2, float64(f.bps)) - 1
+	for j := range out {
+		out[j] = interpolate(out[j], 0, maxSample, f.decode[2*j], f.decode[2*j+1])
+		out[j] = clip(out[j], f.rng[2*j], f.rng[2*j+1])
+	}
+	return out, nil
+}
+
+// readBits reads an n-bit big-endian value starting at the given bit offset.
+func readBits(data []byte, bitPos, n int) uint32 {
+	var v uint32
+	for i := 0; i < n; i++ {
+		b := data[(bitPos+i)/8]
+		bit := (b >> (7 - uint((bitPos+i)%8))) & 1
+		v = v<<1 | uint32(bit)
+	}
+	return v
+}
+
+// stitching is a Type 3 function that divides its domain into subdomains,
+// each handled by its own function.
+type stitching struct {
+	domain    []float64
+	rng       []float64 // Optional
+	functions []Function
+	bounds    []float64
+	encode    []float64
+}
+
+func parseStitching(reader *cos.Reader, dict cos.Dict, depth int) (*stitching, error) {
+	fn := &stitching{
+		domain: readNumbers(reader, dict.Get("Domain")),
+		rng:    readNumbers(reader, dict.Get("Range")),
+		bounds: readNumbers(reader, dict.Get("Bounds")),
+		encode: readNumbers(reader, dict.Get("Encode")),
+	}
+	if len(fn.domain) < 2 {
+		return nil, fmt.Errorf("stitching function has no Domain")
+	}
+
+	obj, err := resolve(reader, dict.Get("Functions"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve Functions: %w", err)
+	}
+	arr, ok := obj.(cos.Array)
+	if !ok || len(arr) == 0 {
+		return nil, fmt.Errorf("stitching function has no Functions")
+	}
+	for _, item := range arr {
+		sub, err := parse(reader, item, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		fn.functions = append(fn.functions, sub)
+	}
+
+	k := len(fn.functions)
+	if len(fn.bounds) != k-1 {
+		return nil, fmt.Errorf("stitching function Bounds has %d entries, want %d", len(fn.bounds), k-1)
+	}
+	if len(fn.encode) < 2*k {
+		return nil, fmt.Errorf("stitching function Encode too short")
+	}
+
+	return fn, nil
+}
+
+func (f *stitching) Evaluate(input []float64) ([]float64, error) {
+	if len(input) < 1 {
+		return nil, fmt.Errorf("stitching function needs 1 input")
+	}
+
+	x := clip(input[0], f.domain[0], f.domain[1])
+
+	// Subdomain i spans [bounds[i-1], bounds[i]); the last one includes
+	// the end of the domain
+	i := 0
+	for i < len(f.bounds) && x >= f.bounds[i] {
+		i++
+	}
+	low, high := f.domain[0], f.domain[1]
+	if i > 0 {
+		low = f.bounds[i-1]
+	}
+	if i < len(f.bounds) {
+		high = f.bounds[i]
+	}
+
+	e := interpolate(x, low, high, f.encode[2*i], f.encode[2*i+1])
+	out, err := f.functions[i].Evaluate([]float64{e})
+	if err != nil {
+		return nil, err
+	}
+	for j := range out {
+		if 2*j+1 < len(f.rng) {
+			out[j] = clip(out[j], f.rng[2*j], f.rng[2*j+1])
+		}
+	}
+	return out, nil
+}
+
+// interpolate maps x from [xmin, xmax] to [ymin, ymax].
+func interpolate(x, xmin, xmax, ymin, ymax float64) float64 {
+	if xmax == xmin {
+		return ymin
+	}
+	return ymin + (x-xmin)*(ymax-ymin)/(xmax-xmin)
+}
+
+// clip limits x to [min, max].
+func clip(x, min, max float64) float64 {
+	if x < min {
+		return min
+	}
+	if x > max {
+		return max
+	}
+	return x
+}
+
+// resolve resolves a reference. Without a reader only direct objects can
+// be read.
+func resolve(reader *cos.Reader, obj cos.Object) (cos.Object, error) {
+	if _, ok := obj.(*cos.Reference); ok && reader == nil {
+		return nil, fmt.Errorf("cannot resolve reference without a reader")
+	}
+	return reader.Resolve(obj)
+}
+
+// readNumbers resolves an array of numbers, returning nil if absent.
+func readNumbers(reader *cos.Reader, obj cos.Object) []float64 {
+	if obj == nil {
+		return nil
+	}
+	resolved, err := resolve(reader, obj)
+	if err != nil {
+		return nil
+	}
+	arr, ok := resolved.(cos.Array)
+	if !ok {
+		return nil
+	}
+
+	values := make([]float64, 0, len(arr))
+	for _, item := range arr {
+		v, _ := resolveNumber(reader, item)
+		values = append(values, v)
+	}
+	return values
+}
+
+// resolveNumber resolves an integer or real.
+func resolveNumber(reader *cos.Reader, obj cos.Object) (float64, bool) {
+	if obj == nil {
+		return 0, false
+	}
+	resolved, err := resolve(reader, obj)
+	if err != nil {
+		return 0, false
+	}
+	switch v := resolved.(type) {
+	case cos.Integer:
+		return float64(v), true
+	case cos.Real:
+		return float64(v), true
+	}
+	return 0, false
+}
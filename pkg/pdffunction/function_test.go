@@ -0,0 +1,276 @@
+package pdffunction
+
+import (
+	"math"
+	"testing"
+
+	"gumgum/pkg/cos"
+)
+
+// numbers returns an array of numbers.
+func numbers(values ...float64) cos.Array {
+	arr := make(cos.Array, len(values))
+	for i, v := range values {
+		arr[i] = cos.Real(v)
+	}
+	return arr
+}
+
+// sampledFunction returns a Type 0 function over samples with a domain of
+// [0, 1] in each of len(size) inputs; entries in extra are added to or
+// replace the defaults.
+func sampledFunction(size []float64, bps int, rng []float64, samples []byte, extra cos.Dict) *cos.Stream {
+	domain := make([]float64, 0, 2*len(size))
+	for range size {
+		domain = append(domain, 0, 1)
+	}
+	dict := cos.Dict{
+		"FunctionType":  cos.Integer(0),
+		"Domain":        numbers(domain...),
+		"Range":         numbers(rng...),
+		"Size":          numbers(size...),
+		"BitsPerSample": cos.Integer(bps),
+	}
+	for key, value := range extra {
+		dict[key] = value
+	}
+	return &cos.Stream{Dict: dict, Data: samples}
+}
+
+// exponentialFunction returns a Type 2 function over [0, 1].
+func exponentialFunction(c0, c1 []float64, n float64) cos.Dict {
+	return cos.Dict{
+		"FunctionType": cos.Integer(2),
+		"Domain":       numbers(0, 1),
+		"C0":           numbers(c0...),
+		"C1":           numbers(c1...),
+		"N":            cos.Real(n),
+	}
+}
+
+// checkOutputs compares the outputs of a function with want.
+func checkOutputs(t *testing.T, fn cos.Object, input, want []float64) {
+	t.Helper()
+	got, err := Evaluate(fn, input)
+	if err != nil {
+		t.Fatalf("Evaluate(%v): %v", input, err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Evaluate(%v) = %v, want %v", input, got, want)
+	}
+	for i := range got {
+		if math.Abs(got[i]-want[i]) > 1e-9 {
+			t.Errorf("Evaluate(%v) = %v, want %v", input, got, want)
+			return
+		}
+	}
+}
+
+func TestSampled(t *testing.T) {
+	ramp := sampledFunction([]float64{3}, 8, []float64{0, 1}, []byte{0, 128, 255}, nil)
+	twoOutputs := sampledFunction([]float64{2}, 8, []float64{0, 1, 0, 1}, []byte{0, 255, 255, 0}, nil)
+	grid := sampledFunction([]float64{2, 2}, 8, []float64{0, 1}, []byte{0, 100, 200, 255}, nil)
+
+	tests := []struct {
+		name  string
+		fn    cos.Object
+		input []float64
+		want  []float64
+	}{
+		{"first sample", ramp, []float64{0}, []float64{0}},
+		{"between samples", ramp, []float64{0.25}, []float64{64.0 / 255}},
+		{"between later samples", ramp, []float64{0.75}, []float64{191.5 / 255}},
+		{"last sample", ramp, []float64{1}, []float64{1}},
+		{"clipped below domain", ramp, []float64{-1}, []float64{0}},
+		{"clipped above domain", ramp, []float64{2}, []float64{1}},
+		{"1 bit", sampledFunction([]float64{2}, 1, []float64{0, 1}, []byte{0x80}, nil),
+			[]float64{0.25}, []float64{0.75}},
+		{"2 bits", sampledFunction([]float64{2}, 2, []float64{0, 1}, []byte{0x30}, nil),
+			[]float64{0.5}, []float64{0.5}},
+		{"4 bits", sampledFunction([]float64{2}, 4, []float64{0, 1}, []byte{0x0F}, nil),
+			[]float64{0.5}, []float64{0.5}},
+		{"12 bits", sampledFunction([]float64{2}, 12, []float64{0, 1}, []byte{0x00, 0x0F, 0xFF}, nil),
+			[]float64{0.25}, []float64{0.25}},
+		{"16 bits", sampledFunction([]float64{2}, 16, []float64{0, 1}, []byte{0xFF, 0xFF, 0x00, 0x00}, nil),
+			[]float64{0.5}, []float64{0.5}},
+		{"32 bits", sampledFunction([]float64{2}, 32, []float64{0, 1}, []byte{0xFF, 0xFF, 0xFF, 0xFF, 0, 0, 0, 0}, nil),
+			[]float64{0}, []float64{1}},
+		{"reversed Encode", sampledFunction([]float64{3}, 8, []float64{0, 1}, []byte{0, 128, 255},
+			cos.Dict{"Encode": numbers(2, 0)}), []float64{0}, []float64{1}},
+		{"Encode within table", sampledFunction([]float64{3}, 8, []float64{0, 1}, []byte{0, 128, 255},
+			cos.Dict{"Encode": numbers(1, 2)}), []float64{0}, []float64{128.0 / 255}},
+		{"Decode", sampledFunction([]float64{2}, 8, []float64{0, 100}, []byte{0, 255},
+			cos.Dict{"Decode": numbers(10, 20)}), []float64{0.5}, []float64{15}},
+		{"Decode clipped to Range", sampledFunction([]float64{2}, 8, []float64{0, 12}, []byte{0, 255},
+			cos.Dict{"Decode": numbers(10, 20)}), []float64{1}, []float64{12}},
+		{"two outputs", twoOutputs, []float64{0.25}, []float64{0.25, 0.75}},
+		{"two inputs corner", grid, []float64{1, 0}, []float64{100.0 / 255}},
+		{"two inputs bilinear", grid, []float64{0.5, 0.5}, []float64{555.0 / 4 / 255}},
+		{"two inputs edge", grid, []float64{0.5, 1}, []float64{455.0 / 2 / 255}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkOutputs(t, tt.fn, tt.input, tt.want)
+		})
+	}
+}
+
+func TestSampledErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   *cos.Stream
+	}{
+		{"truncated samples", sampledFunction([]float64{4}, 8, []float64{0, 1}, []byte{1, 2, 3}, nil)},
+		{"invalid BitsPerSample", sampledFunction([]float64{2}, 3, []float64{0, 1}, []byte{0xFF}, nil)},
+		{"Size for too few inputs", sampledFunction([]float64{2}, 8, []float64{0, 1}, []byte{0, 1},
+			cos.Dict{"Domain": numbers(0, 1, 0, 1)})},
+		{"empty Size", sampledFunction([]float64{0}, 8, []float64{0, 1}, []byte{0}, nil)},
+		{"no Range", sampledFunction([]float64{2}, 8, nil, []byte{0, 1}, nil)},
+		{"short Decode", sampledFunction([]float64{2}, 8, []float64{0, 1, 0, 1}, []byte{0, 1, 2, 3},
+			cos.Dict{"Decode": numbers(0, 1)})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(nil, tt.fn); err == nil {
+				t.Error("Parse succeeded")
+			}
+		})
+	}
+}
+
+func TestExponential(t *testing.T) {
+	clipped := exponentialFunction([]float64{0}, []float64{2}, 1)
+	clipped["Range"] = numbers(0, 1)
+
+	tests := []struct {
+		name  string
+		fn    cos.Object
+		input float64
+		want  []float64
+	}{
+		{"linear", exponentialFunction([]float64{0}, []float64{1}, 1), 0.25, []float64{0.25}},
+		{"squared", exponentialFunction([]float64{0}, []float64{1}, 2), 0.5, []float64{0.25}},
+		{"square root", exponentialFunction([]float64{0}, []float64{1}, 0.5), 0.25, []float64{0.5}},
+		{"three components", exponentialFunction([]float64{1, 0, 0}, []float64{0, 0, 1}, 1), 0.25,
+			[]float64{0.75, 0, 0.25}},
+		{"decreasing", exponentialFunction([]float64{10}, []float64{20}, 2), 0.5, []float64{12.5}},
+		{"clipped below domain", exponentialFunction([]float64{0.2}, []float64{0.8}, 1), -1, []float64{0.2}},
+		{"clipped above domain", exponentialFunction([]float64{0.2}, []float64{0.8}, 1), 3, []float64{0.8}},
+		{"clipped to Range", clipped, 1, []float64{1}},
+		{"default C0 and C1", cos.Dict{
+			"FunctionType": cos.Integer(2),
+			"Domain":       numbers(0, 1),
+			"N":            cos.Integer(3),
+		}, 0.5, []float64{0.125}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkOutputs(t, tt.fn, []float64{tt.input}, tt.want)
+		})
+	}
+}
+
+func TestExponentialErrors(t *testing.T) {
+	mismatched := exponentialFunction([]float64{0, 0}, []float64{1}, 1)
+	noDomain := exponentialFunction([]float64{0}, []float64{1}, 1)
+	delete(noDomain, "Domain")
+
+	for name, fn := range map[string]cos.Dict{"C0 and C1 differ": mismatched, "no Domain": noDomain} {
+		if _, err := Parse(nil, fn); err == nil {
+			t.Errorf("%s: Parse succeeded", name)
+		}
+	}
+}
+
+// rampStitching returns a Type 3 function over [0, 1] rising to 1 at 0.5
+// and falling again, from one linear function used through Encode.
+func rampStitching() cos.Dict {
+	linear := exponentialFunction([]float64{0}, []float64{1}, 1)
+	return cos.Dict{
+		"FunctionType": cos.Integer(3),
+		"Domain":       numbers(0, 1),
+		"Functions":    cos.Array{linear, linear},
+		"Bounds":       numbers(0.5),
+		"Encode":       numbers(0, 1, 1, 0),
+	}
+}
+
+func TestStitching(t *testing.T) {
+	threeParts := cos.Dict{
+		"FunctionType": cos.Integer(3),
+		"Domain":       numbers(0, 3),
+		"Functions": cos.Array{
+			exponentialFunction([]float64{0}, []float64{1}, 1),
+			exponentialFunction([]float64{10}, []float64{20}, 1),
+			exponentialFunction([]float64{0}, []float64{1}, 2),
+		},
+		"Bounds": numbers(1, 2),
+		"Encode": numbers(0, 1, 0, 1, 0, 1),
+	}
+	clipped := rampStitching()
+	clipped["Range"] = numbers(0, 0.5)
+
+	tests := []struct {
+		name  string
+		fn    cos.Object
+		input float64
+		want  float64
+	}{
+		{"start of domain", rampStitching(), 0, 0},
+		{"first subdomain", rampStitching(), 0.25, 0.5},
+		{"bound belongs to next subdomain", rampStitching(), 0.5, 1},
+		{"reversed Encode", rampStitching(), 0.75, 0.5},
+		{"end of domain", rampStitching(), 1, 0},
+		{"clipped below domain", rampStitching(), -1, 0},
+		{"clipped above domain", rampStitching(), 2, 0},
+		{"clipped to Range", clipped, 0.5, 0.5},
+		{"middle function", threeParts, 1.5, 15},
+		{"last function", threeParts, 2.5, 0.25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkOutputs(t, tt.fn, []float64{tt.input}, []float64{tt.want})
+		})
+	}
+}
+
+func TestStitchingErrors(t *testing.T) {
+	wrongBounds := rampStitching()
+	wrongBounds["Bounds"] = numbers(0.3, 0.6)
+	shortEncode := rampStitching()
+	shortEncode["Encode"] = numbers(0, 1)
+	noFunctions := rampStitching()
+	noFunctions["Functions"] = cos.Array{}
+
+	// Each level nests the previous one as its only function
+	deep := exponentialFunction([]float64{0}, []float64{1}, 1)
+	for i := 0; i <= maxFunctionDepth+1; i++ {
+		deep = cos.Dict{
+			"FunctionType": cos.Integer(3),
+			"Domain":       numbers(0, 1),
+			"Functions":    cos.Array{deep},
+			"Bounds":       cos.Array{},
+			"Encode":       numbers(0, 1),
+		}
+	}
+
+	tests := map[string]cos.Dict{
+		"wrong number of Bounds": wrongBounds,
+		"Encode too short":       shortEncode,
+		"no Functions":           noFunctions,
+		"nested too deeply":      deep,
+	}
+	for name, fn := range tests {
+		if _, err := Parse(nil, fn); err == nil {
+			t.Errorf("%s: Parse succeeded", name)
+		}
+	}
+}
+
+func TestFunctionArray(t *testing.T) {
+	fns := cos.Array{
+		exponentialFunction([]float64{0}, []float64{1}, 1),
+		exponentialFunction([]float64{1, 0}, []float64{0, 1}, 2),
+	}
+	checkOutputs(t, fns, []float64{0.5}, []float64{0.5, 0.75, 0.25})
+}
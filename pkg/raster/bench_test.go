@@ -0,0 +1,92 @@
+package raster_test
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+
+	"gumgum/pkg/cos"
+	"gumgum/pkg/font/ttf"
+	"gumgum/pkg/raster"
+	"gumgum/pkg/testutil"
+)
+
+// benchmarkRender renders the first page of a fixture at 150 DPI with a
+// new Renderer each time, so every iteration parses and draws the page
+// from scratch. Run with -benchmem to see allocations per render.
+func benchmarkRender(b *testing.B, name string) {
+	reader, err := cos.NewReader(testutil.FixtureBytes(b, name))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r := raster.NewRenderer(reader)
+		r.SetDPI(150)
+		if _, err := r.RenderPage(0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRenderSimplePage(b *testing.B) {
+	benchmarkRender(b, testutil.SimpleText)
+}
+
+func BenchmarkRenderImagePage(b *testing.B) {
+	benchmarkRender(b, testutil.Photo)
+}
+
+func BenchmarkRenderTextHeavyPage(b *testing.B) {
+	benchmarkRender(b, testutil.TextHeavy)
+}
+
+func BenchmarkParseXref(b *testing.B) {
+	for _, name := range []string{testutil.SimpleText, testutil.XrefStream} {
+		b.Run(name, func(b *testing.B) {
+			data := testutil.FixtureBytes(b, name)
+			i := bytes.LastIndex(data, []byte("startxref"))
+			fields := bytes.Fields(data[i+len("startxref"):])
+			if len(fields) == 0 {
+				b.Fatal("no startxref offset")
+			}
+			offset, err := strconv.ParseInt(string(fields[0]), 10, 64)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := cos.ParseXref(data, offset); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkParseCmap looks up 1000 characters in the cmap of Go Regular,
+// as none of the fixtures embed a TrueType font.
+func BenchmarkParseCmap(b *testing.B) {
+	f, err := ttf.Parse(goregular.TTF)
+	if err != nil {
+		b.Fatal(err)
+	}
+	runes := make([]rune, 1000)
+	for i := range runes {
+		runes[i] = rune(0x20 + i%0x200)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, r := range runes {
+			f.GetGlyphID(r)
+		}
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"image/draw"
 	"math"
 
+	"gumgum/pkg/font"
+	"gumgum/pkg/font/ttf"
 	"gumgum/pkg/graphics"
 	pathpkg "gumgum/pkg/path"
 
@@ -22,6 +24,14 @@ type Canvas struct {
 
 	// Default background
 	background color.Color
+
+	// Soft mask and clip multiplied into the coverage of every drawing
+	// (nil = none)
+	mask *image.Alpha
+	clip *image.Alpha
+
+	// Transparency group layers pushed onto this canvas
+	layers []*Canvas
 }
 
 // NewCanvas creates a new canvas with the given dimensions.
@@ -80,27 +90,141 @@ func (c *Canvas) SetBackground(col color.Color) {
 	c.background = col
 }
 
+// SetMask sets the soft mask applied to subsequent drawing. The mask must
+// cover the canvas bounds; nil removes it.
+func (c *Canvas) SetMask(mask *image.Alpha) {
+	c.mask = mask
+}
+
+// SetClip sets the clip coverage applied to subsequent drawing. The clip
+// must cover the canvas bounds; nil removes it.
+func (c *Canvas) SetClip(clip *image.Alpha) {
+	c.clip = clip
+}
+
 // Fill fills a path with the given color using the specified fill rule.
 func (c *Canvas) Fill(path *graphics.Path, col color.Color, rule graphics.FillRule) {
+	c.FillWith(path, &image.Uniform{col}, rule)
+}
+
+// FillWith fills a path with a source image aligned to the canvas, such as a
+// pattern.
+func (c *Canvas) FillWith(path *graphics.Path, src image.Image, rule graphics.FillRule) {
 	if path.IsEmpty() {
 		return
 	}
 
+	// Rasterize only the pixels the path can cover, so small shapes such as
+	// glyphs do not cost a pass over the whole canvas
+	bounds := c.pathBounds(path)
+	if bounds.Empty() {
+		return
+	}
+
 	// Create rasterizer
 	r := &vector.Rasterizer{}
-	r.Reset(c.width, c.height)
+	r.Reset(bounds.Dx(), bounds.Dy())
 
 	// Convert and add path
+	if bounds.Min != (image.Point{}) {
+		path = path.Transform(graphics.Translate(-float64(bounds.Min.X), -float64(bounds.Min.Y)))
+	}
 	pathpkg.ToVector(path, r)
 
 	// Draw based on fill rule
-	var src image.Image = &image.Uniform{col}
-
 	if rule == graphics.FillRuleEvenOdd {
 		r.DrawOp = draw.Src
 	}
 
-	r.Draw(c.img, c.img.Bounds(), src, image.Point{})
+	if c.mask != nil || c.clip != nil {
+		c.drawMasked(r, src, bounds)
+		return
+	}
+
+	r.Draw(c.img, bounds, src, bounds.Min)
+}
+
+// pathBounds returns the pixels of the canvas a path can cover. Paths with
+// coordinates that are not finite are given the whole canvas.
+func (c *Canvas) pathBounds(path *graphics.Path) image.Rectangle {
+	b := path.Bounds()
+	minX, minY := math.Floor(b.X), math.Floor(b.Y)
+	maxX, maxY := math.Ceil(b.X+b.Width), math.Ceil(b.Y+b.Height)
+	for _, v := range []float64{minX, minY, maxX, maxY} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return c.img.Bounds()
+		}
+	}
+
+	canvas := c.img.Bounds()
+	clamp := func(v float64, lo, hi int) int {
+		return int(math.Max(float64(lo), math.Min(v, float64(hi))))
+	}
+	return image.Rect(
+		clamp(minX, canvas.Min.X, canvas.Max.X), clamp(minY, canvas.Min.Y, canvas.Max.Y),
+		clamp(maxX, canvas.Min.X, canvas.Max.X), clamp(maxY, canvas.Min.Y, canvas.Max.Y),
+	)
+}
+
+// drawMasked draws the rasterized coverage of bounds scaled by the soft
+// mask.
+func (c *Canvas) drawMasked(r *vector.Rasterizer, src image.Image, bounds image.Rectangle) {
+	coverage := image.NewAlpha(bounds)
+	r.Draw(coverage, bounds, image.Opaque, image.Point{})
+
+	c.applyMasks(coverage)
+
+	draw.DrawMask(c.img, bounds, src, bounds.Min, coverage, bounds.Min, draw.Over)
+}
+
+// applyMasks multiplies coverage, which may cover part of the canvas, by
+// the soft mask and clip.
+func (c *Canvas) applyMasks(coverage *image.Alpha) {
+	b := coverage.Rect
+	for _, m := range []*image.Alpha{c.mask, c.clip} {
+		if m == nil {
+			continue
+		}
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			row := coverage.Pix[coverage.PixOffset(b.Min.X, y):coverage.PixOffset(b.Max.X, y)]
+			maskRow := m.Pix[m.PixOffset(b.Min.X, y):]
+			for i, a := range row {
+				if a != 0 {
+					row[i] = uint8(uint16(a) * uint16(maskRow[i]) / 255)
+				}
+			}
+		}
+	}
+}
+
+// DrawLayer composites a canvas-sized image over the canvas, applying the
+// soft mask and clip.
+func (c *Canvas) DrawLayer(layer image.Image) {
+	bounds := c.img.Bounds()
+
+	if c.mask == nil && c.clip == nil {
+		draw.Draw(c.img, bounds, layer, image.Point{}, draw.Over)
+		return
+	}
+
+	coverage := image.NewAlpha(bounds)
+	for i := range coverage.Pix {
+		coverage.Pix[i] = 0xFF
+	}
+	c.applyMasks(coverage)
+
+	draw.DrawMask(c.img, bounds, layer, image.Point{}, coverage, image.Point{}, draw.Over)
+}
+
+// Coverage rasterizes a path to an alpha image covering the canvas.
+func (c *Canvas) Coverage(path *graphics.Path) *image.Alpha {
+	r := &vector.Rasterizer{}
+	r.Reset(c.width, c.height)
+	pathpkg.ToVector(path, r)
+
+	coverage := image.NewAlpha(c.img.Bounds())
+	r.Draw(coverage, coverage.Bounds(), image.Opaque, image.Point{})
+	return coverage
 }
 
 // Stroke draws the outline of a path with the given style.
@@ -328,18 +452,94 @@ func (c *Canvas) DrawImage(img image.Image, x, y int) {
 		img, image.Point{}, draw.Over)
 }
 
+// ScaleMode selects how DrawImageScaled samples the source image.
+type ScaleMode int
+
+const (
+	// ScaleNearest takes the source pixel nearest each destination pixel.
+	ScaleNearest ScaleMode = iota
+	// ScaleBilinear blends the four source pixels around each destination
+	// pixel, giving smoother results when an image is resized.
+	ScaleBilinear
+)
+
 // DrawImageScaled draws an image scaled to fit the given rectangle.
-func (c *Canvas) DrawImageScaled(img image.Image, x, y, w, h int) {
-	// Simple nearest-neighbor scaling
+func (c *Canvas) DrawImageScaled(img image.Image, x, y, w, h int, mode ScaleMode) {
 	srcBounds := img.Bounds()
 	srcW := srcBounds.Dx()
 	srcH := srcBounds.Dy()
+	if srcW == 0 || srcH == 0 {
+		return
+	}
 
 	for dy := 0; dy < h; dy++ {
 		for dx := 0; dx < w; dx++ {
-			srcX := srcBounds.Min.X + dx*srcW/w
-			srcY := srcBounds.Min.Y + dy*srcH/h
-			c.img.Set(x+dx, y+dy, img.At(srcX, srcY))
+			if mode == ScaleNearest {
+				srcX := srcBounds.Min.X + dx*srcW/w
+				srcY := srcBounds.Min.Y + dy*srcH/h
+				c.img.Set(x+dx, y+dy, img.At(srcX, srcY))
+				continue
+			}
+
+			// Map pixel centers, then clamp to the edge pixels
+			fx := (float64(dx)+0.5)*float64(srcW)/float64(w) - 0.5
+			fy := (float64(dy)+0.5)*float64(srcH)/float64(h) - 0.5
+			c.img.Set(x+dx, y+dy, bilinearAt(img, fx, fy))
+		}
+	}
+}
+
+// bilinearAt samples img at fractional coordinates relative to its bounds,
+// blending the four surrounding pixels.
+func bilinearAt(img image.Image, fx, fy float64) color.Color {
+	b := img.Bounds()
+	fx = math.Max(0, math.Min(fx, float64(b.Dx()-1)))
+	fy = math.Max(0, math.Min(fy, float64(b.Dy()-1)))
+
+	x0, y0 := int(fx), int(fy)
+	x1, y1 := min(x0+1, b.Dx()-1), min(y0+1, b.Dy()-1)
+	tx, ty := fx-float64(x0), fy-float64(y0)
+
+	r00, g00, b00, a00 := img.At(b.Min.X+x0, b.Min.Y+y0).RGBA()
+	r10, g10, b10, a10 := img.At(b.Min.X+x1, b.Min.Y+y0).RGBA()
+	r01, g01, b01, a01 := img.At(b.Min.X+x0, b.Min.Y+y1).RGBA()
+	r11, g11, b11, a11 := img.At(b.Min.X+x1, b.Min.Y+y1).RGBA()
+
+	// Premultiplied components blend correctly across transparent pixels
+	blend := func(p00, p10, p01, p11 uint32) uint16 {
+		v := (1-tx)*(1-ty)*float64(p00) + tx*(1-ty)*float64(p10) +
+			(1-tx)*ty*float64(p01) + tx*ty*float64(p11)
+		return uint16(v + 0.5)
+	}
+	return color.RGBA64{
+		R: blend(r00, r10, r01, r11),
+		G: blend(g00, g10, g01, g11),
+		B: blend(b00, b10, b01, b11),
+		A: blend(a00, a10, a01, a11),
+	}
+}
+
+// DrawText draws a string with a TrueType font, its baseline starting at
+// (x, y) in canvas pixels. The point size is converted to pixels at the
+// canvas DPI. It returns the advance width in pixels.
+func (c *Canvas) DrawText(text string, f *ttf.Font, pointSize float64, x, y float64, col color.Color) float64 {
+	r := font.NewRenderer(f)
+	r.SetScale(pointSize * c.dpi / 72)
+
+	// Glyphs are drawn y-up from the origin; flip them onto the baseline
+	flip := graphics.Matrix{1, 0, 0, -1, x, y}
+	if r.HasColorGlyphs() {
+		for _, layer := range r.RenderColorString(text, 0, 0) {
+			layerColor := col
+			if !layer.Foreground {
+				layerColor = layer.Color
+			}
+			c.Fill(layer.Path.Transform(flip), layerColor, graphics.FillRuleNonZero)
 		}
+	} else {
+		path := r.RenderString(text, 0, 0)
+		c.Fill(path.Transform(flip), col, graphics.FillRuleNonZero)
 	}
+
+	return r.Advance(text)
 }
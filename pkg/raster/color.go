@@ -7,24 +7,166 @@ import (
 	"gumgum/pkg/graphics"
 )
 
+// Rendering intents (ri operator and ExtGState RI entry).
+const (
+	IntentAbsoluteColorimetric = "AbsoluteColorimetric"
+	IntentRelativeColorimetric = "RelativeColorimetric"
+	IntentSaturation           = "Saturation"
+	IntentPerceptual           = "Perceptual"
+)
+
+// Reference white points in XYZ.
+var (
+	WhitePointD50 = [3]float64{0.96422, 1.0, 0.82521}
+	WhitePointD65 = [3]float64{0.95047, 1.0, 1.08883}
+)
+
+// saturationBoost scales chroma under the Saturation intent.
+const saturationBoost = 1.2
+
 // ColorConverter handles color space conversions for rendering.
 type ColorConverter struct {
 	// ICC profiles would go here for accurate color management
+
+	// Intent is the rendering intent; empty means RelativeColorimetric.
+	Intent string
 }
 
 // NewColorConverter creates a new color converter.
 func NewColorConverter() *ColorConverter {
-	return &ColorConverter{}
+	return &ColorConverter{Intent: IntentRelativeColorimetric}
 }
 
-// ToRGBA converts a graphics.Color to a standard RGBA color.
+// ToRGBA converts a graphics.Color to a standard RGBA color. Device colors
+// are already in the output space, so only Saturation changes them.
 func (cc *ColorConverter) ToRGBA(c graphics.Color) color.RGBA {
-	return c.ToRGBA()
+	rgba := c.ToRGBA()
+	if cc.Intent != IntentSaturation {
+		return rgba
+	}
+
+	r, g, b := cc.boostChroma(float64(rgba.R)/255, float64(rgba.G)/255, float64(rgba.B)/255)
+	return color.RGBA{uint8(r*255 + 0.5), uint8(g*255 + 0.5), uint8(b*255 + 0.5), 255}
+}
+
+// LabToRGB converts a CIE Lab color with the given white point to sRGB.
+// Colorimetric intents other than AbsoluteColorimetric adapt the white
+// point to D65 with the Bradford transform; Perceptual compresses colors
+// outside the sRGB gamut instead of clipping them, and Saturation boosts
+// chroma.
+func (cc *ColorConverter) LabToRGB(l, a, b float64, whitePoint [3]float64) (float64, float64, float64) {
+	x, y, z := labToXYZ(l, a, b, whitePoint)
+	if cc.Intent != IntentAbsoluteColorimetric {
+		x, y, z = bradfordAdapt(x, y, z, whitePoint, WhitePointD65)
+	}
+
+	// XYZ to linear sRGB
+	r := x*3.2406 + y*-1.5372 + z*-0.4986
+	g := x*-0.9689 + y*1.8758 + z*0.0415
+	bl := x*0.0557 + y*-0.2040 + z*1.0570
+
+	if cc.Intent == IntentPerceptual {
+		r, g, bl = compressGamut(r, g, bl)
+	}
+
+	r = clamp(gammaCorrect(r), 0, 1)
+	g = clamp(gammaCorrect(g), 0, 1)
+	bl = clamp(gammaCorrect(bl), 0, 1)
+
+	if cc.Intent == IntentSaturation {
+		return cc.boostChroma(r, g, bl)
+	}
+	return r, g, bl
+}
+
+// boostChroma increases the saturation of an RGB color.
+func (cc *ColorConverter) boostChroma(r, g, b float64) (float64, float64, float64) {
+	h, s, v := RGBToHSV(r, g, b)
+	return HSVToRGB(h, clamp(s*saturationBoost, 0, 1), v)
+}
+
+// labToXYZ converts Lab to XYZ relative to a white point.
+func labToXYZ(l, a, b float64, whitePoint [3]float64) (x, y, z float64) {
+	fy := (l + 16) / 116
+	fx := a/500 + fy
+	fz := fy - b/200
+
+	inverse := func(t float64) float64 {
+		if t3 := t * t * t; t3 > 0.008856 {
+			return t3
+		}
+		return (t - 16.0/116) / 7.787
+	}
+
+	return inverse(fx) * whitePoint[0], inverse(fy) * whitePoint[1], inverse(fz) * whitePoint[2]
+}
+
+// bradford is the Bradford cone response matrix.
+var bradford = [3][3]float64{
+	{0.8951, 0.2664, -0.1614},
+	{-0.7502, 1.7135, 0.0367},
+	{0.0389, -0.0685, 1.0296},
+}
+
+// bradfordInverse is the inverse of the Bradford matrix.
+var bradfordInverse = [3][3]float64{
+	{0.9869929, -0.1470543, 0.1599627},
+	{0.4323053, 0.5183603, 0.0492912},
+	{-0.0085287, 0.0400428, 0.9684867},
+}
+
+// bradfordAdapt maps an XYZ color seen under one white point to the color
+// that looks the same under another.
+func bradfordAdapt(x, y, z float64, from, to [3]float64) (float64, float64, float64) {
+	if from == to {
+		return x, y, z
+	}
+
+	cone := func(v [3]float64) [3]float64 {
+		var out [3]float64
+		for i := range out {
+			out[i] = bradford[i][0]*v[0] + bradford[i][1]*v[1] + bradford[i][2]*v[2]
+		}
+		return out
+	}
+
+	src, dst := cone(from), cone(to)
+	c := cone([3]float64{x, y, z})
+	for i := range c {
+		if src[i] != 0 {
+			c[i] *= dst[i] / src[i]
+		}
+	}
+
+	var out [3]float64
+	for i := range out {
+		out[i] = bradfordInverse[i][0]*c[0] + bradfordInverse[i][1]*c[1] + bradfordInverse[i][2]*c[2]
+	}
+	return out[0], out[1], out[2]
+}
+
+// compressGamut brings a linear RGB color outside [0, 1] into gamut by
+// reducing its chroma toward a gray of the same luminance, keeping hue and
+// lightness where clipping each channel would shift them.
+func compressGamut(r, g, b float64) (float64, float64, float64) {
+	lum := clamp(0.2126*r+0.7152*g+0.0722*b, 0, 1)
+
+	// Largest fraction of the chroma that fits
+	t := 1.0
+	for _, v := range []float64{r, g, b} {
+		if v > 1 {
+			t = math.Min(t, (1-lum)/(v-lum))
+		} else if v < 0 {
+			t = math.Min(t, lum/(lum-v))
+		}
+	}
+
+	return lum + t*(r-lum), lum + t*(g-lum), lum + t*(b-lum)
 }
 
 // ToNRGBA converts a graphics.Color to a non-premultiplied RGBA color with alpha.
 func (cc *ColorConverter) ToNRGBA(c graphics.Color, alpha float64) color.NRGBA {
-	rgba := c.ToRGBA()
+	rgba := cc.ToRGBA(c)
 	return color.NRGBA{
 		R: rgba.R,
 		G: rgba.G,
@@ -75,6 +217,12 @@ func RGBToCMYK(r, g, b float64) (c, m, y, k float64) {
 	return
 }
 
+// paintColor converts a fill or stroke color under a rendering intent.
+func paintColor(c graphics.Color, alpha float64, intent string) color.NRGBA {
+	cc := ColorConverter{Intent: intent}
+	return cc.ToNRGBA(c, alpha)
+}
+
 // LabToRGB converts CIE Lab to RGB (D65 illuminant).
 func LabToRGB(l, a, b float64) (r, g, bb float64) {
 	// Lab to XYZ
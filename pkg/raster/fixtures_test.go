@@ -0,0 +1,111 @@
+package raster_test
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gumgum/pkg/cos"
+	"gumgum/pkg/raster"
+	"gumgum/pkg/testutil"
+)
+
+func renderFixture(t *testing.T, name string, pageNum int, dpi float64) *image.RGBA {
+	t.Helper()
+	reader, err := cos.NewReader(testutil.FixtureBytes(t, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := raster.NewRenderer(reader)
+	r.SetDPI(dpi)
+	img, err := r.RenderPage(pageNum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+func TestFixturesRender(t *testing.T) {
+	tests := []struct {
+		name          string
+		pages         int
+		width, height int // At 72 DPI, before /Rotate is applied
+	}{
+		{testutil.SimpleText, 1, 612, 792},
+		{testutil.Images, 1, 200, 200},
+		{testutil.Form, 1, 612, 792},
+		{testutil.Rotated, 1, 612, 792},
+		{testutil.Encrypted, 1, 612, 792},
+		{testutil.Linearized, 2, 612, 792},
+		{testutil.XrefStream, 1, 612, 792},
+		{testutil.Photo, 1, 612, 792},
+		{testutil.TextHeavy, 1, 612, 792},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for pageNum := 0; pageNum < tt.pages; pageNum++ {
+				img := renderFixture(t, tt.name, pageNum, 72)
+				if got := img.Bounds().Size(); got != image.Pt(tt.width, tt.height) {
+					t.Errorf("page %d size = %v, want %dx%d", pageNum, got, tt.width, tt.height)
+				}
+			}
+		})
+	}
+}
+
+// The images fixture draws a 2x2 image of red, green, blue and white
+// into the square from (50, 50) to (150, 150).
+func TestImagesFixturePixels(t *testing.T) {
+	img := renderFixture(t, testutil.Images, 0, 72)
+
+	// Image rows run top to bottom
+	tests := []struct {
+		at      image.Point
+		r, g, b uint8
+	}{
+		{image.Pt(75, 75), 255, 0, 0},
+		{image.Pt(125, 75), 0, 255, 0},
+		{image.Pt(75, 125), 0, 0, 255},
+		{image.Pt(125, 125), 255, 255, 255},
+		{image.Pt(10, 10), 255, 255, 255},
+	}
+	for _, tt := range tests {
+		c := img.RGBAAt(tt.at.X, tt.at.Y)
+		if c.R != tt.r || c.G != tt.g || c.B != tt.b {
+			t.Errorf("pixel %v = %v, want rgb(%d, %d, %d)", tt.at, c, tt.r, tt.g, tt.b)
+		}
+	}
+}
+
+func TestRenderToFile(t *testing.T) {
+	reader, err := cos.NewReader(testutil.FixtureBytes(t, testutil.Images))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := raster.NewRenderer(reader)
+	dir := t.TempDir()
+
+	name := filepath.Join(dir, "page.png")
+	if err := r.RenderToFile(0, name); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := png.Decode(f); err != nil {
+		t.Errorf("decode: %v", err)
+	}
+
+	// A page that fails to render leaves no file behind
+	missing := filepath.Join(dir, "missing.png")
+	if err := r.RenderToFile(5, missing); err == nil {
+		t.Fatal("rendering a page past the end succeeded")
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Errorf("file created for a failed render: %v", err)
+	}
+}
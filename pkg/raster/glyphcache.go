@@ -0,0 +1,164 @@
+package raster
+
+import (
+	"container/list"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sync"
+
+	"gumgum/pkg/graphics"
+	pathpkg "gumgum/pkg/path"
+
+	"golang.org/x/image/vector"
+)
+
+// GlyphCacheMaxSize is the largest pixel size at which glyphs are cached.
+// Larger glyphs are rare enough per page that filling their outlines
+// directly costs less than keeping their bitmaps.
+const GlyphCacheMaxSize = 16
+
+// DefaultMaxCachedGlyphs is the default capacity of a Renderer's glyph
+// cache. Cached bitmaps are at most 16x16 pixels, so a full cache holds
+// a few megabytes.
+const DefaultMaxCachedGlyphs = 8192
+
+// glyphKey identifies a glyph bitmap: a font, a glyph in it and the em
+// size in pixels.
+type glyphKey struct {
+	font  string
+	glyph uint16
+	size  float64
+}
+
+// glyphEntry is an element of the LRU list.
+type glyphEntry struct {
+	key    glyphKey
+	bitmap *image.Alpha
+}
+
+// GlyphCache holds rasterized glyph bitmaps for reuse when the same glyph
+// is drawn at the same size many times, as in body text, evicting the
+// least recently used bitmaps when full. It is safe for concurrent use.
+type GlyphCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // Front is most recently used
+	glyphs   map[glyphKey]*list.Element
+}
+
+// NewGlyphCache creates a cache holding at most capacity bitmaps. A
+// capacity of zero or less disables eviction.
+func NewGlyphCache(capacity int) *GlyphCache {
+	return &GlyphCache{
+		capacity: capacity,
+		order:    list.New(),
+		glyphs:   make(map[glyphKey]*list.Element),
+	}
+}
+
+// Glyph returns the coverage bitmap of a glyph at the given em size in
+// pixels, rasterizing it on first access. font identifies the font program
+// across pages, such as the object reference of the font dictionary. The
+// outline function supplies the glyph path in em units, y up. The bitmap's
+// bounds are relative to the glyph origin, y down, so its Min is usually
+// negative.
+func (gc *GlyphCache) Glyph(font string, glyphID uint16, sizePixels float64, outline func() (*graphics.Path, error)) (*image.Alpha, error) {
+	key := glyphKey{font: font, glyph: glyphID, size: sizePixels}
+
+	gc.mu.Lock()
+	elem, ok := gc.glyphs[key]
+	if ok {
+		gc.order.MoveToFront(elem)
+	}
+	gc.mu.Unlock()
+	if ok {
+		return elem.Value.(*glyphEntry).bitmap, nil
+	}
+
+	path, err := outline()
+	if err != nil {
+		return nil, err
+	}
+	bitmap := rasterizeGlyph(path, sizePixels)
+
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	if _, ok := gc.glyphs[key]; !ok {
+		gc.glyphs[key] = gc.order.PushFront(&glyphEntry{key: key, bitmap: bitmap})
+		if gc.capacity > 0 && gc.order.Len() > gc.capacity {
+			oldest := gc.order.Back()
+			gc.order.Remove(oldest)
+			delete(gc.glyphs, oldest.Value.(*glyphEntry).key)
+		}
+	}
+	return bitmap, nil
+}
+
+// Len returns the number of cached bitmaps.
+func (gc *GlyphCache) Len() int {
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	return gc.order.Len()
+}
+
+// rasterizeGlyph draws an em-unit outline at the given pixel size into an
+// alpha image just large enough to hold it.
+func rasterizeGlyph(path *graphics.Path, sizePixels float64) *image.Alpha {
+	if path.IsEmpty() {
+		return image.NewAlpha(image.Rectangle{})
+	}
+
+	scaled := path.Transform(graphics.Matrix{sizePixels, 0, 0, -sizePixels, 0, 0})
+	b := scaled.Bounds()
+	bounds := image.Rect(
+		int(math.Floor(b.X)), int(math.Floor(b.Y)),
+		int(math.Ceil(b.X+b.Width)), int(math.Ceil(b.Y+b.Height)),
+	)
+	if bounds.Empty() {
+		return image.NewAlpha(image.Rectangle{})
+	}
+
+	r := &vector.Rasterizer{}
+	r.Reset(bounds.Dx(), bounds.Dy())
+	origin := graphics.Translate(-float64(bounds.Min.X), -float64(bounds.Min.Y))
+	pathpkg.ToVector(scaled.Transform(origin), r)
+
+	bitmap := image.NewAlpha(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	r.Draw(bitmap, bitmap.Bounds(), image.Opaque, image.Point{})
+
+	// Pix is laid out from Rect.Min, so moving the rectangle re-bases it
+	bitmap.Rect = bounds
+	return bitmap
+}
+
+// DrawGlyph draws a cached glyph bitmap with its origin at (x, y) in canvas
+// pixels, applying the soft mask and clip.
+func (c *Canvas) DrawGlyph(glyph *image.Alpha, x, y int, col color.Color) {
+	origin := image.Pt(x, y)
+	dst := glyph.Bounds().Add(origin).Intersect(c.img.Bounds())
+	if dst.Empty() {
+		return
+	}
+	src := &image.Uniform{col}
+
+	if c.mask == nil && c.clip == nil {
+		draw.DrawMask(c.img, dst, src, image.Point{}, glyph, dst.Min.Sub(origin), draw.Over)
+		return
+	}
+
+	coverage := image.NewAlpha(dst)
+	for py := dst.Min.Y; py < dst.Max.Y; py++ {
+		for px := dst.Min.X; px < dst.Max.X; px++ {
+			a := uint16(glyph.Pix[glyph.PixOffset(px-x, py-y)])
+			for _, m := range []*image.Alpha{c.mask, c.clip} {
+				if m != nil && a != 0 {
+					a = a * uint16(m.Pix[m.PixOffset(px, py)]) / 255
+				}
+			}
+			coverage.Pix[coverage.PixOffset(px, py)] = uint8(a)
+		}
+	}
+	draw.DrawMask(c.img, dst, src, image.Point{}, coverage, dst.Min, draw.Over)
+}
@@ -0,0 +1,214 @@
+package raster
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/goregular"
+
+	"gumgum/pkg/cos"
+	"gumgum/pkg/font/ttf"
+	"gumgum/pkg/graphics"
+)
+
+// writeType0PDF writes a document of 200x100 point pages drawing text in
+// Type 0 fonts with Identity-H encoding and embedded TrueType programs.
+// fonts lists the programs; pages[i] maps the resource names of page i to
+// indexes in fonts, and contents[i] is its content stream.
+func writeType0PDF(tb testing.TB, fonts [][]byte, pages []map[string]int, contents []string) []byte {
+	tb.Helper()
+
+	var buf bytes.Buffer
+	w, err := cos.NewWriter(&buf, "1.7")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	add := func(obj cos.Object) *cos.Reference {
+		ref, err := w.AddObject(obj)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		return ref
+	}
+	stream := func(data []byte) *cos.Reference {
+		ref, err := w.WriteStream(cos.Dict{}, data, cos.StreamOptions{Compress: true, Level: zlib.DefaultCompression})
+		if err != nil {
+			tb.Fatal(err)
+		}
+		return ref
+	}
+
+	fontRefs := make([]*cos.Reference, len(fonts))
+	for i, program := range fonts {
+		name := cos.Name(fmt.Sprintf("Font%d", i))
+		descriptor := add(cos.Dict{
+			"Type":      cos.Name("FontDescriptor"),
+			"FontName":  name,
+			"Flags":     cos.Integer(32),
+			"FontFile2": stream(program),
+		})
+		cidFont := add(cos.Dict{
+			"Type":           cos.Name("Font"),
+			"Subtype":        cos.Name("CIDFontType2"),
+			"BaseFont":       name,
+			"CIDToGIDMap":    cos.Name("Identity"),
+			"DW":             cos.Integer(600),
+			"FontDescriptor": descriptor,
+		})
+		fontRefs[i] = add(cos.Dict{
+			"Type":            cos.Name("Font"),
+			"Subtype":         cos.Name("Type0"),
+			"BaseFont":        name,
+			"Encoding":        cos.Name("Identity-H"),
+			"DescendantFonts": cos.Array{cidFont},
+		})
+	}
+
+	pagesRef := w.Reserve()
+	kids := make(cos.Array, len(pages))
+	for i, names := range pages {
+		resources := cos.Dict{}
+		for name, font := range names {
+			resources[cos.Name(name)] = fontRefs[font]
+		}
+		kids[i] = add(cos.Dict{
+			"Type":      cos.Name("Page"),
+			"Parent":    pagesRef,
+			"MediaBox":  cos.Array{cos.Integer(0), cos.Integer(0), cos.Integer(200), cos.Integer(100)},
+			"Resources": cos.Dict{"Font": resources},
+			"Contents":  stream([]byte(contents[i])),
+		})
+	}
+	if err := w.WriteObject(pagesRef.ObjectNumber, cos.Dict{
+		"Type":  cos.Name("Pages"),
+		"Kids":  kids,
+		"Count": cos.Integer(len(pages)),
+	}); err != nil {
+		tb.Fatal(err)
+	}
+	catalog := add(cos.Dict{"Type": cos.Name("Catalog"), "Pages": pagesRef})
+	if err := w.Close(cos.Dict{"Root": catalog}); err != nil {
+		tb.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// glyphHex encodes text as the Identity-H hex string of its glyph IDs in
+// a TrueType program.
+func glyphHex(tb testing.TB, program []byte, text string) string {
+	tb.Helper()
+	f, err := ttf.Parse(program)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	var hex strings.Builder
+	hex.WriteByte('<')
+	for _, r := range text {
+		fmt.Fprintf(&hex, "%04X", f.GetGlyphID(r))
+	}
+	hex.WriteByte('>')
+	return hex.String()
+}
+
+func renderType0Page(t *testing.T, r *Renderer, pageNum int) *image.RGBA {
+	t.Helper()
+	img, err := r.RenderPage(pageNum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+func TestGlyphCacheSharedAcrossPages(t *testing.T) {
+	show := func(name string, y int) string {
+		return fmt.Sprintf("BT /%s 10 Tf 10 %d Td %s Tj ET", name, y, glyphHex(t, goregular.TTF, "gumgum"))
+	}
+	// Page 0 shows Go Regular as F1. Page 1 shows the same glyph IDs in Go
+	// Regular as F2 and in Go Mono, whose outlines for them differ, as F1
+	data := writeType0PDF(t,
+		[][]byte{goregular.TTF, gomono.TTF},
+		[]map[string]int{{"F1": 0}, {"F1": 1, "F2": 0}},
+		[]string{show("F1", 50), show("F2", 70) + " " + show("F1", 30)},
+	)
+	reader, err := cos.NewReader(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRenderer(reader)
+	r.SetDPI(72)
+	renderType0Page(t, r, 0)
+	regular := r.glyphs.Len()
+	if regular == 0 {
+		t.Fatal("no glyphs cached for 10 pixel text")
+	}
+	shared := renderType0Page(t, r, 1)
+	if got := r.glyphs.Len(); got != 2*regular {
+		t.Errorf("%d glyphs cached after page 1, want %d: Go Regular's from page 0 and Go Mono's", got, 2*regular)
+	}
+
+	// Go Mono is not drawn with Go Regular's bitmaps cached for F1
+	fresh := NewRenderer(reader)
+	fresh.SetDPI(72)
+	if !bytes.Equal(shared.Pix, renderType0Page(t, fresh, 1).Pix) {
+		t.Error("page 1 differs when rendered after page 0")
+	}
+}
+
+func TestGlyphCacheEvicts(t *testing.T) {
+	gc := NewGlyphCache(2)
+	outline := func() (*graphics.Path, error) {
+		p := graphics.NewPath()
+		p.Rect(0, 0, 0.5, 0.5)
+		return p, nil
+	}
+	for id := uint16(1); id <= 3; id++ {
+		if _, err := gc.Glyph("1 0 R", id, 10, outline); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := gc.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+}
+
+// BenchmarkGlyphCache renders a paragraph of 7 point text at 144 DPI, 14
+// pixels to the em, drawing its glyphs from cached bitmaps and, for
+// comparison, from their outlines.
+func BenchmarkGlyphCache(b *testing.B) {
+	var content strings.Builder
+	content.WriteString("BT /F1 7 Tf 9 TL 4 92 Td\n")
+	line := glyphHex(b, goregular.TTF, "the quick brown fox jumps over the lazy dog")
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(&content, "%s Tj T*\n", line)
+	}
+	content.WriteString("ET")
+	data := writeType0PDF(b, [][]byte{goregular.TTF}, []map[string]int{{"F1": 0}}, []string{content.String()})
+	reader, err := cos.NewReader(data)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, cached := range []bool{true, false} {
+		b.Run(fmt.Sprintf("cached=%v", cached), func(b *testing.B) {
+			r := NewRenderer(reader)
+			r.SetDPI(144)
+			if !cached {
+				r.glyphs = nil
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := r.RenderPage(0); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package raster
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"gumgum/pkg/graphics"
+)
+
+// ICC profile data color spaces.
+const (
+	ICCSpaceGray = "GRAY"
+	ICCSpaceRGB  = "RGB"
+	ICCSpaceCMYK = "CMYK"
+	ICCSpaceLab  = "Lab"
+)
+
+// iccHeaderSize is the size of the fixed ICC profile header.
+const iccHeaderSize = 128
+
+// ICCProfile holds the parts of an ICC profile header needed to interpret
+// colors: the data color space and the media white point. Transforms in
+// the profile's tags are not read.
+type ICCProfile struct {
+	ColorSpace string
+	Components int
+
+	// Version is the major profile version, 2 or 4.
+	Version int
+
+	// WhitePoint is the media white point in XYZ, from the wtpt tag. It
+	// is D50 if the profile has none.
+	WhitePoint [3]float64
+}
+
+// LoadICCProfile parses the header and tag table of an ICC profile.
+func LoadICCProfile(data []byte) (*ICCProfile, error) {
+	if len(data) < iccHeaderSize+4 {
+		return nil, fmt.Errorf("ICC profile too short: %d bytes", len(data))
+	}
+	if string(data[36:40]) != "acsp" {
+		return nil, fmt.Errorf("invalid ICC profile signature")
+	}
+
+	profile := &ICCProfile{
+		Version:    int(data[8]),
+		WhitePoint: WhitePointD50,
+	}
+
+	switch sig := string(data[16:20]); sig {
+	case "GRAY":
+		profile.ColorSpace, profile.Components = ICCSpaceGray, 1
+	case "RGB ":
+		profile.ColorSpace, profile.Components = ICCSpaceRGB, 3
+	case "CMYK":
+		profile.ColorSpace, profile.Components = ICCSpaceCMYK, 4
+	case "Lab ":
+		profile.ColorSpace, profile.Components = ICCSpaceLab, 3
+	default:
+		return nil, fmt.Errorf("unsupported ICC color space: %q", sig)
+	}
+
+	// Each tag entry is a signature, offset and size
+	count := binary.BigEndian.Uint32(data[iccHeaderSize:])
+	for i := uint32(0); i < count; i++ {
+		entry := iccHeaderSize + 4 + int(i)*12
+		if entry+12 > len(data) {
+			break
+		}
+		if string(data[entry:entry+4]) != "wtpt" {
+			continue
+		}
+		offset := int(binary.BigEndian.Uint32(data[entry+4:]))
+		if wp, ok := readICCXYZ(data, offset); ok {
+			profile.WhitePoint = wp
+		}
+		break
+	}
+
+	return profile, nil
+}
+
+// readICCXYZ reads an XYZType tag: the type signature, four reserved bytes
+// and three s15Fixed16Number values.
+func readICCXYZ(data []byte, offset int) ([3]float64, bool) {
+	var xyz [3]float64
+	if offset < 0 || offset+20 > len(data) || string(data[offset:offset+4]) != "XYZ " {
+		return xyz, false
+	}
+	for i := range xyz {
+		v := int32(binary.BigEndian.Uint32(data[offset+8+4*i:]))
+		xyz[i] = float64(v) / 65536
+	}
+	if xyz[1] <= 0 {
+		return xyz, false
+	}
+	return xyz, true
+}
+
+// ToColor converts components under the profile's media white point.
+// Colors are read as device colors of the profile's color space; under the
+// AbsoluteColorimetric intent they are then scaled by the media white, a
+// simplified von Kries adaptation. Other intents map media white to white.
+func (p *ICCProfile) ToColor(c graphics.Color, intent string) graphics.Color {
+	if intent != IntentAbsoluteColorimetric || p.WhitePoint == WhitePointD50 {
+		return c
+	}
+
+	// The PCS is D50; the media white seen on a D65 display
+	x, y, z := bradfordAdapt(p.WhitePoint[0], p.WhitePoint[1], p.WhitePoint[2], WhitePointD50, WhitePointD65)
+	white := [3]float64{
+		x*3.2406 + y*-1.5372 + z*-0.4986,
+		x*-0.9689 + y*1.8758 + z*0.0415,
+		x*0.0557 + y*-0.2040 + z*1.0570,
+	}
+
+	rgba := c.ToRGBA()
+	out := [3]float64{float64(rgba.R) / 255, float64(rgba.G) / 255, float64(rgba.B) / 255}
+	for i := range out {
+		out[i] = clamp(gammaCorrect(linearize(out[i])*white[i]), 0, 1)
+	}
+	return graphics.NewRGB(out[0], out[1], out[2])
+}
+
+// linearize inverts the sRGB transfer function.
+func linearize(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
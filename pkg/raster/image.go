@@ -0,0 +1,860 @@
+package raster
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"math"
+
+	"gumgum/pkg/cos"
+	"gumgum/pkg/graphics"
+	"gumgum/pkg/pdffunction"
+	"gumgum/pkg/stream"
+)
+
+// maxColorSpaceDepth limits nesting of color space arrays.
+const maxColorSpaceDepth = 4
+
+// imageColorSpace describes how image samples map to colors.
+type imageColorSpace struct {
+	family     string // DeviceGray, DeviceRGB, DeviceCMYK, Lab, Indexed or Separation
+	components int
+
+	// Indexed color spaces map one sample through a palette of base colors
+	base    *imageColorSpace
+	hival   int
+	palette []byte
+
+	// Separation and DeviceN spaces map their colorants to the base space
+	// through a tint transform
+	tint pdffunction.Function
+
+	// Lab spaces have a white point and a range for the a* and b*
+	// components
+	whitePoint [3]float64
+	labRange   [4]float64
+
+	// ICCBased spaces keep their profile for white point adaptation
+	icc *ICCProfile
+
+	// Rendering intent used for CIE-based conversions
+	intent string
+}
+
+// inlineNames maps abbreviated inline image values to their full names.
+var inlineNames = map[string]string{
+	"G":    "DeviceGray",
+	"RGB":  "DeviceRGB",
+	"CMYK": "DeviceCMYK",
+	"I":    "Indexed",
+	"AHx":  "ASCIIHexDecode",
+	"A85":  "ASCII85Decode",
+	"LZW":  "LZWDecode",
+	"Fl":   "FlateDecode",
+	"RL":   "RunLengthDecode",
+	"CCF":  "CCITTFaxDecode",
+	"DCT":  "DCTDecode",
+}
+
+// drawXObject draws a named image or form XObject (Do operator).
+func (rc *renderContext) drawXObject(name string, state *graphics.State) error {
+	obj, err := rc.lookupResourceObject("XObject", name)
+	if err != nil {
+		return err
+	}
+	xobj, ok := obj.(*cos.Stream)
+	if !ok {
+		return fmt.Errorf("XObject is not a stream")
+	}
+
+	subtype, _ := xobj.Dict.GetName("Subtype")
+	switch subtype {
+	case "Image":
+		if rc.imageOffCanvas(state) {
+			return nil
+		}
+		img, err := rc.imageXObject(name, xobj, state)
+		if err != nil {
+			return err
+		}
+		rc.applyMasks(state)
+		interpolate, _ := xobj.Dict.Get("Interpolate").(cos.Boolean)
+		rc.drawImage(img, state, bool(interpolate))
+		return nil
+	case "Form":
+		return rc.drawForm(xobj, state)
+	}
+	return fmt.Errorf("unsupported XObject subtype: %s", subtype)
+}
+
+// imageKey identifies a decoded image XObject.
+type imageKey struct {
+	num    int
+	intent string
+}
+
+// imageXObject decodes an image XObject and applies its SMask. When
+// rendering tiles the result is kept for the page's other tiles, except
+// for stencil masks, whose color comes from the graphics state.
+func (rc *renderContext) imageXObject(name string, xobj *cos.Stream, state *graphics.State) (*image.NRGBA, error) {
+	key := imageKey{num: -1, intent: state.RenderingIntent}
+	if mask, _ := xobj.Dict.Get("ImageMask").(cos.Boolean); rc.images != nil && !mask {
+		if entries, err := rc.reader.ResolveDict(rc.resources.Get("XObject")); err == nil {
+			if ref, ok := entries.Get(name).(*cos.Reference); ok {
+				key.num = ref.ObjectNumber
+			}
+		}
+		if img, ok := rc.images[key]; ok {
+			return img, nil
+		}
+	}
+
+	img, err := rc.decodeImage(xobj, state)
+	if err != nil {
+		return nil, err
+	}
+	if smask := xobj.Dict.Get("SMask"); smask != nil {
+		if masked, err := rc.applyImageSMask(img, smask, state); err != nil {
+			fmt.Printf("Warning: ignoring image soft mask: %v\n", err)
+		} else {
+			img = masked
+		}
+	}
+
+	if key.num >= 0 {
+		rc.images[key] = img
+	}
+	return img, nil
+}
+
+// imageOffCanvas reports whether an image, which fills the unit square of
+// user space, lands outside the canvas, so decoding it can be skipped.
+func (rc *renderContext) imageOffCanvas(state *graphics.State) bool {
+	device := graphics.Matrix{rc.scale, 0, 0, -rc.scale, 0, rc.height * rc.scale}
+	square := graphics.NewPath()
+	square.Rect(0, 0, 1, 1)
+	return rc.offCanvas(square.Transform(state.CTM.Multiply(device)).Bounds(), 1)
+}
+
+// applyImageSMask returns an image with the alpha of img multiplied by its
+// SMask, a grayscale image giving the opacity of each sample. The image and
+// mask may differ in size; both are stretched to the larger of the two, so
+// neither loses detail.
+func (rc *renderContext) applyImageSMask(img *image.NRGBA, obj cos.Object, state *graphics.State) (*image.NRGBA, error) {
+	resolved, err := rc.reader.Resolve(obj)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := resolved.(*cos.Stream)
+	if !ok {
+		return nil, fmt.Errorf("SMask is not a stream")
+	}
+
+	// The mask must be DeviceGray; do not rely on it saying so
+	dict := s.Dict.Clone()
+	dict["ColorSpace"] = cos.Name("DeviceGray")
+	delete(dict, "ImageMask")
+	mask, err := rc.decodeImage(&cos.Stream{Dict: dict, Data: s.Data}, state)
+	if err != nil {
+		return nil, err
+	}
+
+	iw, ih := img.Bounds().Dx(), img.Bounds().Dy()
+	mw, mh := mask.Bounds().Dx(), mask.Bounds().Dy()
+	if iw == 0 || ih == 0 || mw == 0 || mh == 0 {
+		return nil, fmt.Errorf("empty image or soft mask")
+	}
+	w, h := max(iw, mw), max(ih, mh)
+
+	// The mask's gray level becomes a per-pixel alpha
+	alpha := image.NewAlpha(image.Rect(0, 0, w, h))
+	src := img
+	if w != iw || h != ih {
+		src = image.NewNRGBA(alpha.Rect)
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			alpha.Pix[alpha.PixOffset(x, y)] = mask.Pix[mask.PixOffset(x*mw/w, y*mh/h)]
+			if src != img {
+				src.SetNRGBA(x, y, img.NRGBAAt(x*iw/w, y*ih/h))
+			}
+		}
+	}
+
+	masked := image.NewNRGBA(alpha.Rect)
+	draw.DrawMask(masked, masked.Rect, src, image.Point{}, alpha, image.Point{}, draw.Src)
+	return masked, nil
+}
+
+// drawInlineImage draws an image given by BI ... ID ... EI. Keys of the
+// dictionary have been expanded by the content stream parser.
+func (rc *renderContext) drawInlineImage(dict map[string]interface{}, data []byte, state *graphics.State) error {
+	if rc.imageOffCanvas(state) {
+		return nil
+	}
+	imgDict := make(cos.Dict, len(dict))
+	for key, value := range dict {
+		imgDict[cos.Name(key)] = inlineToCOS(value)
+	}
+
+	img, err := rc.decodeImage(&cos.Stream{Dict: imgDict, Data: data}, state)
+	if err != nil {
+		return err
+	}
+	rc.applyMasks(state)
+	interpolate, _ := imgDict.Get("Interpolate").(cos.Boolean)
+	rc.drawImage(img, state, bool(interpolate))
+	return nil
+}
+
+// inlineToCOS converts an inline image operand to a COS object. Names and
+// strings cannot be told apart after parsing, so strings become names unless
+// they only make sense as data, such as an Indexed lookup table.
+func inlineToCOS(v interface{}) cos.Object {
+	switch x := v.(type) {
+	case float64:
+		if x == math.Trunc(x) {
+			return cos.Integer(x)
+		}
+		return cos.Real(x)
+	case bool:
+		return cos.Boolean(x)
+	case string:
+		if full, ok := inlineNames[x]; ok {
+			return cos.Name(full)
+		}
+		return cos.Name(x)
+	case []interface{}:
+		arr := make(cos.Array, len(x))
+		for i, item := range x {
+			arr[i] = inlineToCOS(item)
+		}
+		// The lookup table of [/Indexed base hival lookup] is a string
+		if len(arr) == 4 && arr[0] == cos.Name("Indexed") {
+			if s, ok := x[3].(string); ok {
+				arr[3] = cos.String(s)
+			}
+		}
+		return arr
+	case map[string]interface{}:
+		dict := make(cos.Dict, len(x))
+		for key, value := range x {
+			dict[cos.Name(key)] = inlineToCOS(value)
+		}
+		return dict
+	}
+	return cos.Null{}
+}
+
+// decodeImage decodes an image stream to non-premultiplied RGBA. Stencil
+// masks (ImageMask) are painted with the fill color; other images are
+// converted under the state's rendering intent.
+func (rc *renderContext) decodeImage(s *cos.Stream, state *graphics.State) (*image.NRGBA, error) {
+	width, _ := s.Dict.GetInt("Width")
+	height, _ := s.Dict.GetInt("Height")
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("invalid image size %dx%d", width, height)
+	}
+
+	switch soleFilter(rc.reader, s.Dict.Get("Filter")) {
+	case "DCTDecode":
+		return decodeJPEG(s.Data)
+	case "JPXDecode":
+		return decodeJPX(s.Data)
+	}
+
+	data, err := rc.reader.DecodeStream(s)
+	if err != nil {
+		return nil, err
+	}
+
+	w, h := int(width), int(height)
+	decode := rc.readNumbers(s.Dict.Get("Decode"))
+
+	if mask, _ := s.Dict.Get("ImageMask").(cos.Boolean); mask {
+		return decodeStencil(data, w, h, decode, state.FillColor)
+	}
+
+	bpc, ok := s.Dict.GetInt("BitsPerComponent")
+	if !ok {
+		bpc = 8
+	}
+	switch bpc {
+	case 1, 2, 4, 8, 16:
+	default:
+		return nil, fmt.Errorf("invalid BitsPerComponent: %d", bpc)
+	}
+
+	cs, err := rc.parseImageColorSpace(s.Dict.Get("ColorSpace"), 0)
+	if err != nil {
+		return nil, err
+	}
+	cs.setIntent(state.RenderingIntent)
+
+	return decodeSamples(data, w, h, int(bpc), cs, decode)
+}
+
+// decodeSamples converts packed image samples to colors. Each row starts on
+// a byte boundary.
+func decodeSamples(data []byte, w, h, bpc int, cs *imageColorSpace, decode []float64) (*image.NRGBA, error) {
+	n := cs.components
+	rowBits := w * n * bpc
+	rowBytes := (rowBits + 7) / 8
+	if len(data) < rowBytes*h {
+		// Draw what is present; missing rows stay transparent
+		h = len(data) / rowBytes
+	}
+
+	maxValue := float64(int(1)<<uint(bpc) - 1)
+
+	// Decode maps each sample from [0, maxValue] to a component range
+	dmin := make([]float64, n)
+	dmax := make([]float64, n)
+	for i := 0; i < n; i++ {
+		if len(decode) >= 2*n {
+			dmin[i], dmax[i] = decode[2*i], decode[2*i+1]
+		} else if cs.family == "Indexed" {
+			dmin[i], dmax[i] = 0, maxValue
+		} else if cs.family == "Lab" {
+			// L* spans [0, 100], a* and b* the space's Range
+			dmin[i], dmax[i] = 0, 100
+			if i > 0 {
+				dmin[i], dmax[i] = cs.labRange[2*i-2], cs.labRange[2*i-1]
+			}
+		} else {
+			dmin[i], dmax[i] = 0, 1
+		}
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	comps := make([]float64, n)
+
+	for y := 0; y < h; y++ {
+		row := data[y*rowBytes : (y+1)*rowBytes]
+		bitPos := 0
+		for x := 0; x < w; x++ {
+			for i := 0; i < n; i++ {
+				sample := float64(readSample(row, bitPos, bpc))
+				comps[i] = dmin[i] + sample*(dmax[i]-dmin[i])/maxValue
+				bitPos += bpc
+			}
+			img.SetNRGBA(x, y, cs.toNRGBA(comps))
+		}
+	}
+
+	return img, nil
+}
+
+// decodeStencil decodes a 1-bit image mask. Samples of 0 are painted unless
+// Decode is [1 0].
+func decodeStencil(data []byte, w, h int, decode []float64, fill graphics.Color) (*image.NRGBA, error) {
+	rowBytes := (w + 7) / 8
+	if len(data) < rowBytes*h {
+		h = len(data) / rowBytes
+	}
+
+	paintBit := byte(0)
+	if len(decode) >= 2 && decode[0] > decode[1] {
+		paintBit = 1
+	}
+
+	paint := fill.WithAlpha(1)
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		row := data[y*rowBytes:]
+		for x := 0; x < w; x++ {
+			bit := (row[x/8] >> (7 - uint(x%8))) & 1
+			if bit == paintBit {
+				img.SetNRGBA(x, y, paint)
+			}
+		}
+	}
+	return img, nil
+}
+
+// readSample reads an n-bit sample at the given bit offset.
+func readSample(data []byte, bitPos, n int) uint32 {
+	switch n {
+	case 8:
+		return uint32(data[bitPos/8])
+	case 16:
+		return uint32(data[bitPos/8])<<8 | uint32(data[bitPos/8+1])
+	}
+	b := data[bitPos/8]
+	shift := 8 - n - bitPos%8
+	return uint32(b>>uint(shift)) & (1<<uint(n) - 1)
+}
+
+// decodeJPEG decodes DCTDecode image data.
+func decodeJPEG(data []byte) (*image.NRGBA, error) {
+	src, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode JPEG: %w", err)
+	}
+
+	b := src.Bounds()
+	img := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(img, img.Bounds(), src, b.Min, draw.Src)
+	return img, nil
+}
+
+// decodeJPX decodes JPXDecode image data. The color space and bit depth
+// come from the JPEG2000 data, so the image's ColorSpace entry is ignored.
+func decodeJPX(data []byte) (*image.NRGBA, error) {
+	src, err := stream.DecodeJPX(data)
+	if err != nil {
+		return nil, err
+	}
+
+	b := src.Bounds()
+	img := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(img, img.Bounds(), src, b.Min, draw.Src)
+	return img, nil
+}
+
+// soleFilter returns the filter of a Filter entry naming a single filter,
+// or "" for none or several.
+func soleFilter(reader *cos.Reader, filter cos.Object) cos.Name {
+	resolved, err := reader.Resolve(filter)
+	if err != nil {
+		return ""
+	}
+	switch f := resolved.(type) {
+	case cos.Name:
+		return f
+	case cos.Array:
+		if len(f) == 1 {
+			name, _ := f[0].(cos.Name)
+			return name
+		}
+	}
+	return ""
+}
+
+// parseImageColorSpace reads an image ColorSpace entry, which may be a name,
+// an array or the name of a ColorSpace resource.
+func (rc *renderContext) parseImageColorSpace(obj cos.Object, depth int) (*imageColorSpace, error) {
+	if depth > maxColorSpaceDepth {
+		return nil, fmt.Errorf("color space nested too deeply")
+	}
+
+	resolved, err := rc.reader.Resolve(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cs := resolved.(type) {
+	case cos.Name:
+		switch cs {
+		case "DeviceGray", "CalGray":
+			return &imageColorSpace{family: "DeviceGray", components: 1}, nil
+		case "DeviceRGB", "CalRGB":
+			return &imageColorSpace{family: "DeviceRGB", components: 3}, nil
+		case "DeviceCMYK":
+			return &imageColorSpace{family: "DeviceCMYK", components: 4}, nil
+		}
+		// Named resource
+		named, err := rc.reader.ResolveColorSpace(cs, rc.resources)
+		if err != nil {
+			return nil, err
+		}
+		if name, ok := named.(cos.Name); ok && name == cs {
+			return nil, fmt.Errorf("unsupported color space %s", cs)
+		}
+		return rc.parseImageColorSpace(named, depth+1)
+	case cos.Array:
+		if len(cs) == 0 {
+			return nil, fmt.Errorf("empty color space array")
+		}
+		family, _ := cs[0].(cos.Name)
+		switch family {
+		case "CalGray", "CalRGB", "DeviceGray", "DeviceRGB", "DeviceCMYK":
+			return rc.parseImageColorSpace(family, depth+1)
+		case "Lab":
+			return rc.parseLab(cs), nil
+		case "ICCBased":
+			return rc.parseICCBased(cs, depth)
+		case "Indexed":
+			return rc.parseIndexed(cs, depth)
+		case "Separation", "DeviceN":
+			return rc.parseSeparation(cs, depth)
+		}
+		return nil, fmt.Errorf("unsupported color space %s", family)
+	}
+
+	return nil, fmt.Errorf("invalid color space: %T", resolved)
+}
+
+// parseLab reads [/Lab dict]. The white point defaults to D50 and the a*
+// and b* range to [-100, 100].
+func (rc *renderContext) parseLab(cs cos.Array) *imageColorSpace {
+	space := &imageColorSpace{
+		family:     "Lab",
+		components: 3,
+		whitePoint: WhitePointD50,
+		labRange:   [4]float64{-100, 100, -100, 100},
+	}
+	if len(cs) < 2 {
+		return space
+	}
+	dict, err := rc.reader.ResolveDict(cs[1])
+	if err != nil {
+		return space
+	}
+
+	if wp := rc.readNumbers(dict.Get("WhitePoint")); len(wp) >= 3 && wp[1] > 0 {
+		copy(space.whitePoint[:], wp)
+	}
+	if r := rc.readNumbers(dict.Get("Range")); len(r) >= 4 {
+		copy(space.labRange[:], r)
+	}
+	return space
+}
+
+// setIntent sets the rendering intent for the space and its base.
+func (cs *imageColorSpace) setIntent(intent string) {
+	cs.intent = intent
+	if cs.base != nil {
+		cs.base.setIntent(intent)
+	}
+}
+
+// parseICCBased reads [/ICCBased stream]. The profile header gives the
+// color space and component count; the transforms in the profile are not
+// interpreted. If the profile cannot be read, the alternate space or the N
+// entry is used instead.
+func (rc *renderContext) parseICCBased(cs cos.Array, depth int) (*imageColorSpace, error) {
+	if len(cs) < 2 {
+		return nil, fmt.Errorf("ICCBased color space has no profile")
+	}
+	obj, err := rc.reader.Resolve(cs[1])
+	if err != nil {
+		return nil, err
+	}
+	stream, ok := obj.(*cos.Stream)
+	if !ok {
+		return nil, fmt.Errorf("ICCBased profile is not a stream")
+	}
+
+	if data, err := rc.reader.DecodeStream(stream); err == nil {
+		if profile, err := LoadICCProfile(data); err == nil {
+			space := &imageColorSpace{icc: profile, components: profile.Components}
+			switch profile.ColorSpace {
+			case ICCSpaceGray:
+				space.family = "DeviceGray"
+			case ICCSpaceRGB:
+				space.family = "DeviceRGB"
+			case ICCSpaceCMYK:
+				space.family = "DeviceCMYK"
+			case ICCSpaceLab:
+				// The Lab conversion applies the white point itself
+				space = &imageColorSpace{
+					family:     "Lab",
+					components: 3,
+					whitePoint: profile.WhitePoint,
+					labRange:   [4]float64{-128, 127, -128, 127},
+				}
+				if r := rc.readNumbers(stream.Dict.Get("Range")); len(r) >= 6 {
+					copy(space.labRange[:], r[2:6])
+				}
+			}
+			return space, nil
+		}
+	}
+
+	if alt := stream.Dict.Get("Alternate"); alt != nil {
+		if space, err := rc.parseImageColorSpace(alt, depth+1); err == nil {
+			return space, nil
+		}
+	}
+
+	n, _ := stream.Dict.GetInt("N")
+	switch n {
+	case 1:
+		return &imageColorSpace{family: "DeviceGray", components: 1}, nil
+	case 3:
+		return &imageColorSpace{family: "DeviceRGB", components: 3}, nil
+	case 4:
+		return &imageColorSpace{family: "DeviceCMYK", components: 4}, nil
+	}
+	return nil, fmt.Errorf("invalid ICCBased component count: %d", n)
+}
+
+// parseIndexed reads [/Indexed base hival lookup]. The lookup table holds
+// (hival+1) colors of the base space, one byte per component.
+func (rc *renderContext) parseIndexed(cs cos.Array, depth int) (*imageColorSpace, error) {
+	if len(cs) < 4 {
+		return nil, fmt.Errorf("Indexed color space needs 4 entries")
+	}
+
+	base, err := rc.parseImageColorSpace(cs[1], depth+1)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Indexed base: %w", err)
+	}
+	if base.family == "Indexed" {
+		return nil, fmt.Errorf("Indexed base cannot be Indexed")
+	}
+
+	hival := int(toFloat(cs[2]))
+	if hival < 0 || hival > 255 {
+		return nil, fmt.Errorf("invalid Indexed hival: %d", hival)
+	}
+
+	lookup, err := rc.reader.Resolve(cs[3])
+	if err != nil {
+		return nil, err
+	}
+
+	var palette []byte
+	switch l := lookup.(type) {
+	case cos.String:
+		palette = []byte(l)
+	case *cos.Stream:
+		palette, err = rc.reader.DecodeStream(l)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode Indexed lookup: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("invalid Indexed lookup: %T", lookup)
+	}
+
+	// Pad short tables so every index in range has a color
+	need := (hival + 1) * base.components
+	if len(palette) < need {
+		palette = append(palette, make([]byte, need-len(palette))...)
+	}
+
+	return &imageColorSpace{
+		family:     "Indexed",
+		components: 1,
+		base:       base,
+		hival:      hival,
+		palette:    palette,
+	}, nil
+}
+
+// parseSeparation reads [/Separation name alternate tintTransform] or
+// [/DeviceN names alternate tintTransform attributes]. Colorants are always
+// shown through the alternate space.
+func (rc *renderContext) parseSeparation(cs cos.Array, depth int) (*imageColorSpace, error) {
+	family := string(cs[0].(cos.Name))
+	if len(cs) < 4 {
+		return nil, fmt.Errorf("%s color space needs 4 entries", family)
+	}
+
+	components := 1
+	if family == "DeviceN" {
+		names, err := rc.reader.ResolveArray(cs[1])
+		if err != nil || len(names) == 0 {
+			return nil, fmt.Errorf("DeviceN color space has no colorants")
+		}
+		components = len(names)
+	}
+
+	base, err := rc.parseImageColorSpace(cs[2], depth+1)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s alternate: %w", family, err)
+	}
+
+	tint, err := pdffunction.Parse(rc.reader, cs[3])
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s tint transform: %w", family, err)
+	}
+
+	return &imageColorSpace{
+		family:     family,
+		components: components,
+		base:       base,
+		tint:       tint,
+	}, nil
+}
+
+// toNRGBA converts decoded components to an opaque color.
+func (cs *imageColorSpace) toNRGBA(comps []float64) color.NRGBA {
+	return cs.ToColor(comps).WithAlpha(1)
+}
+
+// ToColor converts components to a device color. It lets the interpreter
+// set colors in named color spaces (sc and scn operators).
+func (cs *imageColorSpace) ToColor(comps []float64) graphics.Color {
+	if len(comps) < cs.components {
+		padded := make([]float64, cs.components)
+		copy(padded, comps)
+		comps = padded
+	}
+
+	if cs.icc != nil {
+		device := *cs
+		device.icc = nil
+		return cs.icc.ToColor(device.ToColor(comps), cs.intent)
+	}
+
+	switch cs.family {
+	case "Separation", "DeviceN":
+		out, err := cs.tint.Evaluate(comps)
+		if err != nil {
+			return graphics.Black()
+		}
+		return cs.base.ToColor(out)
+	case "Indexed":
+		index := int(comps[0] + 0.5)
+		if index < 0 {
+			index = 0
+		}
+		if index > cs.hival {
+			index = cs.hival
+		}
+
+		n := cs.base.components
+		entry := cs.palette[index*n : (index+1)*n]
+		baseComps := make([]float64, n)
+		for i, b := range entry {
+			baseComps[i] = float64(b) / 255
+		}
+		if cs.base.family == "Lab" {
+			// Lab lookup bytes span the component ranges
+			r := cs.base.labRange
+			baseComps[0] *= 100
+			baseComps[1] = r[0] + baseComps[1]*(r[1]-r[0])
+			baseComps[2] = r[2] + baseComps[2]*(r[3]-r[2])
+		}
+		return cs.base.ToColor(baseComps)
+	case "DeviceGray":
+		return graphics.NewGray(comps[0])
+	case "DeviceCMYK":
+		return graphics.NewCMYK(comps[0], comps[1], comps[2], comps[3])
+	case "Lab":
+		cc := ColorConverter{Intent: cs.intent}
+		r, g, b := cc.LabToRGB(comps[0], comps[1], comps[2], cs.whitePoint)
+		return graphics.NewRGB(r, g, b)
+	}
+	return graphics.NewRGB(comps[0], comps[1], comps[2])
+}
+
+// drawImage draws an image into the unit square of user space, which the CTM
+// maps onto the page. Each device pixel samples the nearest image pixel,
+// unless the image asks for interpolation or is drawn smaller than its
+// natural size, when the four nearest pixels are blended.
+func (rc *renderContext) drawImage(img *image.NRGBA, state *graphics.State, interpolate bool) {
+	device := graphics.Matrix{rc.scale, 0, 0, -rc.scale, 0, rc.height * rc.scale}
+	toDevice := state.CTM.Multiply(device)
+	if toDevice.Determinant() == 0 {
+		return
+	}
+	inverse := toDevice.Inverse()
+
+	// Device bounds of the unit square
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, corner := range [][2]float64{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+		x, y := toDevice.Transform(corner[0], corner[1])
+		minX, minY = math.Min(minX, x), math.Min(minY, y)
+		maxX, maxY = math.Max(maxX, x), math.Max(maxY, y)
+	}
+	area := image.Rect(
+		int(math.Floor(minX)), int(math.Floor(minY)),
+		int(math.Ceil(maxX)), int(math.Ceil(maxY)),
+	).Intersect(rc.canvas.Image().Bounds())
+	if area.Empty() {
+		return
+	}
+
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	alpha := clamp(state.FillAlpha, 0, 1)
+
+	// Device lengths of the image's edges
+	if math.Hypot(toDevice[0], toDevice[1]) < float64(w) ||
+		math.Hypot(toDevice[2], toDevice[3]) < float64(h) {
+		interpolate = true
+	}
+
+	layer := image.NewNRGBA(area)
+	for py := area.Min.Y; py < area.Max.Y; py++ {
+		for px := area.Min.X; px < area.Max.X; px++ {
+			u, v := inverse.Transform(float64(px)+0.5, float64(py)+0.5)
+			if u < 0 || u >= 1 || v < 0 || v >= 1 {
+				continue
+			}
+
+			// Image row 0 is at the top of the unit square
+			var c color.NRGBA
+			if interpolate {
+				sample := bilinearAt(img, u*float64(w)-0.5, (1-v)*float64(h)-0.5)
+				c = color.NRGBAModel.Convert(sample).(color.NRGBA)
+			} else {
+				c = img.NRGBAAt(int(u*float64(w)), int((1-v)*float64(h)))
+			}
+			c.A = uint8(float64(c.A) * alpha)
+			layer.SetNRGBA(px, py, c)
+		}
+	}
+
+	rc.canvas.DrawLayer(layer)
+}
+
+// drawForm draws a form XObject with the graphics state in effect at Do,
+// clipped to the form's BBox.
+func (rc *renderContext) drawForm(form *cos.Stream, state *graphics.State) error {
+	if rc.depth >= maxFormDepth {
+		return fmt.Errorf("forms nested too deeply")
+	}
+
+	initial := state.Clone()
+	initial.CTM = streamMatrix(form).Multiply(state.CTM)
+
+	if bbox := rc.readNumbers(form.Dict.Get("BBox")); len(bbox) >= 4 {
+		clip := graphics.NewPath()
+		clip.Rect(bbox[0], bbox[1], bbox[2]-bbox[0], bbox[3]-bbox[1])
+		clip = clip.Transform(initial.CTM)
+		if initial.ClipPath != nil {
+			clip = graphics.PathIntersection(initial.ClipPath, clip)
+		}
+		initial.ClipPath = clip
+	}
+
+	child := &renderContext{
+		reader:     rc.reader,
+		canvas:     rc.canvas,
+		height:     rc.height,
+		scale:      rc.scale,
+		originX:    rc.originX,
+		originY:    rc.originY,
+		images:     rc.images,
+		glyphCache: rc.glyphCache,
+		depth:      rc.depth + 1,
+		stats:      rc.stats,
+	}
+	if !rc.isIsolatedGroup(form) {
+		return child.executeContent(form, initial)
+	}
+
+	// An isolated group is drawn on its own layer and composited as a
+	// unit, so its alpha, blend mode and soft mask apply to the whole
+	// group rather than to each object in it
+	initial.FillAlpha = 1
+	initial.StrokeAlpha = 1
+	initial.BlendMode = graphics.BlendNormal
+	initial.SoftMask = nil
+
+	child.canvas = rc.canvas.PushLayer()
+	err := child.executeContent(form, initial)
+	rc.applyMasks(state)
+	rc.canvas.PopLayer(state.BlendMode, state.FillAlpha)
+	return err
+}
+
+// isIsolatedGroup reports whether a form is an isolated transparency group.
+func (rc *renderContext) isIsolatedGroup(form *cos.Stream) bool {
+	group, err := rc.reader.ResolveDict(form.Dict.Get("Group"))
+	if err != nil {
+		return false
+	}
+	if s, _ := group.GetName("S"); s != "Transparency" {
+		return false
+	}
+	isolated, _ := rc.reader.Resolve(group.Get("I"))
+	return isolated == cos.Boolean(true)
+}
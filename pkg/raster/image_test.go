@@ -0,0 +1,89 @@
+package raster
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	"gumgum/pkg/cos"
+)
+
+// renderContent renders a 200x100 point page showing content, at 72 DPI so
+// pixels are points.
+func renderContent(t *testing.T, content string) *image.RGBA {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := cos.NewWriter(&buf, "1.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents, err := w.WriteStream(cos.Dict{}, []byte(content), cos.StreamOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pagesRef := w.Reserve()
+	page, err := w.AddObject(cos.Dict{
+		"Type":     cos.Name("Page"),
+		"Parent":   pagesRef,
+		"MediaBox": cos.Array{cos.Integer(0), cos.Integer(0), cos.Integer(200), cos.Integer(100)},
+		"Contents": contents,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteObject(pagesRef.ObjectNumber, cos.Dict{
+		"Type":  cos.Name("Pages"),
+		"Kids":  cos.Array{page},
+		"Count": cos.Integer(1),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	catalog, err := w.AddObject(cos.Dict{"Type": cos.Name("Catalog"), "Pages": pagesRef})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(cos.Dict{"Root": catalog}); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := cos.NewReader(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRenderer(reader)
+	r.SetDPI(72)
+	img, err := r.RenderPage(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+func TestImageInterpolation(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		at      image.Point
+		want    uint8
+	}{
+		// A black and a white sample stretched across the page meet in
+		// the middle
+		{"nearest", "200 0 0 100 0 0 cm BI /W 2 /H 1 /CS /G /BPC 8 /F /AHx ID 00FF> EI", image.Pt(100, 50), 255},
+		{"interpolated", "200 0 0 100 0 0 cm BI /W 2 /H 1 /CS /G /BPC 8 /I true /F /AHx ID 00FF> EI", image.Pt(100, 50), 129},
+		{"interpolated edge", "200 0 0 100 0 0 cm BI /W 2 /H 1 /CS /G /BPC 8 /I true /F /AHx ID 00FF> EI", image.Pt(10, 50), 0},
+
+		// Four samples drawn into two pixels are blended in pairs rather
+		// than every other one being dropped
+		{"downscaled", "2 0 0 1 0 0 cm BI /W 4 /H 1 /CS /G /BPC 8 /F /AHx ID 00FF00FF> EI", image.Pt(0, 99), 128},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := renderContent(t, tt.content)
+			c := img.RGBAAt(tt.at.X, tt.at.Y)
+			if c.R != tt.want || c.G != tt.want || c.B != tt.want {
+				t.Errorf("pixel %v = %v, want gray %d", tt.at, c, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package raster
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"gumgum/pkg/graphics"
+)
+
+// PushLayer starts a transparency group: it returns a new, fully
+// transparent canvas of the same size that drawing of the group goes to.
+// PopLayer composites it back onto c.
+func (c *Canvas) PushLayer() *Canvas {
+	layer := &Canvas{
+		img:        image.NewRGBA(c.img.Bounds()),
+		width:      c.width,
+		height:     c.height,
+		dpi:        c.dpi,
+		background: color.Transparent,
+	}
+	c.layers = append(c.layers, layer)
+	return layer
+}
+
+// PopLayer composites the most recently pushed layer onto the canvas as a
+// unit, with the given blend mode and constant alpha. The canvas soft mask
+// and clip apply to the layer as a whole. It does nothing if no layer is
+// pushed.
+func (c *Canvas) PopLayer(blendMode graphics.BlendMode, alpha float64) {
+	if len(c.layers) == 0 {
+		return
+	}
+	layer := c.layers[len(c.layers)-1]
+	c.layers = c.layers[:len(c.layers)-1]
+
+	// Coverage of the layer: constant alpha times soft mask and clip
+	bounds := c.img.Bounds()
+	coverage := image.NewAlpha(bounds)
+	a := uint8(clamp(alpha, 0, 1)*255 + 0.5)
+	for i := range coverage.Pix {
+		coverage.Pix[i] = a
+	}
+	c.applyMasks(coverage)
+
+	if blendMode == "" || blendMode == graphics.BlendNormal {
+		draw.DrawMask(c.img, bounds, layer.img, image.Point{}, coverage, image.Point{}, draw.Over)
+		return
+	}
+	c.blendLayer(layer.img, coverage, blendMode)
+}
+
+// blendLayer composites a layer with a separable blend mode. Each backdrop
+// color Cb and source color Cs combine as
+//
+//	(1 - ab) * Cs + ab * B(Cb, Cs)
+//
+// which is then composited over the backdrop with the source alpha.
+func (c *Canvas) blendLayer(layer *image.RGBA, coverage *image.Alpha, mode graphics.BlendMode) {
+	for i := 0; i+3 < len(layer.Pix); i += 4 {
+		m := float64(coverage.Pix[i/4]) / 255
+		as := float64(layer.Pix[i+3]) / 255 * m
+		if as == 0 {
+			continue
+		}
+		ab := float64(c.img.Pix[i+3]) / 255
+		ar := as + ab - as*ab
+
+		for k := 0; k < 3; k++ {
+			// Unpremultiply both colors
+			cs := float64(layer.Pix[i+k]) / float64(layer.Pix[i+3])
+			var cb float64
+			if c.img.Pix[i+3] != 0 {
+				cb = float64(c.img.Pix[i+k]) / float64(c.img.Pix[i+3])
+			}
+
+			mixed := (1-ab)*cs + ab*blendChannel(mode, cb, cs)
+			cr := (1-as/ar)*cb + as/ar*mixed
+			c.img.Pix[i+k] = uint8(clamp(cr*ar, 0, 1)*255 + 0.5)
+		}
+		c.img.Pix[i+3] = uint8(clamp(ar, 0, 1)*255 + 0.5)
+	}
+}
+
+// blendChannel applies a separable blend mode to one color channel of the
+// backdrop b and source s. Non-separable modes blend as Normal.
+func blendChannel(mode graphics.BlendMode, b, s float64) float64 {
+	switch mode {
+	case graphics.BlendMultiply:
+		return b * s
+	case graphics.BlendScreen:
+		return b + s - b*s
+	case graphics.BlendOverlay:
+		return blendChannel(graphics.BlendHardLight, s, b)
+	case graphics.BlendDarken:
+		return math.Min(b, s)
+	case graphics.BlendLighten:
+		return math.Max(b, s)
+	case graphics.BlendColorDodge:
+		if b == 0 {
+			return 0
+		}
+		if s >= 1 {
+			return 1
+		}
+		return math.Min(1, b/(1-s))
+	case graphics.BlendColorBurn:
+		if b >= 1 {
+			return 1
+		}
+		if s <= 0 {
+			return 0
+		}
+		return 1 - math.Min(1, (1-b)/s)
+	case graphics.BlendHardLight:
+		if s <= 0.5 {
+			return b * 2 * s
+		}
+		return blendChannel(graphics.BlendScreen, b, 2*s-1)
+	case graphics.BlendSoftLight:
+		if s <= 0.5 {
+			return b - (1-2*s)*b*(1-b)
+		}
+		var d float64
+		if b <= 0.25 {
+			d = ((16*b-12)*b + 4) * b
+		} else {
+			d = math.Sqrt(b)
+		}
+		return b + (2*s-1)*(d-b)
+	case graphics.BlendDifference:
+		return math.Abs(b - s)
+	case graphics.BlendExclusion:
+		return b + s - 2*b*s
+	}
+	return s
+}
@@ -0,0 +1,69 @@
+package raster
+
+import (
+	"container/list"
+
+	"gumgum/pkg/graphics"
+)
+
+// DefaultMaxCachedOperators is the default number of parsed operators a
+// Renderer keeps for content streams shared between pages.
+const DefaultMaxCachedOperators = 1 << 18
+
+// opsEntry is an element of the LRU list.
+type opsEntry struct {
+	objNum int
+	ops    []graphics.Operator
+}
+
+// opsCache is an LRU cache of parsed content streams keyed by object
+// number, holding at most budget operators in all.
+type opsCache struct {
+	budget int
+	size   int        // Operators held
+	order  *list.List // Front is most recently used
+	items  map[int]*list.Element
+}
+
+// newOpsCache creates a cache holding at most budget operators.
+func newOpsCache(budget int) *opsCache {
+	return &opsCache{
+		budget: budget,
+		order:  list.New(),
+		items:  make(map[int]*list.Element),
+	}
+}
+
+// get returns the parsed operators of a stream and marks them as recently
+// used.
+func (c *opsCache) get(objNum int) ([]graphics.Operator, bool) {
+	elem, ok := c.items[objNum]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*opsEntry).ops, true
+}
+
+// put adds the parsed operators of a stream, evicting the least recently
+// used streams until the cache is within its budget. Streams larger than
+// the whole budget are not kept.
+func (c *opsCache) put(objNum int, ops []graphics.Operator) {
+	if len(ops) > c.budget {
+		return
+	}
+	if elem, ok := c.items[objNum]; ok {
+		c.size -= len(elem.Value.(*opsEntry).ops)
+		c.order.Remove(elem)
+	}
+
+	c.items[objNum] = c.order.PushFront(&opsEntry{objNum: objNum, ops: ops})
+	c.size += len(ops)
+
+	for c.size > c.budget {
+		oldest := c.order.Back()
+		entry := c.order.Remove(oldest).(*opsEntry)
+		delete(c.items, entry.objNum)
+		c.size -= len(entry.ops)
+	}
+}
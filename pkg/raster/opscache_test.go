@@ -0,0 +1,47 @@
+package raster
+
+import (
+	"testing"
+
+	"gumgum/pkg/graphics"
+)
+
+func TestOpsCacheBudget(t *testing.T) {
+	c := newOpsCache(10)
+	ops := func(n int) []graphics.Operator { return make([]graphics.Operator, n) }
+
+	c.put(1, ops(4))
+	c.put(2, ops(4))
+	if _, ok := c.get(1); !ok {
+		t.Fatal("stream 1 missing")
+	}
+
+	// Stream 2 is the least recently used, so it makes room for stream 3
+	c.put(3, ops(4))
+	if _, ok := c.get(2); ok {
+		t.Error("stream 2 kept over budget")
+	}
+	for _, objNum := range []int{1, 3} {
+		if _, ok := c.get(objNum); !ok {
+			t.Errorf("stream %d evicted", objNum)
+		}
+	}
+	if c.size != 8 {
+		t.Errorf("size = %d, want 8", c.size)
+	}
+
+	// Replacing a stream counts only its new operators
+	c.put(3, ops(6))
+	if c.size != 10 || c.order.Len() != 2 {
+		t.Errorf("after replacing stream 3: size = %d with %d streams, want 10 with 2", c.size, c.order.Len())
+	}
+
+	// A stream larger than the budget is not cached and evicts nothing
+	c.put(4, ops(11))
+	if _, ok := c.get(4); ok {
+		t.Error("stream larger than the budget cached")
+	}
+	if c.order.Len() != 2 {
+		t.Errorf("%d streams cached, want 2", c.order.Len())
+	}
+}
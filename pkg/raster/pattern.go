@@ -0,0 +1,186 @@
+package raster
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"gumgum/pkg/cos"
+	"gumgum/pkg/graphics"
+)
+
+// maxTileSize limits the pixel size of a rendered pattern cell.
+const maxTileSize = 2048
+
+// patternTile is one rendered cell of a tiling pattern.
+type patternTile struct {
+	img      *image.RGBA
+	colored  bool            // PaintType 1; uncolored cells only supply coverage
+	toCell   graphics.Matrix // Device pixels -> pattern space
+	originX  float64         // Cell origin in pattern space (BBox corner)
+	originY  float64
+	xStep    float64
+	yStep    float64
+	pixScale float64 // Cell pixels per pattern space unit
+}
+
+// fillPattern fills a device-space path with a named pattern. It returns
+// false if the pattern cannot be drawn, so the caller can fall back to a
+// plain color.
+func (rc *renderContext) fillPattern(name string, path *graphics.Path, rule graphics.FillRule, col graphics.Color, alpha float64) bool {
+	tile, ok := rc.tiles[name]
+	if !ok {
+		obj, err := rc.lookupResourceObject("Pattern", name)
+		if err == nil {
+			tile, err = rc.renderTilingPattern(obj)
+		}
+		if err != nil {
+			fmt.Printf("Warning: pattern %s: %v\n", name, err)
+		}
+
+		// Failed patterns are cached too, so the warning is shown once
+		if rc.tiles == nil {
+			rc.tiles = make(map[string]*patternTile)
+		}
+		rc.tiles[name] = tile
+	}
+	if tile == nil {
+		return false
+	}
+
+	bounds := rc.canvas.Image().Bounds()
+	pb := path.Bounds()
+	area := image.Rect(
+		int(math.Floor(pb.X)), int(math.Floor(pb.Y)),
+		int(math.Ceil(pb.X+pb.Width)), int(math.Ceil(pb.Y+pb.Height)),
+	).Intersect(bounds)
+
+	paint := col.WithAlpha(alpha)
+	tw, th := tile.img.Bounds().Dx(), tile.img.Bounds().Dy()
+
+	layer := image.NewNRGBA(bounds)
+	for py := area.Min.Y; py < area.Max.Y; py++ {
+		for px := area.Min.X; px < area.Max.X; px++ {
+			u, v := tile.toCell.Transform(float64(px)+0.5, float64(py)+0.5)
+			u = wrap(u-tile.originX, tile.xStep)
+			v = wrap(v-tile.originY, tile.yStep)
+
+			// Cell rows run top to bottom, pattern space bottom to top
+			tx := clampInt(int(u*tile.pixScale), 0, tw-1)
+			ty := clampInt(int((tile.yStep-v)*tile.pixScale), 0, th-1)
+
+			src := tile.img.RGBAAt(tx, ty)
+			if src.A == 0 {
+				continue
+			}
+
+			if tile.colored {
+				// Un-premultiply the cell color
+				a := float64(src.A)
+				layer.SetNRGBA(px, py, color.NRGBA{
+					R: uint8(float64(src.R) * 255 / a),
+					G: uint8(float64(src.G) * 255 / a),
+					B: uint8(float64(src.B) * 255 / a),
+					A: uint8(a * alpha),
+				})
+			} else {
+				layer.SetNRGBA(px, py, color.NRGBA{
+					R: paint.R,
+					G: paint.G,
+					B: paint.B,
+					A: uint8(uint16(src.A) * uint16(paint.A) / 255),
+				})
+			}
+		}
+	}
+
+	rc.canvas.FillWith(path, layer, rule)
+	return true
+}
+
+// renderTilingPattern draws one cell of a Type 1 pattern to an image.
+func (rc *renderContext) renderTilingPattern(obj cos.Object) (*patternTile, error) {
+	pattern, ok := obj.(*cos.Stream)
+	if !ok {
+		return nil, fmt.Errorf("unsupported pattern object: %T", obj)
+	}
+
+	if patternType, _ := pattern.Dict.GetInt("PatternType"); patternType != 1 {
+		return nil, fmt.Errorf("unsupported pattern type: %d", patternType)
+	}
+
+	bbox := rc.readNumbers(pattern.Dict.Get("BBox"))
+	if len(bbox) < 4 {
+		return nil, fmt.Errorf("tiling pattern has no BBox")
+	}
+
+	tile := &patternTile{
+		originX: math.Min(bbox[0], bbox[2]),
+		originY: math.Min(bbox[1], bbox[3]),
+	}
+
+	if v, ok := pattern.Dict.GetReal("XStep"); ok {
+		tile.xStep = math.Abs(v)
+	}
+	if v, ok := pattern.Dict.GetReal("YStep"); ok {
+		tile.yStep = math.Abs(v)
+	}
+	if tile.xStep == 0 || tile.yStep == 0 {
+		return nil, fmt.Errorf("tiling pattern has zero step")
+	}
+
+	paintType, _ := pattern.Dict.GetInt("PaintType")
+	tile.colored = paintType != 2
+
+	// The pattern matrix maps pattern space to the page's default space
+	device := graphics.Matrix{rc.scale, 0, 0, -rc.scale, 0, rc.height * rc.scale}
+	toDevice := streamMatrix(pattern).Multiply(graphics.Translate(-rc.originX, -rc.originY)).Multiply(device)
+	det := toDevice.Determinant()
+	if det == 0 {
+		return nil, fmt.Errorf("pattern matrix is not invertible")
+	}
+	tile.toCell = toDevice.Inverse()
+
+	// Render the cell at the pattern's average device resolution
+	tile.pixScale = math.Sqrt(math.Abs(det))
+	tile.pixScale = math.Min(tile.pixScale, maxTileSize/math.Max(tile.xStep, tile.yStep))
+	tw := clampInt(int(math.Ceil(tile.xStep*tile.pixScale)), 1, maxTileSize)
+	th := clampInt(int(math.Ceil(tile.yStep*tile.pixScale)), 1, maxTileSize)
+
+	cell := NewCanvas(tw, th)
+	cell.SetBackground(color.Transparent)
+	cell.Clear()
+
+	crc := &renderContext{
+		reader:     rc.reader,
+		canvas:     cell,
+		height:     tile.yStep,
+		scale:      tile.pixScale,
+		stats:      rc.stats,
+		glyphCache: rc.glyphCache,
+	}
+	initial := graphics.NewState()
+	initial.CTM = graphics.Translate(-tile.originX, -tile.originY)
+	if err := crc.executeContent(pattern, initial); err != nil {
+		return nil, err
+	}
+
+	tile.img = cell.Image()
+	return tile, nil
+}
+
+// wrap reduces x to [0, step).
+func wrap(x, step float64) float64 {
+	return x - step*math.Floor(x/step)
+}
+
+func clampInt(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
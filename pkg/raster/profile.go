@@ -0,0 +1,68 @@
+package raster
+
+import (
+	"time"
+
+	"gumgum/pkg/graphics"
+)
+
+// RenderStats counts the work done rendering a page and the time spent in
+// each stage. Forms, patterns, soft masks and Type 3 glyphs are included.
+// The stages can overlap: the time to draw Type 3 text includes parsing and
+// filling the glyph procedures.
+type RenderStats struct {
+	Operators    int // Content stream operators executed
+	PathSegments int // Segments of filled and stroked paths
+	Glyphs       int // Glyphs drawn, including invisible text
+
+	ParseContent   time.Duration // Parsing content streams
+	RasterizePaths time.Duration // Filling and stroking paths
+	RasterizeText  time.Duration // Drawing text
+}
+
+// SetProfiling enables collecting RenderStats for each rendered page. It
+// is off by default, since timing every operator slows rendering.
+func (r *Renderer) SetProfiling(enabled bool) {
+	r.profiling = enabled
+}
+
+// Stats returns the statistics of the last page rendered with profiling
+// enabled.
+func (r *Renderer) Stats() RenderStats {
+	return r.stats
+}
+
+// runContent parses and executes a content stream. With profiling enabled
+// it counts operators and attributes the time not spent executing them to
+// parsing.
+func (rc *renderContext) runContent(interp *graphics.Interpreter, data []byte) error {
+	if rc.stats == nil {
+		return interp.ExecuteStream(data)
+	}
+
+	var executing time.Duration
+	interp.OnOperator = func(op graphics.Operator, d time.Duration) {
+		rc.stats.Operators++
+		executing += d
+	}
+	start := time.Now()
+	err := interp.ExecuteStream(data)
+	rc.stats.ParseContent += time.Since(start) - executing
+	return err
+}
+
+// runOps executes already parsed operators, counting them when profiling.
+func (rc *renderContext) runOps(interp *graphics.Interpreter, ops []graphics.Operator) error {
+	if rc.stats != nil {
+		rc.stats.Operators += len(ops)
+	}
+	return interp.Execute(ops)
+}
+
+// timePath records a path filled or stroked since start.
+func (rc *renderContext) timePath(path *graphics.Path, start time.Time) {
+	if rc.stats != nil {
+		rc.stats.PathSegments += len(path.Segments)
+		rc.stats.RasterizePaths += time.Since(start)
+	}
+}
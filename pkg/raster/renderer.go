@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"image"
 	"image/png"
+	"io"
+	"math"
 	"os"
+	"time"
 
 	"gumgum/pkg/cos"
 	"gumgum/pkg/graphics"
@@ -14,13 +17,32 @@ import (
 type Renderer struct {
 	reader *cos.Reader
 	dpi    float64
+
+	profiling bool        // Collect stats while rendering
+	stats     RenderStats // Stats of the last page rendered with profiling
+
+	// Content streams are parsed once they are seen on a second page;
+	// generated documents often share one template stream across pages
+	seenContents map[int]bool
+	opsCache     *opsCache
+
+	// Bitmaps of small glyphs, shared by all pages
+	glyphs *GlyphCache
+
+	// Images decoded while rendering tiles of tilePage, so the page's
+	// other tiles do not decode them again
+	tilePage   int
+	tileImages map[imageKey]*image.NRGBA
 }
 
 // NewRenderer creates a new renderer for a PDF reader.
 func NewRenderer(reader *cos.Reader) *Renderer {
 	return &Renderer{
-		reader: reader,
-		dpi:    150, // Default DPI
+		reader:       reader,
+		dpi:          150, // Default DPI
+		seenContents: make(map[int]bool),
+		opsCache:     newOpsCache(DefaultMaxCachedOperators),
+		glyphs:       NewGlyphCache(DefaultMaxCachedGlyphs),
 	}
 }
 
@@ -31,6 +53,20 @@ func (r *Renderer) SetDPI(dpi float64) {
 
 // RenderPage renders a page to an image.
 func (r *Renderer) RenderPage(pageNum int) (*image.RGBA, error) {
+	return r.renderPage(pageNum, nil)
+}
+
+// RenderTile renders part of a page: tile is in points from the top-left
+// corner of the page, so the image is the region of pixels tile.Min*dpi/72
+// to tile.Max*dpi/72 of the full rendering. The tile is clipped to the
+// page. Only the tile's pixels are allocated, so large pages can be drawn
+// in pieces.
+func (r *Renderer) RenderTile(pageNum int, tile image.Rectangle) (*image.RGBA, error) {
+	return r.renderPage(pageNum, &tile)
+}
+
+// renderPage renders a page, or the tile of it if tile is not nil.
+func (r *Renderer) renderPage(pageNum int, tile *image.Rectangle) (*image.RGBA, error) {
 	// Get page
 	page, err := r.reader.GetPage(pageNum)
 	if err != nil {
@@ -39,6 +75,7 @@ func (r *Renderer) RenderPage(pageNum int) (*image.RGBA, error) {
 
 	// Get page dimensions from MediaBox
 	var width, height float64 = 612, 792 // Default to US Letter
+	var boxX, boxY float64               // Lower-left corner of the MediaBox
 
 	if mediaBox, ok := page.GetArray("MediaBox"); ok && len(mediaBox) >= 4 {
 		x1 := toFloat(mediaBox[0])
@@ -47,70 +84,373 @@ func (r *Renderer) RenderPage(pageNum int) (*image.RGBA, error) {
 		y2 := toFloat(mediaBox[3])
 		width = x2 - x1
 		height = y2 - y1
+		boxX, boxY = x1, y1
+	}
+
+	// UserUnit sets the size of a unit of page space in points
+	userUnit := PageUserUnit(page)
+
+	// The canvas is drawn by moving its bottom-left corner, the MediaBox
+	// corner or that of the tile, to the origin of default page space,
+	// like a pattern cell
+	canvasWidth, canvasHeight := width*userUnit, height*userUnit
+	originX, originY := boxX, boxY
+	if tile != nil {
+		t := tile.Canon().Intersect(image.Rect(0, 0, int(math.Ceil(canvasWidth)), int(math.Ceil(canvasHeight))))
+		if t.Empty() {
+			return nil, fmt.Errorf("tile %v is outside the page", *tile)
+		}
+		canvasWidth, canvasHeight = float64(t.Dx()), float64(t.Dy())
+		originX += float64(t.Min.X) / userUnit
+		originY += height - float64(t.Max.Y)/userUnit
 	}
 
 	// Create canvas
-	canvas := NewCanvasWithDPI(width, height, r.dpi)
+	canvas := NewCanvasWithDPI(canvasWidth, canvasHeight, r.dpi)
 	canvas.Clear()
 
-	// Get page contents
-	contents, err := r.reader.GetPageContents(page)
+	// Get page contents, unless they are parsed already
+	var contents []byte
+	ops, shared := r.cachedOps(page)
+	if ops == nil {
+		contents, err = r.reader.GetPageContents(page)
+		if err != nil {
+			return canvas.Image(), fmt.Errorf("failed to get page contents: %w", err)
+		}
+		if len(contents) == 0 {
+			return canvas.Image(), nil
+		}
+	}
+
+	resources, err := r.reader.GetPageResources(page)
 	if err != nil {
-		return canvas.Image(), fmt.Errorf("failed to get page contents: %w", err)
+		return canvas.Image(), fmt.Errorf("failed to get page resources: %w", err)
 	}
 
-	if len(contents) == 0 {
-		return canvas.Image(), nil
+	rc := &renderContext{
+		reader:     r.reader,
+		canvas:     canvas,
+		height:     canvasHeight / userUnit,
+		scale:      r.dpi / 72.0 * userUnit,
+		originX:    originX,
+		originY:    originY,
+		glyphCache: r.glyphs,
+	}
+	if r.profiling {
+		r.stats = RenderStats{}
+		rc.stats = &r.stats
+	}
+	if tile == nil {
+		r.tileImages = nil
+	} else {
+		if r.tileImages == nil || r.tilePage != pageNum {
+			r.tileImages = make(map[imageKey]*image.NRGBA)
+			r.tilePage = pageNum
+		}
+		rc.images = r.tileImages
+	}
+	interp := rc.newInterpreter(resources)
+	if originX != 0 || originY != 0 {
+		initial := graphics.NewState()
+		initial.CTM = graphics.Translate(-originX, -originY)
+		interp.SetState(initial)
 	}
 
-	// Parse content stream
-	ops, err := graphics.ParseContentStream(contents)
+	// A stream shared with another page is parsed once and kept; others
+	// are executed as they are read
+	if ops == nil && shared >= 0 {
+		if parsed, err := graphics.ParseContentStream(contents); err == nil {
+			r.opsCache.put(shared, parsed)
+			ops = parsed
+		}
+	}
+	if ops != nil {
+		err = rc.runOps(interp, ops)
+	} else {
+		err = rc.runContent(interp, contents)
+	}
 	if err != nil {
-		return canvas.Image(), fmt.Errorf("failed to parse content stream: %w", err)
+		// Log but don't fail
+		fmt.Printf("Warning: execution error: %v\n", err)
 	}
 
-	// Create interpreter
+	return canvas.Image(), nil
+}
+
+// cachedOps returns the parsed operators of a page whose Contents is a
+// single stream that was parsed before. Otherwise it returns nil and, if
+// this is the second page seen using the stream, the stream's object
+// number so the caller parses and caches it; -1 means not to cache.
+func (r *Renderer) cachedOps(page cos.Dict) ([]graphics.Operator, int) {
+	ref, ok := page.Get("Contents").(*cos.Reference)
+	if !ok {
+		return nil, -1
+	}
+	if ops, ok := r.opsCache.get(ref.ObjectNumber); ok {
+		return ops, ref.ObjectNumber
+	}
+	if r.seenContents[ref.ObjectNumber] {
+		return nil, ref.ObjectNumber
+	}
+	r.seenContents[ref.ObjectNumber] = true
+	return nil, -1
+}
+
+// PageUserUnit returns the UserUnit of a page, the size of a unit of page
+// space in points. It is 1 if the entry is missing or not positive.
+func PageUserUnit(page cos.Dict) float64 {
+	if unit, ok := page.GetReal("UserUnit"); ok && unit > 0 {
+		return unit
+	}
+	return 1
+}
+
+// renderContext draws the output of an interpreter onto a canvas.
+type renderContext struct {
+	reader *cos.Reader
+	canvas *Canvas
+	height float64 // Canvas height in page units, for flipping Y
+	scale  float64 // Pixels per page unit
+
+	// Point of default page space at the bottom-left corner of the canvas,
+	// non-zero for a MediaBox away from the origin or when rendering a
+	// tile. The CTM already includes it; only
+	// spaces defined relative to default page space, such as pattern
+	// space, need it added.
+	originX, originY float64
+
+	resources cos.Dict
+
+	// Soft mask image last built, and the mask it was built from
+	maskSource *graphics.SoftMask
+	maskImage  *image.Alpha
+
+	// Clip coverage last built, and the clip path it was built from
+	clipSource *graphics.Path
+	clipImage  *image.Alpha
+
+	// Rendered tiling pattern cells by resource name
+	tiles map[string]*patternTile
+
+	// Type 3, Type 0 and embedded TrueType fonts by resource name, nil
+	// for other font types
+	type3Fonts    map[string]*type3Font
+	type0Faces    map[string]*type0Face
+	trueTypeFaces map[string]*trueTypeFace
+
+	// The Renderer's bitmaps of small glyphs, nil to draw every glyph
+	// from its outline
+	glyphCache *GlyphCache
+
+	// Decoded image XObjects by object number, nil unless rendering tiles
+	images map[imageKey]*image.NRGBA
+
+	// Number of enclosing form XObjects
+	depth int
+
+	// Work done so far, nil unless profiling
+	stats *RenderStats
+}
+
+// maxFormDepth limits how deeply form XObjects may draw other forms.
+const maxFormDepth = 16
+
+// newInterpreter creates an interpreter that draws onto the context's canvas.
+func (rc *renderContext) newInterpreter(resources cos.Dict) *graphics.Interpreter {
 	interp := graphics.NewInterpreter()
+	loadResources(rc.reader, resources, &interp.Resources)
+	rc.resources = resources
+	rc.loadColorSpaces(&interp.Resources)
 
-	// Scale factor for DPI
-	scale := r.dpi / 72.0
+	height, scale := rc.height, rc.scale
 
 	// Set up rendering callbacks
 	interp.OnFill = func(path *graphics.Path, state *graphics.State, rule graphics.FillRule) {
 		// Transform path for rendering (flip Y and scale)
 		transformed := transformPath(path, height, scale)
-		col := state.FillColor.WithAlpha(state.FillAlpha)
-		canvas.Fill(transformed, col, rule)
+		if rc.offCanvas(transformed.Bounds(), 0) {
+			return
+		}
+		rc.applyMasks(state)
+		if state.FillPattern != "" && rc.fillPattern(state.FillPattern, transformed, rule, state.FillColor, state.FillAlpha) {
+			return
+		}
+		col := paintColor(state.FillColor, state.FillAlpha, state.RenderingIntent)
+		defer rc.timePath(path, time.Now())
+		rc.canvas.Fill(transformed, col, rule)
 	}
 
 	interp.OnStroke = func(path *graphics.Path, state *graphics.State) {
 		transformed := transformPath(path, height, scale)
-		col := state.StrokeColor.WithAlpha(state.StrokeAlpha)
+		col := paintColor(state.StrokeColor, state.StrokeAlpha, state.RenderingIntent)
 		lineWidth := state.LineWidth * scale
 		if lineWidth < 1 {
 			lineWidth = 1
 		}
-		canvas.Stroke(transformed, col, lineWidth, state.LineCap, state.LineJoin)
+		// Miter joins may reach several widths beyond the path
+		if rc.offCanvas(transformed.Bounds(), 4*lineWidth) {
+			return
+		}
+		rc.applyMasks(state)
+		if state.StrokePattern != "" {
+			outline := strokeToPath(transformed, lineWidth, state.LineCap, state.LineJoin)
+			if rc.fillPattern(state.StrokePattern, outline, graphics.FillRuleNonZero, state.StrokeColor, state.StrokeAlpha) {
+				return
+			}
+		}
+		defer rc.timePath(path, time.Now())
+		rc.canvas.Stroke(transformed, col, lineWidth, state.LineCap, state.LineJoin)
 	}
 
 	interp.OnText = func(text string, state *graphics.State) {
-		// Text rendering will be handled by the font package
-		// For now, this is a placeholder
+		if rc.stats != nil {
+			defer func(start time.Time) { rc.stats.RasterizeText += time.Since(start) }(time.Now())
+		}
+		if rc.showType3Text(text, state) || rc.showType0Text(text, state) {
+			return
+		}
+		if rc.showTrueTypeText(text, state) {
+			return
+		}
+		// Other fonts are not drawn yet
 		_ = text
 	}
 
 	interp.OnImage = func(name string, state *graphics.State) {
-		// Image rendering will be handled later
-		_ = name
+		if err := rc.drawXObject(name, state); err != nil {
+			fmt.Printf("Warning: XObject %s: %v\n", name, err)
+		}
 	}
 
-	// Execute operators
-	if err := interp.Execute(ops); err != nil {
-		// Log but don't fail
-		fmt.Printf("Warning: execution error: %v\n", err)
+	interp.OnInlineImage = func(dict map[string]interface{}, data []byte, state *graphics.State) {
+		if err := rc.drawInlineImage(dict, data, state); err != nil {
+			fmt.Printf("Warning: inline image: %v\n", err)
+		}
 	}
 
-	return canvas.Image(), nil
+	interp.OnShading = func(name string, state *graphics.State) {
+		shading, err := rc.lookupResource("Shading", name)
+		if err != nil {
+			fmt.Printf("Warning: shading %s: %v\n", name, err)
+			return
+		}
+		rc.applyMasks(state)
+		if err := rc.renderShading(shading, state); err != nil {
+			fmt.Printf("Warning: shading %s: %v\n", name, err)
+		}
+	}
+
+	return interp
+}
+
+// lookupResource returns a named entry from a resource category such as
+// Shading or Pattern. Stream entries return the stream dictionary.
+func (rc *renderContext) lookupResource(category, name string) (cos.Dict, error) {
+	obj, err := rc.lookupResourceObject(category, name)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := obj.(type) {
+	case cos.Dict:
+		return v, nil
+	case *cos.Stream:
+		return v.Dict, nil
+	}
+	return nil, fmt.Errorf("resource not found")
+}
+
+// lookupResourceObject returns a named entry from a resource category,
+// resolved but otherwise as stored.
+func (rc *renderContext) lookupResourceObject(category, name string) (cos.Object, error) {
+	if rc.resources == nil {
+		return nil, fmt.Errorf("no resources")
+	}
+
+	entries, err := rc.reader.ResolveDict(rc.resources.Get(category))
+	if err != nil {
+		return nil, fmt.Errorf("no %s resources", category)
+	}
+
+	obj := entries.Get(name)
+	if obj == nil {
+		return nil, fmt.Errorf("resource not found")
+	}
+	return rc.reader.Resolve(obj)
+}
+
+// offCanvas reports whether device-space bounds, grown by margin pixels on
+// each side, miss the canvas, so drawing them can be skipped.
+func (rc *renderContext) offCanvas(bounds graphics.Rect, margin float64) bool {
+	w, h := float64(rc.canvas.Width()), float64(rc.canvas.Height())
+	return bounds.X-margin >= w || bounds.Y-margin >= h ||
+		bounds.X+bounds.Width+margin <= 0 || bounds.Y+bounds.Height+margin <= 0
+}
+
+// applyMasks sets the canvas soft mask and clip for drawing with the state.
+func (rc *renderContext) applyMasks(state *graphics.State) {
+	rc.applySoftMask(state)
+
+	if state.ClipPath == nil {
+		rc.canvas.SetClip(nil)
+		return
+	}
+	if state.ClipPath != rc.clipSource {
+		rc.clipSource = state.ClipPath
+		rc.clipImage = rc.canvas.Coverage(transformPath(state.ClipPath, rc.height, rc.scale))
+	}
+	rc.canvas.SetClip(rc.clipImage)
+}
+
+// executeForm draws a form XObject with the given transformation, starting
+// from the default graphics state.
+func (rc *renderContext) executeForm(form *cos.Stream, ctm graphics.Matrix) error {
+	// The form matrix maps form space into the space it is drawn in
+	initial := graphics.NewState()
+	initial.CTM = streamMatrix(form).Multiply(ctm)
+	return rc.executeContent(form, initial)
+}
+
+// executeContent runs a content stream with its own resources, such as a
+// form or pattern cell, starting from the given graphics state.
+func (rc *renderContext) executeContent(s *cos.Stream, initial *graphics.State) error {
+	var resources cos.Dict
+	if res := s.Dict.Get("Resources"); res != nil {
+		var err error
+		resources, err = rc.reader.ResolveDict(res)
+		if err != nil {
+			return fmt.Errorf("failed to get resources: %w", err)
+		}
+	}
+
+	return rc.executeStream(s, resources, initial)
+}
+
+// executeStream runs a content stream with the given resources, such as a
+// Type 3 glyph that uses its font's resources.
+func (rc *renderContext) executeStream(s *cos.Stream, resources cos.Dict, initial *graphics.State) error {
+	data, err := rc.reader.DecodeStream(s)
+	if err != nil {
+		return fmt.Errorf("failed to decode content: %w", err)
+	}
+
+	interp := rc.newInterpreter(resources)
+	interp.SetState(initial)
+
+	return rc.runContent(interp, data)
+}
+
+// streamMatrix returns the Matrix entry of a form or pattern, or the
+// identity if it has none.
+func streamMatrix(s *cos.Stream) graphics.Matrix {
+	m, ok := s.Dict.GetArray("Matrix")
+	if !ok || len(m) < 6 {
+		return graphics.Identity()
+	}
+	return graphics.Matrix{
+		toFloat(m[0]), toFloat(m[1]), toFloat(m[2]),
+		toFloat(m[3]), toFloat(m[4]), toFloat(m[5]),
+	}
 }
 
 // transformPath transforms a path from PDF coordinates to image coordinates.
@@ -160,7 +500,8 @@ func toFloat(obj cos.Object) float64 {
 	return 0
 }
 
-// RenderToFile renders a page and saves it to a file.
+// RenderToFile renders a page and saves it to a file as PNG. The file is
+// created only once the page has rendered, and removed if writing fails.
 func (r *Renderer) RenderToFile(pageNum int, filename string) error {
 	img, err := r.RenderPage(pageNum)
 	if err != nil {
@@ -171,9 +512,25 @@ func (r *Renderer) RenderToFile(pageNum int, filename string) error {
 	if err != nil {
 		return fmt.Errorf("failed to create file: %w", err)
 	}
-	defer f.Close()
+	err = png.Encode(f, img)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(filename)
+		return fmt.Errorf("failed to write %s: %w", filename, err)
+	}
+	return nil
+}
 
-	return png.Encode(f, img)
+// RenderTo renders a page and writes it to w as PNG. Unlike RenderToFile it
+// needs no file system, so it also works in WebAssembly.
+func (r *Renderer) RenderTo(pageNum int, w io.Writer) error {
+	img, err := r.RenderPage(pageNum)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
 }
 
 // RenderAllPages renders all pages to a slice of images.
@@ -0,0 +1,93 @@
+package raster
+
+import (
+	"gumgum/pkg/cos"
+	"gumgum/pkg/graphics"
+)
+
+// maxResourceDepth limits how deeply nested resource objects are converted.
+const maxResourceDepth = 8
+
+// loadResources converts the page resources used by the interpreter.
+func loadResources(reader *cos.Reader, resources cos.Dict, target *graphics.Resources) {
+	if resources == nil {
+		return
+	}
+
+	if extGState, err := reader.ResolveDict(resources.Get("ExtGState")); err == nil {
+		for name, value := range extGState {
+			dict, err := reader.ResolveDict(value)
+			if err != nil {
+				continue
+			}
+			target.ExtGState[string(name)] = convertObject(reader, dict, 0)
+		}
+	}
+}
+
+// loadColorSpaces parses the named color spaces of the current resources so
+// the interpreter can convert sc and scn operands. Spaces that cannot be
+// parsed are left out and fall back to device colors.
+func (rc *renderContext) loadColorSpaces(target *graphics.Resources) {
+	if rc.resources == nil {
+		return
+	}
+
+	spaces, err := rc.reader.ResolveDict(rc.resources.Get("ColorSpace"))
+	if err != nil {
+		return
+	}
+	for name := range spaces {
+		cs, err := rc.parseImageColorSpace(name, 0)
+		if err != nil {
+			continue
+		}
+		target.ColorSpaces[string(name)] = cs
+	}
+}
+
+// convertObject converts a COS object to the operand types used by the
+// graphics interpreter: float64 numbers, string names and strings,
+// []interface{} arrays and map[string]interface{} dictionaries. References are
+// resolved; streams are passed through unchanged.
+func convertObject(reader *cos.Reader, obj cos.Object, depth int) interface{} {
+	if depth > maxResourceDepth {
+		return nil
+	}
+
+	if ref, ok := obj.(*cos.Reference); ok {
+		resolved, err := reader.Resolve(ref)
+		if err != nil {
+			return nil
+		}
+		obj = resolved
+	}
+
+	switch v := obj.(type) {
+	case cos.Integer:
+		return float64(v)
+	case cos.Real:
+		return float64(v)
+	case cos.Boolean:
+		return bool(v)
+	case cos.Name:
+		return string(v)
+	case cos.String:
+		return string(v)
+	case cos.Array:
+		arr := make([]interface{}, len(v))
+		for i, item := range v {
+			arr[i] = convertObject(reader, item, depth+1)
+		}
+		return arr
+	case cos.Dict:
+		dict := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			dict[string(key)] = convertObject(reader, value, depth+1)
+		}
+		return dict
+	case *cos.Stream:
+		return v
+	}
+	return nil
+}
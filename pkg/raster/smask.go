@@ -0,0 +1,92 @@
+package raster
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"gumgum/pkg/cos"
+	"gumgum/pkg/graphics"
+)
+
+// applySoftMask sets the canvas mask for the soft mask in the state, building
+// the mask image the first time it is used.
+func (rc *renderContext) applySoftMask(state *graphics.State) {
+	if state.SoftMask == nil {
+		rc.canvas.SetMask(nil)
+		return
+	}
+
+	if state.SoftMask != rc.maskSource {
+		rc.maskSource = state.SoftMask
+		rc.maskImage = rc.renderSoftMask(state.SoftMask)
+	}
+	rc.canvas.SetMask(rc.maskImage)
+}
+
+// renderSoftMask draws the mask's group XObject to a separate canvas and
+// converts it to an alpha mask. Returns nil if the group cannot be drawn.
+func (rc *renderContext) renderSoftMask(sm *graphics.SoftMask) *image.Alpha {
+	group, ok := sm.Group.(*cos.Stream)
+	if !ok {
+		return nil
+	}
+
+	luminosity := sm.Subtype != "Alpha"
+
+	maskCanvas := NewCanvas(rc.canvas.Width(), rc.canvas.Height())
+	if luminosity {
+		maskCanvas.SetBackground(backdropColor(sm.Backdrop))
+	} else {
+		maskCanvas.SetBackground(color.Transparent)
+	}
+	maskCanvas.Clear()
+
+	mrc := &renderContext{
+		reader:     rc.reader,
+		canvas:     maskCanvas,
+		height:     rc.height,
+		scale:      rc.scale,
+		originX:    rc.originX,
+		originY:    rc.originY,
+		images:     rc.images,
+		glyphCache: rc.glyphCache,
+		stats:      rc.stats,
+	}
+	if err := mrc.executeForm(group, sm.CTM); err != nil {
+		fmt.Printf("Warning: soft mask: %v\n", err)
+		return nil
+	}
+
+	img := maskCanvas.Image()
+	mask := image.NewAlpha(img.Bounds())
+	for i := range mask.Pix {
+		p := img.Pix[i*4 : i*4+4]
+		if luminosity {
+			// Luminosity weights from the PDF blend mode definitions
+			lum := 0.30*float64(p[0]) + 0.59*float64(p[1]) + 0.11*float64(p[2])
+			mask.Pix[i] = uint8(clamp(lum, 0, 255))
+		} else {
+			mask.Pix[i] = p[3]
+		}
+	}
+
+	return mask
+}
+
+// backdropColor converts BC components to a color, choosing the color space
+// from the number of components. The default backdrop is black.
+func backdropColor(bc []float64) color.Color {
+	var c graphics.Color
+	switch len(bc) {
+	case 1:
+		c = graphics.NewGray(bc[0])
+	case 3:
+		c = graphics.NewRGB(bc[0], bc[1], bc[2])
+	case 4:
+		c = graphics.NewCMYK(bc[0], bc[1], bc[2], bc[3])
+	default:
+		return color.Black
+	}
+	return c.ToRGBA()
+}
@@ -0,0 +1,129 @@
+package raster
+
+import (
+	"fmt"
+
+	"gumgum/pkg/cos"
+	"gumgum/pkg/font"
+	"gumgum/pkg/font/ttf"
+	"gumgum/pkg/graphics"
+)
+
+// trueTypeFace is a loaded simple TrueType font with an embedded program.
+type trueTypeFace struct {
+	encoding map[byte]string
+	glyphs   *font.Renderer
+}
+
+// showTrueTypeText draws text in a simple TrueType font and advances the
+// text matrix. The font renderer lays the string out in text space from
+// the text state, so spacing (Tc, Tw), horizontal scaling (Tz) and rise
+// (Ts) apply. It returns false if the current font is not an embedded
+// TrueType font.
+func (rc *renderContext) showTrueTypeText(text string, state *graphics.State) bool {
+	face := rc.trueTypeFace(state.TextState.FontName)
+	if face == nil {
+		return false
+	}
+
+	ts := &state.TextState
+	face.glyphs.SetTextState(*ts)
+	unicode := face.decode(text)
+	if rc.stats != nil {
+		rc.stats.Glyphs += len(text)
+	}
+
+	if ts.RenderMode != graphics.TextRenderInvisible {
+		path := face.glyphs.RenderString(unicode, 0, 0)
+		if !path.IsEmpty() {
+			rc.applyMasks(state)
+			col := paintColor(state.FillColor, state.FillAlpha, state.RenderingIntent)
+			transformed := transformPath(path.Transform(ts.TextMatrix.Multiply(state.CTM)), rc.height, rc.scale)
+			rc.canvas.Fill(transformed, col, graphics.FillRuleNonZero)
+		}
+	}
+
+	ts.TextMatrix = graphics.Translate(face.glyphs.Advance(unicode), 0).Multiply(ts.TextMatrix)
+	return true
+}
+
+// decode converts shown codes to the characters looked up in the font's
+// cmap. Codes the encoding does not name are used as character codes
+// directly, as symbolic fonts expect.
+func (face *trueTypeFace) decode(text string) string {
+	runes := make([]rune, 0, len(text))
+	for i := 0; i < len(text); i++ {
+		code := text[i]
+		if mapped := []rune(cos.GlyphText(face.encoding[code])); len(mapped) == 1 {
+			runes = append(runes, mapped[0])
+		} else {
+			runes = append(runes, rune(code))
+		}
+	}
+	return string(runes)
+}
+
+// trueTypeFace loads a named font resource if it is a TrueType font with an
+// embedded program. Results, including fonts of other types, are cached.
+func (rc *renderContext) trueTypeFace(name string) *trueTypeFace {
+	if face, ok := rc.trueTypeFaces[name]; ok {
+		return face
+	}
+
+	face, err := rc.loadTrueTypeFace(name)
+	if err != nil {
+		fmt.Printf("Warning: TrueType font %s: %v\n", name, err)
+	}
+	if rc.trueTypeFaces == nil {
+		rc.trueTypeFaces = make(map[string]*trueTypeFace)
+	}
+	rc.trueTypeFaces[name] = face
+	return face
+}
+
+// loadTrueTypeFace reads a simple TrueType font and its embedded program.
+// It returns nil without an error for other fonts, including TrueType
+// fonts that are not embedded.
+func (rc *renderContext) loadTrueTypeFace(name string) (*trueTypeFace, error) {
+	if name == "" {
+		return nil, nil
+	}
+	dict, err := rc.lookupResource("Font", name)
+	if err != nil {
+		return nil, nil
+	}
+	if subtype, _ := dict.GetName("Subtype"); subtype != "TrueType" {
+		return nil, nil
+	}
+
+	descriptor, err := rc.reader.ResolveDict(dict.Get("FontDescriptor"))
+	if err != nil {
+		return nil, nil
+	}
+	obj, err := rc.reader.Resolve(descriptor.Get("FontFile2"))
+	if err != nil {
+		return nil, nil
+	}
+	program, ok := obj.(*cos.Stream)
+	if !ok {
+		return nil, nil
+	}
+
+	data, err := rc.reader.DecodeStream(program)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode font program: %w", err)
+	}
+	parsed, err := ttf.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse font program: %w", err)
+	}
+
+	// Symbolic fonts often have no usable encoding; their codes are looked
+	// up directly
+	encoding, err := cos.ParseFontEncoding(dict, rc.reader)
+	if err != nil {
+		encoding = nil
+	}
+
+	return &trueTypeFace{encoding: encoding, glyphs: font.NewRenderer(parsed)}, nil
+}
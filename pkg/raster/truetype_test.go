@@ -0,0 +1,152 @@
+package raster
+
+import (
+	"bytes"
+	"compress/zlib"
+	"image"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+
+	"gumgum/pkg/cos"
+)
+
+// renderTrueTypeText renders a 200x100 point page showing content with an
+// embedded TrueType font F1, at 72 DPI so pixels are points.
+func renderTrueTypeText(t *testing.T, content string) *image.RGBA {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := cos.NewWriter(&buf, "1.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	add := func(obj cos.Object) *cos.Reference {
+		ref, err := w.AddObject(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ref
+	}
+	stream := func(data []byte, opts cos.StreamOptions) *cos.Reference {
+		ref, err := w.WriteStream(cos.Dict{}, data, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ref
+	}
+
+	program := stream(goregular.TTF, cos.StreamOptions{Compress: true, Level: zlib.DefaultCompression})
+	descriptor := add(cos.Dict{
+		"Type":      cos.Name("FontDescriptor"),
+		"FontName":  cos.Name("GoRegular"),
+		"Flags":     cos.Integer(32),
+		"FontFile2": program,
+	})
+	font := add(cos.Dict{
+		"Type":           cos.Name("Font"),
+		"Subtype":        cos.Name("TrueType"),
+		"BaseFont":       cos.Name("GoRegular"),
+		"Encoding":       cos.Name("WinAnsiEncoding"),
+		"FontDescriptor": descriptor,
+	})
+
+	pagesRef := w.Reserve()
+	page := add(cos.Dict{
+		"Type":      cos.Name("Page"),
+		"Parent":    pagesRef,
+		"MediaBox":  cos.Array{cos.Integer(0), cos.Integer(0), cos.Integer(200), cos.Integer(100)},
+		"Resources": cos.Dict{"Font": cos.Dict{"F1": font}},
+		"Contents":  stream([]byte(content), cos.StreamOptions{}),
+	})
+	if err := w.WriteObject(pagesRef.ObjectNumber, cos.Dict{
+		"Type":  cos.Name("Pages"),
+		"Kids":  cos.Array{page},
+		"Count": cos.Integer(1),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	catalog := add(cos.Dict{"Type": cos.Name("Catalog"), "Pages": pagesRef})
+	if err := w.Close(cos.Dict{"Root": catalog}); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := cos.NewReader(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRenderer(reader)
+	r.SetDPI(72)
+	img, err := r.RenderPage(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+// inkBounds returns the bounds of the pixels that are not white.
+func inkBounds(img *image.RGBA) image.Rectangle {
+	var ink image.Rectangle
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := img.RGBAAt(x, y)
+			if c.R < 128 && c.G < 128 && c.B < 128 {
+				ink = ink.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return ink
+}
+
+func TestTrueTypeTextState(t *testing.T) {
+	plain := inkBounds(renderTrueTypeText(t, "BT /F1 20 Tf 10 40 Td (HHHH) Tj ET"))
+	if plain.Empty() {
+		t.Fatal("embedded TrueType text was not drawn")
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		check   func(got image.Rectangle) bool
+	}{
+		{
+			"character spacing",
+			"BT /F1 20 Tf 10 Tc 10 40 Td (HHHH) Tj ET",
+			func(got image.Rectangle) bool { return got.Dx() == plain.Dx()+30 && got.Min.X == plain.Min.X },
+		},
+		{
+			"word spacing",
+			"BT /F1 20 Tf 20 Tw 10 40 Td (HH HH) Tj ET",
+			func(got image.Rectangle) bool {
+				spaced := inkBounds(renderTrueTypeText(t, "BT /F1 20 Tf 10 40 Td (HH HH) Tj ET"))
+				return got.Dx() == spaced.Dx()+20
+			},
+		},
+		{
+			"rise",
+			"BT /F1 20 Tf 8 Ts 10 40 Td (HHHH) Tj ET",
+			func(got image.Rectangle) bool { return got == plain.Sub(image.Pt(0, 8)) },
+		},
+		{
+			"horizontal scale",
+			"BT /F1 20 Tf 50 Tz 10 40 Td (HHHH) Tj ET",
+			func(got image.Rectangle) bool {
+				return got.Dy() == plain.Dy() && got.Dx() >= plain.Dx()/2-1 && got.Dx() <= plain.Dx()/2+1
+			},
+		},
+		{
+			"advance",
+			"BT /F1 20 Tf 10 40 Td (HH) Tj (HH) Tj ET",
+			func(got image.Rectangle) bool { return got == plain },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := inkBounds(renderTrueTypeText(t, tt.content))
+			if !tt.check(got) {
+				t.Errorf("ink bounds %v, plain text %v", got, plain)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package raster
+
+import (
+	"fmt"
+	"math"
+
+	"gumgum/pkg/cos"
+	"gumgum/pkg/font"
+	"gumgum/pkg/font/ttf"
+	"gumgum/pkg/graphics"
+)
+
+// type0Face is a loaded composite font.
+type type0Face struct {
+	font *cos.Type0Font
+
+	// Object reference of the font dictionary, naming the font in the
+	// glyph cache; "" for a direct dictionary, whose glyphs are not cached
+	ref string
+
+	// Outlines from an embedded TrueType program; nil if the font has none,
+	// in which case text only advances
+	glyphs *font.Renderer
+}
+
+// showType0Text draws text in a composite font and advances the text
+// matrix. Codes are split by the font's CMap, so they may span several
+// bytes. It returns false if the current font is not a Type 0 font.
+func (rc *renderContext) showType0Text(text string, state *graphics.State) bool {
+	face := rc.type0Face(state.TextState.FontName)
+	if face == nil {
+		return false
+	}
+
+	ts := &state.TextState
+	hScale := ts.HScale / 100
+	visible := ts.RenderMode != graphics.TextRenderInvisible && face.glyphs != nil
+
+	for _, code := range face.font.CMap.Decode([]byte(text)) {
+		if rc.stats != nil {
+			rc.stats.Glyphs++
+		}
+		if visible {
+			// Glyph outlines are in em units
+			textSpace := graphics.Matrix{ts.FontSize * hScale, 0, 0, ts.FontSize, 0, ts.Rise}
+			glyphMatrix := textSpace.Multiply(ts.TextMatrix).Multiply(state.CTM)
+			rc.drawType0Glyph(face, face.font.GlyphID(code.CID), glyphMatrix, state)
+		}
+
+		// Word spacing applies only to the single-byte code 32
+		tx := face.font.Width(code.CID)/1000*ts.FontSize + ts.CharSpace
+		if code.Length == 1 && code.Code == ' ' {
+			tx += ts.WordSpace
+		}
+		ts.TextMatrix = graphics.Translate(tx*hScale, 0).Multiply(ts.TextMatrix)
+	}
+	return true
+}
+
+// drawType0Glyph fills one glyph of a composite font. Small upright glyphs
+// are drawn from the glyph cache, snapped to whole pixels.
+func (rc *renderContext) drawType0Glyph(face *type0Face, glyphID uint16, glyphMatrix graphics.Matrix, state *graphics.State) {
+	col := paintColor(state.FillColor, state.FillAlpha, state.RenderingIntent)
+	size := glyphMatrix[0] * rc.scale
+	upright := glyphMatrix[1] == 0 && glyphMatrix[2] == 0 && glyphMatrix[0] == glyphMatrix[3]
+	if upright && size > 0 && size < GlyphCacheMaxSize && rc.glyphCache != nil && face.ref != "" {
+		bitmap, err := rc.glyphCache.Glyph(face.ref, glyphID, size, func() (*graphics.Path, error) {
+			return face.glyphs.GlyphToPath(glyphID)
+		})
+		if err == nil {
+			rc.applyMasks(state)
+			x, y := transformPoint(glyphMatrix[4], glyphMatrix[5], rc.height, rc.scale)
+			rc.canvas.DrawGlyph(bitmap, int(math.Round(x)), int(math.Round(y)), col)
+		}
+		return
+	}
+
+	path, err := face.glyphs.GlyphToPath(glyphID)
+	if err == nil && !path.IsEmpty() {
+		rc.applyMasks(state)
+		transformed := transformPath(path.Transform(glyphMatrix), rc.height, rc.scale)
+		rc.canvas.Fill(transformed, col, graphics.FillRuleNonZero)
+	}
+}
+
+// type0Face loads a named font resource if it is a Type 0 font. Results,
+// including fonts of other types, are cached.
+func (rc *renderContext) type0Face(name string) *type0Face {
+	if face, ok := rc.type0Faces[name]; ok {
+		return face
+	}
+
+	face, err := rc.loadType0Face(name)
+	if err != nil {
+		fmt.Printf("Warning: Type 0 font %s: %v\n", name, err)
+	}
+	if rc.type0Faces == nil {
+		rc.type0Faces = make(map[string]*type0Face)
+	}
+	rc.type0Faces[name] = face
+	return face
+}
+
+// loadType0Face reads a Type 0 font and its embedded TrueType program. It
+// returns nil without an error for fonts of other types.
+func (rc *renderContext) loadType0Face(name string) (*type0Face, error) {
+	if name == "" {
+		return nil, nil
+	}
+	dict, err := rc.lookupResource("Font", name)
+	if err != nil {
+		return nil, nil
+	}
+	if subtype, _ := dict.GetName("Subtype"); subtype != "Type0" {
+		return nil, nil
+	}
+
+	composite, err := rc.reader.Type0Font(dict)
+	if err != nil {
+		return nil, err
+	}
+	face := &type0Face{font: composite}
+	if entries, err := rc.reader.ResolveDict(rc.resources.Get("Font")); err == nil {
+		if ref, ok := entries.Get(name).(*cos.Reference); ok {
+			face.ref = ref.String()
+		}
+	}
+
+	if composite.Descriptor != nil {
+		if obj, err := rc.reader.Resolve(composite.Descriptor.Get("FontFile2")); err == nil {
+			if program, ok := obj.(*cos.Stream); ok {
+				data, err := rc.reader.DecodeStream(program)
+				if err != nil {
+					return face, fmt.Errorf("failed to decode font program: %w", err)
+				}
+				parsed, err := ttf.Parse(data)
+				if err != nil {
+					return face, fmt.Errorf("failed to parse font program: %w", err)
+				}
+				face.glyphs = font.NewRenderer(parsed)
+				face.glyphs.SetScale(1)
+			}
+		}
+	}
+
+	return face, nil
+}
@@ -0,0 +1,199 @@
+package raster
+
+import (
+	"fmt"
+
+	"gumgum/pkg/cos"
+	"gumgum/pkg/graphics"
+)
+
+// type3Font is a font whose glyphs are content streams.
+type type3Font struct {
+	fontMatrix graphics.Matrix
+	bbox       []float64 // Glyph space; nil if unknown
+	charProcs  cos.Dict
+	names      [256]string // Glyph names by character code
+	firstChar  int
+	widths     []float64 // Glyph space
+	resources  cos.Dict
+}
+
+// showType3Text draws text in a Type 3 font and advances the text matrix.
+// It returns false if the current font is not a Type 3 font.
+func (rc *renderContext) showType3Text(text string, state *graphics.State) bool {
+	font := rc.type3Font(state.TextState.FontName)
+	if font == nil {
+		return false
+	}
+
+	ts := &state.TextState
+	hScale := ts.HScale / 100
+
+	for i := 0; i < len(text); i++ {
+		code := text[i]
+		if rc.stats != nil {
+			rc.stats.Glyphs++
+		}
+
+		if ts.RenderMode != graphics.TextRenderInvisible {
+			// Glyph space -> text space -> user space
+			textSpace := graphics.Matrix{ts.FontSize * hScale, 0, 0, ts.FontSize, 0, ts.Rise}
+			glyphMatrix := font.fontMatrix.Multiply(textSpace).Multiply(ts.TextMatrix).Multiply(state.CTM)
+			if err := rc.drawType3Glyph(font, code, glyphMatrix, state); err != nil {
+				fmt.Printf("Warning: Type 3 glyph %d: %v\n", code, err)
+			}
+		}
+
+		// Advance past the glyph
+		var w float64
+		if index := int(code) - font.firstChar; index >= 0 && index < len(font.widths) {
+			w = font.widths[index] * font.fontMatrix[0]
+		}
+		tx := w*ts.FontSize + ts.CharSpace
+		if code == ' ' {
+			tx += ts.WordSpace
+		}
+		ts.TextMatrix = graphics.Translate(tx*hScale, 0).Multiply(ts.TextMatrix)
+	}
+	return true
+}
+
+// drawType3Glyph runs the glyph procedure for a character code, clipped to
+// the font's bounding box.
+func (rc *renderContext) drawType3Glyph(font *type3Font, code byte, glyphMatrix graphics.Matrix, state *graphics.State) error {
+	name := font.names[code]
+	if name == "" {
+		return nil
+	}
+	obj, err := rc.reader.Resolve(font.charProcs.Get(name))
+	if err != nil {
+		return err
+	}
+	proc, ok := obj.(*cos.Stream)
+	if !ok {
+		return nil
+	}
+
+	if rc.depth >= maxFormDepth {
+		return fmt.Errorf("glyphs nested too deeply")
+	}
+
+	initial := state.Clone()
+	initial.CTM = glyphMatrix
+	initial.TextState.TextMatrix = graphics.Identity()
+	initial.TextState.LineMatrix = graphics.Identity()
+
+	if font.bbox != nil {
+		clip := graphics.NewPath()
+		clip.Rect(font.bbox[0], font.bbox[1], font.bbox[2]-font.bbox[0], font.bbox[3]-font.bbox[1])
+		clip = clip.Transform(glyphMatrix)
+		if initial.ClipPath != nil {
+			clip = graphics.PathIntersection(initial.ClipPath, clip)
+		}
+		initial.ClipPath = clip
+	}
+
+	// Glyphs use the font's resources, or the page's if it has none
+	resources := font.resources
+	if resources == nil {
+		resources = rc.resources
+	}
+
+	child := &renderContext{
+		reader:     rc.reader,
+		canvas:     rc.canvas,
+		height:     rc.height,
+		scale:      rc.scale,
+		originX:    rc.originX,
+		originY:    rc.originY,
+		images:     rc.images,
+		glyphCache: rc.glyphCache,
+		depth:      rc.depth + 1,
+		stats:      rc.stats,
+	}
+	return child.executeStream(proc, resources, initial)
+}
+
+// type3Font loads a named font resource if it is a Type 3 font. Results,
+// including fonts of other types, are cached.
+func (rc *renderContext) type3Font(name string) *type3Font {
+	if font, ok := rc.type3Fonts[name]; ok {
+		return font
+	}
+
+	font, err := rc.loadType3Font(name)
+	if err != nil {
+		fmt.Printf("Warning: Type 3 font %s: %v\n", name, err)
+	}
+	if rc.type3Fonts == nil {
+		rc.type3Fonts = make(map[string]*type3Font)
+	}
+	rc.type3Fonts[name] = font
+	return font
+}
+
+// loadType3Font reads a Type 3 font dictionary. It returns nil without an
+// error for fonts of other types.
+func (rc *renderContext) loadType3Font(name string) (*type3Font, error) {
+	if name == "" {
+		return nil, nil
+	}
+	dict, err := rc.lookupResource("Font", name)
+	if err != nil {
+		return nil, nil
+	}
+	if subtype, _ := dict.GetName("Subtype"); subtype != "Type3" {
+		return nil, nil
+	}
+
+	font := &type3Font{}
+
+	matrix := rc.readNumbers(dict.Get("FontMatrix"))
+	if len(matrix) < 6 {
+		return nil, fmt.Errorf("missing FontMatrix")
+	}
+	copy(font.fontMatrix[:], matrix)
+
+	font.charProcs, err = rc.reader.ResolveDict(dict.Get("CharProcs"))
+	if err != nil {
+		return nil, fmt.Errorf("missing CharProcs: %w", err)
+	}
+
+	// An all-zero FontBBox makes no claim about glyph extents
+	if bbox := rc.readNumbers(dict.Get("FontBBox")); len(bbox) >= 4 &&
+		(bbox[0] != 0 || bbox[1] != 0 || bbox[2] != 0 || bbox[3] != 0) {
+		font.bbox = []float64{
+			min(bbox[0], bbox[2]), min(bbox[1], bbox[3]),
+			max(bbox[0], bbox[2]), max(bbox[1], bbox[3]),
+		}
+	}
+
+	if first, ok := dict.GetInt("FirstChar"); ok {
+		font.firstChar = int(first)
+	}
+	font.widths = rc.readNumbers(dict.Get("Widths"))
+
+	if res := dict.Get("Resources"); res != nil {
+		font.resources, _ = rc.reader.ResolveDict(res)
+	}
+
+	// The Differences array lists a starting code followed by glyph names
+	if encoding, err := rc.reader.ResolveDict(dict.Get("Encoding")); err == nil {
+		if diffs, err := rc.reader.ResolveArray(encoding.Get("Differences")); err == nil {
+			code := 0
+			for _, item := range diffs {
+				switch v := item.(type) {
+				case cos.Integer:
+					code = int(v)
+				case cos.Name:
+					if code >= 0 && code < len(font.names) {
+						font.names[code] = string(v)
+					}
+					code++
+				}
+			}
+		}
+	}
+
+	return font, nil
+}
@@ -64,7 +64,7 @@ func Decode(data []byte, filter Filter, params DecodeParams) ([]byte, error) {
 		// JPEG data - pass through (handled by image decoders)
 		return data, nil
 	case FilterJPXDecode:
-		// JPEG2000 data - pass through
+		// JPEG2000 data - pass through (decoded to an image by DecodeJPX)
 		return data, nil
 	default:
 		return nil, fmt.Errorf("unsupported filter: %s", filter)
@@ -109,6 +109,26 @@ func DecodeFlateDecode(data []byte) ([]byte, error) {
 	return decoded, nil
 }
 
+// EncodeFlateDecode compresses data with zlib at the given level (0-9, or
+// zlib.DefaultCompression). It is the inverse of DecodeFlateDecode.
+func EncodeFlateDecode(data []byte, level int) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w, err := zlib.NewWriterLevel(&buf, level)
+	if err != nil {
+		return nil, fmt.Errorf("invalid compression level %d: %w", level, err)
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return nil, fmt.Errorf("zlib write error: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("zlib close error: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
 // DecodeASCIIHex decodes ASCII hexadecimal encoded data.
 func DecodeASCIIHex(data []byte) ([]byte, error) {
 	var result []byte
@@ -220,6 +240,36 @@ func DecodeASCII85(data []byte) ([]byte, error) {
 	return result, nil
 }
 
+// EncodeASCII85 encodes data as ASCII85 with the ~> end marker. It is the
+// inverse of DecodeASCII85.
+func EncodeASCII85(data []byte) []byte {
+	result := make([]byte, 0, len(data)*5/4+7)
+
+	for i := 0; i < len(data); i += 4 {
+		var group [4]byte
+		n := copy(group[:], data[i:])
+
+		tuple := uint32(group[0])<<24 | uint32(group[1])<<16 | uint32(group[2])<<8 | uint32(group[3])
+
+		// A full group of zeros is abbreviated as 'z'
+		if n == 4 && tuple == 0 {
+			result = append(result, 'z')
+			continue
+		}
+
+		var encoded [5]byte
+		for j := 4; j >= 0; j-- {
+			encoded[j] = byte(tuple%85) + '!'
+			tuple /= 85
+		}
+
+		// A partial group of n bytes is written as n+1 characters
+		result = append(result, encoded[:n+1]...)
+	}
+
+	return append(result, '~', '>')
+}
+
 // DecodeLZW decodes LZW-compressed data.
 func DecodeLZW(data []byte, earlyChange int) ([]byte, error) {
 	if len(data) == 0 {
@@ -392,6 +442,45 @@ func DecodeRunLength(data []byte) ([]byte, error) {
 	return result, nil
 }
 
+// EncodeRunLength encodes data with the RunLengthDecode scheme, ending with
+// the EOD marker. It is the inverse of DecodeRunLength. Runs of two or more
+// identical bytes become repeat sequences, except that a run of two inside
+// literal bytes stays literal, since breaking the literal would cost more.
+func EncodeRunLength(data []byte) []byte {
+	result := make([]byte, 0, len(data)+len(data)/128+2)
+
+	for i := 0; i < len(data); {
+		if run := repeatLength(data, i); run >= 2 {
+			result = append(result, byte(257-run), data[i])
+			i += run
+			continue
+		}
+
+		// Literal bytes up to the next run worth encoding
+		start := i
+		for i < len(data) && i-start < 128 {
+			if i > start && repeatLength(data, i) >= 3 {
+				break
+			}
+			i++
+		}
+		result = append(result, byte(i-start-1))
+		result = append(result, data[start:i]...)
+	}
+
+	return append(result, 128)
+}
+
+// repeatLength returns how many times data[i] repeats from i, up to the 128
+// bytes a repeat sequence can hold.
+func repeatLength(data []byte, i int) int {
+	n := 1
+	for i+n < len(data) && n < 128 && data[i+n] == data[i] {
+		n++
+	}
+	return n
+}
+
 func isWhitespace(b byte) bool {
 	return b == ' ' || b == '\t' || b == '\r' || b == '\n' || b == '\f' || b == 0
 }
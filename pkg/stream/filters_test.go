@@ -0,0 +1,103 @@
+package stream
+
+import (
+	"bytes"
+	"testing"
+	"testing/quick"
+)
+
+func TestASCII85RoundTrip(t *testing.T) {
+	roundTrip := func(data []byte) bool {
+		decoded, err := DecodeASCII85(EncodeASCII85(data))
+		return err == nil && bytes.Equal(decoded, data)
+	}
+	if err := quick.Check(roundTrip, &quick.Config{MaxCount: 1000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEncodeASCII85(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		want string
+	}{
+		{"empty", nil, "~>"},
+		{"zero group", []byte{0, 0, 0, 0}, "z~>"},
+		{"partial zero group", []byte{0, 0}, "!!!~>"},
+		{"text", []byte("Man "), "9jqo^~>"},
+		{"partial", []byte("sure."), "F*2M7/c~>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(EncodeASCII85(tt.in))
+			if got != tt.want {
+				t.Errorf("EncodeASCII85(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+			for _, c := range got[:len(got)-2] {
+				if c != 'z' && (c < '!' || c > 'u') {
+					t.Errorf("character %q out of range", c)
+				}
+			}
+		})
+	}
+}
+
+func TestRunLengthRoundTrip(t *testing.T) {
+	roundTrip := func(data []byte) bool {
+		decoded, err := DecodeRunLength(EncodeRunLength(data))
+		return err == nil && bytes.Equal(decoded, data)
+	}
+	if err := quick.Check(roundTrip, &quick.Config{MaxCount: 1000}); err != nil {
+		t.Error(err)
+	}
+
+	// Random bytes rarely repeat, so also try data drawn from two values
+	runs := func(data []byte) bool {
+		for i := range data {
+			data[i] &= 1
+		}
+		return roundTrip(data)
+	}
+	if err := quick.Check(runs, &quick.Config{MaxCount: 1000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEncodeRunLength(t *testing.T) {
+	literal := make([]byte, 128)
+	for i := range literal {
+		literal[i] = byte(i)
+	}
+
+	tests := []struct {
+		name string
+		in   []byte
+		want []byte
+	}{
+		{"empty", nil, []byte{128}},
+		{"run of 128", bytes.Repeat([]byte{'a'}, 128), []byte{129, 'a', 128}},
+		{"run of 129", bytes.Repeat([]byte{'a'}, 129), []byte{129, 'a', 0, 'a', 128}},
+		{"repeat of 2 in literal", []byte("abbc"), []byte{3, 'a', 'b', 'b', 'c', 128}},
+		{
+			"literal of 128 then run",
+			append(append([]byte{}, literal...), 0xff, 0xff, 0xff, 0xff),
+			append(append([]byte{127}, literal...), 253, 0xff, 128),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EncodeRunLength(tt.in)
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("EncodeRunLength(% x) = % x, want % x", tt.in, got, tt.want)
+			}
+			decoded, err := DecodeRunLength(got)
+			if err != nil {
+				t.Fatalf("DecodeRunLength: %v", err)
+			}
+			if !bytes.Equal(decoded, tt.in) {
+				t.Errorf("DecodeRunLength(% x) = % x, want % x", got, decoded, tt.in)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package stream
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+)
+
+// ErrJPXUnsupported is returned by DecodeJPX when no JPEG2000 decoder is
+// registered with the image package.
+var ErrJPXUnsupported = errors.New("JPEG2000 decoding not supported")
+
+// jp2Signature starts a JP2 file: the 12-byte JPEG2000 signature box.
+var jp2Signature = []byte{0x00, 0x00, 0x00, 0x0c, 'j', 'P', ' ', ' ', 0x0d, 0x0a, 0x87, 0x0a}
+
+// j2kSignature starts a bare JPEG2000 codestream: the SOC marker followed
+// by the SIZ marker.
+var j2kSignature = []byte{0xff, 0x4f, 0xff, 0x51}
+
+// DecodeJPX decodes JPXDecode (JPEG2000) data, either a JP2 file or a bare
+// codestream. There is no JPEG2000 decoder in the standard library or
+// golang.org/x/image, so the decoder is found through the image package's
+// format registry; a program that needs JPXDecode images imports one that
+// registers itself. Data that is not JPEG2000 is rejected even if another
+// registered format could decode it.
+func DecodeJPX(data []byte) (image.Image, error) {
+	if !bytes.HasPrefix(data, jp2Signature) && !bytes.HasPrefix(data, j2kSignature) {
+		return nil, errors.New("failed to decode JPEG2000: no JP2 signature or codestream marker")
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if errors.Is(err, image.ErrFormat) {
+		return nil, ErrJPXUnsupported
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode JPEG2000: %w", err)
+	}
+	return img, nil
+}
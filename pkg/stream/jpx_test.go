@@ -0,0 +1,99 @@
+package stream
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// grayJ2K is a 4x2 8-bit grayscale JPEG2000 codestream with no wavelet
+// levels whose only packet is empty. Every coefficient is zero, so after
+// the DC level shift every sample is 128.
+var grayJ2K = []byte{
+	0xff, 0x4f, // SOC
+	0xff, 0x51, 0x00, 0x29, // SIZ, Lsiz 41
+	0x00, 0x00, // Rsiz
+	0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x02, // Xsiz, Ysiz
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // XOsiz, YOsiz
+	0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x02, // XTsiz, YTsiz
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // XTOsiz, YTOsiz
+	0x00, 0x01, // Csiz
+	0x07, 0x01, 0x01, // Ssiz 8-bit unsigned, XRsiz, YRsiz
+	0xff, 0x52, 0x00, 0x0c, // COD, Lcod 12
+	0x00,                   // Scod
+	0x00, 0x00, 0x01, 0x00, // LRCP, 1 layer, no component transform
+	0x00, 0x04, 0x04, 0x00, 0x01, // 0 levels, 64x64 code-blocks, 5-3 wavelet
+	0xff, 0x5c, 0x00, 0x04, // QCD, Lqcd 4
+	0x40, 0x40, // 2 guard bits, no quantization; LL exponent 8
+	0xff, 0x90, 0x00, 0x0a, // SOT, Lsot 10
+	0x00, 0x00, // Isot
+	0x00, 0x00, 0x00, 0x0f, // Psot 15
+	0x00, 0x01, // TPsot, TNsot
+	0xff, 0x93, // SOD
+	0x00,       // Empty packet
+	0xff, 0xd9, // EOC
+}
+
+func TestDecodeJPX(t *testing.T) {
+	img, err := DecodeJPX(grayJ2K)
+	if errors.Is(err, ErrJPXUnsupported) {
+		t.Skip("no JPEG2000 decoder registered")
+	}
+	if err != nil {
+		t.Fatalf("DecodeJPX: %v", err)
+	}
+
+	b := img.Bounds()
+	if b.Dx() != 4 || b.Dy() != 2 {
+		t.Fatalf("image is %dx%d, want 4x2", b.Dx(), b.Dy())
+	}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if g := color.GrayModel.Convert(img.At(x, y)).(color.Gray); g.Y != 128 {
+				t.Fatalf("pixel (%d, %d) = %d, want 128", x, y, g.Y)
+			}
+		}
+	}
+}
+
+func TestDecodeJPXInvalid(t *testing.T) {
+	if _, err := DecodeJPX([]byte("not a JPEG2000 image")); err == nil {
+		t.Error("DecodeJPX of non-JPEG2000 data succeeded")
+	}
+	if _, err := DecodeJPX(grayJ2K[:20]); err == nil {
+		t.Error("DecodeJPX of a truncated codestream succeeded")
+	}
+}
+
+func TestDecodeJPXUnsupported(t *testing.T) {
+	for _, data := range [][]byte{
+		grayJ2K,
+		append(append([]byte{}, jp2Signature...), 0x00, 0x00, 0x00, 0x08),
+	} {
+		_, err := DecodeJPX(data)
+		if err == nil {
+			t.Skip("a JPEG2000 decoder is registered")
+		}
+		if !errors.Is(err, ErrJPXUnsupported) {
+			t.Errorf("DecodeJPX(% x...) error = %v, want ErrJPXUnsupported", data[:4], err)
+		}
+	}
+}
+
+func TestDecodeJPXOtherFormat(t *testing.T) {
+	// PNG is registered with the image package, but is not JPEG2000
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	img, err := DecodeJPX(buf.Bytes())
+	if err == nil {
+		t.Fatalf("DecodeJPX of PNG data returned a %v image", img.Bounds())
+	}
+	if errors.Is(err, ErrJPXUnsupported) {
+		t.Errorf("DecodeJPX of PNG data error = %v, want a signature error", err)
+	}
+}
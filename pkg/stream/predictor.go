@@ -221,8 +221,21 @@ func abs(x int) int {
 	return x
 }
 
-// EncodePNGPredictor applies PNG predictor encoding (for creating PDFs).
+// EncodePNGPredictor applies predictor encoding (for creating PDFs), the
+// inverse of ApplyPredictor. For the PNG predictors (10-15) each row is
+// encoded with whichever filter gives the smallest sum of absolute values,
+// the same heuristic libpng uses; the decoder reads the filter type from
+// each row, so the particular value of 10-15 does not matter.
 func EncodePNGPredictor(data []byte, params DecodeParams) ([]byte, error) {
+	switch predictor := params.Predictor; {
+	case predictor == 1:
+		return data, nil // No predictor
+	case predictor == 2:
+		return encodeTIFFPredictor(data, params)
+	case predictor < 10 || predictor > 15:
+		return nil, fmt.Errorf("unsupported predictor: %d", predictor)
+	}
+	
 	columns := params.Columns
 	colors := params.Colors
 	bpc := params.BitsPerComponent
@@ -257,18 +270,9 @@ func EncodePNGPredictor(data []byte, params DecodeParams) ([]byte, error) {
 		
 		rowData := data[start:end]
 		
-		// Use Sub filter (type 1) - simple and often effective
-		encoded := make([]byte, len(rowData))
-		for i := 0; i < len(rowData); i++ {
-			if i < bytesPerPixel {
-				encoded[i] = rowData[i]
-			} else {
-				encoded[i] = rowData[i] - rowData[i-bytesPerPixel]
-			}
-		}
+		filterType, encoded := selectPNGFilter(rowData, prevRow, bytesPerPixel)
 		
-		// Add filter byte
-		result = append(result, 1) // Sub filter
+		result = append(result, filterType)
 		result = append(result, encoded...)
 		
 		copy(prevRow, rowData)
@@ -276,3 +280,104 @@ func EncodePNGPredictor(data []byte, params DecodeParams) ([]byte, error) {
 	
 	return result, nil
 }
+
+// encodeTIFFPredictor applies TIFF predictor 2 (horizontal differencing),
+// the inverse of applyTIFFPredictor. Like the decoder it only handles 8-bit
+// components.
+func encodeTIFFPredictor(data []byte, params DecodeParams) ([]byte, error) {
+	columns := params.Columns
+	colors := params.Colors
+	bpc := params.BitsPerComponent
+	
+	if columns == 0 {
+		columns = 1
+	}
+	if colors == 0 {
+		colors = 1
+	}
+	if bpc == 0 {
+		bpc = 8
+	}
+	
+	if bpc != 8 {
+		return nil, fmt.Errorf("TIFF predictor with %d bits per component is not supported", bpc)
+	}
+	
+	bytesPerRow := columns * colors
+	if len(data) == 0 {
+		return data, nil
+	}
+	
+	numRows := len(data) / bytesPerRow
+	result := make([]byte, len(data))
+	copy(result, data)
+	
+	// Work right to left so each sample is differenced against the
+	// original value of its left neighbour
+	for row := 0; row < numRows; row++ {
+		rowStart := row * bytesPerRow
+		for col := bytesPerRow - 1; col >= colors; col-- {
+			result[rowStart+col] -= result[rowStart+col-colors]
+		}
+	}
+	
+	return result, nil
+}
+
+// selectPNGFilter encodes a row with each of the five PNG filters and
+// returns the one with the lowest sum of absolute values, treating encoded
+// bytes as signed.
+func selectPNGFilter(row, prevRow []byte, bytesPerPixel int) (byte, []byte) {
+	var bestType byte
+	var best []byte
+	bestSum := -1
+	
+	for filterType := byte(0); filterType <= 4; filterType++ {
+		encoded := encodePNGFilter(row, prevRow, filterType, bytesPerPixel)
+		
+		sum := 0
+		for _, b := range encoded {
+			sum += abs(int(int8(b)))
+		}
+		
+		if bestSum < 0 || sum < bestSum {
+			bestType, best, bestSum = filterType, encoded, sum
+		}
+	}
+	
+	return bestType, best
+}
+
+// encodePNGFilter applies a single PNG filter to a row. It is the inverse
+// of applyPNGFilter.
+func encodePNGFilter(row, prevRow []byte, filterType byte, bytesPerPixel int) []byte {
+	encoded := make([]byte, len(row))
+	
+	for i := 0; i < len(row); i++ {
+		var a, b, c byte
+		if i >= bytesPerPixel {
+			a = row[i-bytesPerPixel]
+		}
+		if i < len(prevRow) {
+			b = prevRow[i]
+		}
+		if i >= bytesPerPixel && i-bytesPerPixel < len(prevRow) {
+			c = prevRow[i-bytesPerPixel]
+		}
+		
+		switch filterType {
+		case 0: // None
+			encoded[i] = row[i]
+		case 1: // Sub
+			encoded[i] = row[i] - a
+		case 2: // Up
+			encoded[i] = row[i] - b
+		case 3: // Average
+			encoded[i] = row[i] - byte((int(a)+int(b))/2)
+		case 4: // Paeth
+			encoded[i] = row[i] - paeth(a, b, c)
+		}
+	}
+	
+	return encoded
+}
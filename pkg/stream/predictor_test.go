@@ -0,0 +1,127 @@
+package stream
+
+import (
+	"bytes"
+	"compress/zlib"
+	"math/rand"
+	"testing"
+)
+
+// testImage returns RGB samples of a smooth gradient with noise and a few
+// hard edges, like a photograph with text on it.
+func testImage(width, height int) []byte {
+	rng := rand.New(rand.NewSource(1))
+	data := make([]byte, 0, width*height*3)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r := x * 255 / width
+			g := y * 255 / height
+			b := (x + y) * 127 / (width + height)
+			if (x/32+y/32)%5 == 0 {
+				r, g, b = 0, 0, 0
+			}
+			noise := rng.Intn(3) - 1
+			data = append(data, byte(r+noise), byte(g+noise), byte(b+noise))
+		}
+	}
+	return data
+}
+
+func TestPNGPredictorRoundTrip(t *testing.T) {
+	data := testImage(37, 11)
+	for _, predictor := range []int{1, 2, 10, 11, 12, 13, 14, 15} {
+		params := DecodeParams{Predictor: predictor, Colors: 3, BitsPerComponent: 8, Columns: 37}
+		encoded, err := EncodePNGPredictor(data, params)
+		if err != nil {
+			t.Fatalf("predictor %d: encode: %v", predictor, err)
+		}
+		decoded, err := ApplyPredictor(encoded, params)
+		if err != nil {
+			t.Fatalf("predictor %d: decode: %v", predictor, err)
+		}
+		if !bytes.Equal(decoded, data) {
+			t.Errorf("predictor %d: round trip changed the data", predictor)
+		}
+	}
+}
+
+func TestPNGPredictorSelectsFilterPerRow(t *testing.T) {
+	// A ramp is smallest under Sub; repeating it makes Up all zeros
+	data := []byte{
+		10, 20, 30, 40,
+		10, 20, 30, 40,
+	}
+	for predictor := 10; predictor <= 15; predictor++ {
+		params := DecodeParams{Predictor: predictor, Columns: 4}
+		encoded, err := EncodePNGPredictor(data, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if encoded[0] != 1 || encoded[5] != 2 {
+			t.Errorf("predictor %d: filter types = %d, %d, want 1 (Sub), 2 (Up)", predictor, encoded[0], encoded[5])
+		}
+	}
+}
+
+func TestEncodePredictorErrors(t *testing.T) {
+	for _, params := range []DecodeParams{
+		{Predictor: 3},
+		{Predictor: 9},
+		{Predictor: 16},
+		{Predictor: 2, BitsPerComponent: 4},
+	} {
+		if _, err := EncodePNGPredictor([]byte{1, 2, 3}, params); err == nil {
+			t.Errorf("%+v: expected an error", params)
+		}
+	}
+}
+
+// encodeFixedFilter encodes every row with the same PNG filter, for
+// comparison with EncodePNGPredictor's choice per row.
+func encodeFixedFilter(data []byte, rowSize, bytesPerPixel int, filterType byte) []byte {
+	result := make([]byte, 0, len(data)/rowSize*(rowSize+1))
+	prevRow := make([]byte, rowSize)
+	for start := 0; start+rowSize <= len(data); start += rowSize {
+		row := data[start : start+rowSize]
+		result = append(result, filterType)
+		result = append(result, encodePNGFilter(row, prevRow, filterType, bytesPerPixel)...)
+		prevRow = row
+	}
+	return result
+}
+
+// BenchmarkEncodePNGPredictor compares no filter and the Sub filter on
+// every row with choosing the filter per row, reporting the size after
+// Flate compression.
+func BenchmarkEncodePNGPredictor(b *testing.B) {
+	const width, height = 512, 512
+	data := testImage(width, height)
+	params := DecodeParams{Predictor: 15, Colors: 3, BitsPerComponent: 8, Columns: width}
+
+	for _, bench := range []struct {
+		name   string
+		encode func() ([]byte, error)
+	}{
+		{"None", func() ([]byte, error) { return encodeFixedFilter(data, width*3, 3, 0), nil }},
+		{"Sub", func() ([]byte, error) { return encodeFixedFilter(data, width*3, 3, 1), nil }},
+		{"Optimum", func() ([]byte, error) { return EncodePNGPredictor(data, params) }},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			var size int
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				encoded, err := bench.encode()
+				if err != nil {
+					b.Fatal(err)
+				}
+				compressed, err := EncodeFlateDecode(encoded, zlib.DefaultCompression)
+				if err != nil {
+					b.Fatal(err)
+				}
+				size = len(compressed)
+			}
+			b.ReportMetric(float64(size), "compressed-bytes")
+			b.ReportMetric(float64(size)/float64(len(data))*100, "%size")
+		})
+	}
+}
@@ -0,0 +1,382 @@
+//go:build ignore
+
+// gen writes the PDF fixtures in testdata. Run it with go generate from
+// pkg/testutil after changing a fixture.
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/md5"
+	"crypto/rc4"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// pdfObject is an indirect object: a dictionary or other value, and for
+// streams the stream data.
+type pdfObject struct {
+	body   string
+	stream []byte
+}
+
+// pdfFile builds a PDF with a classic cross-reference table. Objects are
+// numbered from 1 in the order they are added.
+type pdfFile struct {
+	version string
+	objects []pdfObject
+	trailer string
+}
+
+func (f *pdfFile) add(body string) int {
+	f.objects = append(f.objects, pdfObject{body: body})
+	return len(f.objects)
+}
+
+func (f *pdfFile) addStream(dict string, data []byte) int {
+	f.objects = append(f.objects, pdfObject{body: dict, stream: data})
+	return len(f.objects)
+}
+
+// streamDict adds the Length entry to a stream dictionary body.
+func streamDict(dict string, length int) string {
+	return strings.TrimSuffix(dict, ">>") + fmt.Sprintf(" /Length %d >>", length)
+}
+
+func (f *pdfFile) bytes() []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%%PDF-%s\n%%\xe2\xe3\xcf\xd3\n", f.version)
+	offsets := make([]int, len(f.objects))
+	for i, obj := range f.objects {
+		offsets[i] = buf.Len()
+		writeObject(&buf, i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(f.objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d %s >>\nstartxref\n%d\n%%%%EOF\n", len(f.objects)+1, f.trailer, xref)
+	return buf.Bytes()
+}
+
+func writeObject(buf *bytes.Buffer, num int, obj pdfObject) {
+	fmt.Fprintf(buf, "%d 0 obj\n", num)
+	if obj.stream == nil {
+		fmt.Fprintf(buf, "%s\nendobj\n", obj.body)
+		return
+	}
+	fmt.Fprintf(buf, "%s\nstream\n", streamDict(obj.body, len(obj.stream)))
+	buf.Write(obj.stream)
+	buf.WriteString("\nendstream\nendobj\n")
+}
+
+func deflate(data []byte) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
+
+const helvetica = "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>"
+
+// textPages adds a catalog and a single Letter page showing text, with
+// extra appended to the page dictionary.
+func textPages(f *pdfFile, text string, extra string) {
+	f.add("<< /Type /Catalog /Pages 4 0 R >>")
+	f.add(helvetica)
+	f.addStream("<< >>", []byte(fmt.Sprintf("BT /F1 24 Tf 72 700 Td (%s) Tj ET", text)))
+	f.add("<< /Type /Pages /Kids [5 0 R] /Count 1 >>")
+	f.add("<< /Type /Page /Parent 4 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 2 0 R >> >> /Contents 3 0 R" + extra + " >>")
+	f.trailer = "/Root 1 0 R"
+}
+
+func simpleText() []byte {
+	f := &pdfFile{version: "1.4"}
+	textPages(f, "Hello, world", "")
+	f.add("<< /Title (Simple text) /Producer (gumgum testutil) >>")
+	f.trailer += " /Info 6 0 R"
+	return f.bytes()
+}
+
+func rotated() []byte {
+	f := &pdfFile{version: "1.4"}
+	textPages(f, "Rotated page", " /Rotate 90")
+	return f.bytes()
+}
+
+func images() []byte {
+	f := &pdfFile{version: "1.4"}
+	f.add("<< /Type /Catalog /Pages 2 0 R >>")
+	f.add("<< /Type /Pages /Kids [3 0 R] /Count 1 >>")
+	f.add("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 200 200] /Resources << /XObject << /Im1 4 0 R >> >> /Contents 5 0 R >>")
+	// A 2x2 image: red, green, blue and white
+	pixels := []byte{255, 0, 0, 0, 255, 0, 0, 0, 255, 255, 255, 255}
+	f.addStream("<< /Type /XObject /Subtype /Image /Width 2 /Height 2 /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode >>", deflate(pixels))
+	f.addStream("<< >>", []byte("q 100 0 0 100 50 50 cm /Im1 Do Q"))
+	f.trailer = "/Root 1 0 R"
+	return f.bytes()
+}
+
+// photo draws a 1024x768 JPEG across a Letter page.
+func photo() []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 1024, 768))
+	for y := 0; y < 768; y++ {
+		for x := 0; x < 1024; x++ {
+			img.Set(x, y, color.RGBA{uint8(x / 4), uint8(y / 3), uint8((x + y) % 256), 255})
+		}
+	}
+	var data bytes.Buffer
+	if err := jpeg.Encode(&data, img, &jpeg.Options{Quality: 80}); err != nil {
+		log.Fatal(err)
+	}
+
+	f := &pdfFile{version: "1.4"}
+	f.add("<< /Type /Catalog /Pages 2 0 R >>")
+	f.add("<< /Type /Pages /Kids [3 0 R] /Count 1 >>")
+	f.add("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /XObject << /Im1 4 0 R >> >> /Contents 5 0 R >>")
+	f.addStream("<< /Type /XObject /Subtype /Image /Width 1024 /Height 768 /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /DCTDecode >>", data.Bytes())
+	f.addStream("<< >>", []byte("q 540 0 0 405 36 350 cm /Im1 Do Q"))
+	f.trailer = "/Root 1 0 R"
+	return f.bytes()
+}
+
+// textHeavy fills a Letter page with 60 lines of 20 words, each shown
+// with its own Tj.
+func textHeavy() []byte {
+	var content bytes.Buffer
+	content.WriteString("BT /F1 9 Tf 11 TL 36 756 Td\n")
+	for line := 0; line < 60; line++ {
+		for word := 0; word < 20; word++ {
+			fmt.Fprintf(&content, "(w%02d.%02d ) Tj\n", line, word)
+		}
+		content.WriteString("T*\n")
+	}
+	content.WriteString("ET")
+
+	f := &pdfFile{version: "1.4"}
+	f.add("<< /Type /Catalog /Pages 4 0 R >>")
+	f.add(helvetica)
+	f.addStream("<< /Filter /FlateDecode >>", deflate(content.Bytes()))
+	f.add("<< /Type /Pages /Kids [5 0 R] /Count 1 >>")
+	f.add("<< /Type /Page /Parent 4 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 2 0 R >> >> /Contents 3 0 R >>")
+	f.trailer = "/Root 1 0 R"
+	return f.bytes()
+}
+
+func form() []byte {
+	f := &pdfFile{version: "1.4"}
+	f.add("<< /Type /Catalog /Pages 2 0 R /AcroForm << /Fields [4 0 R] /DA (/Helv 12 Tf 0 g) /DR << /Font << /Helv 6 0 R >> >> >> >>")
+	f.add("<< /Type /Pages /Kids [3 0 R] /Count 1 >>")
+	f.add("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Annots [4 0 R] >>")
+	f.add("<< /Type /Annot /Subtype /Widget /FT /Tx /T (name) /V (Jane Doe) /Rect [72 700 272 724] /F 4 /P 3 0 R /AP << /N 5 0 R >> >>")
+	f.addStream("<< /Type /XObject /Subtype /Form /BBox [0 0 200 24] /Resources << /Font << /Helv 6 0 R >> >> >>",
+		[]byte("/Tx BMC BT /Helv 12 Tf 0 g 2 7 Td (Jane Doe) Tj ET EMC"))
+	f.add(helvetica)
+	f.trailer = "/Root 1 0 R"
+	return f.bytes()
+}
+
+// padding is the password padding string of the standard security handler.
+var padding = []byte{
+	0x28, 0xBF, 0x4E, 0x5E, 0x4E, 0x75, 0x8A, 0x41, 0x64, 0x00, 0x4E, 0x56, 0xFF, 0xFA, 0x01, 0x08,
+	0x2E, 0x2E, 0x00, 0xB6, 0xD0, 0x68, 0x3E, 0x80, 0x2F, 0x0C, 0xA9, 0xFE, 0x64, 0x53, 0x69, 0x7A,
+}
+
+func padPassword(password string) []byte {
+	return append([]byte(password), padding...)[:32]
+}
+
+func rc4Crypt(key, data []byte) []byte {
+	c, err := rc4.NewCipher(key)
+	if err != nil {
+		log.Fatal(err)
+	}
+	out := make([]byte, len(data))
+	c.XORKeyStream(out, data)
+	return out
+}
+
+// encrypted is encrypted with 40-bit RC4 (revision 2), an empty user
+// password and the owner password "owner".
+func encrypted() []byte {
+	fileID := []byte("gumgum-testutil!")
+	perms := int32(-4) // Everything allowed
+
+	ownerKey := md5.Sum(padPassword("owner"))
+	o := rc4Crypt(ownerKey[:5], padPassword(""))
+
+	h := md5.New()
+	h.Write(padPassword(""))
+	h.Write(o)
+	binary.Write(h, binary.LittleEndian, perms)
+	h.Write(fileID)
+	key := h.Sum(nil)[:5]
+	u := rc4Crypt(key, padding)
+
+	objectKey := func(num int) []byte {
+		sum := md5.Sum(append(append([]byte{}, key...), byte(num), byte(num>>8), byte(num>>16), 0, 0))
+		return sum[:10]
+	}
+
+	f := &pdfFile{version: "1.4"}
+	f.add("<< /Type /Catalog /Pages 4 0 R >>")
+	f.add(helvetica)
+	f.addStream("<< >>", rc4Crypt(objectKey(3), []byte("BT /F1 24 Tf 72 700 Td (Encrypted) Tj ET")))
+	f.add("<< /Type /Pages /Kids [5 0 R] /Count 1 >>")
+	f.add("<< /Type /Page /Parent 4 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 2 0 R >> >> /Contents 3 0 R >>")
+	f.add(fmt.Sprintf("<< /Title <%x> >>", rc4Crypt(objectKey(6), []byte("Encrypted"))))
+	f.add(fmt.Sprintf("<< /Filter /Standard /V 1 /R 2 /O <%x> /U <%x> /P %d >>", o, u, perms))
+	f.trailer = fmt.Sprintf("/Root 1 0 R /Info 6 0 R /Encrypt 7 0 R /ID [<%x> <%x>]", fileID, fileID)
+	return f.bytes()
+}
+
+// linearized is laid out like a linearized file: the linearization
+// dictionary and the first-page cross-reference section come first, then
+// the objects of the first page, then the rest of the document and the
+// main cross-reference section, which the first-page trailer names as
+// Prev. The hint stream is a placeholder; readers only use the dictionary.
+func linearized() []byte {
+	// Objects 2 to 9: object 1 is the linearization dictionary and object 2
+	// the hint stream. Objects 1 to 7 are in the first-page section.
+	objects := []pdfObject{
+		{body: "<< /S 0 >>", stream: make([]byte, 16)},
+		{body: "<< /Type /Catalog /Pages 4 0 R >>"},
+		{body: "<< /Type /Pages /Kids [5 0 R 8 0 R] /Count 2 >>"},
+		{body: "<< /Type /Page /Parent 4 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 7 0 R >> >> /Contents 6 0 R >>"},
+		{body: "<< >>", stream: []byte("BT /F1 24 Tf 72 700 Td (First page) Tj ET")},
+		{body: helvetica},
+		{body: "<< /Type /Page /Parent 4 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 7 0 R >> >> /Contents 9 0 R >>"},
+		{body: "<< >>", stream: []byte("BT /F1 24 Tf 72 700 Td (Second page) Tj ET")},
+	}
+	const firstPageObjects = 7
+
+	// Each pass writes the offsets found by the one before; fixed-width
+	// numbers keep them from moving, so three passes settle
+	type layout struct {
+		offsets      [10]int // Offset of each object, by number
+		length       int
+		firstPageEnd int
+		firstXref    int
+		mainXref     int
+	}
+	build := func(l layout) ([]byte, layout) {
+		var out layout
+		var buf bytes.Buffer
+		buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+		out.offsets[1] = buf.Len()
+		writeObject(&buf, 1, pdfObject{body: fmt.Sprintf(
+			"<< /Linearized 1 /L %010d /H [ 0000000000 0000000000 ] /O 5 /E %010d /N 2 /T %010d >>",
+			l.length, l.firstPageEnd, l.mainXref)})
+
+		out.firstXref = buf.Len()
+		fmt.Fprintf(&buf, "xref\n1 %d\n", firstPageObjects)
+		for num := 1; num <= firstPageObjects; num++ {
+			fmt.Fprintf(&buf, "%010d 00000 n \n", l.offsets[num])
+		}
+		fmt.Fprintf(&buf, "trailer\n<< /Size 10 /Prev %010d /Root 3 0 R >>\nstartxref\n0\n%%%%EOF\n", l.mainXref)
+
+		for i, obj := range objects {
+			num := i + 2
+			if num == firstPageObjects+1 {
+				out.firstPageEnd = buf.Len()
+			}
+			out.offsets[num] = buf.Len()
+			writeObject(&buf, num, obj)
+		}
+
+		out.mainXref = buf.Len()
+		fmt.Fprintf(&buf, "xref\n0 1\n0000000000 65535 f \n%d %d\n", firstPageObjects+1, len(objects)+1-firstPageObjects)
+		for num := firstPageObjects + 1; num <= len(objects)+1; num++ {
+			fmt.Fprintf(&buf, "%010d 00000 n \n", l.offsets[num])
+		}
+		fmt.Fprintf(&buf, "trailer\n<< /Size 10 >>\nstartxref\n%d\n%%%%EOF\n", l.firstXref)
+		out.length = buf.Len()
+		return buf.Bytes(), out
+	}
+
+	var data []byte
+	var l layout
+	for pass := 0; pass < 3; pass++ {
+		data, l = build(l)
+	}
+	return data
+}
+
+// xrefStream stores its cross-reference table in a stream and most objects
+// in an object stream.
+func xrefStream() []byte {
+	compressed := []string{
+		"<< /Type /Catalog /Pages 3 0 R >>",
+		helvetica,
+		"<< /Type /Pages /Kids [4 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 3 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 2 0 R >> >> /Contents 5 0 R >>",
+	}
+	var header, body bytes.Buffer
+	for i, obj := range compressed {
+		fmt.Fprintf(&header, "%d %d ", i+1, body.Len())
+		body.WriteString(obj + "\n")
+	}
+	first := header.Len()
+	objStm := append(header.Bytes(), body.Bytes()...)
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.5\n%\xe2\xe3\xcf\xd3\n")
+	contentOffset := buf.Len()
+	writeObject(&buf, 5, pdfObject{body: "<< >>", stream: []byte("BT /F1 24 Tf 72 700 Td (Cross-reference stream) Tj ET")})
+	objStmOffset := buf.Len()
+	writeObject(&buf, 6, pdfObject{
+		body:   fmt.Sprintf("<< /Type /ObjStm /N %d /First %d /Filter /FlateDecode >>", len(compressed), first),
+		stream: deflate(objStm),
+	})
+
+	// Entries are type (1 byte), offset or object stream (4), index (2)
+	xrefOffset := buf.Len()
+	var xref bytes.Buffer
+	entry := func(typ byte, field2 uint32, field3 uint16) {
+		xref.WriteByte(typ)
+		binary.Write(&xref, binary.BigEndian, field2)
+		binary.Write(&xref, binary.BigEndian, field3)
+	}
+	entry(0, 0, 65535)
+	for i := range compressed {
+		entry(2, 6, uint16(i))
+	}
+	entry(1, uint32(contentOffset), 0)
+	entry(1, uint32(objStmOffset), 0)
+	entry(1, uint32(xrefOffset), 0)
+	writeObject(&buf, 7, pdfObject{
+		body:   "<< /Type /XRef /Size 8 /W [1 4 2] /Root 1 0 R /Filter /FlateDecode >>",
+		stream: deflate(xref.Bytes()),
+	})
+	fmt.Fprintf(&buf, "startxref\n%d\n%%%%EOF\n", xrefOffset)
+	return buf.Bytes()
+}
+
+func main() {
+	fixtures := map[string][]byte{
+		"simple-text.pdf": simpleText(),
+		"images.pdf":      images(),
+		"photo.pdf":       photo(),
+		"text-heavy.pdf":  textHeavy(),
+		"form.pdf":        form(),
+		"rotated.pdf":     rotated(),
+		"encrypted.pdf":   encrypted(),
+		"linearized.pdf":  linearized(),
+		"xref-stream.pdf": xrefStream(),
+	}
+	for name, data := range fixtures {
+		if err := os.WriteFile(filepath.Join("testdata", name), data, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Package testutil provides small PDF fixtures for tests. The fixtures are
+// generated by gen.go and embedded in the package, so tests in any package
+// can use them without depending on files outside the module.
+package testutil
+
+//go:generate go run gen.go
+
+import (
+	"embed"
+	"io/fs"
+	"sort"
+	"strings"
+	"testing"
+
+	"gumgum/pkg/api"
+)
+
+//go:embed testdata/*.pdf
+var fixtures embed.FS
+
+// Fixture names.
+const (
+	SimpleText = "simple-text.pdf" // One page of Helvetica text with an Info dictionary
+	Images     = "images.pdf"      // One page drawing a 2x2 RGB image
+	Form       = "form.pdf"        // A text field widget with an appearance stream
+	Rotated    = "rotated.pdf"     // One page with /Rotate 90
+	Encrypted  = "encrypted.pdf"   // 40-bit RC4 with an empty user password
+	Linearized = "linearized.pdf"  // Two pages with a linearization dictionary
+	XrefStream = "xref-stream.pdf" // Cross-reference stream and object stream
+	Photo      = "photo.pdf"       // One page drawing a 1024x768 JPEG
+	TextHeavy  = "text-heavy.pdf"  // One page of 1200 Tj operators
+)
+
+// Fixtures returns the names of all fixtures in sorted order.
+func Fixtures() []string {
+	entries, err := fs.ReadDir(fixtures, "testdata")
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".pdf") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FixtureBytes returns the contents of a fixture, failing the test if it
+// does not exist.
+func FixtureBytes(t testing.TB, name string) []byte {
+	t.Helper()
+	data, err := fixtures.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatalf("fixture %s: %v", name, err)
+	}
+	return data
+}
+
+// OpenFixture opens a fixture as a Document, failing the test on error.
+func OpenFixture(t testing.TB, name string) *api.Document {
+	t.Helper()
+	doc, err := api.OpenBytes(FixtureBytes(t, name))
+	if err != nil {
+		t.Fatalf("failed to open fixture %s: %v", name, err)
+	}
+	return doc
+}
@@ -0,0 +1,90 @@
+package testutil_test
+
+import (
+	"testing"
+
+	"gumgum/pkg/api"
+	"gumgum/pkg/testutil"
+)
+
+// fixtureTests describes what each fixture should look like once opened.
+var fixtureTests = []struct {
+	name     string
+	pages    int
+	width    float64 // Of the first page as displayed, in points
+	height   float64
+	rotation int
+	title    string
+}{
+	{testutil.SimpleText, 1, 612, 792, 0, "Simple text"},
+	{testutil.Images, 1, 200, 200, 0, ""},
+	{testutil.Form, 1, 612, 792, 0, ""},
+	{testutil.Rotated, 1, 792, 612, 90, ""},
+	{testutil.Encrypted, 1, 612, 792, 0, "Encrypted"},
+	{testutil.Linearized, 2, 612, 792, 0, ""},
+	{testutil.XrefStream, 1, 612, 792, 0, ""},
+	{testutil.Photo, 1, 612, 792, 0, ""},
+	{testutil.TextHeavy, 1, 612, 792, 0, ""},
+}
+
+func TestFixturesListed(t *testing.T) {
+	names := testutil.Fixtures()
+	if len(names) != len(fixtureTests) {
+		t.Fatalf("Fixtures() = %v, want %d fixtures", names, len(fixtureTests))
+	}
+	for _, tt := range fixtureTests {
+		found := false
+		for _, name := range names {
+			found = found || name == tt.name
+		}
+		if !found {
+			t.Errorf("fixture %s is not listed", tt.name)
+		}
+	}
+}
+
+func TestFixtures(t *testing.T) {
+	for _, tt := range fixtureTests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := testutil.OpenFixture(t, tt.name)
+			defer doc.Close()
+
+			if got := doc.PageCount(); got != tt.pages {
+				t.Fatalf("PageCount() = %d, want %d", got, tt.pages)
+			}
+			if got := doc.Info().Title; got != tt.title {
+				t.Errorf("Info().Title = %q, want %q", got, tt.title)
+			}
+
+			page, err := doc.Page(0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page.Width() != tt.width || page.Height() != tt.height {
+				t.Errorf("page size = %vx%v, want %vx%v", page.Width(), page.Height(), tt.width, tt.height)
+			}
+			if got := page.Rotation(); got != tt.rotation {
+				t.Errorf("Rotation() = %d, want %d", got, tt.rotation)
+			}
+
+			for pageNum := 0; pageNum < tt.pages; pageNum++ {
+				img, err := doc.RenderWithOptions(pageNum, api.NewRenderOptions(api.DPI(36)))
+				if err != nil {
+					t.Fatalf("page %d: %v", pageNum, err)
+				}
+				if img.Bounds().Empty() {
+					t.Errorf("page %d rendered to an empty image", pageNum)
+				}
+			}
+		})
+	}
+}
+
+func TestFixtureBytesAreValid(t *testing.T) {
+	for _, tt := range fixtureTests {
+		data := testutil.FixtureBytes(t, tt.name)
+		if len(data) < 8 || string(data[:5]) != "%PDF-" {
+			t.Errorf("%s does not start with a PDF header", tt.name)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+// Package textextract extracts text from PDF pages along with its position,
+// and groups it into lines, words and paragraphs.
+package textextract
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"gumgum/pkg/cos"
+	"gumgum/pkg/graphics"
+	"gumgum/pkg/raster"
+)
+
+// TextSpan is the text shown by one text-showing operator. Positions are
+// in default user space, in points with the origin at the bottom-left of
+// the page: X, Y is the start of the baseline.
+type TextSpan struct {
+	Text     string
+	X, Y     float64
+	Width    float64
+	Height   float64
+	FontSize float64 // Font size scaled by the text and current matrices
+	FontName string  // Font resource name
+
+	// Rect bounds the glyphs from their descent to their ascent, and
+	// DeviceRect is the same box in the pixels of the page rendered at the
+	// extractor's DPI.
+	Rect       graphics.Rect
+	DeviceRect image.Rectangle
+
+	index  int // Position in the extractor's spans
+	glyphs []glyphBox
+}
+
+// glyphBox is the text and horizontal extent of one glyph in a span.
+type glyphBox struct {
+	text   string
+	x0, x1 float64
+}
+
+// Ascent and descent of glyph boxes as fractions of the font size, since
+// font metrics are not read.
+const (
+	ascent  = 0.8
+	descent = 0.2
+)
+
+// Default layout parameters, as fractions of the font size.
+const (
+	DefaultLineTolerance = 0.5
+	DefaultWordGap       = 0.2
+	DefaultParagraphGap  = 0.8
+)
+
+// DefaultDPI matches the renderer's default resolution.
+const DefaultDPI = 150
+
+// maxFormDepth limits how deeply form XObjects may draw other forms.
+const maxFormDepth = 16
+
+// Extractor collects the text shown on a page.
+type Extractor struct {
+	// LineTolerance is how far apart the baselines of spans on one line may
+	// be, as a fraction of the font size.
+	LineTolerance float64
+
+	// WordGap is the horizontal gap between glyphs, as a fraction of the
+	// font size, that separates words.
+	WordGap float64
+
+	// ParagraphGap is the vertical gap between lines, as a fraction of the
+	// font size, that separates paragraphs.
+	ParagraphGap float64
+
+	// DPI is the resolution device rectangles are computed for. It must be
+	// set before ExtractPage.
+	DPI float64
+
+	// Page height in page units and pixels per unit, for device rectangles
+	pageHeight float64
+	scale      float64
+
+	reader *cos.Reader
+	spans  []TextSpan
+	fonts  map[*cos.Reference]*font
+}
+
+// NewExtractor creates an Extractor for pages of a document.
+func NewExtractor(reader *cos.Reader) *Extractor {
+	return &Extractor{
+		LineTolerance: DefaultLineTolerance,
+		WordGap:       DefaultWordGap,
+		ParagraphGap:  DefaultParagraphGap,
+		DPI:           DefaultDPI,
+		reader:        reader,
+		fonts:         make(map[*cos.Reference]*font),
+	}
+}
+
+// ExtractPage runs the content stream of a page, adding the text it shows
+// to the spans already collected.
+func (e *Extractor) ExtractPage(page cos.Dict) error {
+	contents, err := e.reader.GetPageContents(page)
+	if err != nil {
+		return fmt.Errorf("failed to get page contents: %w", err)
+	}
+	resources, err := e.reader.GetPageResources(page)
+	if err != nil {
+		return fmt.Errorf("failed to get page resources: %w", err)
+	}
+
+	// Device space matches the renderer: the top-left corner of the
+	// MediaBox is the origin, scaled by the DPI and UserUnit
+	initial := graphics.NewState()
+	e.pageHeight = 792
+	if mediaBox, err := e.reader.ResolveArray(page.Get("MediaBox")); err == nil && len(mediaBox) >= 4 {
+		x1, y1 := number(e.reader, mediaBox[0]), number(e.reader, mediaBox[1])
+		e.pageHeight = number(e.reader, mediaBox[3]) - y1
+		initial.CTM = graphics.Translate(-x1, -y1)
+	}
+	e.scale = e.DPI / 72 * raster.PageUserUnit(page)
+
+	return e.run(contents, resources, initial, 0)
+}
+
+// Spans returns the spans collected in content stream order.
+func (e *Extractor) Spans() []TextSpan {
+	return e.spans
+}
+
+// run executes a content stream, collecting its text.
+func (e *Extractor) run(contents []byte, resources cos.Dict, initial *graphics.State, depth int) error {
+	fonts, _ := e.reader.ResolveDict(resources.Get("Font"))
+	xobjects, _ := e.reader.ResolveDict(resources.Get("XObject"))
+
+	interp := graphics.NewInterpreter()
+	interp.SetState(initial)
+
+	interp.OnText = func(text string, state *graphics.State) {
+		e.showText(text, state, e.font(fonts.Get(state.TextState.FontName)))
+	}
+	interp.OnImage = func(name string, state *graphics.State) {
+		if depth >= maxFormDepth {
+			return
+		}
+		obj, err := e.reader.Resolve(xobjects.Get(name))
+		form, ok := obj.(*cos.Stream)
+		if err != nil || !ok {
+			return
+		}
+		if subtype, _ := form.Dict.GetName("Subtype"); subtype != "Form" {
+			return
+		}
+		data, err := e.reader.DecodeStream(form)
+		if err != nil {
+			return
+		}
+		formResources, err := e.reader.ResolveDict(form.Dict.Get("Resources"))
+		if err != nil {
+			formResources = resources
+		}
+		formState := graphics.NewState()
+		formState.CTM = formMatrix(e.reader, form).Multiply(state.CTM)
+		e.run(data, formResources, formState, depth+1)
+	}
+
+	return interp.ExecuteStream(contents)
+}
+
+// font returns the decoder for a font resource, caching fonts that are
+// indirect objects.
+func (e *Extractor) font(obj cos.Object) *font {
+	ref, isRef := obj.(*cos.Reference)
+	if isRef {
+		if f, ok := e.fonts[ref]; ok {
+			return f
+		}
+	}
+	dict, err := e.reader.ResolveDict(obj)
+	if err != nil {
+		return nil
+	}
+	f := loadFont(e.reader, dict)
+	if isRef {
+		e.fonts[ref] = f
+	}
+	return f
+}
+
+// showText records a shown string as a span and advances the text matrix
+// past it.
+func (e *Extractor) showText(text string, state *graphics.State, f *font) {
+	ts := &state.TextState
+	hScale := ts.HScale / 100
+	if f == nil {
+		f = &font{widthScale: 0.001, missingWidth: defaultWidth}
+	}
+
+	textSpace := graphics.Matrix{ts.FontSize * hScale, 0, 0, ts.FontSize, 0, ts.Rise}
+	start := textSpace.Multiply(ts.TextMatrix).Multiply(state.CTM)
+	span := TextSpan{
+		FontName: ts.FontName,
+		FontSize: ts.FontSize * ts.TextMatrix.Multiply(state.CTM).ScaleY(),
+	}
+	span.X, span.Y = start.Transform(0, 0)
+
+	var textBuilder []byte
+	for _, g := range f.decode(text) {
+		trm := textSpace.Multiply(ts.TextMatrix).Multiply(state.CTM)
+		x0, _ := trm.Transform(0, 0)
+
+		tx := g.width*ts.FontSize + ts.CharSpace
+		if g.space {
+			tx += ts.WordSpace
+		}
+		ts.TextMatrix = graphics.Translate(tx*hScale, 0).Multiply(ts.TextMatrix)
+
+		x1, _ := textSpace.Multiply(ts.TextMatrix).Multiply(state.CTM).Transform(0, 0)
+		span.glyphs = append(span.glyphs, glyphBox{text: g.text, x0: x0, x1: x1})
+		textBuilder = append(textBuilder, g.text...)
+	}
+
+	end := textSpace.Multiply(ts.TextMatrix).Multiply(state.CTM)
+	endX, endY := end.Transform(0, 0)
+	dx, dy := endX-span.X, endY-span.Y
+	span.Width = math.Hypot(dx, dy)
+	span.Height = span.FontSize
+	span.Text = string(textBuilder)
+	span.Rect = graphics.NewRect(span.X, span.Y-descent*span.FontSize, endX, endY+ascent*span.FontSize)
+	span.DeviceRect = e.deviceRect(span.Rect)
+
+	// Invisible text, such as an OCR layer, is kept
+	if span.Text != "" {
+		span.index = len(e.spans)
+		e.spans = append(e.spans, span)
+	}
+}
+
+// deviceRect converts a rectangle in default user space to the smallest
+// pixel rectangle covering it in the rendered page.
+func (e *Extractor) deviceRect(r graphics.Rect) image.Rectangle {
+	return image.Rect(
+		int(math.Floor(r.X*e.scale)),
+		int(math.Floor((e.pageHeight-r.Y-r.Height)*e.scale)),
+		int(math.Ceil((r.X+r.Width)*e.scale)),
+		int(math.Ceil((e.pageHeight-r.Y)*e.scale)),
+	)
+}
+
+// formMatrix returns the Matrix entry of a form, or the identity if it has
+// none.
+func formMatrix(reader *cos.Reader, form *cos.Stream) graphics.Matrix {
+	m, err := reader.ResolveArray(form.Dict.Get("Matrix"))
+	if err != nil || len(m) < 6 {
+		return graphics.Identity()
+	}
+	var matrix graphics.Matrix
+	for i := range matrix {
+		matrix[i] = number(reader, m[i])
+	}
+	return matrix
+}
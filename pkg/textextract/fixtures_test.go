@@ -0,0 +1,57 @@
+package textextract_test
+
+import (
+	"testing"
+
+	"gumgum/pkg/cos"
+	"gumgum/pkg/testutil"
+	"gumgum/pkg/textextract"
+)
+
+func TestFixtureSpans(t *testing.T) {
+	// Every text fixture shows one string in 24 point Helvetica with its
+	// baseline starting at (72, 700)
+	tests := []struct {
+		name    string
+		pageNum int
+		text    string
+	}{
+		{testutil.SimpleText, 0, "Hello, world"},
+		{testutil.Rotated, 0, "Rotated page"},
+		{testutil.Encrypted, 0, "Encrypted"},
+		{testutil.Linearized, 1, "Second page"},
+		{testutil.XrefStream, 0, "Cross-reference stream"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := cos.NewReader(testutil.FixtureBytes(t, tt.name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			page, err := reader.GetPage(tt.pageNum)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			extractor := textextract.NewExtractor(reader)
+			if err := extractor.ExtractPage(page); err != nil {
+				t.Fatal(err)
+			}
+			spans := extractor.Spans()
+			if len(spans) != 1 {
+				t.Fatalf("extracted %d spans, want 1: %+v", len(spans), spans)
+			}
+
+			span := spans[0]
+			if span.Text != tt.text {
+				t.Errorf("Text = %q, want %q", span.Text, tt.text)
+			}
+			if span.FontSize != 24 || span.X != 72 || span.Y != 700 {
+				t.Errorf("span at (%v, %v) size %v, want (72, 700) size 24", span.X, span.Y, span.FontSize)
+			}
+			if span.Width <= 0 {
+				t.Errorf("Width = %v, want a positive width", span.Width)
+			}
+		})
+	}
+}
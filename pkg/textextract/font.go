@@ -0,0 +1,124 @@
+package textextract
+
+import (
+	"gumgum/pkg/cos"
+)
+
+// font decodes the strings shown in one font into text and advances.
+type font struct {
+	type0     *cos.Type0Font // nil for simple fonts
+	toUnicode *cos.ToUnicode // nil if the font has no ToUnicode CMap
+	encoding  map[byte]string
+
+	firstChar    int
+	widths       []float64
+	missingWidth float64
+	widthScale   float64 // Text space units per width unit
+}
+
+// glyph is one character code shown in a font.
+type glyph struct {
+	text  string
+	width float64 // Advance in text space for a font size of 1
+	space bool    // Single-byte code 32, which word spacing applies to
+}
+
+// defaultWidth is the advance, in thousandths of text space, used for
+// simple fonts without widths, such as the standard 14 fonts.
+const defaultWidth = 500
+
+// loadFont reads what is needed to decode text from a font dictionary.
+func loadFont(reader *cos.Reader, dict cos.Dict) *font {
+	f := &font{widthScale: 0.001, missingWidth: defaultWidth}
+	f.toUnicode, _ = reader.FontToUnicode(dict)
+
+	subtype, _ := dict.GetName("Subtype")
+	if subtype == "Type0" {
+		if t0, err := reader.Type0Font(dict); err == nil {
+			f.type0 = t0
+			return f
+		}
+	}
+
+	f.encoding, _ = cos.ParseFontEncoding(dict, reader)
+	if subtype == "Type3" {
+		if matrix, err := reader.ResolveArray(dict.Get("FontMatrix")); err == nil && len(matrix) >= 1 {
+			f.widthScale = number(reader, matrix[0])
+		}
+		f.missingWidth = 0
+	}
+	if first, ok := dict.GetInt("FirstChar"); ok {
+		f.firstChar = int(first)
+	}
+	if widths, err := reader.ResolveArray(dict.Get("Widths")); err == nil {
+		for _, w := range widths {
+			f.widths = append(f.widths, number(reader, w))
+		}
+	}
+	if descriptor, err := reader.ResolveDict(dict.Get("FontDescriptor")); err == nil {
+		if w, ok := descriptor.GetReal("MissingWidth"); ok {
+			f.missingWidth = w
+		}
+	}
+	return f
+}
+
+// decode splits a shown string into glyphs.
+func (f *font) decode(s string) []glyph {
+	data := []byte(s)
+	if f.type0 != nil {
+		codes := f.type0.CMap.Decode(data)
+		glyphs := make([]glyph, len(codes))
+		for i, code := range codes {
+			glyphs[i] = glyph{
+				text:  f.text(code.Code),
+				width: f.type0.Width(code.CID) / 1000,
+				space: code.Length == 1 && code.Code == ' ',
+			}
+		}
+		return glyphs
+	}
+
+	glyphs := make([]glyph, len(data))
+	for i, code := range data {
+		w := f.missingWidth
+		if index := int(code) - f.firstChar; index >= 0 && index < len(f.widths) {
+			w = f.widths[index]
+		}
+		glyphs[i] = glyph{
+			text:  f.text(uint32(code)),
+			width: w * f.widthScale,
+			space: code == ' ',
+		}
+	}
+	return glyphs
+}
+
+// text returns the text of a character code, from the ToUnicode CMap if
+// the font has one and otherwise from the glyph name in its encoding.
+func (f *font) text(code uint32) string {
+	if f.toUnicode != nil {
+		if s, ok := f.toUnicode.Text(code); ok {
+			return s
+		}
+	}
+	if f.type0 == nil && code < 256 {
+		return cos.GlyphText(f.encoding[byte(code)])
+	}
+	return ""
+}
+
+// number resolves an integer or real to a float64.
+func number(reader *cos.Reader, obj cos.Object) float64 {
+	resolved, err := reader.Resolve(obj)
+	if err != nil {
+		return 0
+	}
+	switch v := resolved.(type) {
+	case cos.Integer:
+		return float64(v)
+	case cos.Real:
+		return float64(v)
+	}
+	return 0
+}
@@ -0,0 +1,176 @@
+package textextract
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// TextLine is a run of spans sharing a baseline, ordered left to right.
+type TextLine struct {
+	Text     string
+	Spans    []TextSpan
+	X, Y     float64 // Start of the baseline
+	Width    float64
+	Height   float64
+	FontSize float64 // Largest font size on the line
+}
+
+// TextWord is a run of glyphs on one line without whitespace or large gaps.
+type TextWord struct {
+	Text   string
+	X, Y   float64 // Start of the baseline
+	Width  float64
+	Height float64
+}
+
+// Result holds all the text extracted from a page.
+type Result struct {
+	Spans      []TextSpan
+	Lines      []TextLine
+	Words      []TextWord
+	Paragraphs []string
+}
+
+// Result returns the spans and their grouping into lines, words and
+// paragraphs.
+func (e *Extractor) Result() *Result {
+	return &Result{
+		Spans:      e.spans,
+		Lines:      e.Lines(),
+		Words:      e.Words(),
+		Paragraphs: e.Paragraphs(),
+	}
+}
+
+// Lines groups the spans into lines, from the top of the page down. Spans
+// belong to the same line if their baselines are within LineTolerance of
+// the font size. Lines are assumed to be horizontal.
+func (e *Extractor) Lines() []TextLine {
+	spans := make([]TextSpan, len(e.spans))
+	copy(spans, e.spans)
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].Y > spans[j].Y })
+
+	var lines []TextLine
+	for _, span := range spans {
+		if n := len(lines); n > 0 {
+			line := &lines[n-1]
+			tolerance := e.LineTolerance * math.Max(line.FontSize, span.FontSize)
+			if math.Abs(line.Y-span.Y) <= tolerance {
+				line.Spans = append(line.Spans, span)
+				line.FontSize = math.Max(line.FontSize, span.FontSize)
+				continue
+			}
+		}
+		lines = append(lines, TextLine{Spans: []TextSpan{span}, Y: span.Y, FontSize: span.FontSize})
+	}
+
+	for i := range lines {
+		e.finishLine(&lines[i])
+	}
+	return lines
+}
+
+// finishLine orders the spans of a line and sets its text and extent. A
+// space is put between spans separated by more than WordGap.
+func (e *Extractor) finishLine(line *TextLine) {
+	sort.SliceStable(line.Spans, func(i, j int) bool { return line.Spans[i].X < line.Spans[j].X })
+
+	var sb strings.Builder
+	left, right := math.Inf(1), math.Inf(-1)
+	for i, span := range line.Spans {
+		if i > 0 {
+			prev := line.Spans[i-1]
+			gap := span.X - (prev.X + prev.Width)
+			if gap > e.WordGap*line.FontSize && !endsWithSpace(sb.String()) && !startsWithSpace(span.Text) {
+				sb.WriteByte(' ')
+			}
+		}
+		sb.WriteString(span.Text)
+		left = math.Min(left, span.X)
+		right = math.Max(right, span.X+span.Width)
+		line.Height = math.Max(line.Height, span.Height)
+	}
+	line.Text = sb.String()
+	line.X = left
+	line.Width = right - left
+}
+
+// Words splits each line into words at whitespace and at gaps between
+// glyphs wider than WordGap, in reading order.
+func (e *Extractor) Words() []TextWord {
+	var words []TextWord
+	for _, line := range e.Lines() {
+		var current *TextWord
+		var lastX1 float64
+		flush := func() {
+			if current != nil {
+				words = append(words, *current)
+				current = nil
+			}
+		}
+
+		for _, span := range line.Spans {
+			for _, g := range span.glyphs {
+				if strings.TrimSpace(g.text) == "" {
+					flush()
+					lastX1 = g.x1
+					continue
+				}
+				if current != nil && g.x0-lastX1 > e.WordGap*line.FontSize {
+					flush()
+				}
+				if current == nil {
+					current = &TextWord{X: g.x0, Y: line.Y, Height: span.Height}
+				}
+				current.Text += g.text
+				current.Width = g.x1 - current.X
+				current.Height = math.Max(current.Height, span.Height)
+				lastX1 = g.x1
+			}
+		}
+		flush()
+	}
+	return words
+}
+
+// Paragraphs joins lines into paragraphs, starting a new one where the gap
+// between lines exceeds ParagraphGap of the font size.
+func (e *Extractor) Paragraphs() []string {
+	var paragraphs []string
+	var current []string
+	var prev *TextLine
+
+	lines := e.Lines()
+	for i := range lines {
+		line := &lines[i]
+		text := strings.TrimSpace(line.Text)
+		if text == "" {
+			continue
+		}
+		if prev != nil {
+			// Space between the bottom of the previous line and the top of
+			// this one
+			gap := prev.Y - line.Y - line.Height
+			if gap > e.ParagraphGap*math.Max(prev.FontSize, line.FontSize) {
+				paragraphs = append(paragraphs, strings.Join(current, " "))
+				current = nil
+			}
+		}
+		current = append(current, text)
+		prev = line
+	}
+	if len(current) > 0 {
+		paragraphs = append(paragraphs, strings.Join(current, " "))
+	}
+	return paragraphs
+}
+
+func endsWithSpace(s string) bool {
+	return s != "" && unicode.IsSpace(rune(s[len(s)-1]))
+}
+
+func startsWithSpace(s string) bool {
+	return s != "" && unicode.IsSpace(rune(s[0]))
+}
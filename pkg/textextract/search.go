@@ -0,0 +1,165 @@
+package textextract
+
+import (
+	"image"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gumgum/pkg/graphics"
+)
+
+// SearchHit is one occurrence of a search query.
+type SearchHit struct {
+	PageSpan   int             // Index in Spans of the span the hit starts in
+	DeviceRect image.Rectangle // Bounds of the matched glyphs in device pixels
+	Text       string          // The matched text as it appears on the page
+}
+
+// lineChar is one character of a line's text with the glyph it came from.
+type lineChar struct {
+	r     rune
+	span  *TextSpan // nil for a space put between separated spans
+	glyph int
+}
+
+// SearchText finds every occurrence of query on the page, ignoring case.
+// Matches may cross spans on the same line but not lines; runs of
+// whitespace in query match a single space on the page.
+func (e *Extractor) SearchText(query string) []SearchHit {
+	needle := []rune(strings.ToLower(strings.Join(strings.Fields(query), " ")))
+	if len(needle) == 0 {
+		return nil
+	}
+
+	var hits []SearchHit
+	for _, line := range e.Lines() {
+		chars := e.lineChars(line)
+		for start := 0; start+len(needle) <= len(chars); start++ {
+			if !matchAt(chars[start:], needle) {
+				continue
+			}
+			matched := chars[start : start+len(needle)]
+			if hit, ok := e.searchHit(matched); ok {
+				hits = append(hits, hit)
+			}
+			start += len(needle) - 1
+		}
+	}
+	return hits
+}
+
+// SearchRegex finds every match of re on the page. Each line's text is
+// joined the same way as for SearchText, with a single space between
+// words, before matching; empty matches are skipped.
+func (e *Extractor) SearchRegex(re *regexp.Regexp) []SearchHit {
+	var hits []SearchHit
+	for _, line := range e.Lines() {
+		chars := e.lineChars(line)
+
+		// offsets[i] is the byte offset of chars[i] in text
+		var text strings.Builder
+		offsets := make([]int, len(chars)+1)
+		for i, c := range chars {
+			offsets[i] = text.Len()
+			text.WriteRune(c.r)
+		}
+		offsets[len(chars)] = text.Len()
+
+		for _, m := range re.FindAllStringIndex(text.String(), -1) {
+			if m[0] == m[1] {
+				continue
+			}
+			start := sort.SearchInts(offsets, m[0])
+			end := sort.SearchInts(offsets, m[1])
+			if hit, ok := e.searchHit(chars[start:end]); ok {
+				hits = append(hits, hit)
+			}
+		}
+	}
+	return hits
+}
+
+// lineChars lists the characters of a line. Whitespace runs become one
+// space, and a space is put between spans separated by more than WordGap.
+func (e *Extractor) lineChars(line TextLine) []lineChar {
+	var chars []lineChar
+	space := func() bool { return len(chars) > 0 && chars[len(chars)-1].r == ' ' }
+
+	for i := range line.Spans {
+		span := &line.Spans[i]
+		if i > 0 {
+			prev := line.Spans[i-1]
+			if span.X-(prev.X+prev.Width) > e.WordGap*line.FontSize && !space() {
+				chars = append(chars, lineChar{r: ' '})
+			}
+		}
+		for g, glyph := range span.glyphs {
+			for _, r := range glyph.text {
+				if unicode.IsSpace(r) {
+					if !space() {
+						chars = append(chars, lineChar{r: ' ', span: span, glyph: g})
+					}
+					continue
+				}
+				chars = append(chars, lineChar{r: r, span: span, glyph: g})
+			}
+		}
+	}
+	return chars
+}
+
+func matchAt(chars []lineChar, needle []rune) bool {
+	for i, r := range needle {
+		if unicode.ToLower(chars[i].r) != r {
+			return false
+		}
+	}
+	return true
+}
+
+// searchHit builds a hit from the characters of a match, bounding the
+// glyphs they came from.
+func (e *Extractor) searchHit(matched []lineChar) (SearchHit, bool) {
+	var hit SearchHit
+	var rect graphics.Rect
+	var text strings.Builder
+	found := false
+	lastSpan, lastGlyph := (*TextSpan)(nil), -1
+
+	for _, c := range matched {
+		if c.span == nil {
+			text.WriteByte(' ')
+			continue
+		}
+		// Several characters, such as those of a ligature, share a glyph
+		if c.span == lastSpan && c.glyph == lastGlyph {
+			continue
+		}
+		lastSpan, lastGlyph = c.span, c.glyph
+
+		glyph := c.span.glyphs[c.glyph]
+		text.WriteString(glyph.text)
+		size := c.span.FontSize
+		box := graphics.NewRect(
+			math.Min(glyph.x0, glyph.x1), c.span.Y-descent*size,
+			math.Max(glyph.x0, glyph.x1), c.span.Y+ascent*size,
+		)
+		if !found {
+			hit.PageSpan = c.span.index
+			rect = box
+			found = true
+		} else {
+			rect = rect.Union(box)
+		}
+	}
+	if !found {
+		return hit, false
+	}
+
+	hit.DeviceRect = e.deviceRect(rect)
+	hit.Text = strings.TrimSpace(text.String())
+	return hit, true
+}
@@ -0,0 +1,44 @@
+package textextract
+
+import (
+	fontpkg "gumgum/pkg/font"
+	"gumgum/pkg/graphics"
+)
+
+// LayoutTextOnPath lays text out along path, starting startOffset units
+// along it, and returns the outlines of the glyphs. Each glyph is centered
+// on the point halfway through its advance and rotated so its baseline
+// follows the path's direction there. Glyphs that would run past either
+// end of the path are left out.
+func LayoutTextOnPath(text string, renderer *fontpkg.Renderer, path *graphics.Path, startOffset float64) *graphics.Path {
+	result := graphics.NewPath()
+	length := path.Length()
+	if length == 0 {
+		return result
+	}
+
+	offset := startOffset
+	for _, r := range text {
+		glyph := string(r)
+		advance := renderer.GetStringWidth(glyph)
+		mid := offset + advance/2
+		offset += advance
+		if mid < 0 {
+			continue
+		}
+		if mid > length {
+			break
+		}
+
+		outline := renderer.RenderString(glyph, 0, 0)
+		if outline.IsEmpty() {
+			continue
+		}
+		pt, angle := path.PointAt(mid / length)
+		m := graphics.Translate(-advance/2, 0).
+			Multiply(graphics.Rotate(angle)).
+			Multiply(graphics.Translate(pt.X, pt.Y))
+		result.Append(outline.Transform(m))
+	}
+	return result
+}